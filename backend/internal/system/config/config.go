@@ -180,6 +180,275 @@ type UserConfig struct {
 	Store string `yaml:"store"              json:"store"`
 }
 
+// PreferenceConfig holds the user preference service configuration.
+type PreferenceConfig struct {
+	Compression PreferenceCompressionConfig `yaml:"compression" json:"compression"`
+	// AllowedContentTypes is the MIME allowlist enforced when a preference value is written.
+	// When empty, a conservative built-in allowlist is used. Values whose sniffed content type
+	// is not in the allowlist are rejected, e.g. to prevent stored-XSS via text/html values that
+	// get rendered in a UI.
+	AllowedContentTypes []string                     `yaml:"allowed_content_types" json:"allowed_content_types"`
+	Envelope            PreferenceEnvelopeConfig     `yaml:"envelope"              json:"envelope"`
+	KeyCase             PreferenceKeyCaseConfig      `yaml:"key_case"              json:"key_case"`
+	Namespace           PreferenceNamespaceConfig    `yaml:"namespace"             json:"namespace"`
+	ReadCount           PreferenceReadCountConfig    `yaml:"read_count"            json:"read_count"`
+	Schema              PreferenceSchemaConfig       `yaml:"schema"                json:"schema"`
+	Batch               PreferenceBatchConfig        `yaml:"batch"                 json:"batch"`
+	QueryTimeout        PreferenceQueryTimeoutConfig `yaml:"query_timeout"         json:"query_timeout"`
+	Dedup               PreferenceDedupConfig        `yaml:"dedup"                 json:"dedup"`
+	ProblemJSON         PreferenceProblemJSONConfig  `yaml:"problem_json"          json:"problem_json"`
+	Quota               PreferenceQuotaConfig        `yaml:"quota"                 json:"quota"`
+	Debug               PreferenceDebugConfig        `yaml:"debug"                 json:"debug"`
+	Concurrency         PreferenceConcurrencyConfig  `yaml:"concurrency"           json:"concurrency"`
+	RateLimit           PreferenceRateLimitConfig    `yaml:"rate_limit"            json:"rate_limit"`
+	Import              PreferenceImportConfig       `yaml:"import"                json:"import"`
+	Audit               PreferenceAuditConfig        `yaml:"audit"                 json:"audit"`
+	Defaults            PreferenceDefaultsConfig     `yaml:"defaults"              json:"defaults"`
+	Webhook             PreferenceWebhookConfig      `yaml:"webhook"               json:"webhook"`
+	Limits              PreferenceLimitsConfig       `yaml:"limits"                json:"limits"`
+}
+
+// PreferenceCompressionConfig holds settings for transparently compressing large
+// preference values before they are persisted. Disabled by default.
+type PreferenceCompressionConfig struct {
+	Enabled bool `yaml:"enabled"         json:"enabled"`
+	// ThresholdBytes is the minimum value size, in bytes, above which values are compressed.
+	ThresholdBytes int `yaml:"threshold_bytes" json:"threshold_bytes"`
+}
+
+// PreferenceEnvelopeConfig holds settings for wrapping preference API responses in a standard
+// {data, meta, errors} envelope, e.g. to match what some API gateways expect. Disabled by
+// default so existing clients keep receiving the bare response body.
+type PreferenceEnvelopeConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// PreferenceKeyCaseConfig holds settings for case-insensitive preference keys. When Enabled, the
+// service layer lowercases a key before any store operation, so "theme" and "Theme" refer to the
+// same preference. Disabled by default, so existing case-sensitive keys keep their current
+// behavior.
+type PreferenceKeyCaseConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// PreferenceNamespaceConfig holds settings for limiting namespace sprawl in preference keys.
+// A namespace is a key's first "."-separated segment, e.g. "ui" in "ui.theme". Disabled by
+// default (MaxPerUser of 0 means unlimited).
+type PreferenceNamespaceConfig struct {
+	// MaxPerUser is the maximum number of distinct namespaces a user may have across their
+	// preferences. A write that would introduce a new namespace beyond this limit is rejected
+	// with ErrorNamespaceLimitExceeded. 0 disables the check.
+	MaxPerUser int `yaml:"max_per_user" json:"max_per_user"`
+}
+
+// PreferenceReadCountConfig holds settings for tracking per-preference read counts for usage
+// analytics (e.g. to inform deprecation decisions). Disabled by default, since incrementing a
+// counter on every read adds write overhead that not every deployment wants to pay.
+type PreferenceReadCountConfig struct {
+	// Enabled turns on read-count tracking. Increments are buffered in memory and flushed
+	// periodically as a single batched update per key, rather than on every read, to limit the
+	// write overhead.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// PreferenceDefaultsConfig holds a deployment-configured set of default preference values, merged
+// into a user's preference list for any key the user has not explicitly set (see
+// preferenceService.mergeDefaults), so clients don't need to hardcode their own fallbacks for a
+// freshly-created user with zero stored preferences. Empty by default, which preserves current
+// behavior of listing only a user's own stored keys.
+type PreferenceDefaultsConfig struct {
+	// Values maps a preference key to the value ListPreferences reports for it when the user has
+	// no stored value of their own. A user-set value, even an empty one, always takes precedence.
+	Values map[string]string `yaml:"values" json:"values"`
+}
+
+// PreferenceSchemaConfig holds settings for enforcing the registered preference schema catalog
+// (see RegisterPreferenceSchema) against reads and writes of unregistered keys.
+type PreferenceSchemaConfig struct {
+	// UnknownKeyPolicy controls how a key with no registered schema is treated once any schema
+	// exists. Valid values: "strict" (reject unknown keys on read and write with
+	// ErrorUnknownPreferenceKey) and "lenient" (allow them, preserving current behavior).
+	// Defaults to "lenient" when empty.
+	UnknownKeyPolicy string `yaml:"unknown_key_policy" json:"unknown_key_policy"`
+	// Keys, when non-empty, is this deployment's allowed preference key list: a key with no entry
+	// here is rejected with ErrorInvalidPreferenceKey, independent of UnknownKeyPolicy and the
+	// RegisterPreferenceSchema discovery catalog. Empty by default, which preserves today's
+	// behavior of accepting any syntactically valid key. See preferences.PreferenceSchema.
+	Keys map[string]PreferenceSchemaKeyConfig `yaml:"keys" json:"keys"`
+}
+
+// PreferenceSchemaKeyConfig holds the optional per-key constraints enforced for a single entry in
+// PreferenceSchemaConfig.Keys.
+type PreferenceSchemaKeyConfig struct {
+	// MaxLength, when greater than zero, caps the value length allowed for this key, in addition
+	// to the deployment-wide maxPreferenceValueLength.
+	MaxLength int `yaml:"max_length" json:"max_length"`
+	// ValuePattern, when non-empty, is a regular expression the value must fully match. An entry
+	// whose pattern fails to compile is loaded without a value-pattern constraint; see
+	// preferences.newPreferenceSchema.
+	ValuePattern string `yaml:"value_pattern" json:"value_pattern"`
+}
+
+// PreferenceBatchConfig holds settings for bounding the per-value validation work a single batch
+// request (see BatchRequest) can force the server to do, so a batch with many large values can't
+// be used to exhaust CPU on content-type sniffing and other per-value checks.
+type PreferenceBatchConfig struct {
+	// MaxTotalValueBytes is the maximum combined byte length of every set operation's value in a
+	// single batch request. A request whose total exceeds this is rejected in full with
+	// ErrorBatchValidationLimitExceeded before any operation is applied. 0 disables the check,
+	// which preserves current behavior but leaves a deployment with schema validation enabled
+	// exposed to large batches as a CPU cost; operators who enable costly per-value validation
+	// should set this.
+	MaxTotalValueBytes int `yaml:"max_total_value_bytes" json:"max_total_value_bytes"`
+}
+
+// PreferenceQueryTimeoutConfig holds settings for bounding individual store queries to a timeout
+// shorter than the request context's own deadline, so one expensive query type (e.g. the
+// read-count aggregate) can't hold up a request for the full request timeout. Empty by default,
+// which preserves current behavior of only honoring the request context's deadline.
+type PreferenceQueryTimeoutConfig struct {
+	// PerQueryMillis maps a store query's ID (e.g. "PREF-13" for the read-count aggregate query)
+	// to the maximum time, in milliseconds, it may run before failing with ErrorQueryTimeout. A
+	// query ID with no entry here is bounded only by the request context's deadline.
+	PerQueryMillis map[string]int64 `yaml:"per_query_millis" json:"per_query_millis"`
+}
+
+// PreferenceDedupConfig holds settings for suppressing a write that repeats the value a user's
+// preference was just set to, so a chatty autosave UI does not generate a redundant write (and
+// change event) for every unchanged save. Disabled by default, which preserves current behavior
+// of always writing.
+type PreferenceDedupConfig struct {
+	Enabled bool `yaml:"enabled"       json:"enabled"`
+	// WindowMillis is how long, in milliseconds, after a write the identical (key, value) pair
+	// is suppressed on a repeat write for the same user.
+	WindowMillis int64 `yaml:"window_millis" json:"window_millis"`
+}
+
+// PreferenceProblemJSONConfig holds settings for serializing preference API errors as RFC 7807
+// application/problem+json instead of the service's default apierror.ErrorResponse shape, e.g.
+// to integrate with tooling that expects problem+json. Disabled by default, which preserves the
+// current error shape.
+type PreferenceProblemJSONConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// PreferenceQuotaConfig holds settings for warning, per user, as usage of the total number of
+// preferences and total bytes of preference values stored approaches a configured limit, via the
+// X-Preference-Quota-Usage/X-Preference-Quota-Limit/Warning response headers (see
+// writeQuotaHeaders). By default both limits are soft: nothing is rejected once a limit is
+// reached. Setting EnforceMaxCount makes MaxCount a hard cap instead (see
+// checkPreferenceCountQuota); MaxBytes has no equivalent hard-enforcement mode. 0 in a limit
+// field disables that particular limit; both are 0 by default, which preserves unlimited
+// behavior and writes no headers.
+type PreferenceQuotaConfig struct {
+	// MaxCount is the maximum number of preferences a single user may store. 0 disables it.
+	MaxCount int64 `yaml:"max_count" json:"max_count"`
+	// MaxBytes is the maximum combined byte length of a single user's preference values. 0
+	// disables it.
+	MaxBytes int64 `yaml:"max_bytes" json:"max_bytes"`
+	// WarnThresholdPercent is the usage percentage (0-100) of either limit at which the
+	// X-Preference-Quota-Usage/X-Preference-Quota-Limit headers are joined by a Warning header on
+	// list and upsert responses, e.g. 90 to warn once usage reaches 90% of either limit. 0 (the
+	// default) disables the warning; the usage headers are still written whenever at least one
+	// limit is configured.
+	WarnThresholdPercent int `yaml:"warn_threshold_percent" json:"warn_threshold_percent"`
+	// EnforceMaxCount rejects a write that would create a new preference key once the user is at
+	// MaxCount, with ErrorPreferenceQuotaExceeded, instead of only reporting usage via headers.
+	// A write that merely updates an existing key's value never counts against MaxCount, so it is
+	// never rejected by this setting. False by default, preserving the original soft-quota-only
+	// behavior; has no effect while MaxCount is 0.
+	EnforceMaxCount bool `yaml:"enforce_max_count" json:"enforce_max_count"`
+}
+
+// PreferenceDebugConfig holds settings for the admin endpoint that reports the resolved SQL
+// statement, for the current database type, of every fixed-arity preference store query. Helps
+// operators confirm which query variant a deployment is using without attaching a debugger.
+// Disabled by default, since it reveals internal query structure.
+type PreferenceDebugConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// PreferenceConcurrencyConfig holds settings for bounding how many of a single user's preference
+// requests may be in flight at once, distinct from a requests-per-second rate limit: this bounds
+// simultaneous work rather than request frequency over time, e.g. to protect against a runaway
+// client that opens many concurrent list requests against the same user. 0 disables the check,
+// which preserves current behavior of unbounded per-user concurrency.
+type PreferenceConcurrencyConfig struct {
+	// MaxInFlightPerUser is the maximum number of concurrent preference requests a single user
+	// may have in flight. A request beyond this limit is rejected with
+	// ErrorTooManyConcurrentRequests rather than queued. 0 disables the check.
+	MaxInFlightPerUser int `yaml:"max_in_flight_per_user" json:"max_in_flight_per_user"`
+}
+
+// PreferenceRateLimitConfig holds settings for bounding how many preference requests a single
+// user may make per fixed one-minute window, distinct from PreferenceConcurrencyConfig: this bounds
+// request frequency over time rather than simultaneous in-flight work. List/export/aggregate
+// endpoints scan across a user's (or the whole deployment's) preference set and are much more
+// expensive per request than a single-key write, so they are tracked and limited separately from,
+// and typically more strictly than, single-key writes; single-key reads are exempt from both
+// tiers. 0 in either field disables that tier's check, which preserves current behavior of an
+// unbounded request rate.
+type PreferenceRateLimitConfig struct {
+	// MaxPerMinute is the maximum number of single-key preference writes (a PUT or DELETE of one
+	// key) a single user may make per fixed one-minute window; single-key reads are exempt. A request beyond
+	// this limit is rejected with ErrorPreferenceRateLimited. 0 disables the check.
+	MaxPerMinute int `yaml:"max_per_minute" json:"max_per_minute"`
+	// MaxExpensivePerMinute is the maximum number of list, export, or aggregate preference
+	// requests a single user may make per fixed one-minute window. A request beyond this limit is
+	// rejected with ErrorPreferenceRateLimited. 0 disables the check.
+	MaxExpensivePerMinute int `yaml:"max_expensive_per_minute" json:"max_expensive_per_minute"`
+}
+
+// PreferenceImportConfig holds settings for chunking a bulk import (see ImportPreferences) of
+// many entries into multiple bounded transactions instead of one transaction per request, so a
+// very large import does not hold a single transaction's locks, or buffer its whole working set,
+// for the entire request. 0 ChunkSize disables chunking, which preserves current behavior of
+// importing every entry in one transaction.
+type PreferenceImportConfig struct {
+	// ChunkSize is the maximum number of entries applied per transaction. 0 disables chunking.
+	ChunkSize int `yaml:"chunk_size" json:"chunk_size"`
+	// Atomic, when true, rolls back every chunk already applied if a later chunk fails, so the
+	// import either fully succeeds or leaves no partial effect; the response is not returned in
+	// that case, only an error. When false (the default), each chunk commits independently and a
+	// later chunk's failure does not undo earlier chunks; ImportPreferencesResponse.ChunkResults
+	// reports each chunk's own counts so the caller can see exactly how far the import got.
+	Atomic bool `yaml:"atomic" json:"atomic"`
+}
+
+// PreferenceAuditConfig holds settings for writing preference mutation audit records to a
+// dedicated sink, separate from the general application log, so compliance exports do not have
+// to filter audit entries out of the general operational log stream. Output reuses LogConfig's
+// shape so the same console/file/rotation options apply. Disabled by default, in which case
+// audit records are written through the general logger (log.GetLogger()) instead.
+type PreferenceAuditConfig struct {
+	Enabled bool      `yaml:"enabled" json:"enabled"`
+	Output  LogConfig `yaml:"output"  json:"output"`
+}
+
+// PreferenceWebhookConfig holds settings for notifying an external URL after a preference
+// mutation, so event-driven clients can react without polling. Disabled by default (empty URL).
+type PreferenceWebhookConfig struct {
+	// URL is the endpoint POSTed to after a successful SetPreference/DeletePreference. Empty
+	// disables dispatch entirely.
+	URL string `yaml:"url"         json:"url"`
+	// Secret signs the payload as HMAC-SHA256, carried in the notification's signature header, so
+	// the receiver can verify it actually came from this deployment.
+	Secret string `yaml:"secret"      json:"secret"`
+	// MaxRetries is the number of additional delivery attempts after an initial failed POST.
+	MaxRetries int `yaml:"max_retries" json:"max_retries"`
+}
+
+// PreferenceLimitsConfig holds settings for overriding the built-in preference key/value size
+// limits per deployment. 0 (the zero value) falls back to the built-in default for that field.
+type PreferenceLimitsConfig struct {
+	// MaxKeyLength overrides the maximum allowed length of a preference key. 0 falls back to the
+	// built-in default; a value larger than the PREFERENCE_KEY column's size is clamped to it.
+	MaxKeyLength int `yaml:"max_key_length"   json:"max_key_length"`
+	// MaxValueLength overrides the maximum allowed length of a preference value. 0 falls back to
+	// the built-in default.
+	MaxValueLength int `yaml:"max_value_length" json:"max_value_length"`
+}
+
 // PasskeyConfig holds the passkey configuration details.
 type PasskeyConfig struct {
 	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"`
@@ -547,6 +816,7 @@ type Config struct {
 	Flow                 engineconfig.FlowConfig          `yaml:"flow"                  json:"flow"`
 	Crypto               CryptoConfig                     `yaml:"crypto"                json:"crypto"`
 	User                 UserConfig                       `yaml:"user"                  json:"user"`
+	Preference           PreferenceConfig                 `yaml:"preference"            json:"preference"`
 	DeclarativeResources DeclarativeResources             `yaml:"declarative_resources" json:"declarative_resources"`
 	Resource             engineconfig.ResourceConfig      `yaml:"resource"              json:"resource"`
 	OrganizationUnit     OrganizationUnitConfig           `yaml:"organization_unit"     json:"organization_unit"`
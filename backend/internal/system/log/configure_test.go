@@ -103,6 +103,38 @@ func TestConfigureErrorsOnInvalidFilePath(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestNewWritesToItsOwnFileIndependentOfSingleton(t *testing.T) {
+	singletonPath := filepath.Join(t.TempDir(), "general.log")
+	singleton := freshLogger()
+	require.NoError(t, singleton.Configure(OutputOptions{FileEnabled: true, File: rollingfile.Config{Path: singletonPath}}))
+	defer func() { _ = singleton.Close() }()
+
+	dedicatedPath := filepath.Join(t.TempDir(), "dedicated.log")
+	dedicated, err := New(OutputOptions{FileEnabled: true, File: rollingfile.Config{Path: dedicatedPath}})
+	require.NoError(t, err)
+	defer func() { _ = dedicated.Close() }()
+
+	dedicated.Info(context.Background(), "dedicated sink message")
+	singleton.Info(context.Background(), "singleton message")
+
+	dedicatedContent, err := os.ReadFile(dedicatedPath) // #nosec G304 -- test reads a file under t.TempDir().
+	require.NoError(t, err)
+	assert.Contains(t, string(dedicatedContent), "dedicated sink message")
+	assert.NotContains(t, string(dedicatedContent), "singleton message")
+
+	singletonContent, err := os.ReadFile(singletonPath) // #nosec G304 -- test reads a file under t.TempDir().
+	require.NoError(t, err)
+	assert.Contains(t, string(singletonContent), "singleton message")
+	assert.NotContains(t, string(singletonContent), "dedicated sink message")
+}
+
+func TestNewErrorsOnInvalidFilePath(t *testing.T) {
+	_ = freshLogger()
+
+	_, err := New(OutputOptions{FileEnabled: true, File: rollingfile.Config{Path: ""}})
+	assert.Error(t, err)
+}
+
 func TestConfigureConsoleAndFileWritesFile(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "thunderid.log")
 	log := freshLogger()
@@ -133,6 +133,21 @@ func initLogger() error {
 	return nil
 }
 
+// New creates a standalone Logger configured per opts, independent of the process-wide singleton
+// returned by GetLogger. Used when a subsystem needs its own output destination, e.g. a dedicated
+// audit sink kept separate from the general application log. The returned Logger starts at the
+// same level as the singleton logger; callers that need a different level should call SetLevel.
+func New(opts OutputOptions) (*Logger, error) {
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(GetLogger().levelVar.Level())
+
+	l := &Logger{levelVar: levelVar}
+	if err := l.Configure(opts); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
 // SetLevel updates the minimum log level at runtime.
 func (l *Logger) SetLevel(logLevel string) error {
 	level, err := parseLogLevel(logLevel)
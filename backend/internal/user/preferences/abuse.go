@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/thunder-id/thunderid/internal/system/log"
+)
+
+// validationRejectionLogWindow bounds how often recordValidationRejection logs the same user and
+// rule combination, so a client retrying the same invalid key or value cannot flood the log
+// stream. The rejection is still counted in the metric on every call; only the log line is
+// throttled.
+const validationRejectionLogWindow = time.Minute
+
+var (
+	validationRejectionLogMu   sync.Mutex
+	validationRejectionLastLog map[string]time.Time
+)
+
+func init() {
+	validationRejectionLastLog = make(map[string]time.Time)
+}
+
+// preferenceValidationMetrics holds the lazily-initialized counter for validation rejections, by
+// rule.
+type preferenceValidationMetrics struct {
+	once       sync.Once
+	rejections metric.Int64Counter
+}
+
+var validationMetrics preferenceValidationMetrics
+
+func initValidationMetrics() {
+	validationMetrics.once.Do(func() {
+		meter := otel.Meter("github.com/thunder-id/thunderid/preferences")
+		validationMetrics.rejections, _ = meter.Int64Counter(
+			"thunderid_preference_validation_rejections_total",
+			metric.WithDescription("Total preference requests rejected by input validation, by rule"),
+		)
+	})
+}
+
+// recordValidationRejection increments the validation-rejection counter for rule and, for
+// abuse-detection tooling, writes a structured warn log naming userID and rule, never the
+// rejected key or value itself. At most one log line is emitted per userID/rule pair per
+// validationRejectionLogWindow (see validationRejectionLastLog), so a client repeatedly sending
+// the same invalid input cannot flood the log stream; the metric still counts every rejection.
+func recordValidationRejection(ctx context.Context, userID, rule string) {
+	initValidationMetrics()
+	if validationMetrics.rejections != nil {
+		validationMetrics.rejections.Add(ctx, 1, metric.WithAttributes(attribute.String("rule", rule)))
+	}
+
+	logKey := userID + "|" + rule
+	now := time.Now()
+	validationRejectionLogMu.Lock()
+	last, seen := validationRejectionLastLog[logKey]
+	if seen && now.Sub(last) < validationRejectionLogWindow {
+		validationRejectionLogMu.Unlock()
+		return
+	}
+	validationRejectionLastLog[logKey] = now
+	validationRejectionLogMu.Unlock()
+
+	log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName)).
+		Warn(ctx, "Preference validation rejection", log.MaskedString(log.LoggerKeyUserID, userID), log.String("rule", rule))
+}
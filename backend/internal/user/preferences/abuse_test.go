@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resetValidationRejectionLogForTest() {
+	validationRejectionLogMu.Lock()
+	validationRejectionLastLog = make(map[string]time.Time)
+	validationRejectionLogMu.Unlock()
+}
+
+func TestRecordValidationRejection_LogsFirstOccurrence(t *testing.T) {
+	resetValidationRejectionLogForTest()
+
+	recordValidationRejection(context.Background(), "user-1", validationRuleInvalidKey)
+
+	validationRejectionLogMu.Lock()
+	_, seen := validationRejectionLastLog["user-1|"+validationRuleInvalidKey]
+	validationRejectionLogMu.Unlock()
+	assert.True(t, seen)
+}
+
+func TestRecordValidationRejection_SuppressesRepeatWithinWindow(t *testing.T) {
+	resetValidationRejectionLogForTest()
+
+	recordValidationRejection(context.Background(), "user-1", validationRuleInvalidKey)
+	validationRejectionLogMu.Lock()
+	first := validationRejectionLastLog["user-1|"+validationRuleInvalidKey]
+	validationRejectionLogMu.Unlock()
+
+	recordValidationRejection(context.Background(), "user-1", validationRuleInvalidKey)
+	validationRejectionLogMu.Lock()
+	second := validationRejectionLastLog["user-1|"+validationRuleInvalidKey]
+	validationRejectionLogMu.Unlock()
+
+	assert.Equal(t, first, second)
+}
+
+func TestRecordValidationRejection_DifferentRuleIsNotSuppressed(t *testing.T) {
+	resetValidationRejectionLogForTest()
+
+	recordValidationRejection(context.Background(), "user-1", validationRuleInvalidKey)
+	recordValidationRejection(context.Background(), "user-1", validationRuleUnknownKey)
+
+	validationRejectionLogMu.Lock()
+	_, keySeen := validationRejectionLastLog["user-1|"+validationRuleInvalidKey]
+	_, ruleSeen := validationRejectionLastLog["user-1|"+validationRuleUnknownKey]
+	validationRejectionLogMu.Unlock()
+	assert.True(t, keySeen)
+	assert.True(t, ruleSeen)
+}
+
+func TestRecordValidationRejection_DifferentUserIsNotSuppressed(t *testing.T) {
+	resetValidationRejectionLogForTest()
+
+	recordValidationRejection(context.Background(), "user-1", validationRuleInvalidKey)
+	recordValidationRejection(context.Background(), "user-2", validationRuleInvalidKey)
+
+	validationRejectionLogMu.Lock()
+	_, user1Seen := validationRejectionLastLog["user-1|"+validationRuleInvalidKey]
+	_, user2Seen := validationRejectionLastLog["user-2|"+validationRuleInvalidKey]
+	validationRejectionLogMu.Unlock()
+	assert.True(t, user1Seen)
+	assert.True(t, user2Seen)
+}
+
+func TestRecordValidationRejection_LogsAgainAfterWindowElapses(t *testing.T) {
+	resetValidationRejectionLogForTest()
+
+	validationRejectionLogMu.Lock()
+	validationRejectionLastLog["user-1|"+validationRuleInvalidKey] = time.Now().Add(-2 * validationRejectionLogWindow)
+	validationRejectionLogMu.Unlock()
+
+	recordValidationRejection(context.Background(), "user-1", validationRuleInvalidKey)
+
+	validationRejectionLogMu.Lock()
+	last := validationRejectionLastLog["user-1|"+validationRuleInvalidKey]
+	validationRejectionLogMu.Unlock()
+	assert.WithinDuration(t, time.Now(), last, time.Second)
+}
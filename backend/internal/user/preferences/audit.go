@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/log"
+)
+
+// auditLoggerComponentName identifies audit records in the logger's "component" field, distinct
+// from serviceLoggerComponentName, so a shared sink (the default when no dedicated one is
+// configured) can still be filtered to audit-only entries.
+const auditLoggerComponentName = "PreferenceAudit"
+
+// auditAction identifies the kind of preference mutation an audit record describes. Values are
+// stable strings, not free-form messages, since downstream audit-log ingestion keys off them.
+type auditAction string
+
+const (
+	auditActionSet             auditAction = "preference.set"
+	auditActionConditionalSet  auditAction = "preference.conditional_set"
+	auditActionDelete          auditAction = "preference.delete"
+	auditActionDeleteAll       auditAction = "preference.delete_all"
+	auditActionDeleteNamespace auditAction = "preference.delete_namespace"
+	auditActionImport          auditAction = "preference.import"
+	auditActionCompareAndSwap  auditAction = "preference.compare_and_swap"
+	auditActionSeedDefault     auditAction = "preference.seed_default"
+	auditActionBulkRename      auditAction = "preference.bulk_rename"
+	auditActionReconcile       auditAction = "preference.reconcile"
+	auditActionLockAcquire     auditAction = "preference.lock_acquire"
+	auditActionLockRefresh     auditAction = "preference.lock_refresh"
+	auditActionLockRelease     auditAction = "preference.lock_release"
+	auditActionDeleteByValue   auditAction = "preference.delete_by_value"
+	auditActionCopy            auditAction = "preference.copy"
+	auditActionDeleteBulk      auditAction = "preference.delete_bulk"
+	auditActionDeleteByPrefix  auditAction = "preference.delete_by_prefix"
+)
+
+var (
+	auditLoggerOnce sync.Once
+	auditLogger     *log.Logger
+)
+
+// getAuditLogger returns the logger preference mutations are audited through: a dedicated sink
+// built from PreferenceAuditConfig.Output when auditing is enabled, or the general logger
+// (log.GetLogger()) otherwise. Built once and cached, matching how other lazily-initialized
+// package state (e.g. concurrencyMetrics) is set up.
+func getAuditLogger() *log.Logger {
+	auditLoggerOnce.Do(func() {
+		cfg := config.GetServerRuntime().Config.Preference.Audit
+		if !cfg.Enabled {
+			auditLogger = log.GetLogger()
+			return
+		}
+		l, err := log.New(cfg.Output.BuildOutputOptions(config.GetServerRuntime().ServerHome))
+		if err != nil {
+			log.GetLogger().Error(context.Background(), "Failed to configure preference audit sink, falling back to the general logger", log.Error(err))
+			auditLogger = log.GetLogger()
+			return
+		}
+		auditLogger = l
+	})
+	return auditLogger
+}
+
+// recordPreferenceAudit writes a single structured audit record for a successful preference
+// mutation. userID is masked, matching the convention used for debug logging of preference
+// operations elsewhere in this package.
+func recordPreferenceAudit(ctx context.Context, action auditAction, userID string, fields ...log.Field) {
+	entry := append([]log.Field{
+		log.String(log.LoggerKeyComponentName, auditLoggerComponentName),
+		log.String("action", string(action)),
+		log.MaskedString(log.LoggerKeyUserID, userID),
+	}, fields...)
+	getAuditLogger().Info(ctx, "Preference mutation audit record", entry...)
+}
+
+// PreferenceAuditRecord is a single structured record of a SetPreference or DeletePreference
+// mutation, capturing the before/after value so a security review of the audit trail doesn't need
+// to separately correlate two log lines. OldValue is nil when there was no prior value to compare
+// against (a create, or a delete of a key that was already absent); NewValue is nil for a delete.
+type PreferenceAuditRecord struct {
+	Action   auditAction
+	UserID   string
+	Key      string
+	OldValue *string
+	NewValue *string
+	// Fields carries any extra context recordPreferenceAudit's other callers pass as variadic
+	// log.Field arguments (e.g. "enforced"), so switching SetPreference/DeletePreference over to
+	// the sink doesn't drop information their direct recordPreferenceAudit calls used to log.
+	Fields    []log.Field
+	Timestamp time.Time
+}
+
+// preferenceAuditSink receives every PreferenceAuditRecord emitted by SetPreference and
+// DeletePreference. It exists as an interface, rather than SetPreference/DeletePreference calling
+// recordPreferenceAudit directly as every other audited mutation in this package does, so tests
+// can substitute a fake that captures emitted records instead of asserting on log output.
+type preferenceAuditSink interface {
+	RecordMutation(ctx context.Context, record PreferenceAuditRecord)
+}
+
+// defaultPreferenceAuditSink is the production preferenceAuditSink: it forwards to
+// recordPreferenceAudit/getAuditLogger, the same facility every other audited preference mutation
+// in this package already writes through.
+type defaultPreferenceAuditSink struct{}
+
+// RecordMutation implements preferenceAuditSink.
+func (defaultPreferenceAuditSink) RecordMutation(ctx context.Context, record PreferenceAuditRecord) {
+	fields := append([]log.Field{log.String("key", record.Key)}, record.Fields...)
+	if record.OldValue != nil {
+		fields = append(fields, log.String("oldValue", *record.OldValue))
+	}
+	if record.NewValue != nil {
+		fields = append(fields, log.String("newValue", *record.NewValue))
+	}
+	recordPreferenceAudit(ctx, record.Action, record.UserID, fields...)
+}
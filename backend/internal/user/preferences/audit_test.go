@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/log"
+)
+
+// resetAuditLoggerForTest clears the cached audit logger so the next getAuditLogger call
+// re-reads PreferenceAuditConfig, mirroring resetInFlightForTest's reset-the-package-singleton
+// approach for concurrency state.
+func resetAuditLoggerForTest() {
+	auditLoggerOnce = sync.Once{}
+	auditLogger = nil
+}
+
+func TestGetAuditLogger_DefaultsToGeneralLogger(t *testing.T) {
+	resetAuditLoggerForTest()
+	defer resetAuditLoggerForTest()
+
+	assert.Same(t, log.GetLogger(), getAuditLogger())
+}
+
+func TestGetAuditLogger_DedicatedSinkWritesSeparately(t *testing.T) {
+	resetAuditLoggerForTest()
+	defer resetAuditLoggerForTest()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	config.GetServerRuntime().Config.Preference.Audit = config.PreferenceAuditConfig{
+		Enabled: true,
+		Output: config.LogConfig{
+			Output: config.LogOutputConfig{
+				File: config.LogFileConfig{
+					Enabled:  boolPtrForTest(true),
+					Path:     filepath.Dir(auditPath),
+					FileName: filepath.Base(auditPath),
+				},
+			},
+		},
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.Audit = config.PreferenceAuditConfig{} }()
+
+	recordPreferenceAudit(context.Background(), auditActionSet, "user-1", log.String("key", "theme"))
+	defer func() { _ = getAuditLogger().Close() }()
+
+	content, err := os.ReadFile(auditPath) // #nosec G304 -- test reads a file under t.TempDir().
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "preference.set")
+	assert.Contains(t, string(content), "PreferenceAudit")
+}
+
+func TestRecordPreferenceAudit_MasksUserID(t *testing.T) {
+	resetAuditLoggerForTest()
+	defer resetAuditLoggerForTest()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	config.GetServerRuntime().Config.Preference.Audit = config.PreferenceAuditConfig{
+		Enabled: true,
+		Output: config.LogConfig{
+			Output: config.LogOutputConfig{
+				File: config.LogFileConfig{
+					Enabled:  boolPtrForTest(true),
+					Path:     filepath.Dir(auditPath),
+					FileName: filepath.Base(auditPath),
+				},
+			},
+		},
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.Audit = config.PreferenceAuditConfig{} }()
+
+	recordPreferenceAudit(context.Background(), auditActionDeleteAll, "user-1")
+	defer func() { _ = getAuditLogger().Close() }()
+
+	content, err := os.ReadFile(auditPath) // #nosec G304 -- test reads a file under t.TempDir().
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "user-1")
+	assert.Contains(t, string(content), "u****1")
+}
+
+func boolPtrForTest(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+// compressedValuePrefix marks a stored value as gzip-compressed and base64-encoded.
+// Values without this prefix are read back as-is, so rows written before compression
+// was enabled (or while it is disabled) continue to read correctly.
+const compressedValuePrefix = "gz1:"
+
+// encryptedValuePrefix marks a stored value as encrypted via the deployment's configured
+// crypto provider and base64-encoded. Like compressedValuePrefix, it is checked on read
+// independently of the key's current StoragePolicy, so a value written while a key was
+// storagePolicyEncrypted keeps decrypting correctly even if the policy later changes.
+const encryptedValuePrefix = "enc1:"
+
+// Storage policy values for PreferenceSchemaEntry.StoragePolicy. See StoragePolicy's doc
+// comment for what each one does.
+const (
+	storagePolicyPlaintext  = ""
+	storagePolicyEncrypted  = "encrypted"
+	storagePolicyCompressed = "compressed"
+)
+
+// encodeValue applies transparent gzip compression to value when compression is enabled
+// and value is at least as large as the configured threshold. The encoded form is
+// persisted in place of value; the store layer is the only place this is visible.
+func encodeValue(value string) (string, error) {
+	cfg := config.GetServerRuntime().Config.Preference.Compression
+	if !cfg.Enabled || len(value) < cfg.ThresholdBytes {
+		return value, nil
+	}
+	return compressValue(value)
+}
+
+// compressValue unconditionally gzip-compresses value, ignoring the deployment's configured
+// compression threshold. Used directly for storagePolicyCompressed keys, and by encodeValue
+// once it has decided compression applies.
+func compressValue(value string) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(value)); err != nil {
+		return "", fmt.Errorf("failed to compress preference value: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress preference value: %w", err)
+	}
+
+	return compressedValuePrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeValue reverses encodeValue. It is a no-op for values that do not carry the
+// compressed marker, which covers legacy values written while compression was off.
+func decodeValue(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, compressedValuePrefix)
+	if !ok {
+		return value, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode compressed preference value: %w", err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress preference value: %w", err)
+	}
+	defer gz.Close()
+
+	out, err := io.ReadAll(gz)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress preference value: %w", err)
+	}
+	return string(out), nil
+}
+
+// encodePreferenceValue applies key's registered storage policy to value, returning the form to
+// persist. storagePolicyEncrypted and storagePolicyCompressed override the deployment's general
+// compression settings outright; storagePolicyPlaintext (the default for an unregistered key, or
+// one with no StoragePolicy set) falls through to encodeValue, which applies those settings.
+func (s *preferenceStore) encodePreferenceValue(key, value string) (string, error) {
+	switch getStoragePolicy(key) {
+	case storagePolicyEncrypted:
+		return s.encryptValue(value)
+	case storagePolicyCompressed:
+		return compressValue(value)
+	default:
+		return encodeValue(value)
+	}
+}
+
+// decodePreferenceValue reverses encodePreferenceValue. It dispatches purely on whichever marker
+// value actually carries, not on key's current storage policy, so a value written under a policy
+// that has since changed (or been removed) still decodes correctly.
+func (s *preferenceStore) decodePreferenceValue(value string) (string, error) {
+	if encoded, ok := strings.CutPrefix(value, encryptedValuePrefix); ok {
+		return s.decryptValue(encoded)
+	}
+	return decodeValue(value)
+}
+
+// encryptValue encrypts value via the store's configured crypto provider, base64-encoding the
+// result for storage in a text column.
+func (s *preferenceStore) encryptValue(value string) (string, error) {
+	if s.crypto == nil {
+		return "", fmt.Errorf("failed to encrypt preference value: no crypto provider configured")
+	}
+	ciphertext, err := s.crypto.Encrypt(context.Background(), []byte(value))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt preference value: %w", err)
+	}
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptValue reverses encryptValue's base64 encoding and decryption.
+func (s *preferenceStore) decryptValue(encoded string) (string, error) {
+	if s.crypto == nil {
+		return "", fmt.Errorf("failed to decrypt preference value: no crypto provider configured")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted preference value: %w", err)
+	}
+	plaintext, err := s.crypto.Decrypt(context.Background(), raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt preference value: %w", err)
+	}
+	return string(plaintext), nil
+}
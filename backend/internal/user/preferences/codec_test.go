@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+// identityCrypto is a no-op ConfigCryptoProvider for tests: it exercises the encrypt/decrypt
+// dispatch path without a real symmetric key.
+type identityCrypto struct{}
+
+func (identityCrypto) Encrypt(_ context.Context, content []byte) ([]byte, error) { return content, nil }
+func (identityCrypto) Decrypt(_ context.Context, content []byte) ([]byte, error) { return content, nil }
+
+// failingCrypto is a ConfigCryptoProvider whose Encrypt/Decrypt always fail, for asserting that
+// a crypto-provider error propagates rather than being swallowed.
+type failingCrypto struct{}
+
+func (failingCrypto) Encrypt(_ context.Context, _ []byte) ([]byte, error) {
+	return nil, errors.New("encrypt failed")
+}
+func (failingCrypto) Decrypt(_ context.Context, _ []byte) ([]byte, error) {
+	return nil, errors.New("decrypt failed")
+}
+
+func TestEncodeValue_DisabledByDefault(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Compression = config.PreferenceCompressionConfig{}
+
+	encoded, err := encodeValue(strings.Repeat("a", 1000))
+
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 1000), encoded)
+}
+
+func TestEncodeValue_BelowThreshold(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Compression = config.PreferenceCompressionConfig{
+		Enabled: true, ThresholdBytes: 1000,
+	}
+	defer func() {
+		config.GetServerRuntime().Config.Preference.Compression = config.PreferenceCompressionConfig{}
+	}()
+
+	encoded, err := encodeValue("short")
+
+	require.NoError(t, err)
+	assert.Equal(t, "short", encoded)
+}
+
+func TestEncodeDecodeValue_RoundTrip(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Compression = config.PreferenceCompressionConfig{
+		Enabled: true, ThresholdBytes: 10,
+	}
+	defer func() {
+		config.GetServerRuntime().Config.Preference.Compression = config.PreferenceCompressionConfig{}
+	}()
+	value := strings.Repeat("preference-value-", 100)
+
+	encoded, err := encodeValue(value)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, compressedValuePrefix))
+
+	decoded, err := decodeValue(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, value, decoded)
+}
+
+func TestDecodeValue_LegacyUncompressedValue(t *testing.T) {
+	decoded, err := decodeValue("plain-legacy-value")
+
+	require.NoError(t, err)
+	assert.Equal(t, "plain-legacy-value", decoded)
+}
+
+func TestDecodeValue_InvalidCompressedValue(t *testing.T) {
+	_, err := decodeValue(compressedValuePrefix + "not-valid-base64!!")
+
+	require.Error(t, err)
+}
+
+func TestEncodeDecodePreferenceValue_EncryptedPolicyRoundTrip(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", StoragePolicy: storagePolicyEncrypted})
+	defer delete(schemaRegistry, "secret.token")
+	store := &preferenceStore{crypto: identityCrypto{}}
+
+	encoded, err := store.encodePreferenceValue("secret.token", "super-secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, encryptedValuePrefix))
+	assert.NotContains(t, encoded, "super-secret", "the stored form must not contain the plaintext value")
+
+	decoded, err := store.decodePreferenceValue(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "super-secret", decoded)
+}
+
+func TestEncodePreferenceValue_PlaintextPolicyFallsThroughToCompressionSettings(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Compression = config.PreferenceCompressionConfig{}
+	store := &preferenceStore{}
+
+	encoded, err := store.encodePreferenceValue("ui.theme", "dark")
+
+	require.NoError(t, err)
+	assert.Equal(t, "dark", encoded)
+}
+
+func TestEncodePreferenceValue_CompressedPolicyIgnoresThreshold(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Compression = config.PreferenceCompressionConfig{}
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.bigBlob", StoragePolicy: storagePolicyCompressed})
+	defer delete(schemaRegistry, "ui.bigBlob")
+	store := &preferenceStore{}
+
+	encoded, err := store.encodePreferenceValue("ui.bigBlob", "short")
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, compressedValuePrefix))
+
+	decoded, err := store.decodePreferenceValue(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "short", decoded)
+}
+
+func TestEncodePreferenceValue_EncryptedPolicyWithoutCryptoProviderFails(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", StoragePolicy: storagePolicyEncrypted})
+	defer delete(schemaRegistry, "secret.token")
+	store := &preferenceStore{}
+
+	_, err := store.encodePreferenceValue("secret.token", "super-secret")
+
+	require.Error(t, err)
+}
+
+func TestDecodePreferenceValue_EncryptedValueWithoutCryptoProviderFails(t *testing.T) {
+	store := &preferenceStore{}
+
+	_, err := store.decodePreferenceValue(encryptedValuePrefix + "irrelevant")
+
+	require.Error(t, err)
+}
+
+func TestEncodeDecodePreferenceValue_CryptoProviderErrorPropagates(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", StoragePolicy: storagePolicyEncrypted})
+	defer delete(schemaRegistry, "secret.token")
+	store := &preferenceStore{crypto: failingCrypto{}}
+
+	_, err := store.encodePreferenceValue("secret.token", "value")
+	require.Error(t, err)
+
+	_, err = store.decodePreferenceValue(encryptedValuePrefix + "YWJj")
+	require.Error(t, err)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/security"
+)
+
+var (
+	inFlightMu     sync.Mutex
+	inFlightByUser map[string]int
+)
+
+func init() {
+	inFlightByUser = make(map[string]int)
+}
+
+// preferenceConcurrencyMetrics holds the lazily-initialized gauge tracking the current number of
+// in-flight preference requests, across all users.
+type preferenceConcurrencyMetrics struct {
+	once     sync.Once
+	inFlight metric.Int64UpDownCounter
+}
+
+var concurrencyMetrics preferenceConcurrencyMetrics
+
+func initConcurrencyMetrics() {
+	concurrencyMetrics.once.Do(func() {
+		meter := otel.Meter("github.com/thunder-id/thunderid/preferences")
+		concurrencyMetrics.inFlight, _ = meter.Int64UpDownCounter(
+			"thunderid_preference_requests_in_flight",
+			metric.WithDescription("Current number of in-flight preference requests, across all users"),
+		)
+	})
+}
+
+// acquireInFlightSlot reserves a concurrent-request slot for userID, per
+// PreferenceConcurrencyConfig.MaxInFlightPerUser, and reports whether the slot was reserved. The
+// caller must call releaseInFlightSlot exactly once for every acquire that returns true.
+func acquireInFlightSlot(userID string) bool {
+	limit := config.GetServerRuntime().Config.Preference.Concurrency.MaxInFlightPerUser
+	if limit <= 0 {
+		return true
+	}
+
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	if inFlightByUser[userID] >= limit {
+		return false
+	}
+	inFlightByUser[userID]++
+	initConcurrencyMetrics()
+	if concurrencyMetrics.inFlight != nil {
+		concurrencyMetrics.inFlight.Add(context.Background(), 1)
+	}
+	return true
+}
+
+// releaseInFlightSlot releases the slot a successful acquireInFlightSlot call reserved for
+// userID.
+func releaseInFlightSlot(userID string) {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	if inFlightByUser[userID] > 0 {
+		inFlightByUser[userID]--
+	}
+	if inFlightByUser[userID] == 0 {
+		delete(inFlightByUser, userID)
+	}
+	if concurrencyMetrics.inFlight != nil {
+		concurrencyMetrics.inFlight.Add(context.Background(), -1)
+	}
+}
+
+// concurrencyLimitMiddleware rejects a request with ErrorTooManyConcurrentRequests once the
+// authenticated user already has PreferenceConcurrencyConfig.MaxInFlightPerUser preference
+// requests in flight. This bounds simultaneous work per user, distinct from a requests-per-second
+// rate limit (see ErrorPreferenceRateLimited). Requests with no authenticated subject pass
+// through unthrottled, since there is no per-user key to limit on; the wrapped handler is
+// responsible for rejecting those with ErrorMissingUserID.
+func concurrencyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := security.GetSubject(r.Context())
+		if userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !acquireInFlightSlot(userID) {
+			handleServiceError(r.Context(), w, &ErrorTooManyConcurrentRequests)
+			return
+		}
+		defer releaseInFlightSlot(userID)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/security"
+)
+
+func resetInFlightForTest() {
+	inFlightMu.Lock()
+	inFlightByUser = make(map[string]int)
+	inFlightMu.Unlock()
+}
+
+func TestAcquireInFlightSlot_DisabledByDefault(t *testing.T) {
+	resetInFlightForTest()
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, acquireInFlightSlot("user-1"))
+	}
+}
+
+func TestAcquireInFlightSlot_RejectsBeyondLimit(t *testing.T) {
+	resetInFlightForTest()
+	config.GetServerRuntime().Config.Preference.Concurrency.MaxInFlightPerUser = 2
+	defer func() { config.GetServerRuntime().Config.Preference.Concurrency = config.PreferenceConcurrencyConfig{} }()
+
+	assert.True(t, acquireInFlightSlot("user-1"))
+	assert.True(t, acquireInFlightSlot("user-1"))
+	assert.False(t, acquireInFlightSlot("user-1"))
+}
+
+func TestAcquireInFlightSlot_LimitIsPerUser(t *testing.T) {
+	resetInFlightForTest()
+	config.GetServerRuntime().Config.Preference.Concurrency.MaxInFlightPerUser = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Concurrency = config.PreferenceConcurrencyConfig{} }()
+
+	assert.True(t, acquireInFlightSlot("user-1"))
+	assert.True(t, acquireInFlightSlot("user-2"))
+}
+
+func TestReleaseInFlightSlot_FreesSlotForNextAcquire(t *testing.T) {
+	resetInFlightForTest()
+	config.GetServerRuntime().Config.Preference.Concurrency.MaxInFlightPerUser = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Concurrency = config.PreferenceConcurrencyConfig{} }()
+
+	assert.True(t, acquireInFlightSlot("user-1"))
+	assert.False(t, acquireInFlightSlot("user-1"))
+
+	releaseInFlightSlot("user-1")
+
+	assert.True(t, acquireInFlightSlot("user-1"))
+}
+
+func authenticatedRequestForTest(userID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences", nil)
+	authCtx := security.NewSecurityContextForTest(userID, "", "", nil, nil)
+	return req.WithContext(security.WithSecurityContextTest(req.Context(), authCtx))
+}
+
+func TestConcurrencyLimitMiddleware_PassesThroughWithinLimit(t *testing.T) {
+	resetInFlightForTest()
+	config.GetServerRuntime().Config.Preference.Concurrency.MaxInFlightPerUser = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Concurrency = config.PreferenceConcurrencyConfig{} }()
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	rr := httptest.NewRecorder()
+
+	concurrencyLimitMiddleware(next).ServeHTTP(rr, authenticatedRequestForTest("user-1"))
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestConcurrencyLimitMiddleware_RejectsWithTooManyRequests(t *testing.T) {
+	resetInFlightForTest()
+	config.GetServerRuntime().Config.Preference.Concurrency.MaxInFlightPerUser = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Concurrency = config.PreferenceConcurrencyConfig{} }()
+	assert.True(t, acquireInFlightSlot("user-1"))
+	defer releaseInFlightSlot("user-1")
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	rr := httptest.NewRecorder()
+
+	concurrencyLimitMiddleware(next).ServeHTTP(rr, authenticatedRequestForTest("user-1"))
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
+
+func TestConcurrencyLimitMiddleware_NoSubjectPassesThroughUnlimited(t *testing.T) {
+	resetInFlightForTest()
+	config.GetServerRuntime().Config.Preference.Concurrency.MaxInFlightPerUser = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Concurrency = config.PreferenceConcurrencyConfig{} }()
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	concurrencyLimitMiddleware(next).ServeHTTP(rr, req)
+
+	assert.True(t, called)
+}
@@ -0,0 +1,372 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+const (
+	// maxPreferenceKeyLength is the maximum allowed length of a preference key.
+	maxPreferenceKeyLength = 255
+	// maxPreferenceValueLength is the maximum allowed length of a preference value.
+	maxPreferenceValueLength = 8192
+	// namespaceSeparator delimits a preference key's namespace (its first segment) from the
+	// rest of the key, e.g. "ui" in "ui.theme".
+	namespaceSeparator = "."
+	// defaultSchemaVersion is the value format version assigned to a write that does not declare
+	// one, and to values stored before Preference.SchemaVersion existed.
+	defaultSchemaVersion = 1
+	// maxPreferencePatternLength is the maximum allowed length of a list request's pattern
+	// query parameter (see matchesPreferencePattern).
+	maxPreferencePatternLength = 255
+	// maxPreferencePatternWildcards is the maximum number of '*'/'?' wildcards a list request's
+	// pattern query parameter may contain, bounding the cost of matching it against every key.
+	maxPreferencePatternWildcards = 16
+	// maxPreferenceJSONDepth is the maximum nesting depth a Type "json" preference value may
+	// have (see normalizePreferenceValue), bounding the cost of later schema-validating it.
+	maxPreferenceJSONDepth = 32
+	// maxDeviceIDLength is the maximum allowed length of the X-Preference-Device-Id header.
+	maxDeviceIDLength = 255
+	// deviceScopeSeparator joins a user ID and a device ID into the synthetic storage identity
+	// deviceScopedUserID writes a device-specific preference override under, chosen to be a
+	// sequence a real user ID cannot contain (user IDs are UUIDs; see validateDeviceID's sibling
+	// checks for the device ID half).
+	deviceScopeSeparator = "::device::"
+)
+
+// Validation rejection rule names passed to recordValidationRejection, identifying which check
+// rejected a request for abuse-detection tooling. Values are stable strings, not free-form
+// messages, since downstream anomaly detection keys off them.
+const (
+	validationRuleInvalidKey   = "invalid_key"
+	validationRuleUnknownKey   = "unknown_key"
+	validationRuleInvalidValue = "invalid_value"
+)
+
+// Preference layer names reported by buildPreferenceExplanation (see PreferenceExplanation),
+// identifying which layer contributed a key's effective value. This deployment has no separate
+// tenant or deployment-default store: "layerSchemaDefault" is the key's registered
+// PreferenceSchemaEntry.Default, which is already deployment-wide since schemas are registered
+// once per running deployment.
+const (
+	layerUser          = "user"
+	layerSchemaDefault = "schemaDefault"
+)
+
+const (
+	// headerMaxKeyLength is the response header a client can read off the key-scoped endpoint's
+	// OPTIONS preflight to learn the server's maximum preference key length without hardcoding
+	// it or relying on a rejected write.
+	headerMaxKeyLength = "X-Preference-Max-Key-Length"
+	// headerMaxValueLength is the equivalent response header for the maximum preference value
+	// length.
+	headerMaxValueLength = "X-Preference-Max-Value-Length"
+	// headerQuotaUsage is the response header reporting a user's current preference usage against
+	// PreferenceQuotaConfig, on list and upsert responses. See writeQuotaHeaders.
+	headerQuotaUsage = "X-Preference-Quota-Usage"
+	// headerQuotaLimit is the equivalent response header for the configured limit(s) usage is
+	// measured against.
+	headerQuotaLimit = "X-Preference-Quota-Limit"
+	// headerQuotaWarning is the standard HTTP Warning header, set alongside headerQuotaUsage once
+	// usage crosses PreferenceQuotaConfig.WarnThresholdPercent of either configured limit.
+	headerQuotaWarning = "Warning"
+	// headerPreferenceLockToken is the request header a write carries the token returned by
+	// AcquirePreferenceLock in, so SetPreference can validate it against any active lock (see
+	// checkPreferenceLockForWrite).
+	headerPreferenceLockToken = "X-Preference-Lock-Token"
+	// headerPreferenceDeviceID is the request header a client sets to read or write a
+	// device-specific override of a preference instead of the user-global value; see
+	// deviceScopedUserID.
+	headerPreferenceDeviceID = "X-Preference-Device-Id"
+)
+
+const (
+	serviceLoggerComponentName = "PreferenceService"
+	handlerLoggerComponentName = "PreferenceHandler"
+)
+
+// contentTypeProblemJSON is the media type written for a preference API error when
+// PreferenceProblemJSONConfig.Enabled (see envelope.go).
+const contentTypeProblemJSON = "application/problem+json"
+
+// seedDefaultUserBatchSize is the page size used to walk all users when backfilling a default
+// value via SeedDefaultForAllUsers, bounding memory use for large deployments.
+const seedDefaultUserBatchSize = 200
+
+// redactedPreferenceValue replaces the value of a Sensitive-schema key in a DiffPreferences
+// response unless the caller passed reveal=true and was authorized for it.
+const redactedPreferenceValue = "[REDACTED]"
+
+// retryAfterSeconds is the Retry-After value (in seconds) sent with ErrorServiceUnavailable, a
+// conservative estimate of how long a standby's read-only window during a failover typically lasts.
+const retryAfterSeconds = 5
+
+// exportUserBatchSize is the page size used to walk all users when streaming the admin
+// full-deployment preference export, bounding memory use for large deployments.
+const exportUserBatchSize = 200
+
+// bulkRenameUserBatchSize is the page size used to walk all users when bulk-renaming keys via
+// BulkRenamePreferences, bounding memory use for large deployments.
+const bulkRenameUserBatchSize = 200
+
+// schemaReconcileUserBatchSize is the page size used to walk all users when reconciling
+// preferences against the schema catalog via ReconcileAllUserPreferences, bounding memory use for
+// large deployments. Distinct from reconciler.go's reconcileUserBatchSize, which paginates the
+// unrelated orphan/expiry purge sweep.
+const schemaReconcileUserBatchSize = 200
+
+// deleteByValueUserBatchSize is the page size used to walk all users when deleting preferences by
+// value via DeletePreferencesByValue, bounding memory use for large deployments.
+const deleteByValueUserBatchSize = 200
+
+// parentUserAttributeKey is the User.Attributes field naming the user's parent account, for
+// resolveParentUserID. Absent, empty, or non-string values mean the user has no parent link.
+const parentUserAttributeKey = "parentUserId"
+
+// maxPreferenceInheritanceDepth bounds how many parent links resolveInheritedPreference follows
+// looking for an ancestor with a value for the requested key, so a misconfigured or malicious
+// parent-link cycle cannot loop forever; resolveInheritedPreference also tracks visited user IDs
+// and stops the moment a link would revisit one.
+const maxPreferenceInheritanceDepth = 5
+
+// onMissingNoContentValue is the "on_missing" query parameter value that makes
+// handleGetPreferenceByKey return 204 No Content instead of ErrorPreferenceNotFound's 404 when
+// the key has no value, for clients that treat an optional setting's absence as normal rather
+// than exceptional. Any other value (including the parameter's absence) keeps the 404 default.
+const onMissingNoContentValue = "204"
+
+// preferReturnMinimalValue is the "Prefer" request header value (RFC 7240) that makes
+// handleUpsertPreference return 204 No Content instead of the full PreferenceResponse body on a
+// successful write, for high-frequency callers (e.g. mobile autosave) that don't need the echo.
+// Any other value (including the header's absence) keeps the 200 default.
+const preferReturnMinimalValue = "return=minimal"
+
+// sortValueCatalog is the "sort" query parameter value that makes handleGetPreferences order its
+// result by sortPreferencesByCatalogOrder instead of the store's unspecified row order. Any other
+// value (including the parameter's absence) keeps the default, unsorted order.
+const sortValueCatalog = "catalog"
+
+// preferenceSortKey and preferenceSortUpdatedAt are the "sort" query parameter values (other than
+// sortValueCatalog) that handleGetPreferences accepts for a DB-ordered list; see
+// validatePreferenceSort and preferenceSortColumns. The parameter's absence defaults to
+// preferenceSortKey, preserving the pre-existing PREFERENCE_KEY ASC order.
+const (
+	preferenceSortKey       = "key"
+	preferenceSortUpdatedAt = "updated_at"
+)
+
+// preferenceSortOrderAsc and preferenceSortOrderDesc are the "order" query parameter values
+// handleGetPreferences accepts alongside preferenceSortKey/preferenceSortUpdatedAt; see
+// validatePreferenceSort and preferenceSortDirections. The parameter's absence defaults to
+// preferenceSortOrderAsc.
+const (
+	preferenceSortOrderAsc  = "asc"
+	preferenceSortOrderDesc = "desc"
+)
+
+// unknownKeyPolicyStrict is the PreferenceSchemaConfig.UnknownKeyPolicy value that rejects reads
+// and writes of keys with no registered schema. Any other value (including the empty default)
+// behaves as lenient and allows them, preserving the pre-policy behavior.
+const unknownKeyPolicyStrict = "strict"
+
+// maxExistsCheckKeys is the maximum number of keys accepted in a single ExistsRequest.
+const maxExistsCheckKeys = 200
+
+// defaultPreferenceLockTTLSeconds is the advisory preference lock duration used when
+// AcquirePreferenceLockRequest.TTLSeconds/PreferenceLockTokenRequest.TTLSeconds is omitted or 0.
+// maxPreferenceLockTTLSeconds caps a caller-requested TTL so a crashed admin console's lock
+// cannot block other admins indefinitely. See clampPreferenceLockTTL.
+const (
+	defaultPreferenceLockTTLSeconds = 120
+	maxPreferenceLockTTLSeconds     = 900
+)
+
+// maxPreferenceTTLSeconds caps a caller-requested SetPreferenceRequest.TTLSeconds, so a mistyped
+// TTL cannot pin an otherwise-ephemeral row in place indefinitely. Unlike
+// defaultPreferenceLockTTLSeconds above, there is no default: 0 (the zero value, or omitted) means
+// the preference never expires, rather than falling back to some baseline duration.
+const maxPreferenceTTLSeconds = 30 * 24 * 60 * 60 // 30 days
+
+// defaultPreferenceListLimit is the page size ListPreferences uses when a list request omits the
+// limit query parameter. maxPreferenceListLimit caps a caller-requested limit so a single request
+// cannot force the full decode/serialization cost back onto the server.
+const (
+	defaultPreferenceListLimit = 50
+	maxPreferenceListLimit     = 200
+)
+
+// NOTE: DharshanSR/thunder#synth-1429 asked for a keys=/prefix= filter on a preference-change SSE
+// stream, but no such stream exists in this service (or anywhere else in the backend) to filter:
+// there is no SSE endpoint, no PreferenceChangedEvent type, and no change-notification mechanism
+// at all. Filtering server-side before writing to a stream has no stream to attach to, so this
+// request cannot be implemented as described. Deferred until a preference-change event stream is
+// built; at that point the filter should be added as a predicate applied in the stream's write
+// loop, matching either an exact key or a trailing "." prefix segment.
+
+// NOTE: DharshanSR/thunder#synth-1430 asked for a write-token/version mechanism so a read
+// following a write from the same client sees at least that version, describing staleness via
+// "a read replica or the cache". Neither exists for preferences, or anywhere in this backend's
+// database layer (see internal/system/database/provider): each logical database is a single
+// connection pool with no read-replica split, and the preference store has no caching layer.
+// Every GetPreferenceByKey/GetPreferencesByUserID call already observes the most recently
+// committed UpsertPreference on that same connection pool, so read-your-writes already holds
+// by construction; adding a version token would guard against a staleness source that cannot
+// occur here. Revisit if a read replica or cache is ever introduced in front of the user DB.
+
+// NOTE: DharshanSR/thunder#synth-1432 asked for "type=number" handling that preserves the exact
+// textual form of a numeric value instead of round-tripping it through float parsing. Preferences
+// have no typed-value concept at all, though: Preference.Value/SetPreferenceRequest.Value are
+// plain strings, validateContentType (mime.go) only sniffs a MIME type against an allowlist, and
+// there is no "type" field anywhere on the request, model, or store row that could select a
+// numeric code path. Adding a numeric-validation branch with nothing in the API surface to trigger
+// it would be dead code. Deferred until a typed-value mechanism (e.g. an explicit "type" field on
+// SetPreferenceRequest) exists; at that point the value should be validated as a string of digits
+// (with optional sign/decimal point/exponent) and stored verbatim rather than parsed into a float,
+// so large integers and trailing zeros round-trip exactly.
+
+// NOTE: DharshanSR/thunder#synth-1434 asked for a batch request to be rejected when the same key
+// is declared with conflicting types across its operations, or when an operation's declared type
+// disagrees with the key's registered schema type (PreferenceSchemaEntry.Type, see schema.go).
+// Like synth-1432, this depends on a per-value "type" the caller can declare, and BatchOperation
+// (model.go) has no such field: Value is a plain string with no accompanying type, so there is
+// nothing to compare for a conflict. Deferred until SetPreferenceRequest/BatchOperation gain a
+// "type" field; at that point batch validation should walk the operations up front (before
+// applying any of them) and reject the whole request if a key's declared type disagrees with
+// either another operation on the same key or that key's registered schema type, if one exists.
+
+// NOTE: DharshanSR/thunder#synth-1435 asked for a Shutdown(ctx) that flushes a write-behind
+// buffer, drains async last-accessed updates, and closes an event publisher, all within a
+// timeout. None of those exist: writes go straight through UpsertPreference/InsertPreferenceIfAbsent
+// to the store on the calling goroutine (no write-behind buffer), and synth-1429 already
+// established there is no event publisher anywhere in this backend. synth-1438 has since added a
+// second background subsystem alongside the reconciler's purge loop: the read-count flusher
+// (readcount.go) buffers per-read increments in memory and flushes them periodically. It is
+// drained from Shutdown() the same way the reconciler is, per this note's own guidance, so
+// Shutdown() still has no timeout or ctx parameter to plumb through. Revisit if an event
+// publisher is ever introduced.
+
+// NOTE: DharshanSR/thunder#synth-1447 asked for the correlation ID to be attached to both the
+// service's log fields and to "PreferenceChangedEvent". The log half is implemented: every
+// /users/me/preferences and /admin/preferences route is now wrapped with
+// middleware.CorrelationIDMiddleware (see init.go), which ensures the request context carries a
+// trace ID, and every logger.Error/Warn/Debug call in this package already takes that ctx as its
+// first argument, so log.GetLogger()'s contextHandler attaches the trace ID automatically (see
+// internal/system/log/log.go) with no further change needed here. The event half cannot be
+// implemented: as synth-1429 established, there is no PreferenceChangedEvent type or
+// change-notification mechanism anywhere in this backend. Revisit once a preference-change event
+// stream is built; at that point the same trace ID already in the request context should be
+// copied onto the event before it is published.
+
+// NOTE: DharshanSR/thunder#synth-1453 asked for a de-dup window to suppress "redundant writes
+// (and their change events)". The write-suppression half is implemented: SetPreference consults
+// isDuplicateWrite (dedup.go) and, when PreferenceDedupConfig.Enabled and the repeat value falls
+// within WindowMillis of the existing value's UpdatedAt, skips the store write and increments the
+// thunderid_preference_dedup_suppressed_writes_total metric. The change-event half cannot be
+// implemented: as synth-1429 established, there is no PreferenceChangedEvent type or
+// change-notification mechanism anywhere in this backend, so there is no event to suppress.
+// Revisit once a preference-change event stream is built.
+
+// NOTE: DharshanSR/thunder#synth-1436 also asked for the limit hint to be surfaced on "the
+// capabilities endpoint" and "kept in sync with the configurable-limits feature". The capabilities
+// endpoint still does not exist anywhere in this backend, so that part remains unimplementable.
+// The configurable-limits half was built in synth-1519 (see limits.go): maxPreferenceKeyLength /
+// maxPreferenceValueLength are now only the built-in fallbacks, and init.go's OPTIONS preflight
+// reads the effective, possibly-overridden values via preferenceMaxKeyLength()/
+// preferenceMaxValueLength() so the advertised headers stay in sync. Revisit the capabilities
+// endpoint surfacing if/when one is built.
+
+// NOTE: DharshanSR/thunder#synth-1457 asked for an admin GetPreferencesAsOf(ctx, userID,
+// timestamp) that reconstructs a user's preference set at a past point in time "with the history
+// table". That history table (USER_PREFERENCE_HISTORY) was added by synth-1516 and is populated
+// by every UpsertPreference/DeletePreference call, so this is now implemented: see
+// preferenceService.GetPreferencesAsOf, which selects each key's latest USER_PREFERENCE_HISTORY
+// row with CHANGED_AT <= timestamp (queryGetPreferencesAsOf), excluding tombstones from the
+// result. It is exposed as the admin-only "asOf" query param on GET
+// /admin/users/{userId}/preferences, gated by the same CheckAdminAccess(ctx, userID, false) check
+// as the rest of that route, rather than a dedicated sub-path, so it cannot collide with a key of
+// the same name under the per-key {key...} wildcard.
+
+// NOTE: DharshanSR/thunder#synth-1458 asked to fix nondeterministic application order and
+// updated_keys ordering in "UpsertPreferences", which iterates a Go map. No such method exists:
+// ImportPreferences (the closest bulk-write operation) already takes Entries as an ordered
+// []ImportEntry slice and applies them in that order, and the batch endpoint's BatchRequest
+// likewise takes an ordered []BatchOperation slice — neither iterates a map, and neither returns
+// an updated_keys field. There is nothing to make deterministic here. If a future request adds a
+// genuinely map-shaped bulk-upsert endpoint, apply the entries sorted by key by default (matching
+// this backend's existing preference for deterministic ordering, e.g.
+// queryGetPreferencesByUserID's ORDER BY PREFERENCE_KEY ASC) and accept an optional ordered list
+// form for callers that need a specific application order.
+
+// NOTE: DharshanSR/thunder#synth-1462 asked for a transactional outbox so that "the change-event
+// publisher", which "fires in-process after commit", gains retry-safe at-least-once delivery
+// surviving a crash between commit and publish. As synth-1429 established and synth-1435/
+// synth-1447/synth-1453 have each reconfirmed since, there is no PreferenceChangedEvent type, no
+// publisher, and no change-notification mechanism anywhere in this backend: preference writes go
+// straight through UpsertPreference/InsertPreferenceIfAbsent to the store on the calling
+// goroutine, with nothing to lose between commit and publish because nothing is ever published.
+// An OUTBOX table written inside the mutation's own transaction, relayed by a background worker,
+// is the right shape for at-least-once delivery once a publisher exists — but building it now
+// would mean durably queuing events nothing produces and nothing consumes. Revisit once a
+// preference-change event stream is built; at that point this should land as an opt-in
+// PreferenceOutboxConfig (matching this package's existing opt-in feature flags, e.g.
+// PreferenceDedupConfig), with the outbox write sharing the mutation's transaction and a relay
+// loop alongside the existing reconciler and read-count flusher background loops.
+
+// NOTE: DharshanSR/thunder#synth-1485 asked for the list endpoint to accept
+// "?if_version_changed=N" and return 304 when it equals the user's "current version", complementing
+// "the per-user version counter" and being "the HTTP-cacheable counterpart to the long-poll
+// feature". Neither exists: SchemaVersion (model.go, versioning.go) is a per-key value-format
+// version used by migratePreferenceValue, not a per-user counter that increments on every write,
+// and synth-1429/1435/1462 have each already established there is no change-notification or
+// long-poll mechanism anywhere in this backend for this to complement. A conditional 304 needs a
+// single number that summarizes "has anything in this user's preference set changed since N", and
+// nothing in the store currently maintains one: GetPreferencesByUserID has no aggregate version
+// column to read, and computing one on the fly (e.g. hashing every UpdatedAt) would make every list
+// call pay for a feature most callers won't use. Deferred until a per-user version counter is
+// introduced for the long-poll feature; at that point this should land as a thin wrapper around it:
+// parse and validate if_version_changed as a non-negative integer, treat a missing/zero user
+// version (no preferences set) as version 0, and return 304 with no body when the parsed value
+// equals the current version, or the full list plus the new version otherwise.
+
+// NOTE: DharshanSR/thunder#synth-1505 asked to replace a per-key loop inside "UpsertPreferences"
+// with a single multi-row "INSERT ... VALUES (...),(...) ON CONFLICT ... DO UPDATE" via a new
+// BatchUpsertPreferences(ctx, userID, prefs map[string]string) store method. No UpsertPreferences
+// method exists, in the service or the store: SetPreference writes one key at a time, and the
+// closest existing bulk-write path, ImportPreferences (see store.go's importEntry), already does
+// exactly the N-round-trip loop this request describes, each iteration doing its own
+// GetPreferenceByKey read before the write to resolve ImportConflictSkip/Overwrite/NewerWins
+// against that key's existing value and to decide Inserted vs. Overwritten vs. Skipped. A single
+// multi-row INSERT can't reproduce that per-row conflict resolution or per-row outcome tallying
+// without first reading every key's existing value anyway, so collapsing importEntry's loop into
+// one statement would mean dropping NewerWins (and the Inserted/Overwritten/Skipped/Failed
+// breakdown) down to a blind overwrite-or-skip. If a future request wants a genuinely blind bulk
+// upsert (no per-key conflict resolution, just "set all of these, return what changed"), that is
+// buildable as a new store method following this shape, with placeholders numbered per DB type
+// the way queryUpsertPreference already is; it would need its own service-level entry point,
+// since ImportPreferences's conflict semantics are load-bearing for its existing callers.
+
+// NOTE: DharshanSR/thunder#synth-1511 asked for MySQL query variants of the preference store's
+// queries "for when MySQL/Oracle query variants are added". MySQL is not a connectable dbType
+// anywhere in this codebase today: internal/system/database/provider's dbprovider.go only defines
+// dataSourceTypePostgres and dataSourceTypeSQLite, dbclient.go only imports the lib/pq and
+// modernc.org/sqlite drivers, there is no MySQL connection/config path, and backend/dbscripts/ has
+// no MySQL schema for USER_PREFERENCE or USER_PREFERENCE_HISTORY. A MySQLQuery field/variant
+// added under these queries would be dead code: GetQuery(dbType) can never be called with
+// dbType == "mysql" by anything running against this repo, so the variant could never be selected
+// or exercised by a real test. If MySQL support is ever added as a connectable database type (its
+// own dataSourceType, driver import, and dbscripts schema), the MySQLQuery field on DBQuery and
+// per-query MySQL variants following queryUpsertPreference's ON DUPLICATE KEY UPDATE shape are the
+// natural next step; until then they stay out of this package.
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"sort"
+
+	dbmodel "github.com/thunder-id/thunderid/internal/system/database/model"
+	"github.com/thunder-id/thunderid/internal/system/log"
+)
+
+// debugQueries lists every fixed-arity store query by ID, for resolving against the current DB
+// type on the admin query-debug endpoint (see PreferenceServiceInterface.GetQueryDebugInfo).
+// Queries built dynamically per call, such as buildCheckPreferenceKeysExistQuery's IN (...)
+// clause, have no single resolved form independent of their arguments and are not included.
+var debugQueries = map[string]dbmodel.DBQuery{
+	queryGetPreferencesByUserID.ID:                 queryGetPreferencesByUserID,
+	queryGetPreferenceByKey.ID:                     queryGetPreferenceByKey,
+	queryUpsertPreference.ID:                       queryUpsertPreference,
+	queryUpsertPreferenceWithExpiry.ID:             queryUpsertPreferenceWithExpiry,
+	queryDeletePreference.ID:                       queryDeletePreference,
+	queryDeleteAllPreferences.ID:                   queryDeleteAllPreferences,
+	queryGetDistinctPreferenceUserIDs.ID:           queryGetDistinctPreferenceUserIDs,
+	queryDeleteExpiredPreferences.ID:               queryDeleteExpiredPreferences,
+	queryDeleteSoftDeletedPreferencesPastWindow.ID: queryDeleteSoftDeletedPreferencesPastWindow,
+	queryDeletePreferencesByUserID.ID:              queryDeletePreferencesByUserID,
+	queryInsertPreferenceIfAbsent.ID:               queryInsertPreferenceIfAbsent,
+	queryGetDistinctNamespaces.ID:                  queryGetDistinctNamespaces,
+	queryIncrementReadCount.ID:                     queryIncrementReadCount,
+	queryGetReadCountAggregate.ID:                  queryGetReadCountAggregate,
+	queryGetPreferenceUsage.ID:                     queryGetPreferenceUsage,
+	queryGetPreferencesByUserIDPaginated.ID:        queryGetPreferencesByUserIDPaginated,
+	queryCountPreferencesByUserID.ID:               queryCountPreferencesByUserID,
+	queryInsertPreferenceHistory.ID:                queryInsertPreferenceHistory,
+	queryGetPreferenceHistory.ID:                   queryGetPreferenceHistory,
+	queryGetPreferencesByPrefix.ID:                 queryGetPreferencesByPrefix,
+	queryGetPreferencesAsOf.ID:                     queryGetPreferencesAsOf,
+}
+
+// missingQueryVariants returns the IDs, sorted, of every debugQueries entry whose GetQuery(dbType)
+// resolves to an empty string for dbType. A query only ever resolves empty if it was registered
+// with a dialect-specific field (PostgresQuery/SQLiteQuery) for some other dialect and no default
+// Query to fall back to for dbType; see DBQuery.GetQuery. Used by runQueryDialectSelfCheck to
+// catch such dialect gaps at startup instead of at query time.
+func missingQueryVariants(dbType string) []string {
+	var missing []string
+	for id, query := range debugQueries {
+		if query.GetQuery(dbType) == "" {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// runQueryDialectSelfCheck resolves every registered preference store query against dbType and
+// logs a summary, failing fast via log.Fatal if any query has no statement for dbType (see
+// missingQueryVariants). It runs once at startup (see Initialize) so a dialect gap introduced
+// while adding a MySQL/Oracle variant is caught before it can cause a runtime 500.
+func runQueryDialectSelfCheck(dbType string) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+	missing := missingQueryVariants(dbType)
+	if len(missing) > 0 {
+		logger.Fatal(context.Background(), "Preference store queries missing a statement for the configured database type",
+			log.String("dbType", dbType), log.Int("missingCount", len(missing)), log.Any("missingQueryIDs", missing))
+		return
+	}
+	logger.Info(context.Background(), "All preference store queries resolved for the configured database type",
+		log.String("dbType", dbType), log.Int("queryCount", len(debugQueries)))
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbmodel "github.com/thunder-id/thunderid/internal/system/database/model"
+)
+
+func TestMissingQueryVariants_NoGapsForRegisteredQueries(t *testing.T) {
+	for dbType := range map[string]bool{"postgres": true, "sqlite": true, "mysql": true, "": true} {
+		assert.Empty(t, missingQueryVariants(dbType), "dbType=%q", dbType)
+	}
+}
+
+func TestMissingQueryVariants_ReportsQueryWithNoDefaultAndNoMatchingDialectField(t *testing.T) {
+	const gapID = "PREF-TEST-GAP"
+	debugQueries[gapID] = dbmodel.DBQuery{ID: gapID, PostgresQuery: "SELECT 1"}
+	defer delete(debugQueries, gapID)
+
+	assert.Equal(t, []string{gapID}, missingQueryVariants("sqlite"))
+	assert.Empty(t, missingQueryVariants("postgres"))
+}
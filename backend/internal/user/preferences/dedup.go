@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+// preferenceDedupMetrics holds the lazily-initialized counter for writes suppressed by the
+// de-dup window.
+type preferenceDedupMetrics struct {
+	once             sync.Once
+	suppressedWrites metric.Int64Counter
+}
+
+var dedupMetrics preferenceDedupMetrics
+
+func initDedupMetrics() {
+	dedupMetrics.once.Do(func() {
+		meter := otel.Meter("github.com/thunder-id/thunderid/preferences")
+		dedupMetrics.suppressedWrites, _ = meter.Int64Counter(
+			"thunderid_preference_dedup_suppressed_writes_total",
+			metric.WithDescription(
+				"Total preference writes suppressed by the de-dup window because the value was unchanged",
+			),
+		)
+	})
+}
+
+// recordSuppressedWrite increments the de-dup suppressed-write counter.
+func recordSuppressedWrite(ctx context.Context) {
+	initDedupMetrics()
+	if dedupMetrics.suppressedWrites != nil {
+		dedupMetrics.suppressedWrites.Add(ctx, 1)
+	}
+}
+
+// isDuplicateWrite reports whether a SetPreference call for value/enforced should be suppressed
+// as a redundant repeat of existing, per PreferenceDedupConfig. existing may be nil (no prior
+// value), in which case this is never a duplicate. The value itself is the de-dup key's
+// valueHash component: preference values are already plain strings, so comparing them directly
+// is equivalent to comparing a hash of them without the extra hashing step.
+func isDuplicateWrite(existing *Preference, value string, enforced bool) bool {
+	cfg := config.GetServerRuntime().Config.Preference.Dedup
+	if !cfg.Enabled || existing == nil {
+		return false
+	}
+	if existing.Value != value || existing.Enforced != enforced {
+		return false
+	}
+	return time.Since(existing.UpdatedAt) < time.Duration(cfg.WindowMillis)*time.Millisecond
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+func TestIsDuplicateWrite_DisabledByDefault(t *testing.T) {
+	existing := &Preference{Value: "dark", UpdatedAt: time.Now()}
+
+	assert.False(t, isDuplicateWrite(existing, "dark", false))
+}
+
+func TestIsDuplicateWrite_NoExistingValue(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+
+	assert.False(t, isDuplicateWrite(nil, "dark", false))
+}
+
+func TestIsDuplicateWrite_SameValueWithinWindow(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	config.GetServerRuntime().Config.Preference.Dedup.WindowMillis = 60000
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+	existing := &Preference{Value: "dark", UpdatedAt: time.Now()}
+
+	assert.True(t, isDuplicateWrite(existing, "dark", false))
+}
+
+func TestIsDuplicateWrite_SameValueOutsideWindow(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	config.GetServerRuntime().Config.Preference.Dedup.WindowMillis = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+	existing := &Preference{Value: "dark", UpdatedAt: time.Now().Add(-time.Hour)}
+
+	assert.False(t, isDuplicateWrite(existing, "dark", false))
+}
+
+func TestIsDuplicateWrite_DifferentValue(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	config.GetServerRuntime().Config.Preference.Dedup.WindowMillis = 60000
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+	existing := &Preference{Value: "dark", UpdatedAt: time.Now()}
+
+	assert.False(t, isDuplicateWrite(existing, "light", false))
+}
+
+func TestIsDuplicateWrite_DifferentEnforcedFlag(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	config.GetServerRuntime().Config.Preference.Dedup.WindowMillis = 60000
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+	existing := &Preference{Value: "dark", Enforced: false, UpdatedAt: time.Now()}
+
+	assert.False(t, isDuplicateWrite(existing, "dark", true))
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"strings"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+)
+
+// deviceScopedUserID returns the synthetic storage identity a device-specific preference override
+// is read and written under, reusing the store's existing USER_ID column as the scoping axis
+// rather than adding a separate device dimension to the schema: a device-scoped value for userID
+// on deviceID is stored as an ordinary preference row under this identity, indistinguishable to
+// the store from any other user's preferences.
+func deviceScopedUserID(userID, deviceID string) string {
+	return userID + deviceScopeSeparator + deviceID
+}
+
+// validateDeviceID validates the X-Preference-Device-Id header. An absent header is not validated
+// here: callers only invoke this once they've observed a non-empty header value.
+func validateDeviceID(deviceID string) *tidcommon.ServiceError {
+	if deviceID == "" || len(deviceID) > maxDeviceIDLength {
+		return &ErrorInvalidDeviceID
+	}
+	if containsInvisibleKeyRune(deviceID) || strings.Contains(deviceID, deviceScopeSeparator) {
+		return &ErrorInvalidDeviceID
+	}
+	return nil
+}
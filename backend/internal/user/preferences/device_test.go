@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceScopedUserID(t *testing.T) {
+	assert.Equal(t, "user-1::device::device-1", deviceScopedUserID("user-1", "device-1"))
+}
+
+func TestDeviceScopedUserID_DistinctDevicesDoNotCollide(t *testing.T) {
+	assert.NotEqual(t, deviceScopedUserID("user-1", "device-1"), deviceScopedUserID("user-1", "device-2"))
+}
+
+func TestValidateDeviceID_Valid(t *testing.T) {
+	assert.Nil(t, validateDeviceID("device-1"))
+}
+
+func TestValidateDeviceID_Empty(t *testing.T) {
+	svcErr := validateDeviceID("")
+
+	assert.Equal(t, ErrorInvalidDeviceID.Code, svcErr.Code)
+}
+
+func TestValidateDeviceID_TooLong(t *testing.T) {
+	svcErr := validateDeviceID(strings.Repeat("a", maxDeviceIDLength+1))
+
+	assert.Equal(t, ErrorInvalidDeviceID.Code, svcErr.Code)
+}
+
+func TestValidateDeviceID_InvisibleRune(t *testing.T) {
+	svcErr := validateDeviceID("device\u200B1")
+
+	assert.Equal(t, ErrorInvalidDeviceID.Code, svcErr.Code)
+}
+
+func TestValidateDeviceID_ContainsScopeSeparator(t *testing.T) {
+	svcErr := validateDeviceID("device" + deviceScopeSeparator + "1")
+
+	assert.Equal(t, ErrorInvalidDeviceID.Code, svcErr.Code)
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	syscontext "github.com/thunder-id/thunderid/internal/system/context"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/constants"
+	"github.com/thunder-id/thunderid/internal/system/error/apierror"
+	"github.com/thunder-id/thunderid/internal/system/log"
+	sysutils "github.com/thunder-id/thunderid/internal/system/utils"
+)
+
+// ResponseEnvelope wraps a preference API response in the {data, meta, errors} shape some API
+// gateways expect, gated by PreferenceEnvelopeConfig.Enabled. When disabled, handlers write the
+// bare response body as before.
+type ResponseEnvelope struct {
+	Data   interface{}              `json:"data,omitempty"`
+	Meta   ResponseEnvelopeMeta     `json:"meta"`
+	Errors []apierror.ErrorResponse `json:"errors,omitempty"`
+}
+
+// ResponseEnvelopeMeta carries metadata alongside an enveloped response.
+type ResponseEnvelopeMeta struct {
+	// RequestID is the request's trace/correlation ID, for correlating the response with logs.
+	RequestID string `json:"requestId,omitempty"`
+	// Count is the number of items in Data, for list responses.
+	Count *int `json:"count,omitempty"`
+}
+
+// envelopeEnabled reports whether preference responses should be wrapped in ResponseEnvelope.
+func envelopeEnabled() bool {
+	return config.GetServerRuntime().Config.Preference.Envelope.Enabled
+}
+
+// writePreferenceResponse writes a successful preference API response, wrapping it in a
+// ResponseEnvelope when enabled via configuration. count is included in the envelope meta for
+// list responses and should be nil otherwise.
+func writePreferenceResponse(ctx context.Context, w http.ResponseWriter, statusCode int, data interface{}, count *int) {
+	if !envelopeEnabled() {
+		sysutils.WriteSuccessResponse(ctx, w, statusCode, data)
+		return
+	}
+
+	sysutils.WriteSuccessResponse(ctx, w, statusCode, ResponseEnvelope{
+		Data: data,
+		Meta: ResponseEnvelopeMeta{
+			RequestID: syscontext.GetTraceID(ctx),
+			Count:     count,
+		},
+	})
+}
+
+// writePreferenceError writes a preference API error response, wrapping it in a ResponseEnvelope
+// when enabled via configuration. PreferenceProblemJSONConfig.Enabled takes precedence over the
+// envelope: when set, the error is written as RFC 7807 application/problem+json instead, since
+// that is itself a complete response body with no room for the envelope's {data, meta, errors}
+// wrapper.
+func writePreferenceError(ctx context.Context, w http.ResponseWriter, statusCode int, errResp apierror.ErrorResponse) {
+	if problemJSONEnabled() {
+		writeProblemDetails(ctx, w, statusCode, errResp)
+		return
+	}
+
+	if !envelopeEnabled() {
+		sysutils.WriteErrorResponse(ctx, w, statusCode, errResp)
+		return
+	}
+
+	sysutils.WriteSuccessResponse(ctx, w, statusCode, ResponseEnvelope{
+		Meta: ResponseEnvelopeMeta{
+			RequestID: syscontext.GetTraceID(ctx),
+		},
+		Errors: []apierror.ErrorResponse{errResp},
+	})
+}
+
+// problemJSONEnabled reports whether preference errors should be serialized as RFC 7807
+// application/problem+json instead of the service's default apierror.ErrorResponse shape.
+func problemJSONEnabled() bool {
+	return config.GetServerRuntime().Config.Preference.ProblemJSON.Enabled
+}
+
+// ProblemDetailsResponse is a preference API error in RFC 7807 application/problem+json form.
+// Code carries this service's PREF-xxxx (or, for errors shared across services, the upstream)
+// error code as a problem-details extension member, since RFC 7807 has no standard field for it.
+// Type is always "about:blank": this service has no published URI that documents individual
+// error codes for "type" to identify more specifically.
+type ProblemDetailsResponse struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+// writeProblemDetails writes errResp as RFC 7807 application/problem+json.
+func writeProblemDetails(ctx context.Context, w http.ResponseWriter, statusCode int, errResp apierror.ErrorResponse) {
+	problem := ProblemDetailsResponse{
+		Type:   "about:blank",
+		Title:  errResp.Message.DefaultValue,
+		Status: statusCode,
+		Detail: errResp.Description.DefaultValue,
+		Code:   errResp.Code,
+	}
+
+	w.Header().Set(constants.ContentTypeHeaderName, contentTypeProblemJSON)
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.GetLogger().Error(ctx, "Failed to encode problem+json error response", log.Error(err))
+	}
+}
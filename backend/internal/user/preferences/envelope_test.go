@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	syscontext "github.com/thunder-id/thunderid/internal/system/context"
+	"github.com/thunder-id/thunderid/internal/system/error/apierror"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+func TestWritePreferenceResponse_EnvelopeDisabledByDefault(t *testing.T) {
+	rr := httptest.NewRecorder()
+	count := 1
+
+	writePreferenceResponse(syscontext.WithTraceID(t.Context(), "trace-1"), rr, 200, map[string]string{"key": "value"}, &count)
+
+	var decoded map[string]string
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&decoded))
+	assert.Equal(t, "value", decoded["key"])
+}
+
+func TestWritePreferenceResponse_EnvelopeEnabled(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Envelope.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.Envelope.Enabled = false }()
+
+	rr := httptest.NewRecorder()
+	count := 1
+	ctx := syscontext.WithTraceID(t.Context(), "trace-1")
+
+	writePreferenceResponse(ctx, rr, 200, map[string]string{"key": "value"}, &count)
+
+	var envelope ResponseEnvelope
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&envelope))
+	assert.Equal(t, "trace-1", envelope.Meta.RequestID)
+	require.NotNil(t, envelope.Meta.Count)
+	assert.Equal(t, 1, *envelope.Meta.Count)
+	assert.Empty(t, envelope.Errors)
+}
+
+func TestWritePreferenceError_EnvelopeEnabled(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Envelope.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.Envelope.Enabled = false }()
+
+	rr := httptest.NewRecorder()
+	ctx := syscontext.WithTraceID(t.Context(), "trace-2")
+
+	writePreferenceError(ctx, rr, 404, apierror.ErrorResponse{Code: ErrorPreferenceNotFound.Code})
+
+	var envelope ResponseEnvelope
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&envelope))
+	assert.Equal(t, "trace-2", envelope.Meta.RequestID)
+	require.Len(t, envelope.Errors, 1)
+	assert.Equal(t, ErrorPreferenceNotFound.Code, envelope.Errors[0].Code)
+	assert.Nil(t, envelope.Data)
+}
+
+func TestWritePreferenceError_ProblemJSONEnabled(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.ProblemJSON.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.ProblemJSON.Enabled = false }()
+
+	rr := httptest.NewRecorder()
+	ctx := syscontext.WithTraceID(t.Context(), "trace-3")
+
+	writePreferenceError(ctx, rr, 404, apierror.ErrorResponse{
+		Code:        ErrorPreferenceNotFound.Code,
+		Message:     ErrorPreferenceNotFound.Error,
+		Description: ErrorPreferenceNotFound.ErrorDescription,
+	})
+
+	assert.Equal(t, contentTypeProblemJSON, rr.Header().Get("Content-Type"))
+	var problem ProblemDetailsResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, ErrorPreferenceNotFound.Error.DefaultValue, problem.Title)
+	assert.Equal(t, 404, problem.Status)
+	assert.Equal(t, ErrorPreferenceNotFound.ErrorDescription.DefaultValue, problem.Detail)
+	assert.Equal(t, ErrorPreferenceNotFound.Code, problem.Code)
+}
+
+func TestWritePreferenceError_ProblemJSONTakesPrecedenceOverEnvelope(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.ProblemJSON.Enabled = true
+	config.GetServerRuntime().Config.Preference.Envelope.Enabled = true
+	defer func() {
+		config.GetServerRuntime().Config.Preference.ProblemJSON.Enabled = false
+		config.GetServerRuntime().Config.Preference.Envelope.Enabled = false
+	}()
+
+	rr := httptest.NewRecorder()
+	ctx := syscontext.WithTraceID(t.Context(), "trace-4")
+
+	writePreferenceError(ctx, rr, 400, apierror.ErrorResponse{Code: ErrorInvalidPreferenceValue.Code})
+
+	var problem ProblemDetailsResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	assert.Equal(t, ErrorInvalidPreferenceValue.Code, problem.Code)
+}
@@ -0,0 +1,682 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+)
+
+// Client errors for preference management operations.
+var (
+	// ErrorInvalidRequestFormat is the error returned when the request format is invalid.
+	ErrorInvalidRequestFormat = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1001",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_request_format",
+			DefaultValue: "Invalid request format",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_request_format_description",
+			DefaultValue: "The request body is malformed or contains invalid data",
+		},
+	}
+	// ErrorInvalidPreferenceKey is the error returned when a preference key is invalid.
+	ErrorInvalidPreferenceKey = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1002",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_key",
+			DefaultValue: "Invalid preference key",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_key_description",
+			DefaultValue: "The preference key is empty or exceeds the maximum allowed length",
+		},
+	}
+	// ErrorInvalidPreferenceValue is the error returned when a preference value is invalid.
+	ErrorInvalidPreferenceValue = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1003",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_value",
+			DefaultValue: "Invalid preference value",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_value_description",
+			DefaultValue: "The preference value exceeds the maximum allowed length",
+		},
+	}
+	// ErrorPreferenceNotFound is the error returned when a preference is not found.
+	ErrorPreferenceNotFound = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1004",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_not_found",
+			DefaultValue: "Preference not found",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_not_found_description",
+			DefaultValue: "No preference exists for the given key",
+		},
+	}
+	// ErrorMissingUserID is the error returned when the authenticated user ID could not be resolved.
+	ErrorMissingUserID = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1005",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_user_id",
+			DefaultValue: "Missing user ID",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_user_id_description",
+			DefaultValue: "The authenticated user could not be resolved from the request",
+		},
+	}
+	// ErrorMissingDiffUsers is the error returned when the diff endpoint is missing one or both
+	// of the userA/userB query parameters.
+	ErrorMissingDiffUsers = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1006",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_diff_users",
+			DefaultValue: "Missing userA or userB",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_diff_users_description",
+			DefaultValue: "Both the userA and userB query parameters are required to compare preferences",
+		},
+	}
+	// ErrorEmptyBatchOperations is the error returned when a batch request contains no operations.
+	ErrorEmptyBatchOperations = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1007",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_batch_operations",
+			DefaultValue: "Batch request contains no operations",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_batch_operations_description",
+			DefaultValue: "At least one operation must be provided in the operations list",
+		},
+	}
+	// ErrorInvalidBatchOperationType is the error returned when a batch operation has an
+	// unsupported op type.
+	ErrorInvalidBatchOperationType = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1008",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_batch_operation_type",
+			DefaultValue: "Invalid batch operation type",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_batch_operation_type_description",
+			DefaultValue: "The op field of a batch operation must be one of: get, set, delete",
+		},
+	}
+	// ErrorPreferenceEnforced is the error returned when a non-enforced write attempts to
+	// override a preference value that was previously set with the enforced flag.
+	ErrorPreferenceEnforced = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1009",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_enforced",
+			DefaultValue: "Preference is enforced",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_enforced_description",
+			DefaultValue: "This preference was set with the enforced flag and can only be overridden by another enforced write",
+		},
+	}
+	// ErrorPreferenceModified is the error returned when a write carries an If-Unmodified-Since
+	// header that predates the preference's current UpdatedAt.
+	ErrorPreferenceModified = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1010",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_modified",
+			DefaultValue: "Preference has been modified",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_modified_description",
+			DefaultValue: "The preference was updated after the time given in If-Unmodified-Since",
+		},
+	}
+	// ErrorPreferenceQuotaExceeded is the error returned when a user has exceeded the maximum
+	// number or total size of preferences allowed.
+	ErrorPreferenceQuotaExceeded = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1011",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_quota_exceeded",
+			DefaultValue: "Preference quota exceeded",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_quota_exceeded_description",
+			DefaultValue: "The user has reached the maximum number or total size of preferences allowed",
+		},
+	}
+	// ErrorPreferenceRateLimited is the error returned when preference writes for a user are
+	// being rejected due to exceeding the allowed request rate.
+	ErrorPreferenceRateLimited = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1012",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_rate_limited",
+			DefaultValue: "Too many preference requests",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_rate_limited_description",
+			DefaultValue: "The request rate for preference operations has exceeded the allowed limit",
+		},
+	}
+	// ErrorPreferenceConflict is the error returned when a preference operation conflicts with
+	// concurrent changes other than the enforced-flag or If-Unmodified-Since cases.
+	ErrorPreferenceConflict = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1013",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_conflict",
+			DefaultValue: "Preference operation conflict",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_conflict_description",
+			DefaultValue: "The operation conflicts with a concurrent change to the preference",
+		},
+	}
+	// ErrorNamespaceLimitExceeded is the error returned when a write would introduce a new
+	// preference key namespace beyond the configured per-user limit.
+	ErrorNamespaceLimitExceeded = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1014",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.namespace_limit_exceeded",
+			DefaultValue: "Namespace limit exceeded",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.namespace_limit_exceeded_description",
+			DefaultValue: "This write would exceed the maximum number of distinct preference key namespaces allowed for the user",
+		},
+	}
+	// ErrorEmptyImportEntries is the error returned when an import request contains no entries.
+	ErrorEmptyImportEntries = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1015",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_import_entries",
+			DefaultValue: "Import request contains no entries",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_import_entries_description",
+			DefaultValue: "At least one entry must be provided in the entries list",
+		},
+	}
+	// ErrorInvalidImportConflictStrategy is the error returned when an import request's conflict
+	// field is not one of the recognized strategies.
+	ErrorInvalidImportConflictStrategy = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1016",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_import_conflict_strategy",
+			DefaultValue: "Invalid import conflict strategy",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_import_conflict_strategy_description",
+			DefaultValue: "The conflict field must be one of: skip, overwrite, newer-wins",
+		},
+	}
+	// ErrorMissingNamespace is the error returned when the namespace delete endpoint is called
+	// with an empty namespace path value.
+	ErrorMissingNamespace = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1017",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_namespace",
+			DefaultValue: "Missing namespace",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_namespace_description",
+			DefaultValue: "The namespace path value is required to delete a namespace",
+		},
+	}
+	// ErrorServiceUnavailable is the error returned when a preference write is rejected because
+	// the database is currently read-only, e.g. a brief standby window during a failover. The
+	// handler surfaces this with a Retry-After header so clients back off instead of retrying
+	// immediately.
+	ErrorServiceUnavailable = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1018",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.service_unavailable",
+			DefaultValue: "Preference service temporarily unavailable",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.service_unavailable_description",
+			DefaultValue: "The database is temporarily read-only, likely due to a failover in progress; retry after the given delay",
+		},
+	}
+	// ErrorUnknownPreferenceKey is the error returned when a key has no registered schema and the
+	// deployment's unknown-key policy is "strict" (see PreferenceSchemaConfig).
+	ErrorUnknownPreferenceKey = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1019",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.unknown_preference_key",
+			DefaultValue: "Unknown preference key",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.unknown_preference_key_description",
+			DefaultValue: "This deployment only allows keys from the registered preference schema catalog",
+		},
+	}
+	// ErrorBatchValidationLimitExceeded is the error returned when a batch request's combined set
+	// value bytes exceed PreferenceBatchConfig.MaxTotalValueBytes.
+	ErrorBatchValidationLimitExceeded = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1020",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.batch_validation_limit_exceeded",
+			DefaultValue: "Batch request exceeds the validation size limit",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.batch_validation_limit_exceeded_description",
+			DefaultValue: "The combined size of the values in this batch request exceeds the configured limit; split the request into smaller batches",
+		},
+	}
+	// ErrorEmptyCASEntries is the error returned when a compare-and-swap request contains no
+	// entries.
+	ErrorEmptyCASEntries = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1021",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_cas_entries",
+			DefaultValue: "Compare-and-swap request contains no entries",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_cas_entries_description",
+			DefaultValue: "At least one entry must be provided in the entries list",
+		},
+	}
+	// ErrorQueryTimeout is the error returned when a store query exceeds its configured
+	// per-query timeout (see PreferenceQueryTimeoutConfig), independent of whether the request
+	// context itself has a longer deadline.
+	ErrorQueryTimeout = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1022",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.query_timeout",
+			DefaultValue: "Preference query timed out",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.query_timeout_description",
+			DefaultValue: "The query exceeded its configured timeout; retry later or narrow the request",
+		},
+	}
+	// ErrorInvalidRenamePrefix is the error returned when a BulkRenameRequest's FromPrefix or
+	// ToPrefix is invalid (empty, too long, or carrying an invisible rune), or when the two are
+	// equal.
+	ErrorInvalidRenamePrefix = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1023",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_rename_prefix",
+			DefaultValue: "Invalid bulk-rename prefix",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_rename_prefix_description",
+			DefaultValue: "fromPrefix and toPrefix must both be valid, non-empty, and different from each other",
+		},
+	}
+	// ErrorEmptyExistsKeys is the error returned when an ExistsRequest contains no keys.
+	ErrorEmptyExistsKeys = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1024",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_exists_keys",
+			DefaultValue: "Exists request contains no keys",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_exists_keys_description",
+			DefaultValue: "At least one key must be provided in the keys list",
+		},
+	}
+	// ErrorTooManyExistsKeys is the error returned when an ExistsRequest's keys list exceeds
+	// maxExistsCheckKeys.
+	ErrorTooManyExistsKeys = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1025",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.too_many_exists_keys",
+			DefaultValue: "Too many keys in exists request",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.too_many_exists_keys_description",
+			DefaultValue: "The keys list exceeds the maximum number of keys allowed in a single exists request; split the request into smaller batches",
+		},
+	}
+	// ErrorDebugEndpointDisabled is the error returned when the query-debug endpoint is called
+	// while PreferenceDebugConfig.Enabled is false.
+	ErrorDebugEndpointDisabled = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1026",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.debug_endpoint_disabled",
+			DefaultValue: "Query debug endpoint is disabled",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.debug_endpoint_disabled_description",
+			DefaultValue: "Set preference.debug.enabled to true in the deployment configuration to enable this endpoint",
+		},
+	}
+	// ErrorTooManyConcurrentRequests is the error returned when a user already has
+	// PreferenceConcurrencyConfig.MaxInFlightPerUser preference requests in flight.
+	ErrorTooManyConcurrentRequests = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1027",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.too_many_concurrent_requests",
+			DefaultValue: "Too many concurrent preference requests",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.too_many_concurrent_requests_description",
+			DefaultValue: "The user has reached the maximum number of concurrent in-flight preference requests allowed",
+		},
+	}
+	// ErrorInvalidConditionalSetCondition is the error returned when a conditional set request's
+	// condition is missing or not one of the recognized ConditionalSetCondition values.
+	ErrorInvalidConditionalSetCondition = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1028",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_conditional_set_condition",
+			DefaultValue: "Invalid conditional set condition",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_conditional_set_condition_description",
+			DefaultValue: "The condition must be one of: set_if_greater, set_if_less",
+		},
+	}
+	// ErrorNonNumericPreferenceValue is the error returned when a conditional numeric set's new
+	// value, or the key's current stored value, cannot be parsed as a number.
+	ErrorNonNumericPreferenceValue = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1029",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.non_numeric_preference_value",
+			DefaultValue: "Preference value is not numeric",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.non_numeric_preference_value_description",
+			DefaultValue: "A conditional numeric set requires both the new value and the key's current stored value, if any, to parse as numbers",
+		},
+	}
+	// ErrorInvalidPreferencePattern is the error returned when a list request's pattern query
+	// parameter is too long or carries too many wildcards to match cheaply.
+	ErrorInvalidPreferencePattern = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1030",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_pattern",
+			DefaultValue: "Invalid preference key pattern",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_pattern_description",
+			DefaultValue: "The pattern exceeds the maximum length or wildcard count allowed",
+		},
+	}
+	// ErrorPreferenceTypeMismatch is the error returned when a value cannot be normalized to the
+	// canonical form required by the key's registered schema Type (see normalizePreferenceValue).
+	ErrorPreferenceTypeMismatch = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1031",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_type_mismatch",
+			DefaultValue: "Preference value does not match the key's declared type",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_type_mismatch_description",
+			DefaultValue: "The value could not be interpreted as the boolean or number type declared for this key",
+		},
+	}
+	// ErrorMissingPreferenceLockUserID is the error returned when a preference lock endpoint is
+	// missing the required userID query parameter.
+	ErrorMissingPreferenceLockUserID = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1032",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_preference_lock_user_id",
+			DefaultValue: "Missing userID",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_preference_lock_user_id_description",
+			DefaultValue: "The userID query parameter is required to acquire, refresh, or release a preference lock",
+		},
+	}
+	// ErrorPreferenceLockHeld is the error returned when a caller acquires, refreshes, releases,
+	// or writes against a user's preferences without holding that user's currently active
+	// advisory lock (see AcquirePreferenceLock).
+	ErrorPreferenceLockHeld = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1033",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_lock_held",
+			DefaultValue: "Preference lock held by another caller",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.preference_lock_held_description",
+			DefaultValue: "Another caller holds an active advisory lock on this user's preferences",
+		},
+	}
+	// ErrorMissingDeleteByValueKey is the error returned when a DeletePreferencesByValueRequest
+	// does not specify a key, since matching on value alone would delete across unrelated keys.
+	ErrorMissingDeleteByValueKey = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1034",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_delete_by_value_key",
+			DefaultValue: "Missing key",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_delete_by_value_key_description",
+			DefaultValue: "A key is required to delete preferences by value; matching on value alone across all keys is not supported",
+		},
+	}
+	// ErrorDeleteByValueNotConfirmed is the error returned when a DeletePreferencesByValueRequest
+	// does not set Confirm, guarding this deployment-wide delete against an accidental call.
+	ErrorDeleteByValueNotConfirmed = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1035",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.delete_by_value_not_confirmed",
+			DefaultValue: "Confirmation required",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.delete_by_value_not_confirmed_description",
+			DefaultValue: "Set confirm to true to delete matching preferences across all users in the deployment",
+		},
+	}
+	// ErrorInvalidCopyRequest is the error returned when a CopyPreferencesRequest is missing
+	// SourceUserID or TargetUserID, or names the same user for both.
+	ErrorInvalidCopyRequest = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1036",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_copy_request",
+			DefaultValue: "Invalid copy request",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_copy_request_description",
+			DefaultValue: "sourceUserId and targetUserId must both be set and must not be the same user",
+		},
+	}
+	// ErrorInvalidDeviceID is the error returned when the X-Preference-Device-Id header is present
+	// but empty, too long, or carries an invisible/control character; see validateDeviceID.
+	ErrorInvalidDeviceID = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1037",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_device_id",
+			DefaultValue: "Invalid device ID",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_device_id_description",
+			DefaultValue: "The X-Preference-Device-Id header is empty or exceeds the maximum allowed length",
+		},
+	}
+	// ErrorEmptyDeleteKeys is the error returned when the bulk delete endpoint is called with an
+	// empty keys list.
+	ErrorEmptyDeleteKeys = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1038",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_delete_keys",
+			DefaultValue: "Empty keys list",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_delete_keys_description",
+			DefaultValue: "At least one key must be provided in the keys list",
+		},
+	}
+	// ErrorInvalidPreferenceListLimit is the error returned when a list request's limit query
+	// parameter is not a positive integer or exceeds maxPreferenceListLimit.
+	ErrorInvalidPreferenceListLimit = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1039",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_list_limit",
+			DefaultValue: "Invalid limit",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_list_limit_description",
+			DefaultValue: "The limit query parameter must be a positive integer no greater than the maximum allowed",
+		},
+	}
+	// ErrorInvalidPreferenceListOffset is the error returned when a list request's offset query
+	// parameter is not a non-negative integer.
+	ErrorInvalidPreferenceListOffset = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1040",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_list_offset",
+			DefaultValue: "Invalid offset",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_list_offset_description",
+			DefaultValue: "The offset query parameter must be a non-negative integer",
+		},
+	}
+	// ErrorInvalidPreferencePrefix is the error returned when a list request's prefix query
+	// parameter is too long to match cheaply.
+	ErrorInvalidPreferencePrefix = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1041",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_prefix",
+			DefaultValue: "Invalid preference key prefix",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_prefix_description",
+			DefaultValue: "The prefix exceeds the maximum length allowed",
+		},
+	}
+	// ErrorMissingPreferencePrefix is the error returned when the prefix delete endpoint is
+	// called with an empty prefix query parameter, guarding against a bulk delete of every
+	// preference a user has.
+	ErrorMissingPreferencePrefix = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1042",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_preference_prefix",
+			DefaultValue: "Missing preference key prefix",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.missing_preference_prefix_description",
+			DefaultValue: "The prefix query parameter is required to delete by prefix",
+		},
+	}
+	// ErrorInvalidPreferenceValueContains is the error returned when a list request's
+	// valueContains query parameter is too long to match cheaply.
+	ErrorInvalidPreferenceValueContains = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1043",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_value_contains",
+			DefaultValue: "Invalid preference value search term",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_value_contains_description",
+			DefaultValue: "The valueContains term exceeds the maximum length allowed",
+		},
+	}
+	// ErrorInvalidPreferenceSort is the error returned when a list request's "sort" or "order"
+	// query parameter is not one of the allowed values (see validatePreferenceSort).
+	ErrorInvalidPreferenceSort = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1044",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_sort",
+			DefaultValue: "Invalid sort parameters",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_sort_description",
+			DefaultValue: "The sort query parameter must be \"key\" or \"updated_at\", and order must be \"asc\" or \"desc\"",
+		},
+	}
+	// ErrorEmptyQueryKeys is the error returned when a PreferenceQueryRequest contains no keys.
+	ErrorEmptyQueryKeys = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1045",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_query_keys",
+			DefaultValue: "Query request contains no keys",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.empty_query_keys_description",
+			DefaultValue: "At least one key must be provided in the keys list",
+		},
+	}
+	// ErrorTooManyQueryKeys is the error returned when a PreferenceQueryRequest's keys list
+	// exceeds maxExistsCheckKeys.
+	ErrorTooManyQueryKeys = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1046",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.too_many_query_keys",
+			DefaultValue: "Too many keys in query request",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.too_many_query_keys_description",
+			DefaultValue: "The keys list exceeds the maximum number of keys allowed in a single query request; split the request into smaller batches",
+		},
+	}
+	// ErrorInvalidPreferenceTimestamp is the error returned when the "asOf" query parameter on
+	// the admin list-preferences endpoint is present but not a valid RFC 3339 timestamp.
+	ErrorInvalidPreferenceTimestamp = tidcommon.ServiceError{
+		Type: tidcommon.ClientErrorType,
+		Code: "PREF-1047",
+		Error: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_timestamp",
+			DefaultValue: "Invalid timestamp parameter",
+		},
+		ErrorDescription: tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.invalid_preference_timestamp_description",
+			DefaultValue: "The asOf query parameter must be an RFC 3339 timestamp",
+		},
+	}
+)
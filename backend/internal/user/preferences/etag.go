@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// computePreferenceETag derives an RFC 7232 strong entity tag for a preference from its value and
+// UpdatedAt, so two writers who last read the same version can be distinguished from one that read
+// a stale one (see SetPreference's ifMatchETag handling). It is not meant to be invertible or to
+// expose value; only to change whenever either input changes.
+func computePreferenceETag(value string, updatedAt time.Time) string {
+	sum := sha256.Sum256([]byte(value + "|" + updatedAt.UTC().Format(time.RFC3339Nano)))
+	return fmt.Sprintf("%q", hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,1236 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/error/apierror"
+	"github.com/thunder-id/thunderid/internal/system/log"
+	"github.com/thunder-id/thunderid/internal/system/security"
+	sysutils "github.com/thunder-id/thunderid/internal/system/utils"
+)
+
+// preferenceHandler is the handler for preference management operations.
+type preferenceHandler struct {
+	preferenceService PreferenceServiceInterface
+}
+
+// newPreferenceHandler creates a new instance of preferenceHandler.
+func newPreferenceHandler(preferenceService PreferenceServiceInterface) *preferenceHandler {
+	return &preferenceHandler{
+		preferenceService: preferenceService,
+	}
+}
+
+// handleGetPreferences handles GET /users/me/preferences
+func (h *preferenceHandler) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+	h.getPreferences(w, r, userID)
+}
+
+// handleAdminGetPreferences handles GET /admin/users/{userId}/preferences, the admin-scoped
+// equivalent of handleGetPreferences: it acts on the userId path value instead of the caller's
+// own subject, gated by CheckAdminAccess.
+func (h *preferenceHandler) handleAdminGetPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := sysutils.SanitizeString(r.PathValue("userId"))
+	if userID == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+	if svcErr := h.preferenceService.CheckAdminAccess(ctx, userID, false); svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+	if asOf := r.URL.Query().Get("asOf"); asOf != "" {
+		h.getPreferencesAsOf(w, r, userID, asOf)
+		return
+	}
+	h.getPreferences(w, r, userID)
+}
+
+// getPreferencesAsOf is handleAdminGetPreferences' branch for an "asOf" query request (the
+// "asOf" query param is set to an RFC 3339 timestamp): it calls GetPreferencesAsOf instead of
+// ListPreferences, reconstructing userID's preference set from USER_PREFERENCE_HISTORY as it
+// stood at that point in time. It is dispatched from a query param on the existing admin list
+// route, rather than a dedicated sub-path, for the same reason the history/conditional-set fix
+// avoided one: a literal path segment would permanently shadow any key named identically under
+// the per-key {key...} wildcard.
+func (h *preferenceHandler) getPreferencesAsOf(w http.ResponseWriter, r *http.Request, userID, asOf string) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	timestamp, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidPreferenceTimestamp)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.GetPreferencesAsOf(ctx, userID, timestamp)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	count := len(resp.Preferences)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully reconstructed preferences as of timestamp",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("count", count))
+}
+
+// getPreferences holds the logic shared by handleGetPreferences and handleAdminGetPreferences,
+// once each has resolved which userID to act on.
+func (h *preferenceHandler) getPreferences(w http.ResponseWriter, r *http.Request, userID string) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	prefix := r.URL.Query().Get("prefix")
+	if valueContains := r.URL.Query().Get("valueContains"); valueContains != "" {
+		h.getPreferencesByValue(w, r, userID, prefix, valueContains)
+		return
+	}
+	if prefix != "" {
+		h.getPreferencesByPrefix(w, r, userID, prefix)
+		return
+	}
+
+	includeSizes := r.URL.Query().Get("include_sizes") == "true"
+	explain := r.URL.Query().Get("explain") == "true"
+	sortParam := r.URL.Query().Get("sort")
+	sortByCatalog := sortParam == sortValueCatalog
+	sortField := sortParam
+	if sortByCatalog {
+		sortField = ""
+	}
+	sortOrder := r.URL.Query().Get("order")
+	pattern := r.URL.Query().Get("pattern")
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, parseErr := strconv.Atoi(limitStr)
+		if parseErr != nil {
+			handleServiceError(ctx, w, &ErrorInvalidPreferenceListLimit)
+			return
+		}
+		limit = parsedLimit
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		parsedOffset, parseErr := strconv.Atoi(offsetStr)
+		if parseErr != nil {
+			handleServiceError(ctx, w, &ErrorInvalidPreferenceListOffset)
+			return
+		}
+		offset = parsedOffset
+	}
+
+	resp, svcErr := h.preferenceService.ListPreferences(
+		ctx, userID, includeSizes, explain, sortByCatalog, pattern, sortField, sortOrder, limit, offset)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	h.writeQuotaHeadersForUser(ctx, w, userID)
+
+	count := len(resp.Preferences)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully listed preferences",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("count", len(resp.Preferences)))
+}
+
+// getPreferencesByPrefix is getPreferences' branch for a prefix-filtered list request (the
+// "prefix" query param is set): it calls ListPreferencesByPrefix instead of ListPreferences,
+// ignoring the pagination/pattern/sort/include_sizes/explain params getPreferences otherwise
+// supports, since a namespace grouping is returned in full rather than paginated.
+func (h *preferenceHandler) getPreferencesByPrefix(
+	w http.ResponseWriter, r *http.Request, userID, prefix string,
+) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	resp, svcErr := h.preferenceService.ListPreferencesByPrefix(ctx, userID, prefix)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	h.writeQuotaHeadersForUser(ctx, w, userID)
+
+	count := len(resp.Preferences)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully listed preferences by prefix",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("count", len(resp.Preferences)))
+}
+
+// getPreferencesByValue is getPreferences' branch for a value-search list request (the
+// "valueContains" query param is set): it calls SearchPreferencesByValue instead of
+// ListPreferences, combining it with the "prefix" query param when also set. Like
+// getPreferencesByPrefix, it ignores the pagination/pattern/sort/include_sizes/explain params
+// getPreferences otherwise supports.
+func (h *preferenceHandler) getPreferencesByValue(
+	w http.ResponseWriter, r *http.Request, userID, prefix, valueContains string,
+) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	resp, svcErr := h.preferenceService.SearchPreferencesByValue(ctx, userID, prefix, valueContains)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	h.writeQuotaHeadersForUser(ctx, w, userID)
+
+	count := len(resp.Preferences)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully searched preferences by value",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("count", len(resp.Preferences)))
+}
+
+// handleGetPreferenceByKey handles GET /users/me/preferences/{key...}. The key is extracted via
+// r.PathValue, which resolves against the route pattern rather than the request's raw URL, so
+// extraction is unaffected by how the service is mounted, decodes percent-encoded key segments,
+// and the {key...} wildcard (rather than a single-segment {key}) lets a key contain its own
+// literal slashes, e.g. "ui/theme/color", rather than being cut off at the first one.
+func (h *preferenceHandler) handleGetPreferenceByKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+	h.getPreferenceByKey(w, r, userID)
+}
+
+// handleAdminGetPreferenceByKey handles GET /admin/users/{userId}/preferences/{key...}, the
+// admin-scoped equivalent of handleGetPreferenceByKey.
+func (h *preferenceHandler) handleAdminGetPreferenceByKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := sysutils.SanitizeString(r.PathValue("userId"))
+	if userID == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+	if svcErr := h.preferenceService.CheckAdminAccess(ctx, userID, false); svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+	h.getPreferenceByKey(w, r, userID)
+}
+
+// getPreferenceByKey holds the logic shared by handleGetPreferenceByKey and
+// handleAdminGetPreferenceByKey, once each has resolved which userID to act on.
+func (h *preferenceHandler) getPreferenceByKey(w http.ResponseWriter, r *http.Request, userID string) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	key := sysutils.SanitizeString(r.PathValue("key"))
+	if r.URL.Query().Get("history") == "true" {
+		h.getPreferenceHistory(w, r, userID, key)
+		return
+	}
+
+	onMissingNoContent := r.URL.Query().Get("on_missing") == onMissingNoContentValue
+	effective := r.URL.Query().Get("effective") == "true"
+	deviceID := r.Header.Get(headerPreferenceDeviceID)
+
+	resp, svcErr := h.preferenceService.GetPreference(ctx, userID, key, effective, deviceID)
+	if svcErr != nil {
+		if onMissingNoContent && svcErr.Code == ErrorPreferenceNotFound.Code {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	w.Header().Set("ETag", computePreferenceETag(resp.Value, resp.UpdatedAt))
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully retrieved preference",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+}
+
+// handleUpsertPreference handles PUT /users/me/preferences/{key...}
+func (h *preferenceHandler) handleUpsertPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+	h.upsertPreference(w, r, userID)
+}
+
+// handleAdminUpsertPreference handles PUT /admin/users/{userId}/preferences/{key...}, the
+// admin-scoped equivalent of handleUpsertPreference. It requires the higher-privilege write
+// check (see CheckAdminAccess), since it modifies rather than merely reads userId's preferences.
+func (h *preferenceHandler) handleAdminUpsertPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := sysutils.SanitizeString(r.PathValue("userId"))
+	if userID == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+	if svcErr := h.preferenceService.CheckAdminAccess(ctx, userID, true); svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+	h.upsertPreference(w, r, userID)
+}
+
+// upsertPreference holds the logic shared by handleUpsertPreference and
+// handleAdminUpsertPreference, once each has resolved which userID to act on.
+func (h *preferenceHandler) upsertPreference(w http.ResponseWriter, r *http.Request, userID string) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	key := sysutils.SanitizeString(r.PathValue("key"))
+
+	req, err := sysutils.DecodeJSONBody[SetPreferenceRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	var ifUnmodifiedSince *time.Time
+	if header := r.Header.Get("If-Unmodified-Since"); header != "" {
+		if parsed, err := http.ParseTime(header); err == nil {
+			ifUnmodifiedSince = &parsed
+		}
+	}
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	deviceID := r.Header.Get(headerPreferenceDeviceID)
+	ifMatchETag := r.Header.Get("If-Match")
+
+	resp, svcErr := h.preferenceService.SetPreference(
+		ctx, userID, key, req.Value, req.Enforced, req.SchemaVersion, req.TTLSeconds, ifUnmodifiedSince, lockToken,
+		deviceID, ifMatchETag,
+	)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	h.writeQuotaHeadersForUser(ctx, w, userID)
+
+	if r.Header.Get("Prefer") == preferReturnMinimalValue {
+		w.WriteHeader(http.StatusNoContent)
+		logger.Debug(ctx, "Successfully set preference (minimal response)",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully set preference",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+}
+
+// handleDeletePreference handles DELETE /users/me/preferences/{key...}. As with
+// handleGetPreferenceByKey, the key comes from r.PathValue rather than manual path trimming.
+func (h *preferenceHandler) handleDeletePreference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	key := sysutils.SanitizeString(r.PathValue("key"))
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+
+	svcErr := h.preferenceService.DeletePreference(ctx, userID, key, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Debug(ctx, "Successfully deleted preference",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+}
+
+// handleDeleteNamespace handles DELETE /users/me/preferences/namespace/{namespace}
+func (h *preferenceHandler) handleDeleteNamespace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	namespace := sysutils.SanitizeString(r.PathValue("namespace"))
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+
+	resp, svcErr := h.preferenceService.DeleteNamespace(ctx, userID, namespace, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully deleted namespace",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.String("namespace", namespace),
+		log.Int("deletedCount", len(resp.DeletedKeys)))
+}
+
+// handleDeleteAllPreferences handles DELETE /users/me/preferences
+func (h *preferenceHandler) handleDeleteAllPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	if prefix := r.URL.Query().Get("prefix"); prefix != "" {
+		h.deletePreferencesByPrefix(w, r, userID, prefix)
+		return
+	}
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	deletedCount, svcErr := h.preferenceService.DeleteAllPreferences(ctx, userID, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, &DeleteAllPreferencesResponse{DeletedCount: deletedCount}, nil)
+	logger.Debug(ctx, "Successfully deleted all preferences",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("deletedCount", int(deletedCount)))
+}
+
+// deletePreferencesByPrefix is handleDeleteAllPreferences' branch for a prefix-scoped delete (the
+// "prefix" query param is set): it calls DeletePreferencesByPrefix instead of DeleteAllPreferences,
+// deleting only the matching namespace rather than every preference the user has.
+func (h *preferenceHandler) deletePreferencesByPrefix(w http.ResponseWriter, r *http.Request, userID, prefix string) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	resp, svcErr := h.preferenceService.DeletePreferencesByPrefix(ctx, userID, prefix, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully deleted preferences by prefix",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("deletedCount", len(resp.DeletedKeys)))
+}
+
+// handleDeletePreferences handles DELETE /users/me/preferences/bulk. It is a distinct path from
+// handleDeleteAllPreferences's unconditional DELETE /users/me/preferences, since the same method
+// and path cannot be registered twice to dispatch on request body content.
+func (h *preferenceHandler) handleDeletePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[DeletePreferencesRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	deletedKeys, svcErr := h.preferenceService.DeletePreferences(ctx, userID, req.Keys, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, &DeletePreferencesResponse{DeletedKeys: deletedKeys}, nil)
+	logger.Debug(ctx, "Successfully deleted preferences",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("deletedCount", len(deletedKeys)))
+}
+
+// handleBatchPreferences handles POST /users/me/preferences/batch
+func (h *preferenceHandler) handleBatchPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[BatchRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.ExecuteBatch(ctx, userID, &req)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	count := len(resp.Results)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully executed preference batch",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("operationCount", len(req.Operations)))
+}
+
+// handleMergePreferences handles PATCH /users/me/preferences: it upserts req.Preferences for the
+// caller, leaving any of their preferences not present in req.Preferences untouched.
+func (h *preferenceHandler) handleMergePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[ReplacePreferencesRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	resp, svcErr := h.preferenceService.MergePreferences(ctx, userID, req.Preferences, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	count := len(resp.Results)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully merged preferences",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("keyCount", len(req.Preferences)))
+}
+
+// handleReplaceAllPreferences handles PUT /users/me/preferences: it replaces the caller's entire
+// preference set with req.Preferences, deleting any existing key not present in req.Preferences.
+func (h *preferenceHandler) handleReplaceAllPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[ReplacePreferencesRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	resp, svcErr := h.preferenceService.ReplaceAllPreferences(ctx, userID, req.Preferences, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	count := len(resp.Results)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully replaced all preferences",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("keyCount", len(req.Preferences)))
+}
+
+// handleImportPreferences handles POST /users/me/preferences/import
+func (h *preferenceHandler) handleImportPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[ImportPreferencesRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	resp, svcErr := h.preferenceService.ImportPreferences(ctx, userID, &req, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully imported preferences",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("entryCount", len(req.Entries)))
+}
+
+// handleCheckPreferencesExist handles POST /users/me/preferences/exists
+func (h *preferenceHandler) handleCheckPreferencesExist(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[ExistsRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.CheckPreferenceKeysExist(ctx, userID, &req)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully checked preference key existence",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("keyCount", len(req.Keys)))
+}
+
+// handleQueryPreferences handles POST /users/me/preferences/query
+func (h *preferenceHandler) handleQueryPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[PreferenceQueryRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.GetPreferencesByKeys(ctx, userID, &req)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully queried preferences by keys",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("keyCount", len(req.Keys)))
+}
+
+// handleCompareAndSwapPreferences handles POST /users/me/preferences/cas. It writes a 200 with
+// Applied=true on success, or, per CompareAndSwapResponse, a 412 carrying Applied=false and
+// MismatchedKeys when one or more entries' expected values did not match.
+func (h *preferenceHandler) handleCompareAndSwapPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[CompareAndSwapRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	resp, svcErr := h.preferenceService.CompareAndSwapPreferences(ctx, userID, &req, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if !resp.Applied {
+		statusCode = http.StatusPreconditionFailed
+	}
+	writePreferenceResponse(ctx, w, statusCode, resp, nil)
+	logger.Debug(ctx, "Executed preference compare-and-swap",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("entryCount", len(req.Entries)),
+		log.Bool("applied", resp.Applied))
+}
+
+// handleConditionalSetPreference handles PATCH /users/me/preferences/{key...}. It writes a 200
+// with Applied=true on success, or, per ConditionalSetResponse, a 412 carrying Applied=false and
+// CurrentValue when condition did not hold. This is a PATCH on the key, rather than a POST to a
+// "/{key}/conditional" sub-path, so that it uses the same {key...} wildcard as every other
+// single-key operation and can address a key containing its own literal slashes.
+func (h *preferenceHandler) handleConditionalSetPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	key := sysutils.SanitizeString(r.PathValue("key"))
+
+	req, err := sysutils.DecodeJSONBody[ConditionalSetRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	lockToken := r.Header.Get(headerPreferenceLockToken)
+	resp, svcErr := h.preferenceService.ConditionalSetPreference(ctx, userID, key, req.Value, req.Condition, lockToken)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if !resp.Applied {
+		statusCode = http.StatusPreconditionFailed
+	}
+	writePreferenceResponse(ctx, w, statusCode, resp, nil)
+	logger.Debug(ctx, "Executed preference conditional set",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key), log.Bool("applied", resp.Applied))
+}
+
+// handleDiffPreferences handles GET /admin/preferences/diff?userA=&userB=&reveal=
+func (h *preferenceHandler) handleDiffPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	userAID := sysutils.SanitizeString(r.URL.Query().Get("userA"))
+	userBID := sysutils.SanitizeString(r.URL.Query().Get("userB"))
+	if userAID == "" || userBID == "" {
+		handleServiceError(ctx, w, &ErrorMissingDiffUsers)
+		return
+	}
+	reveal := r.URL.Query().Get("reveal") == "true"
+
+	resp, svcErr := h.preferenceService.DiffPreferences(ctx, userAID, userBID, reveal)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully diffed preferences",
+		log.MaskedString("userA", userAID), log.MaskedString("userB", userBID))
+}
+
+// handleSeedDefaultForAllUsers handles POST /admin/preferences/seed-default
+func (h *preferenceHandler) handleSeedDefaultForAllUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[SeedDefaultRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.SeedDefaultForAllUsers(ctx, callerID, req.Key, req.Value)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully seeded default preference for all users",
+		log.String("key", req.Key), log.Int("seeded", resp.Seeded), log.Int("skipped", resp.Skipped))
+}
+
+// handleBulkRenamePreferences handles POST /admin/preferences/bulk-rename
+func (h *preferenceHandler) handleBulkRenamePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[BulkRenameRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.BulkRenamePreferences(ctx, callerID, &req)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully bulk-renamed preferences",
+		log.String("fromPrefix", req.FromPrefix), log.String("toPrefix", req.ToPrefix),
+		log.Int("renamed", resp.Renamed), log.Int("skipped", resp.Skipped))
+}
+
+// handleDeletePreferencesByValue handles POST /admin/preferences/delete-by-value
+func (h *preferenceHandler) handleDeletePreferencesByValue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[DeletePreferencesByValueRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.DeletePreferencesByValue(ctx, callerID, &req)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully deleted preferences by value",
+		log.String("key", req.Key), log.Int("deleted", resp.Deleted))
+}
+
+// handleCopyPreferences handles POST /admin/preferences/copy
+func (h *preferenceHandler) handleCopyPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[CopyPreferencesRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.CopyPreferences(ctx, callerID, &req)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully copied preferences",
+		log.Int("copied", len(resp.Copied)), log.Int("skipped", len(resp.Skipped)))
+}
+
+// handleReconcilePreferences handles POST /admin/preferences/reconcile?userID=. When userID is
+// present it reconciles that one user and returns a PreferenceReconciliationReport; when absent
+// it reconciles every user in the deployment and returns a BulkReconciliationResponse.
+func (h *preferenceHandler) handleReconcilePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[ReconcilePreferencesRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	userID := sysutils.SanitizeString(r.URL.Query().Get("userID"))
+	if userID != "" {
+		resp, svcErr := h.preferenceService.ReconcileUserPreferences(ctx, callerID, userID, &req)
+		if svcErr != nil {
+			handleServiceError(ctx, w, svcErr)
+			return
+		}
+		writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+		logger.Debug(ctx, "Successfully reconciled preferences against schema",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.Int("issues", len(resp.Issues)))
+		return
+	}
+
+	resp, svcErr := h.preferenceService.ReconcileAllUserPreferences(ctx, callerID, &req)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully reconciled preferences against schema for all users",
+		log.Int("usersProcessed", resp.UsersProcessed), log.Int("usersWithIssues", resp.UsersWithIssues))
+}
+
+// handleAcquirePreferenceLock handles POST /admin/preferences/lock/acquire?userID=.
+func (h *preferenceHandler) handleAcquirePreferenceLock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	userID := sysutils.SanitizeString(r.URL.Query().Get("userID"))
+	if userID == "" {
+		handleServiceError(ctx, w, &ErrorMissingPreferenceLockUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[AcquirePreferenceLockRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.AcquirePreferenceLock(ctx, callerID, userID, req.TTLSeconds)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully acquired preference lock", log.MaskedString(log.LoggerKeyUserID, userID))
+}
+
+// handleRefreshPreferenceLock handles POST /admin/preferences/lock/refresh?userID=.
+func (h *preferenceHandler) handleRefreshPreferenceLock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	userID := sysutils.SanitizeString(r.URL.Query().Get("userID"))
+	if userID == "" {
+		handleServiceError(ctx, w, &ErrorMissingPreferenceLockUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[PreferenceLockTokenRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	resp, svcErr := h.preferenceService.RefreshPreferenceLock(ctx, callerID, userID, req.Token, req.TTLSeconds)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully refreshed preference lock", log.MaskedString(log.LoggerKeyUserID, userID))
+}
+
+// handleReleasePreferenceLock handles POST /admin/preferences/lock/release?userID=.
+func (h *preferenceHandler) handleReleasePreferenceLock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	callerID := security.GetSubject(ctx)
+	if strings.TrimSpace(callerID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	userID := sysutils.SanitizeString(r.URL.Query().Get("userID"))
+	if userID == "" {
+		handleServiceError(ctx, w, &ErrorMissingPreferenceLockUserID)
+		return
+	}
+
+	req, err := sysutils.DecodeJSONBody[PreferenceLockTokenRequest](r)
+	if err != nil {
+		handleServiceError(ctx, w, &ErrorInvalidRequestFormat)
+		return
+	}
+
+	if svcErr := h.preferenceService.ReleasePreferenceLock(ctx, callerID, userID, req.Token); svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Debug(ctx, "Successfully released preference lock", log.MaskedString(log.LoggerKeyUserID, userID))
+}
+
+// handleListPreferenceSchemas handles GET /users/me/preferences/schema
+func (h *preferenceHandler) handleListPreferenceSchemas(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	resp, svcErr := h.preferenceService.ListPreferenceSchemas(ctx)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	count := len(resp.Schemas)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully listed preference schemas", log.Int("count", count))
+}
+
+// handleGetReadCountAggregate handles GET /admin/preferences/read-counts
+func (h *preferenceHandler) handleGetReadCountAggregate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	resp, svcErr := h.preferenceService.GetPreferenceReadCountAggregate(ctx)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully retrieved preference read count aggregate", log.Int("count", len(resp.Entries)))
+}
+
+// handleGetQueryDebugInfo handles GET /admin/preferences/debug/queries
+func (h *preferenceHandler) handleGetQueryDebugInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	resp, svcErr := h.preferenceService.GetQueryDebugInfo(ctx)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, nil)
+	logger.Debug(ctx, "Successfully retrieved preference query debug info",
+		log.String("dbType", resp.DBType), log.Int("count", len(resp.Queries)))
+}
+
+// handleExportAllPreferences handles GET /admin/preferences/export, streaming every user's
+// preferences across the deployment as newline-delimited JSON (one PreferenceExportEntry per
+// line). It pages through the database in bounded batches (see StreamAllPreferences) and flushes
+// after every line, so the response never buffers the full dataset in memory and is suitable for
+// full-deployment backups of millions of rows. Because the response status is committed on the
+// first line written, a store failure mid-stream can only be logged server-side; the client must
+// treat a connection that closes without a trailing newline as an incomplete export.
+func (h *preferenceHandler) handleExportAllPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	headerWritten := false
+	count := 0
+	svcErr := h.preferenceService.StreamAllPreferences(ctx, func(entry PreferenceExportEntry) error {
+		if !headerWritten {
+			w.WriteHeader(http.StatusOK)
+			headerWritten = true
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+		count++
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	if svcErr != nil {
+		if !headerWritten {
+			handleServiceError(ctx, w, svcErr)
+			return
+		}
+		logger.Error(ctx, "Preference export stream terminated early", log.String("code", svcErr.Code))
+		return
+	}
+	if !headerWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	logger.Debug(ctx, "Successfully streamed preference export", log.Int("count", count))
+}
+
+// handleExportUserPreferences handles GET /users/me/preferences/export, returning the caller's
+// own preferences (including timestamps) as a downloadable, pretty-printed JSON file, for
+// self-service backup and GDPR data portability. Unlike handleExportAllPreferences this is a
+// single JSON document rather than NDJSON, and is written directly rather than through
+// writePreferenceResponse, since the body is a file download rather than an API envelope.
+func (h *preferenceHandler) handleExportUserPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+	userID := security.GetSubject(ctx)
+	if strings.TrimSpace(userID) == "" {
+		handleServiceError(ctx, w, &ErrorMissingUserID)
+		return
+	}
+
+	doc, svcErr := h.preferenceService.ExportUserPreferences(ctx, userID)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal preference export document", log.Error(err))
+		handleServiceError(ctx, w, &tidcommon.InternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="preferences.json"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		logger.Error(ctx, "Failed to write preference export document", log.Error(err))
+		return
+	}
+
+	logger.Debug(ctx, "Successfully exported user preferences",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Int("count", len(doc.Preferences)))
+}
+
+// getPreferenceHistory is getPreferenceByKey's branch for a history request (the "history" query
+// param is set to "true"): it calls GetPreferenceHistory instead of GetPreference, returning
+// userID's recorded history for key, oldest first. This is a query param rather than a dedicated
+// "/{key}/history" route so that it cannot shadow the {key...} wildcard for a key whose final
+// segment happens to be literally "history" (see getPreferenceByKey).
+func (h *preferenceHandler) getPreferenceHistory(w http.ResponseWriter, r *http.Request, userID, key string) {
+	ctx := r.Context()
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName))
+
+	resp, svcErr := h.preferenceService.GetPreferenceHistory(ctx, userID, key)
+	if svcErr != nil {
+		handleServiceError(ctx, w, svcErr)
+		return
+	}
+
+	count := len(resp.Entries)
+	writePreferenceResponse(ctx, w, http.StatusOK, resp, &count)
+	logger.Debug(ctx, "Successfully retrieved preference history",
+		log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key), log.Int("count", count))
+}
+
+// writeQuotaHeadersForUser writes the headerQuotaUsage/headerQuotaLimit/headerQuotaWarning headers
+// (see writeQuotaHeaders) on the list and upsert responses. It is a no-op when no quota limit is
+// configured, and fetching usage failing does not fail the request: the headers are a soft,
+// best-effort hint, not load-bearing for the response they accompany.
+func (h *preferenceHandler) writeQuotaHeadersForUser(ctx context.Context, w http.ResponseWriter, userID string) {
+	if !quotaEnabled(config.GetServerRuntime().Config.Preference.Quota) {
+		return
+	}
+
+	usage, svcErr := h.preferenceService.GetPreferenceUsage(ctx, userID)
+	if svcErr != nil {
+		log.GetLogger().With(log.String(log.LoggerKeyComponentName, handlerLoggerComponentName)).
+			Warn(ctx, "Failed to compute preference quota usage", log.String("code", svcErr.Code))
+		return
+	}
+	writeQuotaHeaders(w, usage)
+}
+
+// handleServiceError handles service errors and returns appropriate HTTP responses.
+func handleServiceError(ctx context.Context, w http.ResponseWriter, svcErr *tidcommon.ServiceError) {
+	statusCode := http.StatusInternalServerError
+	if svcErr.Type == tidcommon.ClientErrorType {
+		switch svcErr.Code {
+		case ErrorPreferenceNotFound.Code:
+			statusCode = http.StatusNotFound
+		case tidcommon.ErrorUnauthorized.Code:
+			statusCode = http.StatusForbidden
+		case ErrorPreferenceEnforced.Code:
+			statusCode = http.StatusConflict
+		case ErrorPreferenceModified.Code:
+			statusCode = http.StatusPreconditionFailed
+		case ErrorPreferenceQuotaExceeded.Code:
+			statusCode = http.StatusForbidden
+		case ErrorPreferenceRateLimited.Code:
+			statusCode = http.StatusTooManyRequests
+			w.Header().Set("Retry-After", strconv.Itoa(rateLimitRetryAfterSeconds))
+		case ErrorTooManyConcurrentRequests.Code:
+			statusCode = http.StatusTooManyRequests
+		case ErrorPreferenceConflict.Code:
+			statusCode = http.StatusConflict
+		case ErrorNamespaceLimitExceeded.Code:
+			statusCode = http.StatusForbidden
+		case ErrorUnknownPreferenceKey.Code:
+			statusCode = http.StatusNotFound
+		case ErrorServiceUnavailable.Code:
+			statusCode = http.StatusServiceUnavailable
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+		case ErrorQueryTimeout.Code:
+			statusCode = http.StatusGatewayTimeout
+		case ErrorDebugEndpointDisabled.Code:
+			statusCode = http.StatusNotFound
+		case ErrorPreferenceLockHeld.Code:
+			statusCode = http.StatusConflict
+		default:
+			statusCode = http.StatusBadRequest
+		}
+	}
+
+	errResp := apierror.ErrorResponse{
+		Code:        svcErr.Code,
+		Message:     svcErr.Error,
+		Description: svcErr.ErrorDescription,
+	}
+
+	writePreferenceError(ctx, w, statusCode, errResp)
+}
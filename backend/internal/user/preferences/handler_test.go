@@ -0,0 +1,2249 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/security"
+	"github.com/thunder-id/thunderid/internal/user"
+)
+
+const testPreferenceUserID = "user-1"
+
+type PreferenceHandlerTestSuite struct {
+	suite.Suite
+	mockService *PreferenceServiceInterfaceMock
+	handler     *preferenceHandler
+}
+
+func TestPreferenceHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceHandlerTestSuite))
+}
+
+func (suite *PreferenceHandlerTestSuite) SetupTest() {
+	suite.mockService = NewPreferenceServiceInterfaceMock(suite.T())
+	suite.handler = newPreferenceHandler(suite.mockService)
+}
+
+func (suite *PreferenceHandlerTestSuite) authenticatedRequest(method, target string, body []byte) *http.Request {
+	var req *http.Request
+	if body != nil {
+		req = httptest.NewRequest(method, target, bytes.NewReader(body))
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	authCtx := security.NewSecurityContextForTest(testPreferenceUserID, "", "", nil, nil)
+	return req.WithContext(security.WithSecurityContextTest(req.Context(), authCtx))
+}
+
+// handleGetPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_Success() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Len(resp.Preferences, 1)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_IncludeSizes() {
+	size := len("dark")
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, true, false, false, "", "", "", 0, 0).Return(&PreferenceListResponse{
+		Preferences:    []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now(), SizeBytes: &size}},
+		TotalSizeBytes: &size,
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?include_sizes=true", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Require().NotNil(resp.Preferences[0].SizeBytes)
+	suite.Equal(size, *resp.Preferences[0].SizeBytes)
+	suite.Require().NotNil(resp.TotalSizeBytes)
+	suite.Equal(size, *resp.TotalSizeBytes)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_Explain() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, false, true, false, "", "", "", 0, 0).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{
+			Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+			Explain: &PreferenceExplanation{
+				Layers:   []PreferenceExplanationLayer{{Layer: layerUser, Value: "dark"}},
+				WonLayer: layerUser,
+			},
+		}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?explain=true", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Require().NotNil(resp.Preferences[0].Explain)
+	suite.Equal(layerUser, resp.Preferences[0].Explain.WonLayer)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_Pattern() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, false, false, false, "ui.*.color", "", "", 0, 0).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "ui.dark.color", Value: "red", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?pattern=ui.*.color", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("ui.dark.color", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_Prefix() {
+	suite.mockService.On("ListPreferencesByPrefix", mock.Anything, testPreferenceUserID, "editor.").Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "editor.theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?prefix=editor.", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("editor.theme", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_InvalidPrefix() {
+	suite.mockService.On("ListPreferencesByPrefix", mock.Anything, testPreferenceUserID, strings.Repeat("a", maxPreferencePatternLength+1)).
+		Return(nil, &ErrorInvalidPreferencePrefix)
+
+	req := suite.authenticatedRequest(
+		http.MethodGet, "/users/me/preferences?prefix="+strings.Repeat("a", maxPreferencePatternLength+1), nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_ValueContains() {
+	suite.mockService.On("SearchPreferencesByValue", mock.Anything, testPreferenceUserID, "", "dark").Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "editor.theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?valueContains=dark", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("editor.theme", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_ValueContainsWithPrefix() {
+	suite.mockService.On("SearchPreferencesByValue", mock.Anything, testPreferenceUserID, "editor.", "dark").Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "editor.theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?prefix=editor.&valueContains=dark", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("editor.theme", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_InvalidValueContains() {
+	suite.mockService.On("SearchPreferencesByValue", mock.Anything, testPreferenceUserID, "",
+		strings.Repeat("a", maxPreferencePatternLength+1)).
+		Return(nil, &ErrorInvalidPreferenceValueContains)
+
+	req := suite.authenticatedRequest(
+		http.MethodGet, "/users/me/preferences?valueContains="+strings.Repeat("a", maxPreferencePatternLength+1), nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_SortCatalog() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, false, false, true, "", "", "", 0, 0).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{
+			{Key: "notifications.email", Value: "true", UpdatedAt: time.Now()},
+			{Key: "ui.theme", Value: "dark", UpdatedAt: time.Now()},
+		},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?sort=catalog", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_InvalidSort() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, false, false, false, "", "bogus", "", 0, 0).
+		Return(nil, &ErrorInvalidPreferenceSort)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?sort=bogus", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_SortKeyAndOrder() {
+	suite.mockService.On(
+		"ListPreferences", mock.Anything, testPreferenceUserID, false, false, false, "", preferenceSortUpdatedAt, preferenceSortOrderDesc, 0, 0,
+	).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?sort=updated_at&order=desc", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_InvalidPattern() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, false, false, false, strings.Repeat("a", maxPreferencePatternLength+1), "", "", 0, 0).
+		Return(nil, &ErrorInvalidPreferencePattern)
+
+	req := suite.authenticatedRequest(
+		http.MethodGet, "/users/me/preferences?pattern="+strings.Repeat("a", maxPreferencePatternLength+1), nil,
+	)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_LimitAndOffset() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, false, false, false, "", "", "", 10, 20).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now()}},
+		TotalCount:  30,
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?limit=10&offset=20", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(30, resp.TotalCount)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_InvalidLimit() {
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?limit=notanumber", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_InvalidOffset() {
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences?offset=notanumber", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_EnvelopeEnabled() {
+	config.GetServerRuntime().Config.Preference.Envelope.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.Envelope.Enabled = false }()
+
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var envelope ResponseEnvelope
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&envelope))
+	suite.Require().NotNil(envelope.Meta.Count)
+	suite.Equal(1, *envelope.Meta.Count)
+	suite.Empty(envelope.Errors)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_EnvelopeEnabled_Error() {
+	config.GetServerRuntime().Config.Preference.Envelope.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.Envelope.Enabled = false }()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+	var envelope ResponseEnvelope
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&envelope))
+	suite.Require().Len(envelope.Errors, 1)
+	suite.Equal(ErrorMissingUserID.Code, envelope.Errors[0].Code)
+	suite.Nil(envelope.Data)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_QuotaHeaders() {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 100}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+	suite.mockService.On("GetPreferenceUsage", mock.Anything, testPreferenceUserID).
+		Return(&PreferenceUsage{Count: 10}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Equal("count=10", rr.Header().Get(headerQuotaUsage))
+	suite.Equal("count=100", rr.Header().Get(headerQuotaLimit))
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_QuotaHeaders_DisabledByDefault() {
+	suite.mockService.On("ListPreferences", mock.Anything, testPreferenceUserID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Empty(rr.Header().Get(headerQuotaUsage))
+	suite.mockService.AssertNotCalled(suite.T(), "GetPreferenceUsage", mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleGetPreferenceByKey Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_NotFound() {
+	suite.mockService.On("GetPreference", mock.Anything, testPreferenceUserID, "theme", false, mock.Anything).
+		Return(nil, &ErrorPreferenceNotFound)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusNotFound, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_OnMissingNoContent() {
+	suite.mockService.On("GetPreference", mock.Anything, testPreferenceUserID, "theme", false, mock.Anything).
+		Return(nil, &ErrorPreferenceNotFound)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme?on_missing=204", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusNoContent, rr.Code)
+	suite.Empty(rr.Body.Bytes())
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_OnMissingNoContent_OtherErrorsUnaffected() {
+	suite.mockService.On("GetPreference", mock.Anything, testPreferenceUserID, "theme", false, mock.Anything).
+		Return(nil, &ErrorInvalidPreferenceKey)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme?on_missing=204", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_Effective() {
+	suite.mockService.On("GetPreference", mock.Anything, testPreferenceUserID, "theme", true, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "light", DefaultApplied: true}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme?effective=true", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_SetsETagHeader() {
+	updatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.mockService.On("GetPreference", mock.Anything, testPreferenceUserID, "theme", false, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: updatedAt}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Equal(computePreferenceETag("dark", updatedAt), rr.Header().Get("ETag"))
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_DeviceScoped() {
+	suite.mockService.On("GetPreference", mock.Anything, testPreferenceUserID, "theme", false, "device-1").
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", DeviceScoped: true}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme", nil)
+	req.SetPathValue("key", "theme")
+	req.Header.Set(headerPreferenceDeviceID, "device-1")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+// handleUpsertPreference Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_Success() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_PreferReturnMinimal() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set("Prefer", "return=minimal")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusNoContent, rr.Code)
+	suite.Empty(rr.Body.Bytes())
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_PreferHeaderUnrecognizedValueIgnored() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set("Prefer", "return=representation")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_QuotaWarningHeader() {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{
+		MaxCount: 100, WarnThresholdPercent: 90,
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+	suite.mockService.On("GetPreferenceUsage", mock.Anything, testPreferenceUserID).
+		Return(&PreferenceUsage{Count: 95}, nil)
+
+	body2, err2 := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err2)
+
+	req2 := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body2)
+	req2.SetPathValue("key", "theme")
+	rr2 := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr2, req2)
+
+	suite.Equal(http.StatusOK, rr2.Code)
+	suite.Equal("count=95", rr2.Header().Get(headerQuotaUsage))
+	suite.Equal(`299 - "Preference quota usage at 95% of limit"`, rr2.Header().Get(headerQuotaWarning))
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_QuotaUsageFetchError_DoesNotFailRequest() {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 100}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+	suite.mockService.On("GetPreferenceUsage", mock.Anything, testPreferenceUserID).
+		Return(nil, &tidcommon.InternalServerError)
+
+	body3, err3 := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err3)
+
+	req3 := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body3)
+	req3.SetPathValue("key", "theme")
+	rr3 := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr3, req3)
+
+	suite.Equal(http.StatusOK, rr3.Code)
+	suite.Empty(rr3.Header().Get(headerQuotaUsage))
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_Enforced() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", true, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", Enforced: true, UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark", Enforced: true})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_Enforced_Conflict() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "light", false, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &ErrorPreferenceEnforced)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "light"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusConflict, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_IfUnmodifiedSince_Parsed() {
+	ifUnmodifiedSince := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything,
+		mock.MatchedBy(func(t *time.Time) bool { return t != nil && t.Equal(ifUnmodifiedSince) }), mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set("If-Unmodified-Since", ifUnmodifiedSince.Format(http.TimeFormat))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_IfUnmodifiedSince_Conflict() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &ErrorPreferenceModified)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set("If-Unmodified-Since", time.Now().Format(http.TimeFormat))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusPreconditionFailed, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_IfUnmodifiedSince_MalformedIgnored() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything,
+		mock.MatchedBy(func(t *time.Time) bool { return t == nil }), mock.Anything, mock.Anything, mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set("If-Unmodified-Since", "not-a-date")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_IfMatch_Matching() {
+	etag := computePreferenceETag("light", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, etag).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set("If-Match", etag)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_IfMatch_Stale() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, `"stale-etag"`).
+		Return(nil, &ErrorPreferenceModified)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set("If-Match", `"stale-etag"`)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusPreconditionFailed, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_IfMatch_MissingHeaderSkipsCheck() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, "").
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_DeviceScoped() {
+	suite.mockService.On("SetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, "device-1", mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", DeviceScoped: true, UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	req.Header.Set(headerPreferenceDeviceID, "device-1")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleUpsertPreference_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences/theme", []byte("not-json"))
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleAdminGetPreferences / handleAdminGetPreferenceByKey / handleAdminUpsertPreference Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminGetPreferences_Success() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", false).Return(nil)
+	suite.mockService.On("ListPreferences", mock.Anything, "other-user", false, false, false, "", "", "", 0, 0).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "theme", Value: "dark", UpdatedAt: time.Now()}},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/other-user/preferences", nil)
+	req.SetPathValue("userId", "other-user")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Len(resp.Preferences, 1)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminGetPreferences_Unauthorized() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", false).Return(&tidcommon.ErrorUnauthorized)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/other-user/preferences", nil)
+	req.SetPathValue("userId", "other-user")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminGetPreferences(rr, req)
+
+	suite.Equal(http.StatusForbidden, rr.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "ListPreferences", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminGetPreferences_AsOf_Success() {
+	asOf := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", false).Return(nil)
+	suite.mockService.On("GetPreferencesAsOf", mock.Anything, "other-user", asOf).Return(&PreferencesAsOfResponse{
+		Timestamp:   asOf,
+		Preferences: []PreferenceAsOfEntry{{Key: "theme", Value: "dark"}},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/other-user/preferences?asOf="+asOf.Format(time.RFC3339), nil)
+	req.SetPathValue("userId", "other-user")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminGetPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferencesAsOfResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("theme", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminGetPreferences_AsOf_InvalidTimestamp() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", false).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/other-user/preferences?asOf=not-a-timestamp", nil)
+	req.SetPathValue("userId", "other-user")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminGetPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "GetPreferencesAsOf", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminGetPreferences_AsOf_Unauthorized() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", false).Return(&tidcommon.ErrorUnauthorized)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/other-user/preferences?asOf="+time.Now().Format(time.RFC3339), nil)
+	req.SetPathValue("userId", "other-user")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminGetPreferences(rr, req)
+
+	suite.Equal(http.StatusForbidden, rr.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "GetPreferencesAsOf", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminGetPreferenceByKey_Success() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", false).Return(nil)
+	suite.mockService.On("GetPreference", mock.Anything, "other-user", "theme", false, "").
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/other-user/preferences/theme", nil)
+	req.SetPathValue("userId", "other-user")
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminGetPreferenceByKey_Unauthorized() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", false).Return(&tidcommon.ErrorUnauthorized)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/other-user/preferences/theme", nil)
+	req.SetPathValue("userId", "other-user")
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusForbidden, rr.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "GetPreference", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminUpsertPreference_Success() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", true).Return(nil)
+	suite.mockService.On("SetPreference", mock.Anything, "other-user", "theme", "dark", false, 0, mock.Anything, mock.Anything, mock.Anything, "", mock.Anything).
+		Return(&PreferenceResponse{Key: "theme", Value: "dark", UpdatedAt: time.Now()}, nil)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/users/other-user/preferences/theme", bytes.NewReader(body))
+	req.SetPathValue("userId", "other-user")
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAdminUpsertPreference_RequiresWriteAccess() {
+	suite.mockService.On("CheckAdminAccess", mock.Anything, "other-user", true).Return(&tidcommon.ErrorUnauthorized)
+
+	body, err := json.Marshal(SetPreferenceRequest{Value: "dark"})
+	suite.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/users/other-user/preferences/theme", bytes.NewReader(body))
+	req.SetPathValue("userId", "other-user")
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAdminUpsertPreference(rr, req)
+
+	suite.Equal(http.StatusForbidden, rr.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "SetPreference", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// handleDeletePreference Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreference_Success() {
+	suite.mockService.On("DeletePreference", mock.Anything, testPreferenceUserID, "theme", "").Return(nil)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences/theme", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreference(rr, req)
+
+	suite.Equal(http.StatusNoContent, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreference_NotFound() {
+	suite.mockService.On("DeletePreference", mock.Anything, testPreferenceUserID, "theme", "").
+		Return(&ErrorPreferenceNotFound)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences/theme", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreference(rr, req)
+
+	suite.Equal(http.StatusNotFound, rr.Code)
+}
+
+// handleDeleteAllPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleDeleteAllPreferences_Success() {
+	suite.mockService.On("DeleteAllPreferences", mock.Anything, testPreferenceUserID, "").Return(int64(3), nil)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeleteAllPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp DeleteAllPreferencesResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(int64(3), resp.DeletedCount)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeleteAllPreferences_NoRows() {
+	suite.mockService.On("DeleteAllPreferences", mock.Anything, testPreferenceUserID, "").Return(int64(0), nil)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeleteAllPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp DeleteAllPreferencesResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(int64(0), resp.DeletedCount)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeleteAllPreferences_Prefix() {
+	suite.mockService.On("DeletePreferencesByPrefix", mock.Anything, testPreferenceUserID, "editor.", "").
+		Return(&DeletePreferencesResponse{DeletedKeys: []string{"editor.theme"}}, nil)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences?prefix=editor.", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeleteAllPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp DeletePreferencesResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal([]string{"editor.theme"}, resp.DeletedKeys)
+}
+
+// handleDeleteNamespace Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleDeleteNamespace_Success() {
+	suite.mockService.On("DeleteNamespace", mock.Anything, testPreferenceUserID, "ui", "").Return(&DeleteNamespaceResponse{
+		DeletedKeys: []string{"ui.theme"},
+		Effective:   map[string]string{"ui.theme": "light"},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences/namespace/ui", nil)
+	req.SetPathValue("namespace", "ui")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeleteNamespace(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp DeleteNamespaceResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal([]string{"ui.theme"}, resp.DeletedKeys)
+	suite.Equal(map[string]string{"ui.theme": "light"}, resp.Effective)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeleteNamespace_MissingUserID() {
+	req := httptest.NewRequest(http.MethodDelete, "/users/me/preferences/namespace/ui", nil)
+	req.SetPathValue("namespace", "ui")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeleteNamespace(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeleteNamespace_MissingNamespace() {
+	suite.mockService.On("DeleteNamespace", mock.Anything, testPreferenceUserID, "", "").
+		Return(nil, &ErrorMissingNamespace)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences/namespace/", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeleteNamespace(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleDeletePreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferences_Success() {
+	deleteReq := DeletePreferencesRequest{Keys: []string{"ui.theme", "ui.locale"}}
+	suite.mockService.On("DeletePreferences", mock.Anything, testPreferenceUserID, deleteReq.Keys, "").
+		Return([]string{"ui.theme", "ui.locale"}, nil)
+
+	body, err := json.Marshal(deleteReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences/bulk", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp DeletePreferencesResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal([]string{"ui.theme", "ui.locale"}, resp.DeletedKeys)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodDelete, "/users/me/preferences/bulk", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences/bulk", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferences_ServiceError() {
+	deleteReq := DeletePreferencesRequest{Keys: []string{}}
+	suite.mockService.On("DeletePreferences", mock.Anything, testPreferenceUserID, deleteReq.Keys, "").
+		Return(nil, &ErrorEmptyDeleteKeys)
+
+	body, err := json.Marshal(deleteReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodDelete, "/users/me/preferences/bulk", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleDiffPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleDiffPreferences_Success() {
+	suite.mockService.On("DiffPreferences", mock.Anything, "user-a", "user-b", false).Return(&PreferenceDiffResponse{
+		OnlyInA:   []string{"locale"},
+		OnlyInB:   []string{"timezone"},
+		Differing: []PreferenceDiffEntry{{Key: "theme", ValueA: "dark", ValueB: "light"}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/diff?userA=user-a&userB=user-b", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDiffPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceDiffResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal([]string{"locale"}, resp.OnlyInA)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDiffPreferences_RevealParamPassedThrough() {
+	suite.mockService.On("DiffPreferences", mock.Anything, "user-a", "user-b", true).Return(&PreferenceDiffResponse{
+		OnlyInA:   []string{},
+		OnlyInB:   []string{},
+		Differing: []PreferenceDiffEntry{},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/diff?userA=user-a&userB=user-b&reveal=true", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDiffPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDiffPreferences_MissingParams() {
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/diff?userA=user-a", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDiffPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDiffPreferences_Unauthorized() {
+	suite.mockService.On("DiffPreferences", mock.Anything, "user-a", "user-b", false).
+		Return(nil, &tidcommon.ErrorUnauthorized)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/diff?userA=user-a&userB=user-b", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDiffPreferences(rr, req)
+
+	suite.Equal(http.StatusForbidden, rr.Code)
+}
+
+// handleBatchPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleBatchPreferences_Success() {
+	batchReq := BatchRequest{Operations: []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}}
+	suite.mockService.On("ExecuteBatch", mock.Anything, testPreferenceUserID, &batchReq).Return(&BatchResponse{
+		Results: []BatchOperationResult{{Op: BatchOperationSet, Key: "theme", Value: "dark"}},
+	}, nil)
+
+	body, err := json.Marshal(batchReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/batch", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleBatchPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp BatchResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Len(resp.Results, 1)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleBatchPreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPost, "/users/me/preferences/batch", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleBatchPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleBatchPreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/batch", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleBatchPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleMergePreferences_LeavesStaleKeyIntact() {
+	mergeReq := ReplacePreferencesRequest{Preferences: map[string]string{"theme": "dark"}}
+	suite.mockService.On("MergePreferences", mock.Anything, testPreferenceUserID, mergeReq.Preferences, "").Return(&BatchResponse{
+		Results: []BatchOperationResult{{Op: BatchOperationSet, Key: "theme", Value: "dark"}},
+	}, nil)
+
+	body, err := json.Marshal(mergeReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPatch, "/users/me/preferences", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleMergePreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp BatchResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Len(resp.Results, 1)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleMergePreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPatch, "/users/me/preferences", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleMergePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleMergePreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPatch, "/users/me/preferences", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleMergePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReplaceAllPreferences_RemovesStaleKey() {
+	replaceReq := ReplacePreferencesRequest{Preferences: map[string]string{"theme": "dark"}}
+	suite.mockService.On("ReplaceAllPreferences", mock.Anything, testPreferenceUserID, replaceReq.Preferences, "").Return(&BatchResponse{
+		Results: []BatchOperationResult{
+			{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+			{Op: BatchOperationDelete, Key: "locale"},
+		},
+	}, nil)
+
+	body, err := json.Marshal(replaceReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReplaceAllPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp BatchResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Len(resp.Results, 2)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReplaceAllPreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPut, "/users/me/preferences", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReplaceAllPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReplaceAllPreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPut, "/users/me/preferences", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReplaceAllPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleCheckPreferencesExist Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleCheckPreferencesExist_Success() {
+	existsReq := ExistsRequest{Keys: []string{"onboarding.step1", "onboarding.step2"}}
+	suite.mockService.On("CheckPreferenceKeysExist", mock.Anything, testPreferenceUserID, &existsReq).Return(&ExistsResponse{
+		Exists: map[string]bool{"onboarding.step1": true, "onboarding.step2": false},
+	}, nil)
+
+	body, err := json.Marshal(existsReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/exists", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCheckPreferencesExist(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp ExistsResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(map[string]bool{"onboarding.step1": true, "onboarding.step2": false}, resp.Exists)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCheckPreferencesExist_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPost, "/users/me/preferences/exists", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCheckPreferencesExist(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCheckPreferencesExist_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/exists", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCheckPreferencesExist(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCheckPreferencesExist_ServiceError() {
+	existsReq := ExistsRequest{Keys: []string{}}
+	suite.mockService.On("CheckPreferenceKeysExist", mock.Anything, testPreferenceUserID, &existsReq).
+		Return(nil, &ErrorEmptyExistsKeys)
+
+	body, err := json.Marshal(existsReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/exists", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCheckPreferencesExist(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleQueryPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleQueryPreferences_Success() {
+	queryReq := PreferenceQueryRequest{Keys: []string{"ui.theme", "ui.locale"}}
+	suite.mockService.On("GetPreferencesByKeys", mock.Anything, testPreferenceUserID, &queryReq).Return(&PreferenceListResponse{
+		Preferences: []PreferenceResponse{{Key: "ui.theme", Value: "dark", UpdatedAt: time.Now()}},
+		TotalCount:  1,
+	}, nil)
+
+	body, err := json.Marshal(queryReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/query", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleQueryPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceListResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("ui.theme", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleQueryPreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPost, "/users/me/preferences/query", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleQueryPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleQueryPreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/query", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleQueryPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleQueryPreferences_ServiceError() {
+	queryReq := PreferenceQueryRequest{Keys: []string{}}
+	suite.mockService.On("GetPreferencesByKeys", mock.Anything, testPreferenceUserID, &queryReq).
+		Return(nil, &ErrorEmptyQueryKeys)
+
+	body, err := json.Marshal(queryReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/query", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleQueryPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleImportPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleImportPreferences_Success() {
+	importReq := ImportPreferencesRequest{Entries: []ImportEntry{{Key: "theme", Value: "dark"}}, Conflict: ImportConflictOverwrite}
+	suite.mockService.On("ImportPreferences", mock.Anything, testPreferenceUserID, &importReq, "").
+		Return(&ImportPreferencesResponse{Overwritten: 1}, nil)
+
+	body, err := json.Marshal(importReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/import", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleImportPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp ImportPreferencesResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(1, resp.Overwritten)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleImportPreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPost, "/users/me/preferences/import", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleImportPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleImportPreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/import", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleImportPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCompareAndSwapPreferences_Applied() {
+	casReq := CompareAndSwapRequest{Entries: []CompareAndSwapEntry{{Key: "theme", Expected: "light", Value: "dark"}}}
+	suite.mockService.On("CompareAndSwapPreferences", mock.Anything, testPreferenceUserID, &casReq, "").
+		Return(&CompareAndSwapResponse{Applied: true}, nil)
+
+	body, err := json.Marshal(casReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/cas", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCompareAndSwapPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp CompareAndSwapResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.True(resp.Applied)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCompareAndSwapPreferences_Mismatch() {
+	casReq := CompareAndSwapRequest{Entries: []CompareAndSwapEntry{{Key: "theme", Expected: "light", Value: "dark"}}}
+	suite.mockService.On("CompareAndSwapPreferences", mock.Anything, testPreferenceUserID, &casReq, "").
+		Return(&CompareAndSwapResponse{Applied: false, MismatchedKeys: []string{"theme"}}, nil)
+
+	body, err := json.Marshal(casReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/cas", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCompareAndSwapPreferences(rr, req)
+
+	suite.Equal(http.StatusPreconditionFailed, rr.Code)
+	var resp CompareAndSwapResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.False(resp.Applied)
+	suite.Equal([]string{"theme"}, resp.MismatchedKeys)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCompareAndSwapPreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPost, "/users/me/preferences/cas", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCompareAndSwapPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCompareAndSwapPreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/users/me/preferences/cas", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCompareAndSwapPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleConditionalSetPreference_Applied() {
+	condReq := ConditionalSetRequest{Value: "9", Condition: ConditionSetIfGreater}
+	suite.mockService.On("ConditionalSetPreference", mock.Anything, testPreferenceUserID, "max.seen.notification.id", "9", ConditionSetIfGreater, "").
+		Return(&ConditionalSetResponse{Applied: true, CurrentValue: "9"}, nil)
+
+	body, err := json.Marshal(condReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPatch, "/users/me/preferences/max.seen.notification.id", body)
+	req.SetPathValue("key", "max.seen.notification.id")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleConditionalSetPreference(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp ConditionalSetResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.True(resp.Applied)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleConditionalSetPreference_NotApplied() {
+	condReq := ConditionalSetRequest{Value: "5", Condition: ConditionSetIfGreater}
+	suite.mockService.On("ConditionalSetPreference", mock.Anything, testPreferenceUserID, "max.seen.notification.id", "5", ConditionSetIfGreater, "").
+		Return(&ConditionalSetResponse{Applied: false, CurrentValue: "9"}, nil)
+
+	body, err := json.Marshal(condReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPatch, "/users/me/preferences/max.seen.notification.id", body)
+	req.SetPathValue("key", "max.seen.notification.id")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleConditionalSetPreference(rr, req)
+
+	suite.Equal(http.StatusPreconditionFailed, rr.Code)
+	var resp ConditionalSetResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.False(resp.Applied)
+	suite.Equal("9", resp.CurrentValue)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleConditionalSetPreference_MissingUserID() {
+	req := httptest.NewRequest(http.MethodPatch, "/users/me/preferences/theme", bytes.NewReader([]byte(`{}`)))
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleConditionalSetPreference(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleConditionalSetPreference_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPatch, "/users/me/preferences/theme", []byte("not-json"))
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleConditionalSetPreference(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleConditionalSetPreference_ServiceError() {
+	condReq := ConditionalSetRequest{Value: "dark", Condition: ConditionSetIfGreater}
+	suite.mockService.On("ConditionalSetPreference", mock.Anything, testPreferenceUserID, "theme", "dark", ConditionSetIfGreater, "").
+		Return(nil, &ErrorNonNumericPreferenceValue)
+
+	body, err := json.Marshal(condReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPatch, "/users/me/preferences/theme", body)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleConditionalSetPreference(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleSeedDefaultForAllUsers Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleSeedDefaultForAllUsers_Success() {
+	seedReq := SeedDefaultRequest{Key: "theme", Value: "dark"}
+	suite.mockService.On("SeedDefaultForAllUsers", mock.Anything, testPreferenceUserID, "theme", "dark").
+		Return(&SeedDefaultResponse{UsersProcessed: 2, Seeded: 1, Skipped: 1}, nil)
+
+	body, err := json.Marshal(seedReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/seed-default", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleSeedDefaultForAllUsers(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp SeedDefaultResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(2, resp.UsersProcessed)
+	suite.Equal(1, resp.Seeded)
+	suite.Equal(1, resp.Skipped)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleSeedDefaultForAllUsers_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/seed-default", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleSeedDefaultForAllUsers(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleSeedDefaultForAllUsers_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/seed-default", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleSeedDefaultForAllUsers(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleBulkRenamePreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleBulkRenamePreferences_Success() {
+	renameReq := BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.new."}
+	suite.mockService.On("BulkRenamePreferences", mock.Anything, testPreferenceUserID, &renameReq).
+		Return(&BulkRenameResponse{UsersProcessed: 2, Renamed: 1, Skipped: 1}, nil)
+
+	body, err := json.Marshal(renameReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/bulk-rename", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleBulkRenamePreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp BulkRenameResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(2, resp.UsersProcessed)
+	suite.Equal(1, resp.Renamed)
+	suite.Equal(1, resp.Skipped)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleBulkRenamePreferences_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/bulk-rename", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleBulkRenamePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleBulkRenamePreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/bulk-rename", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleBulkRenamePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleBulkRenamePreferences_ServiceError() {
+	renameReq := BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.old."}
+	suite.mockService.On("BulkRenamePreferences", mock.Anything, testPreferenceUserID, &renameReq).
+		Return(nil, &ErrorInvalidRenamePrefix)
+
+	body, err := json.Marshal(renameReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/bulk-rename", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleBulkRenamePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleDeletePreferencesByValue Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferencesByValue_Success() {
+	deleteReq := DeletePreferencesByValueRequest{Key: "theme", Value: "broken", Confirm: true}
+	suite.mockService.On("DeletePreferencesByValue", mock.Anything, testPreferenceUserID, &deleteReq).
+		Return(&DeletePreferencesByValueResponse{UsersProcessed: 3, Deleted: 1}, nil)
+
+	body, err := json.Marshal(deleteReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/delete-by-value", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferencesByValue(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp DeletePreferencesByValueResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(3, resp.UsersProcessed)
+	suite.Equal(1, resp.Deleted)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferencesByValue_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/delete-by-value", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferencesByValue(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferencesByValue_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/delete-by-value", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferencesByValue(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleDeletePreferencesByValue_ServiceError() {
+	deleteReq := DeletePreferencesByValueRequest{Key: "theme", Value: "broken"}
+	suite.mockService.On("DeletePreferencesByValue", mock.Anything, testPreferenceUserID, &deleteReq).
+		Return(nil, &ErrorDeleteByValueNotConfirmed)
+
+	body, err := json.Marshal(deleteReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/delete-by-value", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleDeletePreferencesByValue(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCopyPreferences_Success() {
+	copyReq := CopyPreferencesRequest{SourceUserID: "template-1", TargetUserID: "user-1", Keys: []string{"ui.theme"}}
+	suite.mockService.On("CopyPreferences", mock.Anything, testPreferenceUserID, &copyReq).
+		Return(&CopyPreferencesResponse{Copied: []string{"ui.theme"}}, nil)
+
+	body, err := json.Marshal(copyReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/copy", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCopyPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp CopyPreferencesResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal([]string{"ui.theme"}, resp.Copied)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCopyPreferences_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/copy", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCopyPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCopyPreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/copy", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCopyPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleCopyPreferences_ServiceError() {
+	copyReq := CopyPreferencesRequest{SourceUserID: "user-1", TargetUserID: "user-1"}
+	suite.mockService.On("CopyPreferences", mock.Anything, testPreferenceUserID, &copyReq).
+		Return(nil, &ErrorInvalidCopyRequest)
+
+	body, err := json.Marshal(copyReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/copy", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleCopyPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleReconcilePreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleReconcilePreferences_SingleUser() {
+	reconcileReq := ReconcilePreferencesRequest{DryRun: true}
+	suite.mockService.On("ReconcileUserPreferences", mock.Anything, testPreferenceUserID, "user-2", &reconcileReq).
+		Return(&PreferenceReconciliationReport{UserID: "user-2", DryRun: true, Issues: []PreferenceReconciliationIssue{
+			{Key: "stale.key", Type: ReconciliationIssueExtraKey, Action: ReconciliationActionNone},
+		}}, nil)
+
+	body, err := json.Marshal(reconcileReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/reconcile?userID=user-2", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReconcilePreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceReconciliationReport
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal("user-2", resp.UserID)
+	suite.Require().Len(resp.Issues, 1)
+	suite.Equal("stale.key", resp.Issues[0].Key)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReconcilePreferences_Bulk() {
+	reconcileReq := ReconcilePreferencesRequest{}
+	suite.mockService.On("ReconcileAllUserPreferences", mock.Anything, testPreferenceUserID, &reconcileReq).
+		Return(&BulkReconciliationResponse{UsersProcessed: 5, UsersWithIssues: 2}, nil)
+
+	body, err := json.Marshal(reconcileReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/reconcile", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReconcilePreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp BulkReconciliationResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal(5, resp.UsersProcessed)
+	suite.Equal(2, resp.UsersWithIssues)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReconcilePreferences_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/reconcile", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReconcilePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReconcilePreferences_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/reconcile", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReconcilePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReconcilePreferences_ServiceError() {
+	reconcileReq := ReconcilePreferencesRequest{}
+	suite.mockService.On("ReconcileUserPreferences", mock.Anything, testPreferenceUserID, "missing-user", &reconcileReq).
+		Return(nil, &user.ErrorUserNotFound)
+
+	body, err := json.Marshal(reconcileReq)
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/reconcile?userID=missing-user", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReconcilePreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+// handleAcquirePreferenceLock Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleAcquirePreferenceLock_Success() {
+	expiresAt := time.Now().Add(60 * time.Second)
+	suite.mockService.On("AcquirePreferenceLock", mock.Anything, testPreferenceUserID, "user-2", 60).
+		Return(&PreferenceLockResponse{UserID: "user-2", Token: "tok-1", ExpiresAt: expiresAt}, nil)
+
+	body, err := json.Marshal(AcquirePreferenceLockRequest{TTLSeconds: 60})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/acquire?userID=user-2", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAcquirePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceLockResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal("tok-1", resp.Token)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAcquirePreferenceLock_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/lock/acquire?userID=user-2", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAcquirePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAcquirePreferenceLock_MissingUserID() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/acquire", []byte(`{}`))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAcquirePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAcquirePreferenceLock_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/acquire?userID=user-2", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAcquirePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleAcquirePreferenceLock_ServiceError() {
+	suite.mockService.On("AcquirePreferenceLock", mock.Anything, testPreferenceUserID, "user-2", 0).
+		Return(nil, &ErrorPreferenceLockHeld)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/acquire?userID=user-2", []byte(`{}`))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleAcquirePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusConflict, rr.Code)
+}
+
+// handleRefreshPreferenceLock Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleRefreshPreferenceLock_Success() {
+	expiresAt := time.Now().Add(120 * time.Second)
+	suite.mockService.On("RefreshPreferenceLock", mock.Anything, testPreferenceUserID, "user-2", "tok-1", 120).
+		Return(&PreferenceLockResponse{UserID: "user-2", Token: "tok-1", ExpiresAt: expiresAt}, nil)
+
+	body, err := json.Marshal(PreferenceLockTokenRequest{Token: "tok-1", TTLSeconds: 120})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/refresh?userID=user-2", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleRefreshPreferenceLock(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleRefreshPreferenceLock_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/lock/refresh?userID=user-2", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleRefreshPreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleRefreshPreferenceLock_MissingUserID() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/refresh", []byte(`{}`))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleRefreshPreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleRefreshPreferenceLock_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/refresh?userID=user-2", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleRefreshPreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleRefreshPreferenceLock_ServiceError() {
+	suite.mockService.On("RefreshPreferenceLock", mock.Anything, testPreferenceUserID, "user-2", "wrong-tok", 0).
+		Return(nil, &ErrorPreferenceLockHeld)
+
+	body, err := json.Marshal(PreferenceLockTokenRequest{Token: "wrong-tok"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/refresh?userID=user-2", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleRefreshPreferenceLock(rr, req)
+
+	suite.Equal(http.StatusConflict, rr.Code)
+}
+
+// handleReleasePreferenceLock Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleReleasePreferenceLock_Success() {
+	suite.mockService.On("ReleasePreferenceLock", mock.Anything, testPreferenceUserID, "user-2", "tok-1").
+		Return(nil)
+
+	body, err := json.Marshal(PreferenceLockTokenRequest{Token: "tok-1"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/release?userID=user-2", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReleasePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusNoContent, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReleasePreferenceLock_MissingCallerID() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/preferences/lock/release?userID=user-2", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReleasePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReleasePreferenceLock_MissingUserID() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/release", []byte(`{}`))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReleasePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReleasePreferenceLock_InvalidBody() {
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/release?userID=user-2", []byte("not-json"))
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReleasePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleReleasePreferenceLock_ServiceError() {
+	suite.mockService.On("ReleasePreferenceLock", mock.Anything, testPreferenceUserID, "user-2", "wrong-tok").
+		Return(&ErrorPreferenceLockHeld)
+
+	body, err := json.Marshal(PreferenceLockTokenRequest{Token: "wrong-tok"})
+	suite.Require().NoError(err)
+
+	req := suite.authenticatedRequest(http.MethodPost, "/admin/preferences/lock/release?userID=user-2", body)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleReleasePreferenceLock(rr, req)
+
+	suite.Equal(http.StatusConflict, rr.Code)
+}
+
+// handleListPreferenceSchemas Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleListPreferenceSchemas_Success() {
+	suite.mockService.On("ListPreferenceSchemas", mock.Anything).Return(&PreferenceSchemaResponse{
+		Schemas: []PreferenceSchemaEntry{{Key: "ui.theme", Type: "enum"}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/schema", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleListPreferenceSchemas(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp PreferenceSchemaResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().Len(resp.Schemas, 1)
+	suite.Equal("ui.theme", resp.Schemas[0].Key)
+}
+
+// handleGetReadCountAggregate Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleGetReadCountAggregate_Success() {
+	suite.mockService.On("GetPreferenceReadCountAggregate", mock.Anything).Return(&ReadCountAggregateResponse{
+		Enabled: true,
+		Entries: []ReadCountAggregateEntry{{Key: "theme", TotalReads: 5}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/read-counts", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetReadCountAggregate(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp ReadCountAggregateResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.True(resp.Enabled)
+	suite.Require().Len(resp.Entries, 1)
+	suite.Equal("theme", resp.Entries[0].Key)
+}
+
+// handleGetQueryDebugInfo Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleGetQueryDebugInfo_Success() {
+	suite.mockService.On("GetQueryDebugInfo", mock.Anything).Return(&QueryDebugResponse{
+		DBType:  "sqlite",
+		Queries: []QueryDebugEntry{{ID: "PREF-01", Query: "SELECT 1"}},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/debug/queries", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetQueryDebugInfo(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp QueryDebugResponse
+	suite.Require().NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal("sqlite", resp.DBType)
+	suite.Require().Len(resp.Queries, 1)
+	suite.Equal("PREF-01", resp.Queries[0].ID)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetQueryDebugInfo_Disabled() {
+	suite.mockService.On("GetQueryDebugInfo", mock.Anything).Return(nil, &ErrorDebugEndpointDisabled)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/debug/queries", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetQueryDebugInfo(rr, req)
+
+	suite.Equal(http.StatusNotFound, rr.Code)
+}
+
+// handleExportAllPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleExportAllPreferences_Success() {
+	suite.mockService.On("StreamAllPreferences", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			emit := args[1].(func(PreferenceExportEntry) error)
+			suite.Require().NoError(emit(PreferenceExportEntry{UserID: "user-1", Key: "theme", Value: "dark"}))
+			suite.Require().NoError(emit(PreferenceExportEntry{UserID: "user-2", Key: "theme", Value: "light"}))
+		}).
+		Return(nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/export", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleExportAllPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Equal("application/x-ndjson", rr.Header().Get("Content-Type"))
+	lines := bytes.Split(bytes.TrimSpace(rr.Body.Bytes()), []byte("\n"))
+	suite.Require().Len(lines, 2)
+	var first PreferenceExportEntry
+	suite.Require().NoError(json.Unmarshal(lines[0], &first))
+	suite.Equal("user-1", first.UserID)
+	suite.Equal("dark", first.Value)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleExportAllPreferences_FailsBeforeAnyRow() {
+	suite.mockService.On("StreamAllPreferences", mock.Anything, mock.Anything).
+		Return(&tidcommon.InternalServerError)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/export", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleExportAllPreferences(rr, req)
+
+	suite.Equal(http.StatusInternalServerError, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleExportAllPreferences_FailsMidStream() {
+	suite.mockService.On("StreamAllPreferences", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			emit := args[1].(func(PreferenceExportEntry) error)
+			suite.Require().NoError(emit(PreferenceExportEntry{UserID: "user-1", Key: "theme", Value: "dark"}))
+		}).
+		Return(&tidcommon.InternalServerError)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/admin/preferences/export", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleExportAllPreferences(rr, req)
+
+	// The status was already committed as 200 when the first row was written; a mid-stream
+	// failure can only be logged, not surfaced as a different status code.
+	suite.Equal(http.StatusOK, rr.Code)
+	lines := bytes.Split(bytes.TrimSpace(rr.Body.Bytes()), []byte("\n"))
+	suite.Require().Len(lines, 1)
+}
+
+// handleExportUserPreferences Tests
+func (suite *PreferenceHandlerTestSuite) TestHandleExportUserPreferences_Success() {
+	updatedAt := time.Now()
+	suite.mockService.On("ExportUserPreferences", mock.Anything, testPreferenceUserID).Return(&UserPreferenceExportDocument{
+		ExportedAt:   updatedAt,
+		DeploymentID: "deployment-1",
+		Preferences: []UserPreferenceExportEntry{
+			{Key: "theme", Value: "dark", UpdatedAt: updatedAt},
+			{Key: "locale", Value: "en-US", UpdatedAt: updatedAt},
+		},
+	}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/export", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleExportUserPreferences(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`attachment; filename="preferences.json"`, rr.Header().Get("Content-Disposition"))
+
+	var doc UserPreferenceExportDocument
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &doc))
+	suite.Equal("deployment-1", doc.DeploymentID)
+	suite.Require().Len(doc.Preferences, 2)
+	suite.Equal("theme", doc.Preferences[0].Key)
+	suite.Equal("dark", doc.Preferences[0].Value)
+	suite.Equal("locale", doc.Preferences[1].Key)
+	suite.Equal("en-US", doc.Preferences[1].Value)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleExportUserPreferences_MissingUserID() {
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences/export", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleExportUserPreferences(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleExportUserPreferences_ServiceError() {
+	suite.mockService.On("ExportUserPreferences", mock.Anything, testPreferenceUserID).
+		Return(nil, &tidcommon.InternalServerError)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/export", nil)
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleExportUserPreferences(rr, req)
+
+	suite.Equal(http.StatusInternalServerError, rr.Code)
+}
+
+// handleServiceError Tests
+func TestHandleServiceError_StatusMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		svcErr     *tidcommon.ServiceError
+		wantStatus int
+	}{
+		{"not found", &ErrorPreferenceNotFound, http.StatusNotFound},
+		{"unauthorized", &tidcommon.ErrorUnauthorized, http.StatusForbidden},
+		{"enforced", &ErrorPreferenceEnforced, http.StatusConflict},
+		{"modified", &ErrorPreferenceModified, http.StatusPreconditionFailed},
+		{"quota exceeded", &ErrorPreferenceQuotaExceeded, http.StatusForbidden},
+		{"rate limited", &ErrorPreferenceRateLimited, http.StatusTooManyRequests},
+		{"conflict", &ErrorPreferenceConflict, http.StatusConflict},
+		{"invalid request format", &ErrorInvalidRequestFormat, http.StatusBadRequest},
+		{"server error", &tidcommon.InternalServerError, http.StatusInternalServerError},
+		{"service unavailable", &ErrorServiceUnavailable, http.StatusServiceUnavailable},
+		{"unknown preference key", &ErrorUnknownPreferenceKey, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handleServiceError(t.Context(), rr, tt.svcErr)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandleServiceError_ServiceUnavailable_SetsRetryAfter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	handleServiceError(t.Context(), rr, &ErrorServiceUnavailable)
+
+	if got := rr.Header().Get("Retry-After"); got != strconv.Itoa(retryAfterSeconds) {
+		t.Errorf("got Retry-After %q, want %q", got, strconv.Itoa(retryAfterSeconds))
+	}
+}
+
+func TestHandleServiceError_RateLimited_SetsRetryAfter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	handleServiceError(t.Context(), rr, &ErrorPreferenceRateLimited)
+
+	if got := rr.Header().Get("Retry-After"); got != strconv.Itoa(rateLimitRetryAfterSeconds) {
+		t.Errorf("got Retry-After %q, want %q", got, strconv.Itoa(rateLimitRetryAfterSeconds))
+	}
+}
+
+// handleGetPreferenceByKey history branch Tests (GET /users/me/preferences/{key...}?history=true)
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_History_Success() {
+	now := time.Now()
+	suite.mockService.On("GetPreferenceHistory", mock.Anything, testPreferenceUserID, "theme").
+		Return(&PreferenceHistoryResponse{
+			Key: "theme",
+			Entries: []PreferenceHistoryEntry{
+				{Value: nil, ChangedAt: now.Add(-time.Hour)},
+			},
+		}, nil)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme?history=true", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusOK, rr.Code)
+
+	var resp PreferenceHistoryResponse
+	suite.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	suite.Equal("theme", resp.Key)
+	suite.Require().Len(resp.Entries, 1)
+	suite.Nil(resp.Entries[0].Value)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_History_MissingUserID() {
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences/theme?history=true", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *PreferenceHandlerTestSuite) TestHandleGetPreferenceByKey_History_ServiceError() {
+	suite.mockService.On("GetPreferenceHistory", mock.Anything, testPreferenceUserID, "theme").
+		Return(nil, &ErrorInvalidPreferenceKey)
+
+	req := suite.authenticatedRequest(http.MethodGet, "/users/me/preferences/theme?history=true", nil)
+	req.SetPathValue("key", "theme")
+	rr := httptest.NewRecorder()
+
+	suite.handler.handleGetPreferenceByKey(rr, req)
+
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/thunder-id/thunderid/internal/user"
+)
+
+// resolveParentUserID extracts the linked parent account's user ID from u.Attributes, for
+// delegated-account preference inheritance (see resolveInheritedPreference). Returns ok=false
+// when u has no parent link, e.g. the attribute is absent, empty, or not a string.
+func resolveParentUserID(u *user.User) (parentUserID string, ok bool) {
+	if len(u.Attributes) == 0 {
+		return "", false
+	}
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(u.Attributes, &attrs); err != nil {
+		return "", false
+	}
+	parentUserID, ok = attrs[parentUserAttributeKey].(string)
+	if !ok || parentUserID == "" {
+		return "", false
+	}
+	return parentUserID, true
+}
+
+// resolveInheritedPreference walks userID's chain of linked parent accounts (see
+// resolveParentUserID), looking for the nearest ancestor with a stored value for key, for a child
+// account's read to fall back to a parent's preference before the key's registered schema
+// default. Writes never go through this path: SetPreference always writes to the caller's own
+// row. Returns ok=false when no ancestor (or nothing within maxPreferenceInheritanceDepth hops)
+// has a value for key. Stops early, without error, the moment the parent chain would revisit a
+// user ID already seen, to guard against a misconfigured or malicious inheritance cycle.
+func (s *preferenceService) resolveInheritedPreference(
+	ctx context.Context, userID, key string,
+) (*Preference, bool) {
+	visited := map[string]bool{userID: true}
+	currentID := userID
+
+	for depth := 0; depth < maxPreferenceInheritanceDepth; depth++ {
+		currentUser, svcErr := s.userService.GetUser(ctx, currentID, false)
+		if svcErr != nil {
+			return nil, false
+		}
+		parentID, ok := resolveParentUserID(currentUser)
+		if !ok || visited[parentID] {
+			return nil, false
+		}
+		visited[parentID] = true
+
+		pref, err := s.store.GetPreferenceByKey(parentID, key)
+		if err != nil {
+			return nil, false
+		}
+		if pref != nil {
+			return pref, true
+		}
+		currentID = parentID
+	}
+	return nil, false
+}
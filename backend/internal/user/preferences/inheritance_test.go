@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thunder-id/thunderid/internal/user"
+)
+
+func TestResolveParentUserID_NoAttributes(t *testing.T) {
+	_, ok := resolveParentUserID(&user.User{ID: "user-1"})
+	assert.False(t, ok)
+}
+
+func TestResolveParentUserID_MalformedAttributes(t *testing.T) {
+	_, ok := resolveParentUserID(&user.User{ID: "user-1", Attributes: json.RawMessage(`not json`)})
+	assert.False(t, ok)
+}
+
+func TestResolveParentUserID_MissingParentKey(t *testing.T) {
+	_, ok := resolveParentUserID(&user.User{ID: "user-1", Attributes: json.RawMessage(`{"username":"alice"}`)})
+	assert.False(t, ok)
+}
+
+func TestResolveParentUserID_EmptyParentValue(t *testing.T) {
+	_, ok := resolveParentUserID(&user.User{ID: "user-1", Attributes: json.RawMessage(`{"parentUserId":""}`)})
+	assert.False(t, ok)
+}
+
+func TestResolveParentUserID_NonStringParentValue(t *testing.T) {
+	_, ok := resolveParentUserID(&user.User{ID: "user-1", Attributes: json.RawMessage(`{"parentUserId":42}`)})
+	assert.False(t, ok)
+}
+
+func TestResolveParentUserID_Found(t *testing.T) {
+	parentID, ok := resolveParentUserID(
+		&user.User{ID: "user-1", Attributes: json.RawMessage(`{"parentUserId":"parent-1"}`)})
+	assert.True(t, ok)
+	assert.Equal(t, "parent-1", parentID)
+}
@@ -0,0 +1,309 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/kmprovider"
+	"github.com/thunder-id/thunderid/internal/system/middleware"
+	"github.com/thunder-id/thunderid/internal/system/sysauthz"
+	"github.com/thunder-id/thunderid/internal/user"
+)
+
+// Initialize loads the deployment's configured allowed-key schema (see loadPreferenceSchema),
+// runs the query-dialect self-check (see runQueryDialectSelfCheck), initializes the preference
+// service, registers its routes, and starts the background reconciliation job that purges
+// orphaned, expired, and soft-deleted rows. configCrypto is used to encrypt keys registered with
+// storagePolicyEncrypted (see codec.go); it may be nil in deployments that register no such keys.
+func Initialize(
+	mux *http.ServeMux, userService user.UserServiceInterface, authzService sysauthz.SystemAuthorizationServiceInterface,
+	configCrypto kmprovider.ConfigCryptoProvider,
+) PreferenceServiceInterface {
+	loadPreferenceSchema()
+	runQueryDialectSelfCheck(config.GetServerRuntime().Config.Database.User.Type)
+
+	preferenceService := newPreferenceService(userService, authzService, configCrypto)
+
+	preferenceHandler := newPreferenceHandler(preferenceService)
+	registerRoutes(mux, preferenceHandler)
+
+	reconciler := newReconciler(preferenceService.store, userService)
+	reconciler.Start()
+	activeReconciler = reconciler
+
+	preferenceService.readCounts.Start()
+	activeReadCountFlusher = preferenceService.readCounts
+
+	return preferenceService
+}
+
+// registerRoutes registers the routes for preference management operations.
+//
+// The canonical paths are "GET /users/me/preferences" for the list and
+// "GET /users/me/preferences/{key...}" for a single lookup. The trailing-slash form
+// "GET /users/me/preferences/" (no key) is registered explicitly as an alias for the
+// list, rather than falling through to the single-key lookup with an empty key. The
+// {key...} wildcard, rather than a single-segment {key}, lets a key contain its own literal
+// slashes (e.g. "ui/theme/color") instead of being truncated at the first one.
+func registerRoutes(mux *http.ServeMux, preferenceHandler *preferenceHandler) {
+	opts := middleware.CORSOptions{
+		AllowedMethods:   []string{"GET", "PUT", "PATCH", "DELETE", "POST"},
+		AllowedHeaders:   middleware.DefaultAllowedHeaders,
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	mux.HandleFunc(middleware.WithCORS("GET /users/me/preferences",
+		middleware.CorrelationIDMiddleware(expensiveRateLimitMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleGetPreferences)))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("PUT /users/me/preferences",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleReplaceAllPreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("PATCH /users/me/preferences",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleMergePreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("POST /users/me/preferences/batch",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleBatchPreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/batch",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("POST /users/me/preferences/import",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleImportPreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/import",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("POST /users/me/preferences/cas",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleCompareAndSwapPreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/cas",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("POST /users/me/preferences/exists",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleCheckPreferencesExist))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/exists",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("POST /users/me/preferences/query",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleQueryPreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/query",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("GET /users/me/preferences/",
+		middleware.CorrelationIDMiddleware(expensiveRateLimitMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleGetPreferences)))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("GET /users/me/preferences/schema",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleListPreferenceSchemas)).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/schema",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("GET /users/me/preferences/export",
+		middleware.CorrelationIDMiddleware(expensiveRateLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleExportUserPreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/export",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("DELETE /users/me/preferences",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleDeleteAllPreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("DELETE /users/me/preferences/bulk",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleDeletePreferences))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/bulk",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("DELETE /users/me/preferences/namespace/{namespace}",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(http.HandlerFunc(preferenceHandler.handleDeleteNamespace))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/namespace/{namespace}",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+	mux.HandleFunc(middleware.WithCORS("GET /users/me/preferences/{key...}",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleGetPreferenceByKey))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("PATCH /users/me/preferences/{key...}",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleConditionalSetPreference))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("PUT /users/me/preferences/{key...}",
+		middleware.CorrelationIDMiddleware(standardRateLimitMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleUpsertPreference)))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("DELETE /users/me/preferences/{key...}",
+		middleware.CorrelationIDMiddleware(standardRateLimitMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleDeletePreference)))).ServeHTTP, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, opts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /users/me/preferences/{key...}",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(headerMaxKeyLength, strconv.Itoa(preferenceMaxKeyLength()))
+			w.Header().Set(headerMaxValueLength, strconv.Itoa(preferenceMaxValueLength()))
+			w.WriteHeader(http.StatusNoContent)
+		}, opts))
+
+	adminOpts := middleware.CORSOptions{
+		AllowedMethods:   []string{"GET"},
+		AllowedHeaders:   middleware.DefaultAllowedHeaders,
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	mux.HandleFunc(middleware.WithCORS("GET /admin/preferences/diff",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleDiffPreferences)).ServeHTTP, adminOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/diff",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminOpts))
+	mux.HandleFunc(middleware.WithCORS("GET /admin/preferences/read-counts",
+		middleware.CorrelationIDMiddleware(expensiveRateLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleGetReadCountAggregate))).ServeHTTP, adminOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/read-counts",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminOpts))
+	mux.HandleFunc(middleware.WithCORS("GET /admin/preferences/debug/queries",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleGetQueryDebugInfo)).ServeHTTP, adminOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/debug/queries",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminOpts))
+	mux.HandleFunc(middleware.WithCORS("GET /admin/preferences/export",
+		middleware.CorrelationIDMiddleware(expensiveRateLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleExportAllPreferences))).ServeHTTP, adminOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/export",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminOpts))
+
+	// adminUserOpts covers the admin routes that read and manage a single named user's
+	// preferences (as opposed to adminOpts's deployment-wide reads and adminSeedOpts's
+	// deployment-wide writes below), so unlike either it also allows PUT.
+	adminUserOpts := middleware.CORSOptions{
+		AllowedMethods:   []string{"GET", "PUT"},
+		AllowedHeaders:   middleware.DefaultAllowedHeaders,
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	mux.HandleFunc(middleware.WithCORS("GET /admin/users/{userId}/preferences",
+		middleware.CorrelationIDMiddleware(expensiveRateLimitMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleAdminGetPreferences)))).ServeHTTP, adminUserOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/users/{userId}/preferences",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminUserOpts))
+	mux.HandleFunc(middleware.WithCORS("GET /admin/users/{userId}/preferences/{key...}",
+		middleware.CorrelationIDMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleAdminGetPreferenceByKey))).ServeHTTP, adminUserOpts))
+	mux.HandleFunc(middleware.WithCORS("PUT /admin/users/{userId}/preferences/{key...}",
+		middleware.CorrelationIDMiddleware(standardRateLimitMiddleware(concurrencyLimitMiddleware(
+			http.HandlerFunc(preferenceHandler.handleAdminUpsertPreference)))).ServeHTTP, adminUserOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/users/{userId}/preferences/{key...}",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminUserOpts))
+
+	adminSeedOpts := middleware.CORSOptions{
+		AllowedMethods:   []string{"POST"},
+		AllowedHeaders:   middleware.DefaultAllowedHeaders,
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/seed-default",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleSeedDefaultForAllUsers)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/seed-default",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/bulk-rename",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleBulkRenamePreferences)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/bulk-rename",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/delete-by-value",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleDeletePreferencesByValue)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/delete-by-value",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/copy",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleCopyPreferences)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/copy",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/reconcile",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleReconcilePreferences)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/reconcile",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/lock/acquire",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleAcquirePreferenceLock)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/lock/acquire",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/lock/refresh",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleRefreshPreferenceLock)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/lock/refresh",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+
+	mux.HandleFunc(middleware.WithCORS("POST /admin/preferences/lock/release",
+		middleware.CorrelationIDMiddleware(http.HandlerFunc(preferenceHandler.handleReleasePreferenceLock)).ServeHTTP,
+		adminSeedOpts))
+	mux.HandleFunc(middleware.WithCORS("OPTIONS /admin/preferences/lock/release",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, adminSeedOpts))
+}
+
+// Shutdown stops the background reconciliation job and the read-count flusher, flushing any
+// buffered read counts before returning. Safe to call even if Initialize was never called.
+func Shutdown() {
+	if activeReconciler != nil {
+		activeReconciler.Stop()
+	}
+	if activeReadCountFlusher != nil {
+		activeReadCountFlusher.Stop()
+	}
+}
+
+// activeReconciler holds the running reconciliation job so Shutdown can stop it.
+var activeReconciler *reconciler
+
+// activeReadCountFlusher holds the running read-count flusher so Shutdown can stop it.
+var activeReadCountFlusher *readCountFlusher
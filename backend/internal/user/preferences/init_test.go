@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/security"
+)
+
+// TestMain initializes the server runtime once for the whole package test binary, since
+// several tests (e.g. content-type validation, envelope config) read it via
+// config.GetServerRuntime().
+func TestMain(m *testing.M) {
+	_ = config.InitializeServerRuntime("", &config.Config{})
+	os.Exit(m.Run())
+}
+
+func TestRegisterRoutes_TrailingSlashIsTreatedAsList(t *testing.T) {
+	mux := http.NewServeMux()
+	registerRoutes(mux, newPreferenceHandler(NewPreferenceServiceInterfaceMock(t)))
+
+	_, pattern := mux.Handler(&http.Request{Method: "GET", URL: &url.URL{Path: "/users/me/preferences/"}})
+
+	assert.Equal(t, "GET /users/me/preferences/", pattern)
+}
+
+func TestRegisterRoutes_CanonicalPaths(t *testing.T) {
+	mux := http.NewServeMux()
+	registerRoutes(mux, newPreferenceHandler(NewPreferenceServiceInterfaceMock(t)))
+
+	_, pattern := mux.Handler(&http.Request{Method: "GET", URL: &url.URL{Path: "/users/me/preferences"}})
+	assert.Equal(t, "GET /users/me/preferences", pattern)
+
+	_, pattern = mux.Handler(&http.Request{Method: "GET", URL: &url.URL{Path: "/users/me/preferences/theme"}})
+	assert.Equal(t, "GET /users/me/preferences/{key...}", pattern)
+}
+
+func TestRegisterRoutes_AdminUserPaths(t *testing.T) {
+	mux := http.NewServeMux()
+	registerRoutes(mux, newPreferenceHandler(NewPreferenceServiceInterfaceMock(t)))
+
+	_, pattern := mux.Handler(&http.Request{Method: "GET", URL: &url.URL{Path: "/admin/users/user-1/preferences"}})
+	assert.Equal(t, "GET /admin/users/{userId}/preferences", pattern)
+
+	_, pattern = mux.Handler(&http.Request{Method: "GET", URL: &url.URL{Path: "/admin/users/user-1/preferences/theme"}})
+	assert.Equal(t, "GET /admin/users/{userId}/preferences/{key...}", pattern)
+
+	_, pattern = mux.Handler(&http.Request{Method: "PUT", URL: &url.URL{Path: "/admin/users/user-1/preferences/theme"}})
+	assert.Equal(t, "PUT /admin/users/{userId}/preferences/{key...}", pattern)
+}
+
+func TestRegisterRoutes_KeyPathValueHandlesEncodedSegments(t *testing.T) {
+	mockService := NewPreferenceServiceInterfaceMock(t)
+	mockService.EXPECT().GetPreference(mock.Anything, testPreferenceUserID, "a/b", false, "").
+		Return(&PreferenceResponse{Key: "a/b", Value: "v"}, nil)
+
+	mux := http.NewServeMux()
+	registerRoutes(mux, newPreferenceHandler(mockService))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences/a%2Fb", nil)
+	authCtx := security.NewSecurityContextForTest(testPreferenceUserID, "", "", nil, nil)
+	req = req.WithContext(security.WithSecurityContextTest(req.Context(), authCtx))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRegisterRoutes_KeyPathValueHandlesEmbeddedSlashes(t *testing.T) {
+	mockService := NewPreferenceServiceInterfaceMock(t)
+	mockService.EXPECT().GetPreference(mock.Anything, testPreferenceUserID, "ui/theme/color", false, "").
+		Return(&PreferenceResponse{Key: "ui/theme/color", Value: "red"}, nil)
+
+	mux := http.NewServeMux()
+	registerRoutes(mux, newPreferenceHandler(mockService))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences/ui/theme/color", nil)
+	authCtx := security.NewSecurityContextForTest(testPreferenceUserID, "", "", nil, nil)
+	req = req.WithContext(security.WithSecurityContextTest(req.Context(), authCtx))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRegisterRoutes_DeleteKeyPathValueHandlesEncodedSegments(t *testing.T) {
+	mockService := NewPreferenceServiceInterfaceMock(t)
+	mockService.EXPECT().DeletePreference(mock.Anything, testPreferenceUserID, "my key", "").Return(nil)
+
+	mux := http.NewServeMux()
+	registerRoutes(mux, newPreferenceHandler(mockService))
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/me/preferences/my%20key", nil)
+	authCtx := security.NewSecurityContextForTest(testPreferenceUserID, "", "", nil, nil)
+	req = req.WithContext(security.WithSecurityContextTest(req.Context(), authCtx))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestRegisterRoutes_KeyPreflightReportsMaxLengths(t *testing.T) {
+	mux := http.NewServeMux()
+	registerRoutes(mux, newPreferenceHandler(NewPreferenceServiceInterfaceMock(t)))
+
+	req := httptest.NewRequest(http.MethodOptions, "/users/me/preferences/theme", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, strconv.Itoa(maxPreferenceKeyLength), rr.Header().Get(headerMaxKeyLength))
+	assert.Equal(t, strconv.Itoa(maxPreferenceValueLength), rr.Header().Get(headerMaxValueLength))
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/log"
+)
+
+// PreferenceSchema is the compiled, process-wide allowed-key list loaded from the deployment's
+// config.PreferenceSchemaConfig.Keys at Initialize time (see loadPreferenceSchema). It is a
+// distinct mechanism from the code-registered RegisterPreferenceSchema discovery catalog: that
+// catalog is for UI discovery and type coercion, while this is an ops-managed allow-list that
+// rejects keys outright. A nil schema means no allow-list is configured, preserving today's
+// behavior of accepting any syntactically valid key.
+type PreferenceSchema struct {
+	keys map[string]compiledPreferenceKeyConstraint
+}
+
+// compiledPreferenceKeyConstraint is a config.PreferenceSchemaKeyConfig entry with its
+// ValuePattern pre-compiled once at load time, so a write doesn't pay regexp.Compile's cost on
+// every request.
+type compiledPreferenceKeyConstraint struct {
+	maxLength    int
+	valuePattern *regexp.Regexp
+}
+
+// newPreferenceSchema compiles cfg into a PreferenceSchema, or returns nil if cfg is empty. An
+// entry whose ValuePattern fails to compile is loaded with its MaxLength constraint still
+// applied, rather than failing startup over one malformed deployment config entry.
+func newPreferenceSchema(cfg map[string]config.PreferenceSchemaKeyConfig) *PreferenceSchema {
+	if len(cfg) == 0 {
+		return nil
+	}
+
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+	keys := make(map[string]compiledPreferenceKeyConstraint, len(cfg))
+	for key, entry := range cfg {
+		constraint := compiledPreferenceKeyConstraint{maxLength: entry.MaxLength}
+		if entry.ValuePattern != "" {
+			pattern, err := regexp.Compile(entry.ValuePattern)
+			if err != nil {
+				logger.Error(context.Background(), "Ignoring invalid preference schema value pattern",
+					log.String("key", key), log.Error(err))
+			} else {
+				constraint.valuePattern = pattern
+			}
+		}
+		keys[key] = constraint
+	}
+	return &PreferenceSchema{keys: keys}
+}
+
+var (
+	allowedKeySchema   *PreferenceSchema
+	allowedKeySchemaMu sync.RWMutex
+)
+
+// loadPreferenceSchema (re)builds the process-wide allowed-key schema from the deployment's
+// current config.PreferenceSchemaConfig.Keys. Called once from Initialize.
+func loadPreferenceSchema() {
+	schema := newPreferenceSchema(config.GetServerRuntime().Config.Preference.Schema.Keys)
+	allowedKeySchemaMu.Lock()
+	allowedKeySchema = schema
+	allowedKeySchemaMu.Unlock()
+}
+
+// checkPreferenceKeySchema validates key against the deployment's configured allowed-key schema,
+// independent of checkUnknownKeyPolicy's RegisterPreferenceSchema-backed catalog. A nil schema
+// (no config.PreferenceSchemaConfig.Keys configured) is a no-op, preserving today's permissive
+// behavior.
+func checkPreferenceKeySchema(key string) *tidcommon.ServiceError {
+	allowedKeySchemaMu.RLock()
+	schema := allowedKeySchema
+	allowedKeySchemaMu.RUnlock()
+	if schema == nil {
+		return nil
+	}
+	if _, ok := schema.keys[key]; ok {
+		return nil
+	}
+	return tidcommon.CustomServiceError(ErrorInvalidPreferenceKey, tidcommon.I18nMessage{
+		Key:          "error.preferenceservice.key_not_in_allowed_schema_description",
+		DefaultValue: fmt.Sprintf("%q is not in the deployment's allowed preference key schema", key),
+	})
+}
+
+// checkPreferenceValueSchema validates value against key's configured MaxLength and ValuePattern
+// constraints, if any. A nil schema, or a key with no entry in it, is a no-op: key membership is
+// checkPreferenceKeySchema's responsibility, not this function's.
+func checkPreferenceValueSchema(key, value string) *tidcommon.ServiceError {
+	allowedKeySchemaMu.RLock()
+	schema := allowedKeySchema
+	allowedKeySchemaMu.RUnlock()
+	if schema == nil {
+		return nil
+	}
+	constraint, ok := schema.keys[key]
+	if !ok {
+		return nil
+	}
+	if constraint.maxLength > 0 && len(value) > constraint.maxLength {
+		return tidcommon.CustomServiceError(ErrorInvalidPreferenceValue, tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.value_exceeds_schema_max_length_description",
+			DefaultValue: fmt.Sprintf("Value for %q exceeds its configured maximum length of %d", key, constraint.maxLength),
+		})
+	}
+	if constraint.valuePattern != nil && !constraint.valuePattern.MatchString(value) {
+		return tidcommon.CustomServiceError(ErrorInvalidPreferenceValue, tidcommon.I18nMessage{
+			Key:          "error.preferenceservice.value_violates_schema_pattern_description",
+			DefaultValue: fmt.Sprintf("Value for %q does not match its configured pattern", key),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+type PreferenceKeySchemaTestSuite struct {
+	suite.Suite
+}
+
+func TestPreferenceKeySchemaTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceKeySchemaTestSuite))
+}
+
+func (suite *PreferenceKeySchemaTestSuite) SetupTest() {
+	allowedKeySchemaMu.Lock()
+	allowedKeySchema = nil
+	allowedKeySchemaMu.Unlock()
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TearDownTest() {
+	allowedKeySchemaMu.Lock()
+	allowedKeySchema = nil
+	allowedKeySchemaMu.Unlock()
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceKeySchema_NoSchemaConfiguredAllowsAnyKey() {
+	suite.Nil(checkPreferenceKeySchema("anything"))
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceKeySchema_AllowedKeyPasses() {
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {},
+	})
+
+	suite.Nil(checkPreferenceKeySchema("theme"))
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceKeySchema_DisallowedKeyIsRejected() {
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {},
+	})
+
+	svcErr := checkPreferenceKeySchema("them")
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+	suite.Contains(svcErr.ErrorDescription.String(), `"them"`)
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceValueSchema_NoConstraintAllowsAnyValue() {
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {},
+	})
+
+	suite.Nil(checkPreferenceValueSchema("theme", "anything"))
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceValueSchema_MaxLengthExceeded() {
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {MaxLength: 4},
+	})
+
+	svcErr := checkPreferenceValueSchema("theme", "toolong")
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceValueSchema_MaxLengthWithinBoundsPasses() {
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {MaxLength: 4},
+	})
+
+	suite.Nil(checkPreferenceValueSchema("theme", "dark"))
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceValueSchema_PatternViolationRejected() {
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {ValuePattern: "^(light|dark)$"},
+	})
+
+	svcErr := checkPreferenceValueSchema("theme", "purple")
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestCheckPreferenceValueSchema_PatternMatchPasses() {
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {ValuePattern: "^(light|dark)$"},
+	})
+
+	suite.Nil(checkPreferenceValueSchema("theme", "dark"))
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestNewPreferenceSchema_EmptyConfigReturnsNilSchema() {
+	suite.Nil(newPreferenceSchema(nil))
+}
+
+func (suite *PreferenceKeySchemaTestSuite) TestNewPreferenceSchema_InvalidPatternIsIgnoredNotFatal() {
+	schema := newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {ValuePattern: "(["},
+	})
+
+	suite.Require().NotNil(schema)
+	suite.Nil(checkPreferenceValueSchema("theme", "anything"))
+}
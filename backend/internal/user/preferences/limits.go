@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"sync"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/log"
+)
+
+// dbPreferenceKeyColumnLength is the VARCHAR(255) size of USER_PREFERENCE.PREFERENCE_KEY (see
+// dbscripts/userdb/postgres.sql and sqlite.sql). A configured MaxKeyLength above this would be
+// silently truncated by the database rather than rejected at the application layer, so it is
+// clamped to it instead. PREFERENCE_VALUE is a TEXT column with no fixed size, so there is no
+// equivalent clamp for MaxValueLength.
+const dbPreferenceKeyColumnLength = 255
+
+var (
+	preferenceLimitsOnce   sync.Once
+	resolvedMaxKeyLength   int
+	resolvedMaxValueLength int
+)
+
+// resolvePreferenceLimits resolves PreferenceLimitsConfig once, falling back to
+// maxPreferenceKeyLength/maxPreferenceValueLength for an unset (0) field, and clamping a
+// configured key length that exceeds dbPreferenceKeyColumnLength.
+func resolvePreferenceLimits() {
+	cfg := config.GetServerRuntime().Config.Preference.Limits
+
+	resolvedMaxKeyLength = maxPreferenceKeyLength
+	if cfg.MaxKeyLength > 0 {
+		resolvedMaxKeyLength = cfg.MaxKeyLength
+	}
+	if resolvedMaxKeyLength > dbPreferenceKeyColumnLength {
+		log.GetLogger().Warn(context.Background(),
+			"Configured preference max key length exceeds the database column size, clamping",
+			log.Int("configured", resolvedMaxKeyLength), log.Int("columnSize", dbPreferenceKeyColumnLength))
+		resolvedMaxKeyLength = dbPreferenceKeyColumnLength
+	}
+
+	resolvedMaxValueLength = maxPreferenceValueLength
+	if cfg.MaxValueLength > 0 {
+		resolvedMaxValueLength = cfg.MaxValueLength
+	}
+}
+
+// preferenceMaxKeyLength returns the deployment's effective maximum preference key length: the
+// configured PreferenceLimitsConfig.MaxKeyLength, falling back to maxPreferenceKeyLength when
+// unset, clamped to dbPreferenceKeyColumnLength.
+func preferenceMaxKeyLength() int {
+	preferenceLimitsOnce.Do(resolvePreferenceLimits)
+	return resolvedMaxKeyLength
+}
+
+// preferenceMaxValueLength returns the deployment's effective maximum preference value length:
+// the configured PreferenceLimitsConfig.MaxValueLength, falling back to maxPreferenceValueLength
+// when unset.
+func preferenceMaxValueLength() int {
+	preferenceLimitsOnce.Do(resolvePreferenceLimits)
+	return resolvedMaxValueLength
+}
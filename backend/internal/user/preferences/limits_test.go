@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+// resetPreferenceLimitsForTest clears the cached preference limits so the next
+// preferenceMaxKeyLength/preferenceMaxValueLength call re-reads PreferenceLimitsConfig, mirroring
+// resetAuditLoggerForTest's reset-the-package-singleton approach.
+func resetPreferenceLimitsForTest() {
+	preferenceLimitsOnce = sync.Once{}
+}
+
+func TestPreferenceLimits_DefaultToBuiltInConstants(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{}
+	resetPreferenceLimitsForTest()
+	defer resetPreferenceLimitsForTest()
+
+	assert.Equal(t, maxPreferenceKeyLength, preferenceMaxKeyLength())
+	assert.Equal(t, maxPreferenceValueLength, preferenceMaxValueLength())
+}
+
+func TestPreferenceLimits_ConfiguredValuesAreHonored(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{
+		MaxKeyLength: 64, MaxValueLength: 1024,
+	}
+	resetPreferenceLimitsForTest()
+	defer func() {
+		config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{}
+		resetPreferenceLimitsForTest()
+	}()
+
+	assert.Equal(t, 64, preferenceMaxKeyLength())
+	assert.Equal(t, 1024, preferenceMaxValueLength())
+}
+
+func TestPreferenceLimits_ConfiguredKeyLengthAboveColumnSizeIsClamped(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{
+		MaxKeyLength: dbPreferenceKeyColumnLength + 100,
+	}
+	resetPreferenceLimitsForTest()
+	defer func() {
+		config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{}
+		resetPreferenceLimitsForTest()
+	}()
+
+	assert.Equal(t, dbPreferenceKeyColumnLength, preferenceMaxKeyLength())
+}
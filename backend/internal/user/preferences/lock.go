@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+)
+
+// preferenceLock is an advisory, in-process lock on a single user's entire preference set, held
+// by the caller that presents Token. It coordinates concurrent admin consoles editing the same
+// user; it is not a hard guarantee: a deployment running more than one backend instance does not
+// share this state, and nothing stops a caller from writing without presenting a token at all
+// when no lock happens to be held.
+type preferenceLock struct {
+	Token     string
+	HolderID  string
+	ExpiresAt time.Time
+}
+
+var (
+	preferenceLocksMu sync.Mutex
+	preferenceLocks   map[string]preferenceLock
+)
+
+func init() {
+	preferenceLocks = make(map[string]preferenceLock)
+}
+
+// activePreferenceLock returns userID's currently held lock, first clearing it if it has expired.
+// ok is false when there is no active lock.
+func activePreferenceLock(userID string) (lock preferenceLock, ok bool) {
+	preferenceLocksMu.Lock()
+	defer preferenceLocksMu.Unlock()
+	return activePreferenceLockLocked(userID)
+}
+
+// activePreferenceLockLocked is activePreferenceLock's body, for callers that already hold
+// preferenceLocksMu.
+func activePreferenceLockLocked(userID string) (preferenceLock, bool) {
+	lock, ok := preferenceLocks[userID]
+	if !ok {
+		return preferenceLock{}, false
+	}
+	if !time.Now().Before(lock.ExpiresAt) {
+		delete(preferenceLocks, userID)
+		return preferenceLock{}, false
+	}
+	return lock, true
+}
+
+// acquirePreferenceLock grants holderID an exclusive advisory lock on userID's preferences for
+// ttlSeconds (see clampPreferenceLockTTL), unless a different holder already holds an unexpired
+// lock. Re-acquiring with the same holderID renews the existing lock rather than being rejected,
+// so a console that repeats its acquire call (e.g. after a page reload) does not need to track
+// whether it already holds the lock.
+func acquirePreferenceLock(userID, holderID string, ttlSeconds int) (preferenceLock, *tidcommon.ServiceError) {
+	preferenceLocksMu.Lock()
+	defer preferenceLocksMu.Unlock()
+
+	if existing, ok := activePreferenceLockLocked(userID); ok && existing.HolderID != holderID {
+		return preferenceLock{}, &ErrorPreferenceLockHeld
+	}
+
+	token, err := generatePreferenceLockToken()
+	if err != nil {
+		return preferenceLock{}, &tidcommon.InternalServerError
+	}
+	lock := preferenceLock{Token: token, HolderID: holderID, ExpiresAt: time.Now().Add(clampPreferenceLockTTL(ttlSeconds))}
+	preferenceLocks[userID] = lock
+	return lock, nil
+}
+
+// refreshPreferenceLock extends userID's active lock by ttlSeconds from now, if token currently
+// holds it.
+func refreshPreferenceLock(userID, token string, ttlSeconds int) (preferenceLock, *tidcommon.ServiceError) {
+	preferenceLocksMu.Lock()
+	defer preferenceLocksMu.Unlock()
+
+	existing, ok := activePreferenceLockLocked(userID)
+	if !ok || existing.Token != token {
+		return preferenceLock{}, &ErrorPreferenceLockHeld
+	}
+	existing.ExpiresAt = time.Now().Add(clampPreferenceLockTTL(ttlSeconds))
+	preferenceLocks[userID] = existing
+	return existing, nil
+}
+
+// releasePreferenceLock releases userID's active lock if token currently holds it. Releasing a
+// lock that is already gone (expired or never acquired) is a no-op rather than an error, since
+// the caller's goal, the lock being gone, already holds.
+func releasePreferenceLock(userID, token string) *tidcommon.ServiceError {
+	preferenceLocksMu.Lock()
+	defer preferenceLocksMu.Unlock()
+
+	existing, ok := activePreferenceLockLocked(userID)
+	if !ok {
+		return nil
+	}
+	if existing.Token != token {
+		return &ErrorPreferenceLockHeld
+	}
+	delete(preferenceLocks, userID)
+	return nil
+}
+
+// checkPreferenceLockForWrite rejects a write to userID's preferences with ErrorPreferenceLockHeld
+// when an unexpired lock is held and token does not match it. A user with no active lock is
+// unaffected, so deployments that never call AcquirePreferenceLock see no change in write
+// behavior.
+func checkPreferenceLockForWrite(userID, token string) *tidcommon.ServiceError {
+	lock, ok := activePreferenceLock(userID)
+	if !ok || lock.Token == token {
+		return nil
+	}
+	return &ErrorPreferenceLockHeld
+}
+
+// clampPreferenceLockTTL bounds a caller-requested lock TTL to (0, maxPreferenceLockTTLSeconds],
+// substituting defaultPreferenceLockTTLSeconds for an omitted or non-positive value.
+func clampPreferenceLockTTL(ttlSeconds int) time.Duration {
+	switch {
+	case ttlSeconds <= 0:
+		return defaultPreferenceLockTTLSeconds * time.Second
+	case ttlSeconds > maxPreferenceLockTTLSeconds:
+		return maxPreferenceLockTTLSeconds * time.Second
+	default:
+		return time.Duration(ttlSeconds) * time.Second
+	}
+}
+
+// generatePreferenceLockToken returns a random, unguessable lock token.
+func generatePreferenceLockToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+)
+
+// defaultAllowedContentTypes is the conservative built-in MIME allowlist used when a deployment
+// has not configured one. It permits plain text but excludes renderable markup types such as
+// text/html, guarding against stored XSS when a preference value is later rendered in a UI.
+var defaultAllowedContentTypes = []string{"text/plain"}
+
+// validateContentType sniffs the MIME type of a preference value, the same way it would be
+// sniffed if later served to a browser, and rejects it unless that type is present in the
+// configured allowlist.
+func validateContentType(value string) *tidcommon.ServiceError {
+	allowlist := config.GetServerRuntime().Config.Preference.AllowedContentTypes
+	if len(allowlist) == 0 {
+		allowlist = defaultAllowedContentTypes
+	}
+
+	detected := http.DetectContentType([]byte(value))
+	mediaType, _, err := mime.ParseMediaType(detected)
+	if err != nil {
+		mediaType = detected
+	}
+
+	for _, allowed := range allowlist {
+		if mediaType == allowed {
+			return nil
+		}
+	}
+	return &ErrorInvalidPreferenceValue
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+func TestValidateContentType_PlainTextAllowedByDefault(t *testing.T) {
+	svcErr := validateContentType("dark")
+
+	assert.Nil(t, svcErr)
+}
+
+func TestValidateContentType_HTMLRejectedByDefault(t *testing.T) {
+	svcErr := validateContentType("<html><body>hi</body></html>")
+
+	require.NotNil(t, svcErr)
+	assert.Equal(t, ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func TestValidateContentType_DeploymentAllowlistOverride(t *testing.T) {
+	original := config.GetServerRuntime().Config.Preference.AllowedContentTypes
+	defer func() { config.GetServerRuntime().Config.Preference.AllowedContentTypes = original }()
+	config.GetServerRuntime().Config.Preference.AllowedContentTypes = []string{"text/html"}
+
+	svcErr := validateContentType("<html><body>hi</body></html>")
+
+	assert.Nil(t, svcErr)
+}
@@ -0,0 +1,771 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package preferences provides per-user preference storage and management.
+package preferences
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thunder-id/thunderid/internal/system/error/apierror"
+)
+
+// --- Service Models ---
+
+// Preference represents a single user preference entity in the service layer.
+type Preference struct {
+	UserID    string
+	Key       string
+	Value     string
+	UpdatedBy string
+	// Enforced marks a preference whose value was set with the enforced flag. An enforced
+	// value can only be overridden by another enforced write; a plain write is rejected with
+	// ErrorPreferenceEnforced, so it wins over the user's own value until cleared by another
+	// enforced write.
+	Enforced bool
+	// SchemaVersion is the value format version the stored Value was written with, for
+	// migratePreferenceValue to select which registered migrations to apply on read. Defaults to
+	// defaultSchemaVersion for values written before this field existed.
+	SchemaVersion int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time
+	ExpiresAt     *time.Time
+}
+
+// PreferenceReadCount represents the aggregate read count for a single preference key across
+// all users in a deployment.
+type PreferenceReadCount struct {
+	Key        string
+	TotalReads int64
+}
+
+// PreferenceUsage is a single user's current preference storage usage, for the
+// X-Preference-Quota-Usage/X-Preference-Quota-Limit response headers (see writeQuotaHeaders).
+type PreferenceUsage struct {
+	Count      int64
+	TotalBytes int64
+}
+
+// --- HTTP Request/Response Models ---
+
+// PreferenceResponse represents a single preference in API responses.
+type PreferenceResponse struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	Enforced  bool      `json:"enforced,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// SizeBytes is the decoded value's size in bytes. Only populated when the list endpoint is
+	// called with include_sizes=true.
+	SizeBytes *int `json:"sizeBytes,omitempty"`
+	// DefaultApplied is true when Value is the key's registered schema default rather than the
+	// stored value, because the stored value was empty and the key opted into
+	// PreferenceSchemaEntry.EmptyFallsThroughToDefault.
+	DefaultApplied bool `json:"defaultApplied,omitempty"`
+	// SchemaVersion is the value format version Value is in. It reports the latest version any
+	// registered migratePreferenceValue chain could upgrade the stored value to, which may be
+	// higher than the version it was originally stored with.
+	SchemaVersion int `json:"schemaVersion"`
+	// Explain reports which preference layer supplied Value and what every layer held, when the
+	// list endpoint is called with explain=true. See PreferenceExplanation.
+	Explain *PreferenceExplanation `json:"explain,omitempty"`
+	// InheritedFromParent is true when the user has no value of their own for Key and Value was
+	// instead resolved from a linked parent account's preference, per resolveInheritedPreference.
+	// UpdatedBy and UpdatedAt describe the parent's write, not the user's own.
+	InheritedFromParent bool `json:"inheritedFromParent,omitempty"`
+	// DeviceScoped is true when the caller supplied X-Preference-Device-Id and Value was resolved
+	// from that device's own override rather than the user-global value; see deviceScopedUserID.
+	DeviceScoped bool `json:"deviceScoped,omitempty"`
+	// IsDefault is true when the user has no stored value for Key at all and Value was instead
+	// filled in from the deployment's configured PreferenceDefaultsConfig; see
+	// preferenceService.mergeDefaults. Unlike DefaultApplied, this entry has no underlying row:
+	// UpdatedBy and UpdatedAt are zero-valued.
+	IsDefault bool `json:"isDefault,omitempty"`
+}
+
+// PreferenceExplanation reports, for a single preference key, the value held by every
+// contributing layer and which layer supplied the effective value. See
+// buildPreferenceExplanation.
+type PreferenceExplanation struct {
+	// Layers lists every layer with a candidate value for this key, in the order they are
+	// considered: the user's own stored value first, then the key's registered schema default,
+	// if any.
+	Layers []PreferenceExplanationLayer `json:"layers"`
+	// WonLayer is the Layer (see PreferenceExplanationLayer.Layer) that supplied Value.
+	WonLayer string `json:"wonLayer"`
+}
+
+// PreferenceExplanationLayer is a single layer's candidate value within a PreferenceExplanation.
+type PreferenceExplanationLayer struct {
+	// Layer is the layer's name, one of layerUser or layerSchemaDefault.
+	Layer string `json:"layer"`
+	Value string `json:"value"`
+}
+
+// PreferenceListResponse represents the response for listing a user's preferences.
+type PreferenceListResponse struct {
+	Preferences []PreferenceResponse `json:"preferences"`
+	// FailedKeys lists the keys of preferences that exist but whose value could not be decoded,
+	// e.g. after a key-rotation mistake. These keys are omitted from Preferences.
+	FailedKeys []string `json:"failedKeys,omitempty"`
+	// TotalSizeBytes is the sum of all decoded values' sizes in bytes. Only populated when the
+	// list endpoint is called with include_sizes=true.
+	TotalSizeBytes *int `json:"totalSizeBytes,omitempty"`
+	// TotalCount is the user's total preference count across all pages, independent of Limit/Offset.
+	TotalCount int `json:"totalCount"`
+	// NextOffset is the offset to request the next page with, nil when Preferences already
+	// reaches the end of the user's preferences.
+	NextOffset *int `json:"nextOffset,omitempty"`
+}
+
+// SetPreferenceRequest represents the request body for setting a single preference.
+type SetPreferenceRequest struct {
+	Value string `json:"value"`
+	// Enforced, when true, marks the value as policy-enforced: it can only be overridden by a
+	// later write that also sets Enforced, so it wins over the user's own (non-enforced) writes.
+	Enforced bool `json:"enforced,omitempty"`
+	// SchemaVersion declares the value format version Value is written in. Defaults to
+	// defaultSchemaVersion (1) when omitted or 0.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// TTLSeconds, when positive, makes the preference auto-expire that many seconds from now
+	// (stored as EXPIRES_AT; see preferenceExpiresAt): once expired it reads back as absent and
+	// is later purged by the background reconciler (see DeleteExpiredPreferences). Capped at
+	// maxPreferenceTTLSeconds. Omitted or 0 means the preference never expires, and overwrites
+	// any expiry a previous write on the same key set.
+	TTLSeconds int `json:"ttlSeconds,omitempty"`
+}
+
+// PreferenceDiffEntry represents a single key whose value differs between two users.
+type PreferenceDiffEntry struct {
+	Key        string `json:"key"`
+	ValueA     string `json:"valueA"`
+	ValueB     string `json:"valueB"`
+	UpdatedByA string `json:"updatedByA,omitempty"`
+	UpdatedByB string `json:"updatedByB,omitempty"`
+	// Redacted reports whether ValueA/ValueB were replaced with redactedPreferenceValue because
+	// Key is registered Sensitive and the caller did not request (or was not authorized for) a
+	// reveal. See DiffPreferences.
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// PreferenceDiffResponse represents the result of comparing two users' preferences.
+type PreferenceDiffResponse struct {
+	OnlyInA   []string              `json:"onlyInA"`
+	OnlyInB   []string              `json:"onlyInB"`
+	Differing []PreferenceDiffEntry `json:"differing"`
+}
+
+// BatchOperationType identifies the action a single batch operation performs.
+type BatchOperationType string
+
+const (
+	BatchOperationGet    BatchOperationType = "get"
+	BatchOperationSet    BatchOperationType = "set"
+	BatchOperationDelete BatchOperationType = "delete"
+)
+
+// BatchOperation represents a single get/set/delete operation within a batch request.
+type BatchOperation struct {
+	Op    BatchOperationType `json:"op"`
+	Key   string             `json:"key"`
+	Value string             `json:"value,omitempty"`
+	// Enforced, when true on a set operation, marks the value as policy-enforced. See
+	// SetPreferenceRequest.Enforced.
+	Enforced bool `json:"enforced,omitempty"`
+	// SchemaVersion, on a set operation, declares the value format version Value is written in.
+	// See SetPreferenceRequest.SchemaVersion.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+}
+
+// BatchRequest represents the request body for the batch preference endpoint.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+	// FailFast, when true, aborts and rolls back the entire batch on the first operation
+	// error instead of continuing and reporting the error against that operation. Defaults
+	// to false, so a single bad operation does not fail its siblings. This already covers
+	// value-size violations: an oversized value on a "set" operation fails only that
+	// operation (reported via BatchOperationResult.Error) unless FailFast is set.
+	FailFast bool `json:"failFast,omitempty"`
+}
+
+// BatchOperationResult represents the outcome of a single operation within a batch request.
+type BatchOperationResult struct {
+	Op       BatchOperationType      `json:"op"`
+	Key      string                  `json:"key"`
+	Value    string                  `json:"value,omitempty"`
+	Enforced bool                    `json:"enforced,omitempty"`
+	Error    *apierror.ErrorResponse `json:"error,omitempty"`
+}
+
+// BatchResponse represents the response body for the batch preference endpoint.
+type BatchResponse struct {
+	Results []BatchOperationResult `json:"results"`
+}
+
+// CompareAndSwapEntry represents a single key's expected current value and new value within a
+// CompareAndSwapRequest.
+type CompareAndSwapEntry struct {
+	Key string `json:"key"`
+	// Expected is the value the key must currently hold for the swap to apply. An empty Expected
+	// matches a key with no current value.
+	Expected string `json:"expected"`
+	Value    string `json:"value"`
+}
+
+// CompareAndSwapRequest represents the request body for the multi-key compare-and-swap endpoint.
+// Entries is the set of keys to check and, if every one matches, write together.
+type CompareAndSwapRequest struct {
+	Entries []CompareAndSwapEntry `json:"entries"`
+}
+
+// CompareAndSwapResponse reports the outcome of a CompareAndSwapRequest. When Applied is false,
+// MismatchedKeys lists every entry whose Expected value did not match the key's current value, so
+// the caller can re-read those keys and retry with fresh expected values.
+type CompareAndSwapResponse struct {
+	Applied        bool     `json:"applied"`
+	MismatchedKeys []string `json:"mismatchedKeys,omitempty"`
+}
+
+// ConditionalSetCondition identifies the comparison a conditional numeric preference set
+// requires to hold against the key's current stored value before the write is applied.
+type ConditionalSetCondition string
+
+const (
+	ConditionSetIfGreater ConditionalSetCondition = "set_if_greater"
+	ConditionSetIfLess    ConditionalSetCondition = "set_if_less"
+)
+
+// ConditionalSetRequest represents the request body for the conditional numeric set endpoint
+// (see PreferenceServiceInterface.ConditionalSetPreference). Value must parse as a number, as
+// must the key's current stored value if it has one.
+type ConditionalSetRequest struct {
+	Value     string                  `json:"value"`
+	Condition ConditionalSetCondition `json:"condition"`
+}
+
+// ConditionalSetResponse reports the outcome of a ConditionalSetRequest. When Applied is false,
+// CurrentValue carries the key's current value, which did not satisfy Condition against Value.
+type ConditionalSetResponse struct {
+	Applied      bool   `json:"applied"`
+	CurrentValue string `json:"currentValue,omitempty"`
+}
+
+// ExistsRequest represents the request body for the bulk key-existence endpoint. Keys is the
+// set of keys to check; it is capped at maxExistsCheckKeys.
+type ExistsRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// ExistsResponse reports, for each key in an ExistsRequest, whether the user currently has a
+// (non-deleted) value for it. Every requested key is present, defaulting to false.
+type ExistsResponse struct {
+	Exists map[string]bool `json:"exists"`
+}
+
+// PreferenceQueryRequest represents the request body for the bulk key-fetch endpoint. Keys is
+// the set of keys to fetch; it is capped at maxExistsCheckKeys.
+type PreferenceQueryRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// SeedDefaultRequest represents the request body for backfilling a default preference value
+// for every user in the deployment who does not already have one.
+type SeedDefaultRequest struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SeedDefaultResponse reports the outcome of a SeedDefaultRequest.
+type SeedDefaultResponse struct {
+	// UsersProcessed is the total number of users walked.
+	UsersProcessed int `json:"usersProcessed"`
+	// Seeded is the number of users who had no value for the key and were given the default.
+	Seeded int `json:"seeded"`
+	// Skipped is the number of users who already had their own value for the key and were
+	// left untouched.
+	Skipped int `json:"skipped"`
+}
+
+// BulkRenameRequest represents the admin request body for renaming every preference key, across
+// all users in the deployment, whose current key starts with FromPrefix: the FromPrefix portion
+// is replaced with ToPrefix, preserving the remainder of the key. For example, FromPrefix
+// "ui.old." and ToPrefix "ui.new." renames a user's "ui.old.theme" to "ui.new.theme".
+type BulkRenameRequest struct {
+	FromPrefix string `json:"fromPrefix"`
+	ToPrefix   string `json:"toPrefix"`
+	// Conflict selects how a rename is resolved when the target key already has a value for
+	// that user: ImportConflictSkip (default) leaves both keys as they are, and
+	// ImportConflictOverwrite replaces the target's value with the source's. ImportConflictNewerWins
+	// replaces the target's value only if the source's value is the more recently updated of
+	// the two.
+	Conflict ImportConflictStrategy `json:"conflict,omitempty"`
+}
+
+// BulkRenameResponse reports the outcome of a BulkRenameRequest, walking every user in the
+// deployment.
+type BulkRenameResponse struct {
+	// UsersProcessed is the total number of users walked.
+	UsersProcessed int `json:"usersProcessed"`
+	// Renamed is the number of keys successfully renamed.
+	Renamed int `json:"renamed"`
+	// Skipped is the number of matching keys left untouched because the target key already had
+	// a value and Conflict resolved in its favor.
+	Skipped int `json:"skipped"`
+}
+
+// DeletePreferencesByValueRequest represents the admin request body for deleting every
+// preference, across all users in the deployment, whose current value for Key exactly equals
+// Value. Confirm must be explicitly set to true; this is a deployment-wide delete and has no
+// dry-run mode.
+type DeletePreferencesByValueRequest struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Confirm bool   `json:"confirm"`
+}
+
+// DeletePreferencesByValueResponse reports the outcome of a DeletePreferencesByValueRequest,
+// walking every user in the deployment.
+type DeletePreferencesByValueResponse struct {
+	// UsersProcessed is the total number of users walked.
+	UsersProcessed int `json:"usersProcessed"`
+	// Deleted is the number of preferences deleted because they matched Key and Value.
+	Deleted int `json:"deleted"`
+}
+
+// CopyPreferencesRequest represents the admin request body for cloning preferences from
+// SourceUserID into TargetUserID, e.g. provisioning a new user from a curated template user.
+// Keys and Prefix are combined with OR semantics: a source key is copied if it is listed in
+// Keys, or it starts with Prefix (when set). Leaving both empty copies every one of
+// SourceUserID's preferences. A target key that already has a value is left untouched (see
+// CopyPreferencesResponse.Skipped) rather than overwritten.
+type CopyPreferencesRequest struct {
+	SourceUserID string   `json:"sourceUserId"`
+	TargetUserID string   `json:"targetUserId"`
+	Keys         []string `json:"keys,omitempty"`
+	Prefix       string   `json:"prefix,omitempty"`
+}
+
+// CopyPreferencesResponse reports the outcome of a CopyPreferencesRequest.
+type CopyPreferencesResponse struct {
+	// Copied lists the keys written to TargetUserID because it had no existing value for them.
+	Copied []string `json:"copied"`
+	// Skipped lists the matching source keys left untouched because TargetUserID already had a
+	// value for them.
+	Skipped []string `json:"skipped"`
+}
+
+// PreferenceSchemaResponse represents the response for listing the registered preference key
+// schemas.
+type PreferenceSchemaResponse struct {
+	Schemas []PreferenceSchemaEntry `json:"schemas"`
+}
+
+// ReconciliationIssueType categorizes a single discrepancy found by ReconcileUserPreferences
+// between a user's stored preferences and the registered schema catalog.
+type ReconciliationIssueType string
+
+const (
+	// ReconciliationIssueExtraKey marks a stored key with no registered schema.
+	ReconciliationIssueExtraKey ReconciliationIssueType = "extra_key"
+	// ReconciliationIssueMissingRequiredKey marks a registered key with Required set that the
+	// user has no stored value for.
+	ReconciliationIssueMissingRequiredKey ReconciliationIssueType = "missing_required_key"
+	// ReconciliationIssueInvalidValue marks a stored value that is not one of the key's
+	// registered AllowedValues.
+	ReconciliationIssueInvalidValue ReconciliationIssueType = "invalid_value"
+)
+
+// ReconciliationAction reports what ReconcileUserPreferences did about a single issue.
+type ReconciliationAction string
+
+const (
+	// ReconciliationActionNone means the issue was reported but left untouched, either because
+	// DryRun was true or because the issue has no defined fix (e.g. invalid_value, or an
+	// extra_key that is Enforced).
+	ReconciliationActionNone ReconciliationAction = "none"
+	// ReconciliationActionRemoved means an extra_key's value was deleted.
+	ReconciliationActionRemoved ReconciliationAction = "removed"
+	// ReconciliationActionSeeded means a missing_required_key was given its registered Default.
+	ReconciliationActionSeeded ReconciliationAction = "seeded"
+)
+
+// PreferenceReconciliationIssue represents a single discrepancy found for one key.
+type PreferenceReconciliationIssue struct {
+	Key    string                  `json:"key"`
+	Type   ReconciliationIssueType `json:"type"`
+	Value  string                  `json:"value,omitempty"`
+	Action ReconciliationAction    `json:"action"`
+}
+
+// PreferenceReconciliationReport reports the outcome of reconciling a single user's preferences
+// against the registered schema catalog via ReconcileUserPreferences.
+type PreferenceReconciliationReport struct {
+	UserID string `json:"userID"`
+	// DryRun reports whether fixes were applied (false) or only reported (true, the value of
+	// the request's DryRun field).
+	DryRun bool                            `json:"dryRun"`
+	Issues []PreferenceReconciliationIssue `json:"issues"`
+}
+
+// ReconcilePreferencesRequest represents the request body for ReconcileUserPreferences and
+// ReconcileAllUserPreferences.
+type ReconcilePreferencesRequest struct {
+	// DryRun, when true, reports issues without fixing them. Defaults to false: a reconcile
+	// request fixes issues (seeding missing required defaults, removing extra keys) unless
+	// DryRun is explicitly set, matching SeedDefaultForAllUsers and BulkRenamePreferences, which
+	// likewise mutate unconditionally.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// BulkReconciliationResponse reports the aggregate outcome of reconciling every user in the
+// deployment against the registered schema catalog via ReconcileAllUserPreferences. Unlike
+// PreferenceReconciliationReport, it reports counts rather than a per-user issue list, since an
+// exhaustive list across every user in a large deployment would be unbounded.
+type BulkReconciliationResponse struct {
+	DryRun                    bool `json:"dryRun"`
+	UsersProcessed            int  `json:"usersProcessed"`
+	UsersWithIssues           int  `json:"usersWithIssues"`
+	ExtraKeysFound            int  `json:"extraKeysFound"`
+	ExtraKeysRemoved          int  `json:"extraKeysRemoved"`
+	MissingRequiredKeysFound  int  `json:"missingRequiredKeysFound"`
+	MissingRequiredKeysSeeded int  `json:"missingRequiredKeysSeeded"`
+	InvalidValuesFound        int  `json:"invalidValuesFound"`
+}
+
+// PreferenceLockResponse reports the outcome of acquiring or refreshing an advisory lock on a
+// user's preferences via AcquirePreferenceLock/RefreshPreferenceLock.
+type PreferenceLockResponse struct {
+	UserID    string    `json:"userID"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AcquirePreferenceLockRequest represents the request body for AcquirePreferenceLock.
+type AcquirePreferenceLockRequest struct {
+	// TTLSeconds is how long the lock should be held before it auto-expires. Clamped to (0,
+	// maxPreferenceLockTTLSeconds]; defaults to defaultPreferenceLockTTLSeconds when omitted or
+	// 0.
+	TTLSeconds int `json:"ttlSeconds,omitempty"`
+}
+
+// PreferenceLockTokenRequest represents the request body for RefreshPreferenceLock and
+// ReleasePreferenceLock.
+type PreferenceLockTokenRequest struct {
+	Token string `json:"token"`
+	// TTLSeconds, on a refresh, is how long to extend the lock from now. See
+	// AcquirePreferenceLockRequest.TTLSeconds. Ignored on release.
+	TTLSeconds int `json:"ttlSeconds,omitempty"`
+}
+
+// ImportConflictStrategy selects how ImportPreferences resolves a key that already has a value.
+type ImportConflictStrategy string
+
+const (
+	// ImportConflictSkip keeps the existing value and leaves it untouched. This is the default
+	// when Conflict is left unset, so a re-run import never clobbers changes made since the
+	// last run.
+	ImportConflictSkip ImportConflictStrategy = "skip"
+	// ImportConflictOverwrite replaces the existing value unconditionally.
+	ImportConflictOverwrite ImportConflictStrategy = "overwrite"
+	// ImportConflictNewerWins replaces the existing value only if the imported entry's
+	// UpdatedAt is strictly after the existing value's UpdatedAt. An entry with a zero
+	// UpdatedAt is never considered newer, so it is skipped.
+	ImportConflictNewerWins ImportConflictStrategy = "newer-wins"
+)
+
+// ImportEntry represents a single key/value pair to import.
+type ImportEntry struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Enforced bool   `json:"enforced,omitempty"`
+	// SchemaVersion declares the value format version Value is written in. Defaults to
+	// defaultSchemaVersion (1) when omitted or 0. Round-trips a value's version across an
+	// export/import cycle (see PreferenceExportEntry.SchemaVersion).
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// UpdatedAt is the imported value's original write time. Only read under
+	// ImportConflictNewerWins, where it is compared against the stored value's UpdatedAt.
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// ImportPreferencesRequest represents the request body for bulk-importing preferences for the
+// authenticated user, e.g. from a migration or a previous export.
+type ImportPreferencesRequest struct {
+	Entries []ImportEntry `json:"entries"`
+	// Conflict selects how a key that already has a value is resolved. Defaults to
+	// ImportConflictSkip when empty.
+	Conflict ImportConflictStrategy `json:"conflict,omitempty"`
+}
+
+// ImportPreferencesResponse reports how many entries were inserted (no existing value),
+// overwritten (existing value replaced per Conflict), skipped (existing value kept per
+// Conflict), or failed (invalid key/value), so a re-run import can confirm it made no
+// unexpected changes.
+type ImportPreferencesResponse struct {
+	Inserted    int `json:"inserted"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+	Failed      int `json:"failed"`
+	// ChunkResults reports the outcome of each transactional batch the import was split into,
+	// per PreferenceImportConfig.ChunkSize. Empty when chunking was not used, i.e. the whole
+	// import ran in a single transaction (see ImportPreferences).
+	ChunkResults []ImportChunkResult `json:"chunkResults,omitempty"`
+}
+
+// ImportChunkResult reports a single chunk's outcome within a chunked import, identified by its
+// zero-based position among the chunks the import was split into.
+type ImportChunkResult struct {
+	Index       int `json:"index"`
+	Inserted    int `json:"inserted"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+	Failed      int `json:"failed"`
+	// Error reports a database-level failure that stopped the import before this chunk could be
+	// applied (see ImportPreferences); chunks before it already committed. Empty on success.
+	Error string `json:"error,omitempty"`
+}
+
+// DeleteNamespaceResponse reports the outcome of deleting every override within a namespace:
+// the override keys that were removed, and the namespace's effective state afterward. Since no
+// overrides remain, Effective reflects pure schema defaults (see PreferenceSchemaEntry.Default)
+// for every key registered under the namespace, distinguishing "deleted" from "reset to default".
+type DeleteNamespaceResponse struct {
+	DeletedKeys []string          `json:"deletedKeys"`
+	Effective   map[string]string `json:"effective"`
+}
+
+// DeletePreferencesRequest lists the keys to remove in a single bulk delete call; see
+// PreferenceServiceInterface.DeletePreferences.
+type DeletePreferencesRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// DeletePreferencesResponse reports the keys that were actually deleted by a bulk delete call. A
+// key in the request with no value is omitted rather than failing the call.
+type DeletePreferencesResponse struct {
+	DeletedKeys []string `json:"deletedKeys"`
+}
+
+// DeleteAllPreferencesResponse reports the number of preferences removed by a reset-to-defaults
+// call. Unlike DeletePreferencesResponse, the deleted keys are not listed individually, since a
+// reset-all can span a user's entire preference set.
+type DeleteAllPreferencesResponse struct {
+	DeletedCount int64 `json:"deletedCount"`
+}
+
+// ReplacePreferencesRequest carries the key/value map to write in a single bulk call; see
+// PreferenceServiceInterface.MergePreferences and PreferenceServiceInterface.ReplaceAllPreferences,
+// which share this request shape but differ in whether a key missing from Preferences is left
+// alone (merge) or deleted (replace).
+type ReplacePreferencesRequest struct {
+	Preferences map[string]string `json:"preferences"`
+}
+
+// PreferenceExportEntry represents a single preference belonging to a single user, one line of
+// the admin NDJSON export across every user in the deployment. See StreamAllPreferences.
+type PreferenceExportEntry struct {
+	UserID   string `json:"userID"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Enforced bool   `json:"enforced,omitempty"`
+	// SchemaVersion is the value format version Value was stored with, carried through so a
+	// re-import (see ImportEntry.SchemaVersion) preserves it.
+	SchemaVersion int       `json:"schemaVersion,omitempty"`
+	UpdatedBy     string    `json:"updatedBy,omitempty"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// UserPreferenceExportEntry represents a single preference within a self-service export
+// document (see UserPreferenceExportDocument); unlike PreferenceExportEntry it omits UserID,
+// since the document it belongs to is already scoped to one user.
+type UserPreferenceExportEntry struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Enforced bool   `json:"enforced,omitempty"`
+	// SchemaVersion is the value format version Value was stored with, carried through so a
+	// re-import (see ImportEntry.SchemaVersion) preserves it.
+	SchemaVersion int       `json:"schemaVersion,omitempty"`
+	UpdatedBy     string    `json:"updatedBy,omitempty"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// UserPreferenceExportDocument is the body of a self-service GDPR data-portability export (see
+// ExportUserPreferences): every preference belonging to one user, plus enough metadata to place
+// the export in time and in the deployment it came from. ExportedAt and DeploymentID are
+// camelCase here, matching every other field in this file, rather than the snake_case
+// "exported_at"/"deployment_id" used by other export tooling outside this service.
+type UserPreferenceExportDocument struct {
+	ExportedAt   time.Time                   `json:"exportedAt"`
+	DeploymentID string                      `json:"deploymentId"`
+	Preferences  []UserPreferenceExportEntry `json:"preferences"`
+}
+
+// ReadCountAggregateEntry represents a single preference key's total read count in the
+// read-count aggregate response.
+type ReadCountAggregateEntry struct {
+	Key        string `json:"key"`
+	TotalReads int64  `json:"totalReads"`
+}
+
+// ReadCountAggregateResponse represents the response for the read-count aggregate endpoint.
+// Enabled reports whether read-count tracking is turned on for this deployment; when false,
+// Entries is empty rather than stale, since tracking was never recording.
+type ReadCountAggregateResponse struct {
+	Enabled bool                      `json:"enabled"`
+	Entries []ReadCountAggregateEntry `json:"entries"`
+}
+
+// PreferenceHistoryEntry represents a single recorded value a preference held at some point in
+// time, oldest-first in PreferenceHistoryResponse. Value is nil for a tombstone entry recording
+// that the preference was deleted at ChangedAt.
+type PreferenceHistoryEntry struct {
+	Value     *string   `json:"value"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// PreferenceHistoryResponse represents the response for the preference history endpoint.
+type PreferenceHistoryResponse struct {
+	Key     string                   `json:"key"`
+	Entries []PreferenceHistoryEntry `json:"entries"`
+}
+
+// PreferenceAsOfEntry represents a single key's reconstructed value as of some past point in
+// time, per PreferencesAsOfResponse.
+type PreferenceAsOfEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// PreferencesAsOfResponse represents the response for the preferences-as-of endpoint: the set of
+// keys that had a value at or before Timestamp, reconstructed from USER_PREFERENCE_HISTORY. A key
+// with no history row at or before Timestamp (never written yet, or already deleted by then) is
+// omitted rather than listed with an empty value.
+type PreferencesAsOfResponse struct {
+	Timestamp   time.Time             `json:"timestamp"`
+	Preferences []PreferenceAsOfEntry `json:"preferences"`
+}
+
+// QueryDebugEntry represents a single store query's resolved SQL text for the current DB type,
+// in the query-debug response.
+type QueryDebugEntry struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+}
+
+// QueryDebugResponse represents the response for the admin query-debug endpoint. DBType is the
+// database type (e.g. "postgres", "sqlite") the queries were resolved against; there is no
+// MySQL or Oracle variant to resolve, since dbmodel.DBQuery only carries Postgres and SQLite
+// forms. Queries is sorted by ID.
+type QueryDebugResponse struct {
+	DBType  string            `json:"dbType"`
+	Queries []QueryDebugEntry `json:"queries"`
+}
+
+// buildPreferenceResponse converts a service-layer Preference into its API representation. An
+// empty pref.Value falls through to the key's registered schema default per
+// resolveEffectiveValue, reported via DefaultApplied. When includeSizes is true, SizeBytes is set
+// to the effective value's size in bytes. When explain is true, Explain reports the layer
+// breakdown behind Value; see buildPreferenceExplanation.
+func buildPreferenceResponse(pref Preference, includeSizes, explain bool) PreferenceResponse {
+	value, defaultApplied := resolveEffectiveValue(pref.Key, pref.Value)
+	// On migration failure, migratePreferenceValue returns value and pref.SchemaVersion
+	// unchanged, so the response falls back to the pre-migration value rather than failing the
+	// whole read.
+	value, schemaVersion, _ := migratePreferenceValue(pref.Key, value, pref.SchemaVersion)
+	resp := PreferenceResponse{
+		Key:            pref.Key,
+		Value:          value,
+		UpdatedBy:      pref.UpdatedBy,
+		Enforced:       pref.Enforced,
+		UpdatedAt:      pref.UpdatedAt,
+		DefaultApplied: defaultApplied,
+		SchemaVersion:  schemaVersion,
+	}
+	if includeSizes {
+		size := len(value)
+		resp.SizeBytes = &size
+	}
+	if explain {
+		resp.Explain = buildPreferenceExplanation(pref.Key, pref.Value)
+	}
+	return resp
+}
+
+// buildPreferenceDiff compares two users' preference sets and returns the keys unique to each
+// side along with the keys present on both sides whose values differ.
+func buildPreferenceDiff(prefsA, prefsB []Preference) *PreferenceDiffResponse {
+	valuesA := make(map[string]Preference, len(prefsA))
+	for _, pref := range prefsA {
+		valuesA[pref.Key] = pref
+	}
+	valuesB := make(map[string]Preference, len(prefsB))
+	for _, pref := range prefsB {
+		valuesB[pref.Key] = pref
+	}
+
+	diff := &PreferenceDiffResponse{
+		OnlyInA:   make([]string, 0),
+		OnlyInB:   make([]string, 0),
+		Differing: make([]PreferenceDiffEntry, 0),
+	}
+	for key, prefA := range valuesA {
+		prefB, present := valuesB[key]
+		if !present {
+			diff.OnlyInA = append(diff.OnlyInA, key)
+			continue
+		}
+		if prefA.Value != prefB.Value {
+			diff.Differing = append(diff.Differing, PreferenceDiffEntry{
+				Key:        key,
+				ValueA:     prefA.Value,
+				ValueB:     prefB.Value,
+				UpdatedByA: prefA.UpdatedBy,
+				UpdatedByB: prefB.UpdatedBy,
+			})
+		}
+	}
+	for key := range valuesB {
+		if _, present := valuesA[key]; !present {
+			diff.OnlyInB = append(diff.OnlyInB, key)
+		}
+	}
+
+	sort.Strings(diff.OnlyInA)
+	sort.Strings(diff.OnlyInB)
+	sort.Slice(diff.Differing, func(i, j int) bool { return diff.Differing[i].Key < diff.Differing[j].Key })
+
+	return diff
+}
+
+// redactSensitiveDiffEntries replaces ValueA/ValueB with redactedPreferenceValue for every
+// Differing entry whose key is registered Sensitive, unless reveal is true. Keys with no
+// registered schema, or registered but not Sensitive, are left untouched.
+func redactSensitiveDiffEntries(diff *PreferenceDiffResponse, reveal bool) {
+	if reveal {
+		return
+	}
+	for i, entry := range diff.Differing {
+		if !isSensitivePreferenceKey(entry.Key) {
+			continue
+		}
+		diff.Differing[i].ValueA = redactedPreferenceValue
+		diff.Differing[i].ValueB = redactedPreferenceValue
+		diff.Differing[i].Redacted = true
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+)
+
+// preferenceTypeBoolean, preferenceTypeNumber, and preferenceTypeJSON are the
+// PreferenceSchemaEntry.Type values that normalizePreferenceValue canonicalizes or validates on
+// write. Any other Type, including the empty string, is passed through unchanged.
+const (
+	preferenceTypeBoolean = "boolean"
+	preferenceTypeNumber  = "number"
+	preferenceTypeJSON    = "json"
+)
+
+// errJSONTooDeep is returned by validateJSONDepth once value's nesting exceeds maxPreferenceJSONDepth.
+var errJSONTooDeep = errors.New("json value nesting exceeds the allowed depth")
+
+// booleanTrueValues and booleanFalseValues are the client input forms normalizePreferenceValue
+// accepts for a boolean key, compared case-insensitively after trimming whitespace. Clients have
+// been observed sending "True", "1", and "on" for the same boolean preference.
+var (
+	booleanTrueValues  = map[string]bool{"true": true, "1": true, "on": true, "yes": true}
+	booleanFalseValues = map[string]bool{"false": true, "0": true, "off": true, "no": true}
+)
+
+// normalizePreferenceValue canonicalizes or validates value for key's registered schema Type
+// before it is written, so that the stored value is consistent regardless of client quirks: a
+// boolean key is canonicalized to "true"/"false", a number key is canonicalized to its trimmed
+// numeric form, and a json key is rejected if it nests deeper than maxPreferenceJSONDepth. A key
+// with no registered schema, or a schema whose Type is not one of these, is returned unchanged.
+// ErrorPreferenceTypeMismatch is returned when value cannot be interpreted as a declared
+// boolean/number type; ErrorInvalidPreferenceValue is returned when a json value is malformed or
+// too deeply nested.
+func normalizePreferenceValue(key, value string) (string, *tidcommon.ServiceError) {
+	schemaRegistryMu.RLock()
+	entry, ok := schemaRegistry[key]
+	schemaRegistryMu.RUnlock()
+	if !ok {
+		return value, nil
+	}
+
+	switch entry.Type {
+	case preferenceTypeBoolean:
+		trimmed := strings.ToLower(strings.TrimSpace(value))
+		switch {
+		case booleanTrueValues[trimmed]:
+			return "true", nil
+		case booleanFalseValues[trimmed]:
+			return "false", nil
+		default:
+			return "", &ErrorPreferenceTypeMismatch
+		}
+	case preferenceTypeNumber:
+		trimmed := strings.TrimSpace(value)
+		if _, err := strconv.ParseFloat(trimmed, 64); err != nil {
+			return "", &ErrorPreferenceTypeMismatch
+		}
+		return trimmed, nil
+	case preferenceTypeJSON:
+		if err := validateJSONDepth(value, maxPreferenceJSONDepth); err != nil {
+			return "", &ErrorInvalidPreferenceValue
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+// validateJSONDepth rejects value, a candidate Type "json" preference value, as soon as its
+// nesting exceeds maxDepth or it is not well-formed JSON. It walks value token-by-token via
+// json.Decoder rather than fully unmarshaling it into a generic tree first, so a maliciously
+// deep (but otherwise small) document is rejected without the cost of building and then
+// schema-validating that tree.
+func validateJSONDepth(value string, maxDepth int) error {
+	dec := json.NewDecoder(strings.NewReader(value))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+				if depth > maxDepth {
+					return errJSONTooDeep
+				}
+			case '}', ']':
+				depth--
+			}
+		}
+	}
+}
+
+// normalizePreferenceKeyCase lowercases key when PreferenceKeyCaseConfig.Enabled, so that
+// "theme" and "Theme" resolve to the same preference. It is called once at the top of each
+// service method that takes a caller-supplied key (GetPreference, SetPreference,
+// DeletePreference), before validation and any store call, so upsert and lookup always agree on
+// the key they operate on. When disabled, key is returned unchanged and keys remain case-sensitive.
+func normalizePreferenceKeyCase(key string) string {
+	if !config.GetServerRuntime().Config.Preference.KeyCase.Enabled {
+		return key
+	}
+	return strings.ToLower(key)
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePreferenceValue_Boolean(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "feature.enabled", Type: preferenceTypeBoolean})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "feature.enabled")
+		schemaRegistryMu.Unlock()
+	}()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"lowercase true", "true", "true", false},
+		{"capitalized True", "True", "true", false},
+		{"numeric 1", "1", "true", false},
+		{"on", "on", "true", false},
+		{"yes", "yes", "true", false},
+		{"lowercase false", "false", "false", false},
+		{"numeric 0", "0", "false", false},
+		{"off", "off", "false", false},
+		{"no", "no", "false", false},
+		{"whitespace padded", "  TRUE  ", "true", false},
+		{"uninterpretable", "maybe", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, svcErr := normalizePreferenceValue("feature.enabled", tt.input)
+			if tt.wantErr {
+				assert.NotNil(t, svcErr)
+				assert.Equal(t, ErrorPreferenceTypeMismatch.Code, svcErr.Code)
+				return
+			}
+			assert.Nil(t, svcErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizePreferenceValue_Number(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.fontSize", Type: preferenceTypeNumber})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "ui.fontSize")
+		schemaRegistryMu.Unlock()
+	}()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"integer", "12", "12", false},
+		{"decimal", "12.5", "12.5", false},
+		{"negative", "-3", "-3", false},
+		{"whitespace padded", "  14  ", "14", false},
+		{"uninterpretable", "large", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, svcErr := normalizePreferenceValue("ui.fontSize", tt.input)
+			if tt.wantErr {
+				assert.NotNil(t, svcErr)
+				assert.Equal(t, ErrorPreferenceTypeMismatch.Code, svcErr.Code)
+				return
+			}
+			assert.Nil(t, svcErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizePreferenceValue_JSON(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.layout", Type: preferenceTypeJSON})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "ui.layout")
+		schemaRegistryMu.Unlock()
+	}()
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"flat object", `{"a":1,"b":"two"}`, false},
+		{"array", `[1,2,3]`, false},
+		{"not json", `not json`, true},
+		{"deeply nested", strings.Repeat(`{"a":`, maxPreferenceJSONDepth+1) + "1" + strings.Repeat("}", maxPreferenceJSONDepth+1), true},
+		{"at depth limit", strings.Repeat(`{"a":`, maxPreferenceJSONDepth) + "1" + strings.Repeat("}", maxPreferenceJSONDepth), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, svcErr := normalizePreferenceValue("ui.layout", tt.input)
+			if tt.wantErr {
+				assert.NotNil(t, svcErr)
+				assert.Equal(t, ErrorInvalidPreferenceValue.Code, svcErr.Code)
+				return
+			}
+			assert.Nil(t, svcErr)
+			assert.Equal(t, tt.input, got)
+		})
+	}
+}
+
+func TestNormalizePreferenceValue_JSONRejectsLargeValueBeforeNormalize(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.layout", Type: preferenceTypeJSON})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "ui.layout")
+		schemaRegistryMu.Unlock()
+	}()
+
+	large := `{"padding":"` + strings.Repeat("x", maxPreferenceValueLength) + `"}`
+
+	svcErr := validatePreferenceValue(large)
+
+	assert.NotNil(t, svcErr)
+	assert.Equal(t, ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func TestValidateJSONDepth_AbortsOnFirstInvalidToken(t *testing.T) {
+	err := validateJSONDepth(`{"a": }`, maxPreferenceJSONDepth)
+	assert.Error(t, err)
+}
+
+func TestNormalizePreferenceValue_UnregisteredKeyPassesThrough(t *testing.T) {
+	got, svcErr := normalizePreferenceValue("no.such.key", "whatever")
+	assert.Nil(t, svcErr)
+	assert.Equal(t, "whatever", got)
+}
+
+func TestNormalizePreferenceValue_NonTypedSchemaPassesThrough(t *testing.T) {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Type: "enum", AllowedValues: []string{"light", "dark"}})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "ui.theme")
+		schemaRegistryMu.Unlock()
+	}()
+
+	got, svcErr := normalizePreferenceValue("ui.theme", "dark")
+	assert.Nil(t, svcErr)
+	assert.Equal(t, "dark", got)
+}
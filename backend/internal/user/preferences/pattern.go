@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"container/list"
+	"regexp"
+	"strings"
+	"sync"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+)
+
+// validatePreferencePattern rejects a list request's pattern query parameter before it is
+// compiled, bounding both how long a pattern may be and how many '*'/'?' wildcards it may carry,
+// since GetPreferencesByUserID already loads every one of the user's preferences into memory and
+// matches them here rather than pushing the filter into a store query (see
+// matchesPreferencePattern); an unbounded pattern would let a single request force an
+// expensive match against every key.
+func validatePreferencePattern(pattern string) *tidcommon.ServiceError {
+	if len(pattern) > maxPreferencePatternLength {
+		return &ErrorInvalidPreferencePattern
+	}
+	if strings.Count(pattern, "*")+strings.Count(pattern, "?") > maxPreferencePatternWildcards {
+		return &ErrorInvalidPreferencePattern
+	}
+	return nil
+}
+
+// validatePreferencePrefix rejects a list request's prefix query parameter before it reaches
+// GetPreferencesByPrefix, bounding its length the same way validatePreferencePattern bounds
+// pattern, since it is pushed into SQL as a LIKE pattern whose matching cost grows with its size.
+func validatePreferencePrefix(prefix string) *tidcommon.ServiceError {
+	if len(prefix) > maxPreferencePatternLength {
+		return &ErrorInvalidPreferencePrefix
+	}
+	return nil
+}
+
+// validatePreferenceValueContains rejects a list request's valueContains query parameter before
+// it reaches SearchPreferencesByValue, bounding its length the same way validatePreferencePrefix
+// bounds prefix, since it is likewise pushed into SQL as a LIKE pattern.
+func validatePreferenceValueContains(valueContains string) *tidcommon.ServiceError {
+	if len(valueContains) > maxPreferencePatternLength {
+		return &ErrorInvalidPreferenceValueContains
+	}
+	return nil
+}
+
+// validatePreferenceSort rejects a list request's sortField/sortOrder (the "sort" and "order"
+// query parameters, sortValueCatalog already handled separately) unless each is either empty or
+// one of its allowed values, the same allowlist preferenceSortColumns/preferenceSortDirections
+// use to build the ORDER BY clause pushed down to GetPreferencesByUserIDSorted. Rejecting an
+// unrecognized value here, rather than silently falling back to the default, is what keeps that
+// allowlist from ever being bypassed with an unexpected value.
+func validatePreferenceSort(sortField, sortOrder string) *tidcommon.ServiceError {
+	switch sortField {
+	case "", preferenceSortKey, preferenceSortUpdatedAt:
+	default:
+		return &ErrorInvalidPreferenceSort
+	}
+	switch sortOrder {
+	case "", preferenceSortOrderAsc, preferenceSortOrderDesc:
+	default:
+		return &ErrorInvalidPreferenceSort
+	}
+	return nil
+}
+
+// patternRegexCacheCapacity bounds patternRegexCache's size. Patterns are caller-supplied (see
+// validatePreferencePattern's length/wildcard caps, not a closed set), so without a cap a list
+// request that varies its pattern on every call would grow the cache without bound.
+const patternRegexCacheCapacity = 512
+
+// patternRegexCache memoizes the compiled regular expression for a pattern, since the same
+// pattern is matched against every one of a user's keys within a single list request. Bounded to
+// patternRegexCacheCapacity entries, evicting the least recently used pattern once full.
+var patternRegexCache = newPatternRegexCache(patternRegexCacheCapacity)
+
+// lruRegexCache is a fixed-capacity, least-recently-used cache mapping a pattern string to its
+// compiled regular expression, safe for concurrent use.
+type lruRegexCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used; back = least recently used
+}
+
+// lruRegexCacheEntry is the value stored in lruRegexCache.order's elements.
+type lruRegexCacheEntry struct {
+	pattern string
+	regex   *regexp.Regexp
+}
+
+func newPatternRegexCache(capacity int) *lruRegexCache {
+	return &lruRegexCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// getOrCompile returns pattern's compiled regular expression, compiling and caching it via
+// compile on a miss, and evicting the least recently used entry first if the cache is full.
+func (c *lruRegexCache) getOrCompile(pattern string, compile func(pattern string) *regexp.Regexp) *regexp.Regexp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*lruRegexCacheEntry).regex
+	}
+
+	re := compile(pattern)
+	elem := c.order.PushFront(&lruRegexCacheEntry{pattern: pattern, regex: re})
+	c.entries[pattern] = elem
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruRegexCacheEntry).pattern)
+	}
+	return re
+}
+
+// matchesPreferencePattern reports whether key matches pattern, using glob semantics: '*' matches
+// any run of characters (including none), '?' matches exactly one character, and every other
+// character matches itself literally. This operates on keys already loaded into memory (see
+// ListPreferences), rather than translating pattern into a SQL LIKE clause: a '*'/'?' glob isn't
+// a SQL LIKE pattern, and DeletePreferencesByNamespace similarly filters a fully-loaded set in
+// application code. The simpler "starts with a literal prefix" case is instead pushed down to
+// SQL via GetPreferencesByPrefix, which needs no glob translation.
+func matchesPreferencePattern(key, pattern string) bool {
+	re := patternRegexCache.getOrCompile(pattern, func(pattern string) *regexp.Regexp {
+		return regexp.MustCompile("^" + globToRegexSource(pattern) + "$")
+	})
+	return re.MatchString(key)
+}
+
+// globToRegexSource translates a '*'/'?' glob pattern into the source of an equivalent regular
+// expression, escaping every other character so it matches itself literally rather than as a
+// regex metacharacter.
+func globToRegexSource(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesPreferencePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		pattern string
+		want    bool
+	}{
+		{"exact match", "theme", "theme", true},
+		{"exact mismatch", "theme", "locale", false},
+		{"star matches middle segment", "ui.dark.color", "ui.*.color", true},
+		{"star does not bridge missing segment", "ui.color", "ui.*.color", false},
+		{"star matches empty run", "ui..color", "ui.*.color", true},
+		{"star matches everything", "anything.at.all", "*", true},
+		{"question matches exactly one char", "ui.a.color", "ui.?.color", true},
+		{"question does not match multiple chars", "ui.ab.color", "ui.?.color", false},
+		{"question does not match zero chars", "ui..color", "ui.?.color", false},
+		{"literal dot in key is not a regex wildcard", "uiXdarkXcolor", "ui.dark.color", false},
+		{"regex metacharacters in key match literally", "ui.a+b", "ui.a+b", true},
+		{"regex metacharacters without escaping would over-match", "ui.aXb", "ui.a+b", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, matchesPreferencePattern(tc.key, tc.pattern))
+		})
+	}
+}
+
+func TestGlobToRegexSource_EscapesLiteralCharacters(t *testing.T) {
+	assert.Equal(t, `a\.b\+c`, globToRegexSource("a.b+c"))
+	assert.Equal(t, `.*a.`, globToRegexSource("*a?"))
+}
+
+func TestValidatePreferencePattern_Valid(t *testing.T) {
+	assert.Nil(t, validatePreferencePattern("ui.*.color"))
+	assert.Nil(t, validatePreferencePattern(""))
+}
+
+func TestValidatePreferencePattern_TooLong(t *testing.T) {
+	svcErr := validatePreferencePattern(strings.Repeat("a", maxPreferencePatternLength+1))
+	assert.Same(t, &ErrorInvalidPreferencePattern, svcErr)
+}
+
+func TestValidatePreferencePattern_TooManyWildcards(t *testing.T) {
+	svcErr := validatePreferencePattern(strings.Repeat("*", maxPreferencePatternWildcards+1))
+	assert.Same(t, &ErrorInvalidPreferencePattern, svcErr)
+}
+
+func TestLRURegexCache_CachesCompileResultForSamePattern(t *testing.T) {
+	cache := newPatternRegexCache(2)
+	calls := 0
+	compile := func(pattern string) *regexp.Regexp {
+		calls++
+		return regexp.MustCompile(pattern)
+	}
+
+	first := cache.getOrCompile("a.*", compile)
+	second := cache.getOrCompile("a.*", compile)
+
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, calls)
+}
+
+func TestLRURegexCache_EvictsLeastRecentlyUsedOnceFull(t *testing.T) {
+	cache := newPatternRegexCache(2)
+	compile := func(pattern string) *regexp.Regexp { return regexp.MustCompile(pattern) }
+
+	cache.getOrCompile("a", compile)
+	cache.getOrCompile("b", compile)
+	cache.getOrCompile("a", compile) // touch "a" so "b" becomes least recently used
+	cache.getOrCompile("c", compile) // evicts "b", not "a"
+
+	assert.Len(t, cache.entries, 2)
+	_, hasA := cache.entries["a"]
+	_, hasB := cache.entries["b"]
+	_, hasC := cache.entries["c"]
+	assert.True(t, hasA)
+	assert.False(t, hasB)
+	assert.True(t, hasC)
+}
+
+func TestLRURegexCache_NeverGrowsPastCapacity(t *testing.T) {
+	cache := newPatternRegexCache(4)
+	compile := func(pattern string) *regexp.Regexp { return regexp.MustCompile(pattern) }
+
+	for i := 0; i < 100; i++ {
+		cache.getOrCompile(strings.Repeat("x", i+1), compile)
+	}
+
+	assert.Len(t, cache.entries, 4)
+}
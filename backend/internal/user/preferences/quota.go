@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+// quotaEnabled reports whether at least one of PreferenceQuotaConfig's limits is configured.
+func quotaEnabled(cfg config.PreferenceQuotaConfig) bool {
+	return cfg.MaxCount > 0 || cfg.MaxBytes > 0
+}
+
+// writeQuotaHeaders writes the headerQuotaUsage/headerQuotaLimit pair describing usage against
+// whichever of PreferenceQuotaConfig's MaxCount/MaxBytes limits are configured, and, once usage
+// crosses WarnThresholdPercent of either, a headerQuotaWarning so a UI can prompt cleanup before a
+// write is ever rejected. These are soft quotas: nothing here rejects the request. A no-op when
+// neither limit is configured, preserving current behavior by default.
+func writeQuotaHeaders(w http.ResponseWriter, usage *PreferenceUsage) {
+	cfg := config.GetServerRuntime().Config.Preference.Quota
+	if !quotaEnabled(cfg) || usage == nil {
+		return
+	}
+
+	var usageParts, limitParts []string
+	warnPercent := 0
+
+	if cfg.MaxCount > 0 {
+		usageParts = append(usageParts, fmt.Sprintf("count=%d", usage.Count))
+		limitParts = append(limitParts, fmt.Sprintf("count=%d", cfg.MaxCount))
+		warnPercent = max(warnPercent, quotaPercent(usage.Count, cfg.MaxCount))
+	}
+	if cfg.MaxBytes > 0 {
+		usageParts = append(usageParts, fmt.Sprintf("bytes=%d", usage.TotalBytes))
+		limitParts = append(limitParts, fmt.Sprintf("bytes=%d", cfg.MaxBytes))
+		warnPercent = max(warnPercent, quotaPercent(usage.TotalBytes, cfg.MaxBytes))
+	}
+
+	w.Header().Set(headerQuotaUsage, strings.Join(usageParts, ";"))
+	w.Header().Set(headerQuotaLimit, strings.Join(limitParts, ";"))
+
+	if cfg.WarnThresholdPercent > 0 && warnPercent >= cfg.WarnThresholdPercent {
+		w.Header().Set(headerQuotaWarning,
+			fmt.Sprintf(`299 - "Preference quota usage at %d%% of limit"`, warnPercent))
+	}
+}
+
+// quotaPercent returns usage as a percentage of limit, or 0 if limit is unset.
+func quotaPercent(usage, limit int64) int {
+	if limit <= 0 {
+		return 0
+	}
+	return int(usage * 100 / limit)
+}
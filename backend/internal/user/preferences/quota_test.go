@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+func TestWriteQuotaHeaders_DisabledByDefault(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 10, TotalBytes: 1000})
+
+	assert.Empty(t, rr.Header().Get(headerQuotaUsage))
+	assert.Empty(t, rr.Header().Get(headerQuotaLimit))
+}
+
+func TestWriteQuotaHeaders_CountLimitOnly(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 100}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 10, TotalBytes: 1000})
+
+	assert.Equal(t, "count=10", rr.Header().Get(headerQuotaUsage))
+	assert.Equal(t, "count=100", rr.Header().Get(headerQuotaLimit))
+	assert.Empty(t, rr.Header().Get(headerQuotaWarning))
+}
+
+func TestWriteQuotaHeaders_ByteLimitOnly(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxBytes: 1000}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 10, TotalBytes: 500})
+
+	assert.Equal(t, "bytes=500", rr.Header().Get(headerQuotaUsage))
+	assert.Equal(t, "bytes=1000", rr.Header().Get(headerQuotaLimit))
+}
+
+func TestWriteQuotaHeaders_BothLimitsConfigured(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 100, MaxBytes: 1000}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 10, TotalBytes: 500})
+
+	assert.Equal(t, "count=10;bytes=500", rr.Header().Get(headerQuotaUsage))
+	assert.Equal(t, "count=100;bytes=1000", rr.Header().Get(headerQuotaLimit))
+}
+
+func TestWriteQuotaHeaders_WarningBelowThreshold(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{
+		MaxCount: 100, WarnThresholdPercent: 90,
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 50})
+
+	assert.Empty(t, rr.Header().Get(headerQuotaWarning))
+}
+
+func TestWriteQuotaHeaders_WarningAtThreshold(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{
+		MaxCount: 100, WarnThresholdPercent: 90,
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 92})
+
+	assert.Equal(t, `299 - "Preference quota usage at 92% of limit"`, rr.Header().Get(headerQuotaWarning))
+}
+
+func TestWriteQuotaHeaders_WarningUsesWorseOfEitherDimension(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{
+		MaxCount: 100, MaxBytes: 1000, WarnThresholdPercent: 90,
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 10, TotalBytes: 950})
+
+	assert.Equal(t, `299 - "Preference quota usage at 95% of limit"`, rr.Header().Get(headerQuotaWarning))
+}
+
+func TestWriteQuotaHeaders_WarningDisabledWhenThresholdUnset(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 100}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, &PreferenceUsage{Count: 99})
+
+	assert.Empty(t, rr.Header().Get(headerQuotaWarning))
+}
+
+func TestWriteQuotaHeaders_NilUsage(t *testing.T) {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 100}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	rr := httptest.NewRecorder()
+	writeQuotaHeaders(rr, nil)
+
+	assert.Empty(t, rr.Header().Get(headerQuotaUsage))
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/security"
+)
+
+// rateLimitTier distinguishes the two independently configured request-rate limits: the
+// stricter one applied to list/export/aggregate endpoints, and the looser one applied to
+// single-key write operations. Single-key reads are exempt from both (see registerRoutes);
+// only list/export reads (expensive) and single-key PUT/DELETE writes (standard) are throttled.
+type rateLimitTier string
+
+const (
+	rateLimitTierExpensive rateLimitTier = "expensive"
+	rateLimitTierStandard  rateLimitTier = "standard"
+
+	// rateLimitWindow is the fixed window over which PreferenceRateLimitConfig's limits are
+	// counted.
+	rateLimitWindow = time.Minute
+	// rateLimitRetryAfterSeconds is the Retry-After value (in seconds) sent with
+	// ErrorPreferenceRateLimited, equal to rateLimitWindow.
+	rateLimitRetryAfterSeconds = int(rateLimitWindow / time.Second)
+)
+
+// rateLimitCounter tracks the number of requests a user has made in the current fixed window for
+// a single tier.
+type rateLimitCounter struct {
+	windowStart time.Time
+	count       int
+}
+
+// rateLimiter decides whether a request for a user under a tier is currently within its
+// configured limit. Extracted as an interface, rather than calling fixedWindowRateLimiter
+// directly, so rateLimitMiddleware's tests can swap in a fake that does not depend on wall-clock
+// timing.
+type rateLimiter interface {
+	// Allow reports whether a request for userID under tier is within limit, counting it against
+	// the current window if so. A limit of 0 disables the check and always allows the request.
+	Allow(tier rateLimitTier, userID string, limit int) bool
+}
+
+// fixedWindowRateLimiter is the default rateLimiter, counting requests per user per tier in a
+// fixed window (see rateLimitWindow) rather than a token bucket; a window reset allows a full
+// burst of limit requests the instant it rolls over, which is an acceptable trade-off against the
+// simplicity of not tracking individual request timestamps.
+type fixedWindowRateLimiter struct {
+	mu       sync.Mutex
+	counters map[rateLimitTier]map[string]*rateLimitCounter
+}
+
+var _ rateLimiter = (*fixedWindowRateLimiter)(nil)
+
+// newFixedWindowRateLimiter creates a fixedWindowRateLimiter with empty counters for every tier.
+func newFixedWindowRateLimiter() *fixedWindowRateLimiter {
+	return &fixedWindowRateLimiter{
+		counters: map[rateLimitTier]map[string]*rateLimitCounter{
+			rateLimitTierExpensive: make(map[string]*rateLimitCounter),
+			rateLimitTierStandard:  make(map[string]*rateLimitCounter),
+		},
+	}
+}
+
+func (l *fixedWindowRateLimiter) Allow(tier rateLimitTier, userID string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	counters := l.counters[tier]
+	now := time.Now()
+	counter := counters[userID]
+	if counter == nil || now.Sub(counter.windowStart) >= rateLimitWindow {
+		counter = &rateLimitCounter{windowStart: now}
+		counters[userID] = counter
+	}
+	if counter.count >= limit {
+		return false
+	}
+	counter.count++
+	return true
+}
+
+// activeRateLimiter is the rateLimiter consulted by rateLimitMiddleware. Tests may replace it
+// with a fake implementation of rateLimiter, restoring the original afterward.
+var activeRateLimiter rateLimiter = newFixedWindowRateLimiter()
+
+// preferenceRateLimitMetrics holds the lazily-initialized counter for requests rejected by the
+// per-tier rate limit.
+type preferenceRateLimitMetrics struct {
+	once     sync.Once
+	rejected metric.Int64Counter
+}
+
+var rateLimitMetrics preferenceRateLimitMetrics
+
+func initRateLimitMetrics() {
+	rateLimitMetrics.once.Do(func() {
+		meter := otel.Meter("github.com/thunder-id/thunderid/preferences")
+		rateLimitMetrics.rejected, _ = meter.Int64Counter(
+			"thunderid_preference_rate_limit_rejections_total",
+			metric.WithDescription("Total preference requests rejected by the per-user request rate limit"),
+		)
+	})
+}
+
+// recordRateLimitRejection increments the rate-limit-rejection counter for tier.
+func recordRateLimitRejection(ctx context.Context, tier rateLimitTier) {
+	initRateLimitMetrics()
+	if rateLimitMetrics.rejected != nil {
+		rateLimitMetrics.rejected.Add(ctx, 1, metric.WithAttributes(attribute.String("tier", string(tier))))
+	}
+}
+
+// rateLimitMiddleware rejects a request with ErrorPreferenceRateLimited, and a Retry-After
+// header, once the authenticated user has exceeded the per-minute limit configured for tier (see
+// PreferenceRateLimitConfig). This bounds request frequency over time, distinct from
+// concurrencyLimitMiddleware, which bounds simultaneous in-flight work. Requests with no
+// authenticated subject pass through unthrottled, since there is no per-user key to limit on; the
+// wrapped handler is responsible for rejecting those with ErrorMissingUserID.
+func rateLimitMiddleware(tier rateLimitTier, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := security.GetSubject(r.Context())
+		if userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limit := rateLimitForTier(tier)
+		if !activeRateLimiter.Allow(tier, userID, limit) {
+			recordRateLimitRejection(r.Context(), tier)
+			handleServiceError(r.Context(), w, &ErrorPreferenceRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitForTier returns the currently configured per-minute limit for tier.
+func rateLimitForTier(tier rateLimitTier) int {
+	cfg := config.GetServerRuntime().Config.Preference.RateLimit
+	if tier == rateLimitTierExpensive {
+		return cfg.MaxExpensivePerMinute
+	}
+	return cfg.MaxPerMinute
+}
+
+// expensiveRateLimitMiddleware applies the stricter rate limit (PreferenceRateLimitConfig.
+// MaxExpensivePerMinute) to list/export/aggregate endpoints, which scan across a user's or the
+// deployment's whole preference set and are much more expensive per request than a single-key
+// operation.
+func expensiveRateLimitMiddleware(next http.Handler) http.Handler {
+	return rateLimitMiddleware(rateLimitTierExpensive, next)
+}
+
+// standardRateLimitMiddleware applies the looser rate limit (PreferenceRateLimitConfig.
+// MaxPerMinute) to single-key preference PUT and DELETE writes. Single-key reads are exempt (see
+// registerRoutes), since a misbehaving client hammering a read is far cheaper to serve than one
+// hammering a write.
+func standardRateLimitMiddleware(next http.Handler) http.Handler {
+	return rateLimitMiddleware(rateLimitTierStandard, next)
+}
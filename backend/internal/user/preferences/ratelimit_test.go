@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+func resetRateLimitCountersForTest() {
+	activeRateLimiter = newFixedWindowRateLimiter()
+}
+
+// fakeRateLimiter is a rateLimiter test double that always returns a fixed verdict, regardless of
+// tier, userID, or limit, so tests can exercise rateLimitMiddleware's handling of an allowed or
+// rejected request without depending on fixedWindowRateLimiter's wall-clock window.
+type fakeRateLimiter struct {
+	allow bool
+}
+
+func (f *fakeRateLimiter) Allow(tier rateLimitTier, userID string, limit int) bool {
+	return f.allow
+}
+
+func TestAllowRequest_DisabledByDefault(t *testing.T) {
+	resetRateLimitCountersForTest()
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-1", 0))
+	}
+}
+
+func TestAllowRequest_RejectsBeyondLimit(t *testing.T) {
+	resetRateLimitCountersForTest()
+
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-1", 2))
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-1", 2))
+	assert.False(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-1", 2))
+}
+
+func TestAllowRequest_LimitIsPerUser(t *testing.T) {
+	resetRateLimitCountersForTest()
+
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-1", 1))
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-2", 1))
+}
+
+func TestAllowRequest_LimitIsPerTier(t *testing.T) {
+	resetRateLimitCountersForTest()
+
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-1", 1))
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierExpensive, "user-1", 1))
+	assert.False(t, activeRateLimiter.Allow(rateLimitTierStandard, "user-1", 1))
+	assert.False(t, activeRateLimiter.Allow(rateLimitTierExpensive, "user-1", 1))
+}
+
+func TestRateLimitMiddleware_PassesThroughWithinLimit(t *testing.T) {
+	resetRateLimitCountersForTest()
+	config.GetServerRuntime().Config.Preference.RateLimit.MaxExpensivePerMinute = 1
+	defer func() { config.GetServerRuntime().Config.Preference.RateLimit = config.PreferenceRateLimitConfig{} }()
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	rr := httptest.NewRecorder()
+
+	expensiveRateLimitMiddleware(next).ServeHTTP(rr, authenticatedRequestForTest("user-1"))
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRateLimitMiddleware_RejectsWithTooManyRequests(t *testing.T) {
+	resetRateLimitCountersForTest()
+	config.GetServerRuntime().Config.Preference.RateLimit.MaxExpensivePerMinute = 1
+	defer func() { config.GetServerRuntime().Config.Preference.RateLimit = config.PreferenceRateLimitConfig{} }()
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierExpensive, "user-1", 1))
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	rr := httptest.NewRecorder()
+
+	expensiveRateLimitMiddleware(next).ServeHTTP(rr, authenticatedRequestForTest("user-1"))
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
+
+func TestRateLimitMiddleware_ExpensiveAndStandardLimitsAreIndependent(t *testing.T) {
+	resetRateLimitCountersForTest()
+	config.GetServerRuntime().Config.Preference.RateLimit = config.PreferenceRateLimitConfig{
+		MaxExpensivePerMinute: 1,
+		MaxPerMinute:          1,
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.RateLimit = config.PreferenceRateLimitConfig{} }()
+	assert.True(t, activeRateLimiter.Allow(rateLimitTierExpensive, "user-1", 1))
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	rr := httptest.NewRecorder()
+
+	standardRateLimitMiddleware(next).ServeHTTP(rr, authenticatedRequestForTest("user-1"))
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRateLimitMiddleware_NoSubjectPassesThroughUnlimited(t *testing.T) {
+	resetRateLimitCountersForTest()
+	config.GetServerRuntime().Config.Preference.RateLimit.MaxExpensivePerMinute = 1
+	defer func() { config.GetServerRuntime().Config.Preference.RateLimit = config.PreferenceRateLimitConfig{} }()
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	req := httptest.NewRequest(http.MethodGet, "/users/me/preferences", nil)
+	rr := httptest.NewRecorder()
+
+	expensiveRateLimitMiddleware(next).ServeHTTP(rr, req)
+
+	assert.True(t, called)
+}
+
+// TestRateLimitMiddleware_UsesInjectedRateLimiter asserts that rateLimitMiddleware defers to
+// whatever rateLimiter is currently assigned to activeRateLimiter, demonstrating that a fake can
+// be swapped in to assert a rejection deterministically, without depending on
+// fixedWindowRateLimiter's real one-minute window.
+func TestRateLimitMiddleware_UsesInjectedRateLimiter(t *testing.T) {
+	original := activeRateLimiter
+	activeRateLimiter = &fakeRateLimiter{allow: false}
+	defer func() { activeRateLimiter = original }()
+	config.GetServerRuntime().Config.Preference.RateLimit.MaxPerMinute = 1000
+	defer func() { config.GetServerRuntime().Config.Preference.RateLimit = config.PreferenceRateLimitConfig{} }()
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	rr := httptest.NewRecorder()
+
+	standardRateLimitMiddleware(next).ServeHTTP(rr, authenticatedRequestForTest("user-1"))
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/log"
+)
+
+const (
+	readCountFlushInterval   = 1 * time.Minute
+	readCountLoggerComponent = "PreferenceReadCountFlusher"
+)
+
+// readCountKey identifies a single user's preference key for buffering purposes.
+type readCountKey struct {
+	userID string
+	key    string
+}
+
+// readCountFlusher buffers per-read increments in memory and periodically flushes them to the
+// store as one batched update per key, so read-count tracking does not add a database write to
+// every read. Only PreferenceReadCountConfig.Enabled gates whether record is recorded; when
+// disabled, record is a no-op and nothing is ever flushed.
+type readCountFlusher struct {
+	store preferenceStoreInterface
+
+	mu     sync.Mutex
+	buffer map[readCountKey]int64
+
+	ticker *time.Ticker
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// newReadCountFlusher creates a new readCountFlusher. Call Start to begin the periodic flush.
+func newReadCountFlusher(store preferenceStoreInterface) *readCountFlusher {
+	return &readCountFlusher{
+		store:  store,
+		buffer: make(map[readCountKey]int64),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// record buffers a single read of userID/key, to be flushed on the next tick. No-op when
+// read-count tracking is disabled.
+func (f *readCountFlusher) record(userID, key string) {
+	if !config.GetServerRuntime().Config.Preference.ReadCount.Enabled {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buffer[readCountKey{userID: userID, key: key}]++
+}
+
+// Start begins the periodic flush on a background goroutine.
+func (f *readCountFlusher) Start() {
+	f.ticker = time.NewTicker(readCountFlushInterval)
+	f.wg.Add(1)
+
+	go func() {
+		defer f.wg.Done()
+		for {
+			select {
+			case <-f.ticker.C:
+				f.flush(context.Background())
+			case <-f.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the periodic flush, waits for any in-flight flush to finish, and performs one
+// final flush to drain anything buffered since the last tick. Safe to call multiple times.
+func (f *readCountFlusher) Stop() {
+	f.once.Do(func() {
+		if f.ticker != nil {
+			f.ticker.Stop()
+		}
+		close(f.stopCh)
+	})
+	f.wg.Wait()
+	f.flush(context.Background())
+}
+
+// flush drains the buffered increments and writes each as a single IncrementReadCount call,
+// logging rather than failing on a per-key write error so one bad key does not lose the rest.
+func (f *readCountFlusher) flush(ctx context.Context) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, readCountLoggerComponent))
+
+	f.mu.Lock()
+	pending := f.buffer
+	f.buffer = make(map[readCountKey]int64)
+	f.mu.Unlock()
+
+	for k, delta := range pending {
+		if err := f.store.IncrementReadCount(k.userID, k.key, delta); err != nil {
+			logger.Error(ctx, "Failed to flush preference read count", log.Error(err), log.String("key", k.key))
+		}
+	}
+}
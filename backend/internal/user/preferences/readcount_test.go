@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+type PreferenceReadCountFlusherTestSuite struct {
+	suite.Suite
+	mockStore *preferenceStoreInterfaceMock
+	flusher   *readCountFlusher
+}
+
+func TestPreferenceReadCountFlusherTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceReadCountFlusherTestSuite))
+}
+
+func (suite *PreferenceReadCountFlusherTestSuite) SetupTest() {
+	suite.mockStore = newPreferenceStoreInterfaceMock(suite.T())
+	suite.flusher = newReadCountFlusher(suite.mockStore)
+}
+
+func (suite *PreferenceReadCountFlusherTestSuite) enableReadCountTracking() {
+	config.GetServerRuntime().Config.Preference.ReadCount.Enabled = true
+	suite.T().Cleanup(func() { config.GetServerRuntime().Config.Preference.ReadCount.Enabled = false })
+}
+
+// record Tests
+func (suite *PreferenceReadCountFlusherTestSuite) TestRecord_DisabledIsNoOp() {
+	suite.flusher.record("user-1", "theme")
+
+	suite.Empty(suite.flusher.buffer)
+}
+
+func (suite *PreferenceReadCountFlusherTestSuite) TestRecord_BuffersIncrementsByKey() {
+	suite.enableReadCountTracking()
+
+	suite.flusher.record("user-1", "theme")
+	suite.flusher.record("user-1", "theme")
+	suite.flusher.record("user-1", "locale")
+
+	suite.Equal(int64(2), suite.flusher.buffer[readCountKey{userID: "user-1", key: "theme"}])
+	suite.Equal(int64(1), suite.flusher.buffer[readCountKey{userID: "user-1", key: "locale"}])
+}
+
+// flush Tests
+func (suite *PreferenceReadCountFlusherTestSuite) TestFlush_WritesBufferedIncrementsAndClearsBuffer() {
+	suite.enableReadCountTracking()
+	suite.flusher.record("user-1", "theme")
+	suite.mockStore.On("IncrementReadCount", "user-1", "theme", int64(1)).Return(nil)
+
+	suite.flusher.flush(context.Background())
+
+	suite.Empty(suite.flusher.buffer)
+}
+
+func (suite *PreferenceReadCountFlusherTestSuite) TestFlush_EmptyBufferWritesNothing() {
+	suite.flusher.flush(context.Background())
+}
+
+func (suite *PreferenceReadCountFlusherTestSuite) TestFlush_ContinuesAfterStoreError() {
+	suite.enableReadCountTracking()
+	suite.flusher.record("user-1", "theme")
+	suite.mockStore.On("IncrementReadCount", "user-1", "theme", int64(1)).
+		Return(errors.New("db error"))
+
+	suite.flusher.flush(context.Background())
+
+	suite.Empty(suite.flusher.buffer)
+}
+
+// Start/Stop Tests
+func (suite *PreferenceReadCountFlusherTestSuite) TestStartStop_FlushesOnStop() {
+	suite.enableReadCountTracking()
+	suite.flusher.record("user-1", "theme")
+	suite.mockStore.On("IncrementReadCount", "user-1", "theme", int64(1)).Return(nil)
+
+	suite.flusher.Start()
+	time.Sleep(10 * time.Millisecond)
+	suite.flusher.Stop()
+	suite.flusher.Stop()
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thunder-id/thunderid/internal/system/log"
+	"github.com/thunder-id/thunderid/internal/user"
+)
+
+const (
+	reconcileInterval         = 1 * time.Hour
+	reconcileUserBatchSize    = 200
+	softDeleteRetentionWindow = 30 * 24 * time.Hour
+	reconcilerLoggerComponent = "PreferenceReconciler"
+)
+
+// reconciler periodically purges orphaned (deleted-user), expired, and soft-deleted-past-window
+// preference rows. Orphaned rows are found by walking users in bounded batches; expired and
+// soft-deleted-past-window rows are each purged by one DELETE statement that is not split into
+// batches. It is idempotent and safe to run concurrently with traffic:
+// each pass only deletes rows that independently satisfy its own condition (expiry timestamp,
+// soft-delete cutoff, or a missing owning user), so repeated or overlapping runs never remove
+// live data.
+type reconciler struct {
+	store       preferenceStoreInterface
+	userService user.UserServiceInterface
+
+	ticker *time.Ticker
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// newReconciler creates a new reconciler. Call Start to begin the periodic sweep.
+func newReconciler(store preferenceStoreInterface, userService user.UserServiceInterface) *reconciler {
+	return &reconciler{
+		store:       store,
+		userService: userService,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// Start begins the periodic reconciliation sweep on a background goroutine.
+func (r *reconciler) Start() {
+	r.ticker = time.NewTicker(reconcileInterval)
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-r.ticker.C:
+				r.runOnce(context.Background())
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the periodic sweep and waits for any in-flight pass to finish.
+// Safe to call multiple times.
+func (r *reconciler) Stop() {
+	r.once.Do(func() {
+		if r.ticker != nil {
+			r.ticker.Stop()
+		}
+		close(r.stopCh)
+	})
+	r.wg.Wait()
+}
+
+// runOnce performs a single reconciliation pass across the three reclaim categories,
+// logging the number of rows reclaimed per category for observability.
+func (r *reconciler) runOnce(ctx context.Context) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, reconcilerLoggerComponent))
+
+	expired, err := r.store.DeleteExpiredPreferences()
+	if err != nil {
+		logger.Error(ctx, "Failed to purge expired preferences", log.Error(err))
+	} else if expired > 0 {
+		logger.Debug(ctx, "Purged expired preferences", log.Int("rowsReclaimed", int(expired)))
+	}
+
+	cutoff := time.Now().Add(-softDeleteRetentionWindow)
+	softDeleted, err := r.store.DeleteSoftDeletedPreferencesPastWindow(cutoff)
+	if err != nil {
+		logger.Error(ctx, "Failed to purge soft-deleted preferences past retention window", log.Error(err))
+	} else if softDeleted > 0 {
+		logger.Debug(ctx, "Purged soft-deleted preferences past retention window",
+			log.Int("rowsReclaimed", int(softDeleted)))
+	}
+
+	orphaned, err := r.purgeOrphanedUsers(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to purge preferences for deleted users", log.Error(err))
+	} else if orphaned > 0 {
+		logger.Debug(ctx, "Purged preferences for deleted users", log.Int("rowsReclaimed", int(orphaned)))
+	}
+}
+
+// purgeOrphanedUsers walks the distinct set of users with preferences, in bounded batches,
+// and deletes the preferences of any user that no longer exists.
+func (r *reconciler) purgeOrphanedUsers(ctx context.Context) (int64, error) {
+	var totalReclaimed int64
+	offset := 0
+
+	for {
+		userIDs, err := r.store.GetDistinctUserIDs(reconcileUserBatchSize, offset)
+		if err != nil {
+			return totalReclaimed, err
+		}
+		if len(userIDs) == 0 {
+			return totalReclaimed, nil
+		}
+
+		for _, userID := range userIDs {
+			_, svcErr := r.userService.GetUser(ctx, userID, false)
+			if svcErr == nil {
+				continue
+			}
+			if svcErr.Code != user.ErrorUserNotFound.Code {
+				// Treat anything other than a confirmed not-found as transient; the
+				// next pass will re-evaluate this user rather than risk deleting live data.
+				continue
+			}
+
+			reclaimed, err := r.store.DeletePreferencesByUserID(userID)
+			if err != nil {
+				return totalReclaimed, err
+			}
+			totalReclaimed += reclaimed
+		}
+
+		if len(userIDs) < reconcileUserBatchSize {
+			return totalReclaimed, nil
+		}
+		offset += reconcileUserBatchSize
+	}
+}
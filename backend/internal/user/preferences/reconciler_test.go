@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/thunder-id/thunderid/internal/user"
+	"github.com/thunder-id/thunderid/tests/mocks/usermock"
+)
+
+type PreferenceReconcilerTestSuite struct {
+	suite.Suite
+	mockStore       *preferenceStoreInterfaceMock
+	mockUserService *usermock.UserServiceInterfaceMock
+	reconciler      *reconciler
+}
+
+func TestPreferenceReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceReconcilerTestSuite))
+}
+
+func (suite *PreferenceReconcilerTestSuite) SetupTest() {
+	suite.mockStore = newPreferenceStoreInterfaceMock(suite.T())
+	suite.mockUserService = usermock.NewUserServiceInterfaceMock(suite.T())
+	suite.reconciler = newReconciler(suite.mockStore, suite.mockUserService)
+}
+
+// runOnce Tests
+func (suite *PreferenceReconcilerTestSuite) TestRunOnce_ReclaimsExpiredAndSoftDeleted() {
+	suite.mockStore.On("DeleteExpiredPreferences").Return(int64(2), nil)
+	suite.mockStore.On("DeleteSoftDeletedPreferencesPastWindow", mock.Anything).Return(int64(1), nil)
+	suite.mockStore.On("GetDistinctUserIDs", reconcileUserBatchSize, 0).Return([]string{}, nil)
+
+	suite.reconciler.runOnce(context.Background())
+}
+
+func (suite *PreferenceReconcilerTestSuite) TestRunOnce_ContinuesAfterExpiredPreferencesError() {
+	suite.mockStore.On("DeleteExpiredPreferences").Return(int64(0), errors.New("db error"))
+	suite.mockStore.On("DeleteSoftDeletedPreferencesPastWindow", mock.Anything).Return(int64(0), nil)
+	suite.mockStore.On("GetDistinctUserIDs", reconcileUserBatchSize, 0).Return([]string{}, nil)
+
+	suite.reconciler.runOnce(context.Background())
+}
+
+// purgeOrphanedUsers Tests
+func (suite *PreferenceReconcilerTestSuite) TestPurgeOrphanedUsers_DeletesOnlyNotFoundUsers() {
+	suite.mockStore.On("GetDistinctUserIDs", reconcileUserBatchSize, 0).
+		Return([]string{"user-live", "user-gone"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-live", false).
+		Return(&user.User{ID: "user-live"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-gone", false).
+		Return(nil, &user.ErrorUserNotFound)
+	suite.mockStore.On("DeletePreferencesByUserID", "user-gone").Return(int64(3), nil)
+
+	reclaimed, err := suite.reconciler.purgeOrphanedUsers(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(int64(3), reclaimed)
+}
+
+func (suite *PreferenceReconcilerTestSuite) TestPurgeOrphanedUsers_SkipsTransientErrors() {
+	suite.mockStore.On("GetDistinctUserIDs", reconcileUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).
+		Return(nil, &user.ErrorInvalidRequestFormat)
+
+	reclaimed, err := suite.reconciler.purgeOrphanedUsers(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(int64(0), reclaimed)
+}
+
+func (suite *PreferenceReconcilerTestSuite) TestPurgeOrphanedUsers_StoreErrorStopsPagination() {
+	suite.mockStore.On("GetDistinctUserIDs", reconcileUserBatchSize, 0).Return(nil, errors.New("db error"))
+
+	reclaimed, err := suite.reconciler.purgeOrphanedUsers(context.Background())
+
+	suite.Error(err)
+	suite.Equal(int64(0), reclaimed)
+}
+
+// Start/Stop Tests
+func (suite *PreferenceReconcilerTestSuite) TestStartStop_IsIdempotent() {
+	suite.mockStore.On("DeleteExpiredPreferences").Return(int64(0), nil).Maybe()
+	suite.mockStore.On("DeleteSoftDeletedPreferencesPastWindow", mock.Anything).Return(int64(0), nil).Maybe()
+	suite.mockStore.On("GetDistinctUserIDs", reconcileUserBatchSize, 0).Return([]string{}, nil).Maybe()
+
+	suite.reconciler.Start()
+	time.Sleep(10 * time.Millisecond)
+	suite.reconciler.Stop()
+	suite.reconciler.Stop()
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"sort"
+	"sync"
+)
+
+// PreferenceSchemaEntry describes a single preference key for discovery by a generic settings UI.
+// Keys with no registered entry are not returned by ListPreferenceSchemas.
+type PreferenceSchemaEntry struct {
+	// Key is the preference key this schema describes, e.g. "ui.theme".
+	Key string `json:"key"`
+	// Type is a hint for how the value should be rendered/edited, e.g. "string", "boolean",
+	// "number", "enum", "json". "boolean" and "number" are also enforced on write: SetPreference
+	// normalizes the incoming value to a canonical form and rejects one it cannot interpret as
+	// that type; "json" is rejected if it is malformed or nests deeper than
+	// maxPreferenceJSONDepth (see normalizePreferenceValue).
+	Type string `json:"type"`
+	// AllowedValues, if non-empty, restricts the value to one of these options (used with
+	// Type "enum").
+	AllowedValues []string `json:"allowedValues,omitempty"`
+	// Default is the value a client should assume when the user has not set this key.
+	Default string `json:"default,omitempty"`
+	// Label is a short, human-readable name for the key, suitable for a settings UI.
+	Label string `json:"label,omitempty"`
+	// Description is a longer, human-readable explanation of what the key controls.
+	Description string `json:"description,omitempty"`
+	// Sensitive marks a key whose value an admin diff read (see DiffPreferences) redacts by
+	// default, reserving the raw value for a higher-privilege, audited reveal.
+	Sensitive bool `json:"sensitive,omitempty"`
+	// EmptyFallsThroughToDefault opts this key into treating an empty stored value ("") as "use
+	// the default" rather than as an explicit override, for clients (e.g. a form submitting an
+	// unfilled field) that write "" to mean "unset". A whitespace-only value is still treated as
+	// an explicit override: only a stored value of exactly "" falls through.
+	EmptyFallsThroughToDefault bool `json:"emptyFallsThroughToDefault,omitempty"`
+	// Required marks a key every user is expected to have a value for. A user with no value for
+	// a Required key is reported as a missing_required_key issue by ReconcileUserPreferences,
+	// which seeds Default for the key when fixing rather than reporting (see Default).
+	Required bool `json:"required,omitempty"`
+	// StoragePolicy controls how the store persists this key's value: "" (or
+	// storagePolicyPlaintext) stores it subject only to the deployment's general compression
+	// settings (see codec.go); storagePolicyEncrypted always encrypts it at rest via the
+	// deployment's configured crypto provider, regardless of those settings;
+	// storagePolicyCompressed always compresses it, regardless of the configured threshold. A
+	// stored value carries a marker recording which of these was actually applied, so changing a
+	// key's policy after values already exist does not break reads of the older values.
+	StoragePolicy string `json:"storagePolicy,omitempty"`
+	// DisplayOrder is this key's position in the product-designed settings UI sequence, used by
+	// ListPreferences when called with sort=catalog (see sortPreferencesByCatalogOrder). Keys with
+	// equal DisplayOrder, including the shared zero value of keys that don't set it, are ordered
+	// by Key among themselves.
+	DisplayOrder int `json:"displayOrder,omitempty"`
+}
+
+var (
+	schemaRegistry   map[string]PreferenceSchemaEntry
+	schemaRegistryMu sync.RWMutex
+)
+
+func init() {
+	schemaRegistry = make(map[string]PreferenceSchemaEntry)
+}
+
+// RegisterPreferenceSchema registers the schema for a preference key so it is discoverable via
+// the preference schema catalog. Callers should register during their own initialization.
+func RegisterPreferenceSchema(entry PreferenceSchemaEntry) {
+	schemaRegistryMu.Lock()
+	defer schemaRegistryMu.Unlock()
+	schemaRegistry[entry.Key] = entry
+}
+
+// getRegisteredPreferenceSchemas returns all registered preference schemas, sorted by key for a
+// stable response ordering.
+func getRegisteredPreferenceSchemas() []PreferenceSchemaEntry {
+	schemaRegistryMu.RLock()
+	defer schemaRegistryMu.RUnlock()
+
+	entries := make([]PreferenceSchemaEntry, 0, len(schemaRegistry))
+	for _, entry := range schemaRegistry {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// isSensitivePreferenceKey reports whether key is registered with Sensitive set. Keys with no
+// registered schema are treated as not sensitive.
+func isSensitivePreferenceKey(key string) bool {
+	schemaRegistryMu.RLock()
+	defer schemaRegistryMu.RUnlock()
+	return schemaRegistry[key].Sensitive
+}
+
+// isKnownPreferenceKey reports whether key is registered, and whether any schema is registered at
+// all. A deployment with no registered schemas has no catalog to enforce, so callers should treat
+// every key as known in that case regardless of the unknown-key policy.
+func isKnownPreferenceKey(key string) (known, anyRegistered bool) {
+	schemaRegistryMu.RLock()
+	defer schemaRegistryMu.RUnlock()
+	_, known = schemaRegistry[key]
+	return known, len(schemaRegistry) > 0
+}
+
+// getNamespaceDefaults returns the registered schema defaults for every key under namespace, for
+// reporting a namespace's effective state once its overrides have all been deleted (see
+// DeleteNamespaceResponse). A key with no registered schema, or a registered schema with an
+// empty Default, is omitted.
+func getNamespaceDefaults(namespace string) map[string]string {
+	schemaRegistryMu.RLock()
+	defer schemaRegistryMu.RUnlock()
+
+	defaults := make(map[string]string)
+	for key, entry := range schemaRegistry {
+		if extractNamespace(key) == namespace && entry.Default != "" {
+			defaults[key] = entry.Default
+		}
+	}
+	return defaults
+}
+
+// getRegisteredDefault returns key's registered schema default and whether one is registered. A
+// key with no registered schema, or a registered schema with an empty Default, reports ok false.
+func getRegisteredDefault(key string) (value string, ok bool) {
+	schemaRegistryMu.RLock()
+	defer schemaRegistryMu.RUnlock()
+	entry, registered := schemaRegistry[key]
+	if !registered || entry.Default == "" {
+		return "", false
+	}
+	return entry.Default, true
+}
+
+// getStoragePolicy returns key's registered StoragePolicy. A key with no registered schema, or a
+// registered schema with an empty StoragePolicy, reports storagePolicyPlaintext, the default
+// behavior of applying only the deployment's general compression settings.
+func getStoragePolicy(key string) string {
+	schemaRegistryMu.RLock()
+	defer schemaRegistryMu.RUnlock()
+	entry, ok := schemaRegistry[key]
+	if !ok || entry.StoragePolicy == "" {
+		return storagePolicyPlaintext
+	}
+	return entry.StoragePolicy
+}
+
+// getDisplayOrder returns key's registered DisplayOrder and whether key is registered at all. A
+// key with no registered schema reports ok false, distinguishing it from a registered key that
+// simply leaves DisplayOrder at its zero value.
+func getDisplayOrder(key string) (order int, ok bool) {
+	schemaRegistryMu.RLock()
+	defer schemaRegistryMu.RUnlock()
+	entry, registered := schemaRegistry[key]
+	if !registered {
+		return 0, false
+	}
+	return entry.DisplayOrder, true
+}
+
+// sortPreferencesByCatalogOrder reorders prefs in place for the list endpoint's sort=catalog
+// option: keys with a registered schema sort first, ordered by DisplayOrder and then by Key to
+// break ties; keys with no registered schema follow, ordered by Key.
+func sortPreferencesByCatalogOrder(prefs []PreferenceResponse) {
+	sort.SliceStable(prefs, func(i, j int) bool {
+		orderI, registeredI := getDisplayOrder(prefs[i].Key)
+		orderJ, registeredJ := getDisplayOrder(prefs[j].Key)
+		if registeredI != registeredJ {
+			return registeredI
+		}
+		if registeredI && orderI != orderJ {
+			return orderI < orderJ
+		}
+		return prefs[i].Key < prefs[j].Key
+	})
+}
+
+// resolveEffectiveValue applies a key's EmptyFallsThroughToDefault policy to a stored value. It
+// returns value unchanged, with applied false, unless value is exactly empty and the key is
+// registered with EmptyFallsThroughToDefault and a non-empty Default, in which case it returns
+// the Default, with applied true. A whitespace-only value is never substituted: only a stored
+// value of exactly "" is eligible.
+func resolveEffectiveValue(key, value string) (resolved string, applied bool) {
+	if value != "" {
+		return value, false
+	}
+
+	schemaRegistryMu.RLock()
+	entry, ok := schemaRegistry[key]
+	schemaRegistryMu.RUnlock()
+	if !ok || !entry.EmptyFallsThroughToDefault || entry.Default == "" {
+		return value, false
+	}
+	return entry.Default, true
+}
+
+// buildPreferenceExplanation reports, for a single key, the candidate value held by each
+// preference layer and which layer supplied the effective value, for the list endpoint's
+// explain=true option. The user layer (rawValue, the stored row) is always reported; the
+// schemaDefault layer is reported only when the key has a registered, non-empty default, since a
+// key with no registered schema has no other layer to fall back to.
+func buildPreferenceExplanation(key, rawValue string) *PreferenceExplanation {
+	schemaRegistryMu.RLock()
+	entry, ok := schemaRegistry[key]
+	schemaRegistryMu.RUnlock()
+
+	explanation := &PreferenceExplanation{
+		Layers:   []PreferenceExplanationLayer{{Layer: layerUser, Value: rawValue}},
+		WonLayer: layerUser,
+	}
+	if !ok || entry.Default == "" {
+		return explanation
+	}
+
+	explanation.Layers = append(explanation.Layers, PreferenceExplanationLayer{
+		Layer: layerSchemaDefault, Value: entry.Default,
+	})
+	if rawValue == "" && entry.EmptyFallsThroughToDefault {
+		explanation.WonLayer = layerSchemaDefault
+	}
+	return explanation
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PreferenceSchemaTestSuite struct {
+	suite.Suite
+}
+
+func TestPreferenceSchemaTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceSchemaTestSuite))
+}
+
+func (suite *PreferenceSchemaTestSuite) SetupTest() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = make(map[string]PreferenceSchemaEntry)
+	schemaRegistryMu.Unlock()
+}
+
+func (suite *PreferenceSchemaTestSuite) TestRegisterPreferenceSchema_Unregistered() {
+	suite.Empty(getRegisteredPreferenceSchemas())
+}
+
+func (suite *PreferenceSchemaTestSuite) TestRegisterPreferenceSchema_SortedByKey() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Type: "enum", AllowedValues: []string{"light", "dark"}})
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "notifications.email", Type: "boolean", Default: "true"})
+
+	entries := getRegisteredPreferenceSchemas()
+
+	suite.Require().Len(entries, 2)
+	suite.Equal("notifications.email", entries[0].Key)
+	suite.Equal("ui.theme", entries[1].Key)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestRegisterPreferenceSchema_Overwrite() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Type: "enum"})
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Type: "enum", Default: "dark"})
+
+	entries := getRegisteredPreferenceSchemas()
+
+	suite.Require().Len(entries, 1)
+	suite.Equal("dark", entries[0].Default)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetNamespaceDefaults_ReturnsOnlyMatchingNamespace() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: "light"})
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.locale", Default: "en"})
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "billing.plan", Default: "free"})
+
+	defaults := getNamespaceDefaults("ui")
+
+	suite.Equal(map[string]string{"ui.theme": "light", "ui.locale": "en"}, defaults)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetNamespaceDefaults_OmitsEmptyDefault() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: ""})
+
+	defaults := getNamespaceDefaults("ui")
+
+	suite.Empty(defaults)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetNamespaceDefaults_NoRegisteredKeys() {
+	defaults := getNamespaceDefaults("ui")
+
+	suite.Empty(defaults)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestResolveEffectiveValue_EmptyFallsThrough() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: "light", EmptyFallsThroughToDefault: true})
+
+	value, applied := resolveEffectiveValue("ui.theme", "")
+
+	suite.Equal("light", value)
+	suite.True(applied)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestResolveEffectiveValue_WhitespaceIsExplicitOverride() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: "light", EmptyFallsThroughToDefault: true})
+
+	value, applied := resolveEffectiveValue("ui.theme", " ")
+
+	suite.Equal(" ", value)
+	suite.False(applied)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestResolveEffectiveValue_PresentValueUnaffected() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: "light", EmptyFallsThroughToDefault: true})
+
+	value, applied := resolveEffectiveValue("ui.theme", "dark")
+
+	suite.Equal("dark", value)
+	suite.False(applied)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestResolveEffectiveValue_PolicyDisabled() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: "light"})
+
+	value, applied := resolveEffectiveValue("ui.theme", "")
+
+	suite.Equal("", value)
+	suite.False(applied)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestResolveEffectiveValue_NoRegisteredSchema() {
+	value, applied := resolveEffectiveValue("ui.theme", "")
+
+	suite.Equal("", value)
+	suite.False(applied)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestResolveEffectiveValue_EmptyDefault() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: "", EmptyFallsThroughToDefault: true})
+
+	value, applied := resolveEffectiveValue("ui.theme", "")
+
+	suite.Equal("", value)
+	suite.False(applied)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestIsKnownPreferenceKey_Registered() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme"})
+
+	known, anyRegistered := isKnownPreferenceKey("ui.theme")
+
+	suite.True(known)
+	suite.True(anyRegistered)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestIsKnownPreferenceKey_UnregisteredWithOtherSchemas() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme"})
+
+	known, anyRegistered := isKnownPreferenceKey("ui.unregistered")
+
+	suite.False(known)
+	suite.True(anyRegistered)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestIsKnownPreferenceKey_NoSchemasRegistered() {
+	known, anyRegistered := isKnownPreferenceKey("ui.theme")
+
+	suite.False(known)
+	suite.False(anyRegistered)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetStoragePolicy_Registered() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", StoragePolicy: storagePolicyEncrypted})
+
+	suite.Equal(storagePolicyEncrypted, getStoragePolicy("secret.token"))
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetStoragePolicy_UnregisteredKeyIsPlaintext() {
+	suite.Equal(storagePolicyPlaintext, getStoragePolicy("ui.theme"))
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetStoragePolicy_RegisteredWithNoPolicyIsPlaintext() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Default: "light"})
+
+	suite.Equal(storagePolicyPlaintext, getStoragePolicy("ui.theme"))
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetDisplayOrder_Registered() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", DisplayOrder: 3})
+
+	order, ok := getDisplayOrder("ui.theme")
+
+	suite.True(ok)
+	suite.Equal(3, order)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestGetDisplayOrder_Unregistered() {
+	order, ok := getDisplayOrder("ui.theme")
+
+	suite.False(ok)
+	suite.Equal(0, order)
+}
+
+func (suite *PreferenceSchemaTestSuite) TestSortPreferencesByCatalogOrder_MixesCatalogAndNonCatalogKeys() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", DisplayOrder: 2})
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "notifications.email", DisplayOrder: 1})
+
+	prefs := []PreferenceResponse{
+		{Key: "unregistered.b"},
+		{Key: "ui.theme"},
+		{Key: "unregistered.a"},
+		{Key: "notifications.email"},
+	}
+
+	sortPreferencesByCatalogOrder(prefs)
+
+	suite.Equal([]string{"notifications.email", "ui.theme", "unregistered.a", "unregistered.b"}, preferenceKeys(prefs))
+}
+
+func (suite *PreferenceSchemaTestSuite) TestSortPreferencesByCatalogOrder_TiesBrokenByKey() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", DisplayOrder: 1})
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.font", DisplayOrder: 1})
+
+	prefs := []PreferenceResponse{{Key: "ui.theme"}, {Key: "ui.font"}}
+
+	sortPreferencesByCatalogOrder(prefs)
+
+	suite.Equal([]string{"ui.font", "ui.theme"}, preferenceKeys(prefs))
+}
+
+func preferenceKeys(prefs []PreferenceResponse) []string {
+	keys := make([]string, len(prefs))
+	for i, pref := range prefs {
+		keys[i] = pref.Key
+	}
+	return keys
+}
@@ -0,0 +1,2210 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/kmprovider"
+	"github.com/thunder-id/thunderid/internal/system/log"
+	"github.com/thunder-id/thunderid/internal/system/security"
+	"github.com/thunder-id/thunderid/internal/system/sysauthz"
+	"github.com/thunder-id/thunderid/internal/user"
+)
+
+// PreferenceServiceInterface defines the interface for preference management operations.
+type PreferenceServiceInterface interface {
+	// ListPreferences returns a page of userID's preferences (see PreferenceListResponse.TotalCount
+	// and NextOffset), sized by limit (0 defaults to defaultPreferenceListLimit; a negative limit or
+	// one over maxPreferenceListLimit is rejected) starting at offset. When explain is true, each returned preference's
+	// Explain field reports the layer breakdown behind its value; see PreferenceExplanation. When
+	// pattern is non-empty, only keys matching it (glob semantics; see matchesPreferencePattern)
+	// are returned, and TotalCount/pagination apply to the matching set. When sortByCatalog is
+	// true, the result is ordered by each key's registered PreferenceSchemaEntry.DisplayOrder,
+	// falling back to key order for unregistered keys; see sortPreferencesByCatalogOrder. Otherwise,
+	// sortField ("" or preferenceSortKey default to PREFERENCE_KEY, preferenceSortUpdatedAt orders
+	// by UPDATED_AT) and sortOrder ("" or preferenceSortOrderAsc default to ascending,
+	// preferenceSortOrderDesc to descending) select the DB-level ORDER BY; see
+	// GetPreferencesByUserIDSorted and validatePreferenceSort.
+	ListPreferences(
+		ctx context.Context, userID string, includeSizes, explain, sortByCatalog bool, pattern, sortField, sortOrder string,
+		limit, offset int,
+	) (*PreferenceListResponse, *tidcommon.ServiceError)
+	// ListPreferencesByPrefix returns every one of userID's preferences whose key starts with
+	// prefix, matched via a SQL LIKE pushed down to GetPreferencesByPrefix rather than loaded and
+	// filtered in memory like ListPreferences' pattern matching. Unlike ListPreferences, the
+	// result is not paginated: a namespace grouping is expected to be small enough to return in
+	// full.
+	ListPreferencesByPrefix(ctx context.Context, userID, prefix string) (*PreferenceListResponse, *tidcommon.ServiceError)
+	// SearchPreferencesByValue returns every one of userID's preferences whose decoded value
+	// contains valueContains, matched in application code against each row's decoded value
+	// rather than pushed down as a SQL LIKE, since the stored column holds ciphertext or gzip
+	// bytes for encrypted/compressed keys (see codec.go). When prefix is non-empty, it is
+	// combined with valueContains using AND semantics, scoping the search to keys starting with
+	// prefix. Like ListPreferencesByPrefix, the result is not paginated.
+	SearchPreferencesByValue(
+		ctx context.Context, userID, prefix, valueContains string,
+	) (*PreferenceListResponse, *tidcommon.ServiceError)
+	// GetPreference returns userID's value for key. When effective is true, a key with no value
+	// and no inherited parent value falls back to its registered schema default rather than
+	// ErrorPreferenceNotFound; see the preferenceService implementation. When deviceID is
+	// non-empty, a device-specific override for key takes precedence over the user-global value
+	// (falling back to it, and then to the same effective/inherited resolution, when the device
+	// has none); see deviceScopedUserID.
+	GetPreference(
+		ctx context.Context, userID, key string, effective bool, deviceID string,
+	) (*PreferenceResponse, *tidcommon.ServiceError)
+	GetPreferenceWithFallback(
+		ctx context.Context, userID string, keys []string,
+	) (*PreferenceResponse, *tidcommon.ServiceError)
+	// lockToken, if non-empty, must match the token of any currently active advisory lock (see
+	// AcquirePreferenceLock) on userID's preferences, or the write is rejected with
+	// ErrorPreferenceLockHeld. A user with no active lock is unaffected regardless of lockToken.
+	// deviceID, if non-empty, targets the write at that device's own override of key instead of
+	// the user-global value; see deviceScopedUserID. ifMatchETag, if non-empty, must match the
+	// existing preference's current computePreferenceETag value (or the write is rejected with
+	// ErrorPreferenceModified, the same error ifUnmodifiedSince uses), including when no preference
+	// exists yet; it is the If-Match counterpart to ifUnmodifiedSince's If-Unmodified-Since.
+	// ttlSeconds, when positive, makes the write auto-expire that many seconds from now (see
+	// preferenceExpiresAt and SetPreferenceRequest.TTLSeconds); omitted or 0 means no expiry,
+	// clearing any expiry a previous write on the same key set.
+	SetPreference(
+		ctx context.Context, userID, key, value string, enforced bool, schemaVersion, ttlSeconds int,
+		ifUnmodifiedSince *time.Time, lockToken, deviceID, ifMatchETag string,
+	) (*PreferenceResponse, *tidcommon.ServiceError)
+	// ConditionalSetPreference writes value for key only if condition holds between value and
+	// the key's current numeric value; a key with no current value always accepts the write. See
+	// ConditionalSetRequest. Intended for high-water-mark style keys (e.g. a monotonically
+	// increasing notification ID) where a plain read-modify-write risks losing an update to a
+	// concurrent writer. lockToken is checked the same way as SetPreference's.
+	ConditionalSetPreference(
+		ctx context.Context, userID, key, value string, condition ConditionalSetCondition, lockToken string,
+	) (*ConditionalSetResponse, *tidcommon.ServiceError)
+	// DeletePreference deletes a single preference for userID. lockToken is checked the same way
+	// as SetPreference's.
+	DeletePreference(ctx context.Context, userID, key, lockToken string) *tidcommon.ServiceError
+	// DeleteAllPreferences deletes every preference for userID in a single statement and returns
+	// the number of preferences deleted. lockToken is checked the same way as SetPreference's.
+	DeleteAllPreferences(ctx context.Context, userID, lockToken string) (int64, *tidcommon.ServiceError)
+	// DeleteNamespace deletes every override under namespace and returns the deleted keys
+	// alongside the namespace's resulting effective state. See DeleteNamespaceResponse. lockToken
+	// is checked the same way as SetPreference's.
+	DeleteNamespace(ctx context.Context, userID, namespace, lockToken string) (*DeleteNamespaceResponse, *tidcommon.ServiceError)
+	// DeletePreferencesByPrefix deletes every one of userID's preferences whose key starts with
+	// prefix and returns the deleted keys. prefix must be non-empty, rejected with
+	// ErrorMissingPreferencePrefix otherwise, to guard against a bulk delete of every preference
+	// the user has. lockToken is checked the same way as SetPreference's.
+	DeletePreferencesByPrefix(ctx context.Context, userID, prefix, lockToken string) (*DeletePreferencesResponse, *tidcommon.ServiceError)
+	// DeletePreferences deletes every key in keys that userID currently has a value for, in a
+	// single transaction, and returns the keys that were actually removed. A key with no value is
+	// skipped rather than failing the rest of the batch. lockToken is checked the same way as
+	// SetPreference's.
+	DeletePreferences(ctx context.Context, userID string, keys []string, lockToken string) ([]string, *tidcommon.ServiceError)
+	DiffPreferences(
+		ctx context.Context, userAID, userBID string, reveal bool,
+	) (*PreferenceDiffResponse, *tidcommon.ServiceError)
+	// CheckAdminAccess validates that the caller is authorized to act on userID's preferences via
+	// the admin API (the "GET/PUT /admin/users/{userId}/preferences..." routes) rather than
+	// userID's own "me" session. write requests the higher-privilege check required to modify
+	// rather than merely read, reusing the same ActionReadUser/ActionUpdateUser checks DiffPreferences
+	// already performs for cross-user access, since admin access to a single user's preferences is
+	// the same authorization question DiffPreferences answers for two.
+	CheckAdminAccess(ctx context.Context, userID string, write bool) *tidcommon.ServiceError
+	ExecuteBatch(ctx context.Context, userID string, req *BatchRequest) (*BatchResponse, *tidcommon.ServiceError)
+	// MergePreferences upserts every key in preferences for userID within a single transaction,
+	// leaving any of the user's preferences not present in preferences untouched. It is the merge
+	// counterpart to ReplaceAllPreferences, which additionally deletes the keys merge leaves alone.
+	// lockToken is checked the same way as SetPreference's.
+	MergePreferences(ctx context.Context, userID string, preferences map[string]string, lockToken string) (*BatchResponse, *tidcommon.ServiceError)
+	// ReplaceAllPreferences replaces userID's entire preference set with preferences within a
+	// single transaction: it diffs preferences against the user's current keys, upserting every
+	// key in preferences and deleting every existing key absent from it. It is the full-replace
+	// counterpart to MergePreferences, which only ever upserts. lockToken is checked the same way
+	// as SetPreference's.
+	ReplaceAllPreferences(ctx context.Context, userID string, preferences map[string]string, lockToken string) (*BatchResponse, *tidcommon.ServiceError)
+	SeedDefaultForAllUsers(ctx context.Context, callerID, key, value string) (*SeedDefaultResponse, *tidcommon.ServiceError)
+	ListPreferenceSchemas(ctx context.Context) (*PreferenceSchemaResponse, *tidcommon.ServiceError)
+	GetPreferenceReadCountAggregate(ctx context.Context) (*ReadCountAggregateResponse, *tidcommon.ServiceError)
+	GetQueryDebugInfo(ctx context.Context) (*QueryDebugResponse, *tidcommon.ServiceError)
+	// ImportPreferences's lockToken is checked the same way as SetPreference's.
+	ImportPreferences(
+		ctx context.Context, userID string, req *ImportPreferencesRequest, lockToken string,
+	) (*ImportPreferencesResponse, *tidcommon.ServiceError)
+	// CompareAndSwapPreferences applies req.Entries atomically only if every entry's Expected
+	// value matches the key's current value; see CompareAndSwapResponse. lockToken is checked the
+	// same way as SetPreference's.
+	CompareAndSwapPreferences(
+		ctx context.Context, userID string, req *CompareAndSwapRequest, lockToken string,
+	) (*CompareAndSwapResponse, *tidcommon.ServiceError)
+	// StreamAllPreferences walks every user's preferences across the whole deployment, invoking
+	// emit once per preference, for a full-deployment NDJSON backup (see PreferenceExportEntry)
+	// that never buffers more than one batch of users in memory.
+	StreamAllPreferences(ctx context.Context, emit func(PreferenceExportEntry) error) *tidcommon.ServiceError
+	// ExportUserPreferences returns every preference belonging to userID as a single
+	// UserPreferenceExportDocument, for a self-service GDPR data-portability download. Unlike
+	// StreamAllPreferences it is not paginated or streamed, since it is scoped to one user's
+	// preferences rather than the whole deployment.
+	ExportUserPreferences(ctx context.Context, userID string) (*UserPreferenceExportDocument, *tidcommon.ServiceError)
+	// GetPreferenceHistory returns the recorded history of a single preference for userID,
+	// oldest first, including a tombstone entry for each time it was deleted.
+	GetPreferenceHistory(ctx context.Context, userID, key string) (*PreferenceHistoryResponse, *tidcommon.ServiceError)
+	// GetPreferencesAsOf reconstructs userID's preference set as it stood at timestamp, from
+	// USER_PREFERENCE_HISTORY. It is an admin-only operation; callers must gate it with
+	// CheckAdminAccess(ctx, userID, false) before calling, the same as GetPreferences' admin path.
+	GetPreferencesAsOf(
+		ctx context.Context, userID string, timestamp time.Time,
+	) (*PreferencesAsOfResponse, *tidcommon.ServiceError)
+	// BulkRenamePreferences renames every matching key across all users in the deployment; see
+	// BulkRenameRequest and BulkRenameResponse.
+	BulkRenamePreferences(
+		ctx context.Context, callerID string, req *BulkRenameRequest,
+	) (*BulkRenameResponse, *tidcommon.ServiceError)
+	// DeletePreferencesByValue deletes, across all users in the deployment, every preference
+	// whose current value for req.Key exactly equals req.Value; see DeletePreferencesByValueRequest
+	// and DeletePreferencesByValueResponse. Supports remediation after a bad rollout propagated
+	// the same incorrect value to many users.
+	DeletePreferencesByValue(
+		ctx context.Context, callerID string, req *DeletePreferencesByValueRequest,
+	) (*DeletePreferencesByValueResponse, *tidcommon.ServiceError)
+	// CopyPreferences clones req.SourceUserID's preferences matching req.Keys or req.Prefix (or
+	// every preference, if both are empty) into req.TargetUserID, e.g. provisioning a new user
+	// from a curated template user; see CopyPreferencesRequest and CopyPreferencesResponse.
+	CopyPreferences(
+		ctx context.Context, callerID string, req *CopyPreferencesRequest,
+	) (*CopyPreferencesResponse, *tidcommon.ServiceError)
+	// ReconcileUserPreferences compares userID's stored preferences against the registered schema
+	// catalog and reports extra keys, missing required keys, and values violating AllowedValues;
+	// see PreferenceReconciliationReport. Unless req.DryRun, it fixes what it can: seeding missing
+	// required keys with their registered Default, and removing extra keys that are not Enforced.
+	ReconcileUserPreferences(
+		ctx context.Context, callerID, userID string, req *ReconcilePreferencesRequest,
+	) (*PreferenceReconciliationReport, *tidcommon.ServiceError)
+	// ReconcileAllUserPreferences runs the same comparison as ReconcileUserPreferences across
+	// every user in the deployment, walking users in bounded batches, and returns aggregate
+	// counts rather than a per-user issue list; see BulkReconciliationResponse.
+	ReconcileAllUserPreferences(
+		ctx context.Context, callerID string, req *ReconcilePreferencesRequest,
+	) (*BulkReconciliationResponse, *tidcommon.ServiceError)
+	// AcquirePreferenceLock grants callerID an exclusive advisory lock on userID's preferences for
+	// up to ttlSeconds (see clampPreferenceLockTTL), so concurrent admin consoles editing the same
+	// user don't silently clobber each other's writes. Every write method on this interface that
+	// takes a lockToken parameter consults the lock (see checkPreferenceLockForWrite), but the lock
+	// is advisory only: a caller that never presents a token is not prevented from writing while no
+	// lock happens to be held. A caller that already holds the lock renews it rather than being
+	// rejected.
+	AcquirePreferenceLock(
+		ctx context.Context, callerID, userID string, ttlSeconds int,
+	) (*PreferenceLockResponse, *tidcommon.ServiceError)
+	// RefreshPreferenceLock extends userID's active lock by ttlSeconds from now, if token
+	// currently holds it.
+	RefreshPreferenceLock(
+		ctx context.Context, callerID, userID, token string, ttlSeconds int,
+	) (*PreferenceLockResponse, *tidcommon.ServiceError)
+	// ReleasePreferenceLock releases userID's active lock if token currently holds it. Releasing
+	// an already-expired or nonexistent lock is a no-op.
+	ReleasePreferenceLock(ctx context.Context, callerID, userID, token string) *tidcommon.ServiceError
+	// GetPreferenceUsage reports userID's current preference count and total value byte length,
+	// for the PreferenceQuotaConfig usage headers (see writeQuotaHeaders).
+	GetPreferenceUsage(ctx context.Context, userID string) (*PreferenceUsage, *tidcommon.ServiceError)
+	// CheckPreferenceKeysExist reports, for each of req.Keys, whether userID has a current
+	// value for it, via a single existence query rather than fetching every value.
+	CheckPreferenceKeysExist(
+		ctx context.Context, userID string, req *ExistsRequest,
+	) (*ExistsResponse, *tidcommon.ServiceError)
+	// GetPreferencesByKeys returns userID's preferences among req.Keys, via a single query rather
+	// than one GET per key (see GetPreferencesByKeys on the store). A key with no current value
+	// is simply absent from the result rather than an error.
+	GetPreferencesByKeys(
+		ctx context.Context, userID string, req *PreferenceQueryRequest,
+	) (*PreferenceListResponse, *tidcommon.ServiceError)
+}
+
+// preferenceService is the default implementation of PreferenceServiceInterface.
+type preferenceService struct {
+	store        preferenceStoreInterface
+	userService  user.UserServiceInterface
+	authzService sysauthz.SystemAuthorizationServiceInterface
+	readCounts   *readCountFlusher
+	// auditSink receives the before/after PreferenceAuditRecord for every SetPreference and
+	// DeletePreference call; defaults to defaultPreferenceAuditSink, swapped for a fake in tests.
+	auditSink preferenceAuditSink
+	// webhook receives a PreferenceWebhookPayload for every successful SetPreference and
+	// DeletePreference call; defaults to noopWebhookDispatcher when no webhook URL is configured,
+	// swapped for a fake in tests.
+	webhook webhookDispatcher
+	// defaults is the deployment's configured PreferenceDefaultsConfig.Values, loaded once at
+	// construction time; see mergeDefaults.
+	defaults map[string]string
+}
+
+// newPreferenceService creates a new instance of preferenceService. configCrypto is forwarded to
+// the store for encrypting keys registered with storagePolicyEncrypted (see codec.go); it may be
+// nil in deployments that register no such keys.
+func newPreferenceService(
+	userService user.UserServiceInterface, authzService sysauthz.SystemAuthorizationServiceInterface,
+	configCrypto kmprovider.ConfigCryptoProvider,
+) *preferenceService {
+	store := newPreferenceStore(configCrypto)
+	return &preferenceService{
+		store:        store,
+		userService:  userService,
+		authzService: authzService,
+		readCounts:   newReadCountFlusher(store),
+		auditSink:    defaultPreferenceAuditSink{},
+		webhook:      newWebhookDispatcher(config.GetServerRuntime().Config.Preference.Webhook),
+		defaults:     config.GetServerRuntime().Config.Preference.Defaults.Values,
+	}
+}
+
+// ListPreferences returns a page of the given user's preferences. limit defaults to
+// defaultPreferenceListLimit when 0, and is rejected if negative or over maxPreferenceListLimit;
+// offset defaults to 0 and is rejected if negative. When includeSizes is true, each returned
+// preference carries its decoded value's size in bytes, plus a running total over the returned
+// page (not the full matching set), for storage-aware clients (e.g. a quota UI). When
+// explain is true, each returned preference's Explain field reports the layer breakdown behind
+// its value. When pattern is non-empty, it is matched against each key with glob semantics
+// ('*'/'?') in application code rather than pushed into a store-level query, matching how
+// DeletePreferencesByNamespace filters the same GetPreferencesByUserID result set; see
+// matchesPreferencePattern. When sortByCatalog is true, the matching set is reordered by
+// sortPreferencesByCatalogOrder before being paginated.
+//
+// Pattern matching, catalog sorting, and merging in any configured PreferenceDefaultsConfig
+// default the user hasn't set (see mergeDefaults) all require the full set in memory to apply
+// correctly, so pagination for those requests is done in-memory after filtering/sorting/merging.
+// A plain list request (no pattern, not sorted, no configured defaults) is paginated by
+// GetPreferencesByUserIDPaginated instead, which never loads more than one page from the store,
+// for the common case of a user with many keys.
+func (s *preferenceService) ListPreferences(
+	ctx context.Context, userID string, includeSizes, explain, sortByCatalog bool, pattern, sortField, sortOrder string,
+	limit, offset int,
+) (*PreferenceListResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if pattern != "" {
+		if svcErr := validatePreferencePattern(pattern); svcErr != nil {
+			return nil, svcErr
+		}
+	}
+	if svcErr := validatePreferenceSort(sortField, sortOrder); svcErr != nil {
+		return nil, svcErr
+	}
+	if limit < 0 || limit > maxPreferenceListLimit {
+		return nil, &ErrorInvalidPreferenceListLimit
+	}
+	if limit == 0 {
+		limit = defaultPreferenceListLimit
+	}
+	if offset < 0 {
+		return nil, &ErrorInvalidPreferenceListOffset
+	}
+
+	customSort := sortField != "" || sortOrder != ""
+	if pattern == "" && !sortByCatalog && !customSort && len(s.defaults) == 0 {
+		return s.listPreferencesPaginated(ctx, userID, includeSizes, explain, limit, offset)
+	}
+
+	var prefs []Preference
+	var failedKeys []string
+	var err error
+	if customSort && !sortByCatalog {
+		prefs, failedKeys, err = s.store.GetPreferencesByUserIDSorted(userID, sortField, sortOrder)
+	} else {
+		prefs, failedKeys, err = s.store.GetPreferencesByUserID(userID)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to list preferences", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedKeys {
+		logger.Error(ctx, "Failed to decode preference value; omitting from list",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+	}
+
+	valueLengths := make(map[string]int, len(prefs))
+	matched := make([]PreferenceResponse, 0, len(prefs))
+	for _, pref := range prefs {
+		if pattern != "" && !matchesPreferencePattern(pref.Key, pattern) {
+			continue
+		}
+		matched = append(matched, buildPreferenceResponse(pref, includeSizes, explain))
+		valueLengths[pref.Key] = len(pref.Value)
+	}
+	matched = s.mergeDefaults(matched, pattern, includeSizes, valueLengths)
+	if sortByCatalog {
+		sortPreferencesByCatalogOrder(matched)
+	}
+
+	resp := &PreferenceListResponse{
+		FailedKeys:  failedKeys,
+		TotalCount:  len(matched),
+		Preferences: paginatePreferenceResponses(matched, limit, offset),
+	}
+	totalSize := 0
+	for _, pref := range resp.Preferences {
+		s.readCounts.record(userID, pref.Key)
+		totalSize += valueLengths[pref.Key]
+	}
+	if includeSizes {
+		resp.TotalSizeBytes = &totalSize
+	}
+	if next := offset + len(resp.Preferences); next < len(matched) {
+		resp.NextOffset = &next
+	}
+	return resp, nil
+}
+
+// listPreferencesPaginated is ListPreferences's fast path for a plain list request (no pattern,
+// not sorted by catalog): it pages directly off GetPreferencesByUserIDPaginated instead of
+// loading the user's full preference set into memory.
+func (s *preferenceService) listPreferencesPaginated(
+	ctx context.Context, userID string, includeSizes, explain bool, limit, offset int,
+) (*PreferenceListResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	prefs, failedKeys, totalCount, err := s.store.GetPreferencesByUserIDPaginated(userID, limit, offset)
+	if err != nil {
+		logger.Error(ctx, "Failed to list preferences", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedKeys {
+		logger.Error(ctx, "Failed to decode preference value; omitting from list",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+	}
+
+	resp := &PreferenceListResponse{
+		Preferences: make([]PreferenceResponse, 0, len(prefs)),
+		FailedKeys:  failedKeys,
+		TotalCount:  int(totalCount),
+	}
+	totalSize := 0
+	for _, pref := range prefs {
+		resp.Preferences = append(resp.Preferences, buildPreferenceResponse(pref, includeSizes, explain))
+		totalSize += len(pref.Value)
+		s.readCounts.record(userID, pref.Key)
+	}
+	if includeSizes {
+		resp.TotalSizeBytes = &totalSize
+	}
+	if next := int64(offset + len(prefs)); next < totalCount {
+		nextOffset := int(next)
+		resp.NextOffset = &nextOffset
+	}
+	return resp, nil
+}
+
+// ListPreferencesByPrefix returns every one of userID's preferences whose key starts with prefix;
+// see GetPreferencesByPrefix. It does not apply any of ListPreferences' pattern matching, catalog
+// sorting, configured defaults merging, or pagination, since a namespace grouping is expected to
+// be small enough to return in full.
+func (s *preferenceService) ListPreferencesByPrefix(
+	ctx context.Context, userID, prefix string,
+) (*PreferenceListResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := validatePreferencePrefix(prefix); svcErr != nil {
+		return nil, svcErr
+	}
+
+	prefs, failedKeys, err := s.store.GetPreferencesByPrefix(userID, prefix)
+	if err != nil {
+		logger.Error(ctx, "Failed to list preferences by prefix", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedKeys {
+		logger.Error(ctx, "Failed to decode preference value; omitting from list",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+	}
+
+	resp := &PreferenceListResponse{
+		Preferences: make([]PreferenceResponse, 0, len(prefs)),
+		FailedKeys:  failedKeys,
+		TotalCount:  len(prefs),
+	}
+	for _, pref := range prefs {
+		resp.Preferences = append(resp.Preferences, buildPreferenceResponse(pref, false, false))
+		s.readCounts.record(userID, pref.Key)
+	}
+	return resp, nil
+}
+
+// SearchPreferencesByValue returns every one of userID's preferences whose value contains
+// valueContains, optionally AND-ed with prefix; see SearchPreferencesByValue on
+// preferenceStoreInterface. Like ListPreferencesByPrefix, it does not apply any of
+// ListPreferences' pagination, since a value search is expected to be run interactively rather
+// than over a user with an unbounded number of matches.
+func (s *preferenceService) SearchPreferencesByValue(
+	ctx context.Context, userID, prefix, valueContains string,
+) (*PreferenceListResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := validatePreferenceValueContains(valueContains); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := validatePreferencePrefix(prefix); svcErr != nil {
+		return nil, svcErr
+	}
+
+	prefs, failedKeys, err := s.store.SearchPreferencesByValue(userID, prefix, valueContains)
+	if err != nil {
+		logger.Error(ctx, "Failed to search preferences by value", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedKeys {
+		logger.Error(ctx, "Failed to decode preference value; omitting from list",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+	}
+
+	resp := &PreferenceListResponse{
+		Preferences: make([]PreferenceResponse, 0, len(prefs)),
+		FailedKeys:  failedKeys,
+		TotalCount:  len(prefs),
+	}
+	for _, pref := range prefs {
+		resp.Preferences = append(resp.Preferences, buildPreferenceResponse(pref, false, false))
+		s.readCounts.record(userID, pref.Key)
+	}
+	return resp, nil
+}
+
+// paginatePreferenceResponses returns the limit-sized slice of prefs starting at offset, or an
+// empty slice when offset is past the end.
+func paginatePreferenceResponses(prefs []PreferenceResponse, limit, offset int) []PreferenceResponse {
+	if offset >= len(prefs) {
+		return []PreferenceResponse{}
+	}
+	end := offset + limit
+	if end > len(prefs) {
+		end = len(prefs)
+	}
+	return prefs[offset:end]
+}
+
+// mergeDefaults appends a PreferenceResponse, flagged via IsDefault, for every key in
+// PreferenceDefaultsConfig.Values that existing has no entry for, so a user who has never set a
+// configured default key still sees it in their preference list. A key the user has explicitly
+// set always takes precedence and is left untouched, even if its stored value happens to match
+// the configured default. pattern, when non-empty, is applied to candidate default keys the same
+// way ListPreferences applies it to stored keys, so a filtered list doesn't surface defaults the
+// filter would otherwise exclude. valueLengths is updated in place for each merged default, so
+// ListPreferences's total-size accounting includes them when includeSizes is requested.
+func (s *preferenceService) mergeDefaults(
+	existing []PreferenceResponse, pattern string, includeSizes bool, valueLengths map[string]int,
+) []PreferenceResponse {
+	if len(s.defaults) == 0 {
+		return existing
+	}
+
+	present := make(map[string]struct{}, len(existing))
+	for _, pref := range existing {
+		present[pref.Key] = struct{}{}
+	}
+
+	merged := existing
+	for key, value := range s.defaults {
+		if _, ok := present[key]; ok {
+			continue
+		}
+		if pattern != "" && !matchesPreferencePattern(key, pattern) {
+			continue
+		}
+		resp := PreferenceResponse{Key: key, Value: value, IsDefault: true}
+		if includeSizes {
+			size := len(value)
+			resp.SizeBytes = &size
+		}
+		valueLengths[key] = len(value)
+		merged = append(merged, resp)
+	}
+	return merged
+}
+
+// GetPreference returns a single preference for the given user and key. By default, a key the
+// user has not set (and cannot inherit from a parent, see resolveInheritedPreference) returns
+// ErrorPreferenceNotFound even if the key has a registered schema default. When effective is
+// true, that case instead returns the schema default, flagged via PreferenceResponse.
+// DefaultApplied, so a client can get a usable value for a known key in one call. When deviceID
+// is non-empty and has its own override of key, that value is returned (flagged via
+// PreferenceResponse.DeviceScoped) instead of going through the rest of this resolution; a device
+// with no override of key falls back to the same user-global/inherited/effective resolution as if
+// deviceID had not been set.
+func (s *preferenceService) GetPreference(
+	ctx context.Context, userID, key string, effective bool, deviceID string,
+) (*PreferenceResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+	key = normalizePreferenceKeyCase(key)
+
+	if svcErr := validatePreferenceKey(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+		return nil, svcErr
+	}
+	if svcErr := checkUnknownKeyPolicy(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleUnknownKey)
+		return nil, svcErr
+	}
+	if svcErr := checkPreferenceKeySchema(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+		return nil, svcErr
+	}
+
+	if deviceID != "" {
+		if svcErr := validateDeviceID(deviceID); svcErr != nil {
+			return nil, svcErr
+		}
+		devicePref, err := s.store.GetPreferenceByKey(deviceScopedUserID(userID, deviceID), key)
+		if err != nil {
+			logger.Error(ctx, "Failed to get device-scoped preference", log.Error(err))
+			return nil, &tidcommon.InternalServerError
+		}
+		if devicePref != nil {
+			s.readCounts.record(userID, key)
+			resp := buildPreferenceResponse(*devicePref, false, false)
+			resp.DeviceScoped = true
+			return &resp, nil
+		}
+	}
+
+	pref, err := s.store.GetPreferenceByKey(userID, key)
+	if err != nil {
+		logger.Error(ctx, "Failed to get preference", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	if pref == nil {
+		if inherited, ok := s.resolveInheritedPreference(ctx, userID, key); ok {
+			pref = inherited
+		} else if effective {
+			if defaultValue, ok := getRegisteredDefault(key); ok {
+				s.readCounts.record(userID, key)
+				return &PreferenceResponse{
+					Key: key, Value: defaultValue, DefaultApplied: true, SchemaVersion: defaultSchemaVersion,
+				}, nil
+			}
+			return nil, &ErrorPreferenceNotFound
+		} else {
+			return nil, &ErrorPreferenceNotFound
+		}
+	}
+	s.readCounts.record(userID, key)
+
+	resp := buildPreferenceResponse(*pref, false, false)
+	resp.InheritedFromParent = pref.UserID != userID
+	return &resp, nil
+}
+
+// GetPreferenceWithFallback returns the preference for the first key in keys that has a value,
+// trying each key in order. This lets clients read through a key migration without a hard
+// cutover: pass the new key first and the old key(s) it replaces as fallbacks. The returned
+// PreferenceResponse's Key reports which key in the chain actually matched. Returns
+// ErrorPreferenceNotFound if none of the keys have a value.
+func (s *preferenceService) GetPreferenceWithFallback(
+	ctx context.Context, userID string, keys []string,
+) (*PreferenceResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if len(keys) == 0 {
+		return nil, &ErrorInvalidPreferenceKey
+	}
+	for _, key := range keys {
+		if svcErr := validatePreferenceKey(key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+			return nil, svcErr
+		}
+		if svcErr := checkUnknownKeyPolicy(key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleUnknownKey)
+			return nil, svcErr
+		}
+		if svcErr := checkPreferenceKeySchema(key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+			return nil, svcErr
+		}
+	}
+
+	for _, key := range keys {
+		pref, err := s.store.GetPreferenceByKey(userID, key)
+		if err != nil {
+			logger.Error(ctx, "Failed to get preference for fallback lookup", log.Error(err))
+			return nil, &tidcommon.InternalServerError
+		}
+		if pref != nil {
+			s.readCounts.record(userID, key)
+			resp := buildPreferenceResponse(*pref, false, false)
+			return &resp, nil
+		}
+	}
+
+	return nil, &ErrorPreferenceNotFound
+}
+
+// SetPreference creates or updates a single preference for the given user. When enforced is
+// true, the value is marked as policy-enforced and can only be overridden by a later write that
+// also sets enforced; when an existing enforced value is present, a non-enforced write is
+// rejected with ErrorPreferenceEnforced. When ifUnmodifiedSince is non-nil, the write is rejected
+// with ErrorPreferenceModified if the existing preference's UpdatedAt is later than that time.
+// When PreferenceDedupConfig is enabled and this call repeats the value already stored within its
+// de-dup window, the write is suppressed (see isDuplicateWrite) and the existing preference is
+// returned as if the write had succeeded. schemaVersion declares the value format version value
+// is written in; 0 is normalized to defaultSchemaVersion. When deviceID is non-empty, the value is
+// written as that device's own override of key (see deviceScopedUserID) rather than the
+// user-global value; UpdatedBy still records userID, the caller's own identity. When ifMatchETag
+// is non-empty, the write is rejected with ErrorPreferenceModified unless it equals the existing
+// preference's current computePreferenceETag value (or no preference exists, matching ifMatchETag
+// is never correct since there is no ETag to match). ttlSeconds, when positive, sets the write to
+// auto-expire that many seconds from now (see preferenceExpiresAt); 0 means no expiry.
+func (s *preferenceService) SetPreference(
+	ctx context.Context, userID, key, value string, enforced bool, schemaVersion, ttlSeconds int,
+	ifUnmodifiedSince *time.Time, lockToken, deviceID, ifMatchETag string,
+) (*PreferenceResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+	key = normalizePreferenceKeyCase(key)
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := validatePreferenceKey(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+		return nil, svcErr
+	}
+	if svcErr := checkUnknownKeyPolicy(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleUnknownKey)
+		return nil, svcErr
+	}
+	if svcErr := checkPreferenceKeySchema(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+		return nil, svcErr
+	}
+	if svcErr := validatePreferenceValue(value); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidValue)
+		return nil, svcErr
+	}
+	if svcErr := checkPreferenceValueSchema(key, value); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidValue)
+		return nil, svcErr
+	}
+	if svcErr := validateContentType(value); svcErr != nil {
+		return nil, svcErr
+	}
+	normalized, svcErr := normalizePreferenceValue(key, value)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	value = normalized
+
+	storageUserID := userID
+	if deviceID != "" {
+		if svcErr := validateDeviceID(deviceID); svcErr != nil {
+			return nil, svcErr
+		}
+		storageUserID = deviceScopedUserID(userID, deviceID)
+	}
+
+	existing, err := s.store.GetPreferenceByKey(storageUserID, key)
+	if err != nil {
+		logger.Error(ctx, "Failed to check existing preference before set", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	if existing != nil && existing.Enforced && !enforced {
+		return nil, &ErrorPreferenceEnforced
+	}
+	if existing != nil && ifUnmodifiedSince != nil && existing.UpdatedAt.Truncate(time.Second).After(*ifUnmodifiedSince) {
+		return nil, &ErrorPreferenceModified
+	}
+	if ifMatchETag != "" && (existing == nil || computePreferenceETag(existing.Value, existing.UpdatedAt) != ifMatchETag) {
+		return nil, &ErrorPreferenceModified
+	}
+	if isDuplicateWrite(existing, value, enforced) {
+		recordSuppressedWrite(ctx)
+		resp := buildPreferenceResponse(*existing, false, false)
+		resp.DeviceScoped = deviceID != ""
+		return &resp, nil
+	}
+	if existing == nil {
+		if svcErr := s.checkNamespaceLimit(ctx, storageUserID, key); svcErr != nil {
+			return nil, svcErr
+		}
+		if svcErr := s.checkPreferenceCountQuota(ctx, storageUserID); svcErr != nil {
+			return nil, svcErr
+		}
+	}
+
+	if err := s.store.UpsertPreference(
+		storageUserID, key, value, userID, enforced, schemaVersion, preferenceExpiresAt(ttlSeconds),
+	); err != nil {
+		logger.Error(ctx, "Failed to set preference", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	pref, err := s.store.GetPreferenceByKey(storageUserID, key)
+	if err != nil {
+		logger.Error(ctx, "Failed to read back preference after set", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	if pref == nil {
+		logger.Error(ctx, "Preference missing immediately after upsert")
+		return nil, &tidcommon.InternalServerError
+	}
+
+	resp := buildPreferenceResponse(*pref, false, false)
+	resp.DeviceScoped = deviceID != ""
+
+	var oldValue *string
+	if existing != nil {
+		oldValue = &existing.Value
+	}
+	newValue := pref.Value
+	s.auditSink.RecordMutation(ctx, PreferenceAuditRecord{
+		Action:    auditActionSet,
+		UserID:    userID,
+		Key:       key,
+		OldValue:  oldValue,
+		NewValue:  &newValue,
+		Fields:    []log.Field{log.Bool("enforced", enforced)},
+		Timestamp: time.Now(),
+	})
+	s.webhook.Dispatch(PreferenceWebhookPayload{
+		UserID: userID, ChangedKeys: []string{key}, Operation: webhookOperationUpsert, Timestamp: time.Now(),
+	})
+	return &resp, nil
+}
+
+// preferenceExpiresAt converts a caller-requested ttlSeconds into an absolute expiry time, capped
+// at maxPreferenceTTLSeconds. Unlike clampPreferenceLockTTL, a non-positive ttlSeconds returns nil
+// (no expiry) rather than falling back to some default duration: most preferences are meant to
+// live indefinitely, so silence must mean "never expires," not "expire soon."
+func preferenceExpiresAt(ttlSeconds int) *time.Time {
+	if ttlSeconds <= 0 {
+		return nil
+	}
+	if ttlSeconds > maxPreferenceTTLSeconds {
+		ttlSeconds = maxPreferenceTTLSeconds
+	}
+	expiresAt := time.Now().Add(time.Duration(ttlSeconds) * time.Second)
+	return &expiresAt
+}
+
+// ConditionalSetPreference writes value for key for the given user only if condition holds
+// between value and the key's current numeric value, per ConditionalSetRequest. The stored value
+// may be gzip-compressed (see codec.go), so the comparison cannot be pushed into a single SQL
+// WHERE clause; it is made here, in application code, against the decoded current value, before
+// writing, mirroring CompareAndSwapPreferences's read-then-write shape and its same tolerance for
+// losing a race to a concurrent writer between the read and the write.
+func (s *preferenceService) ConditionalSetPreference(
+	ctx context.Context, userID, key, value string, condition ConditionalSetCondition, lockToken string,
+) (*ConditionalSetResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := validatePreferenceKey(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+		return nil, svcErr
+	}
+	if condition != ConditionSetIfGreater && condition != ConditionSetIfLess {
+		return nil, &ErrorInvalidConditionalSetCondition
+	}
+	newNumeric, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, &ErrorNonNumericPreferenceValue
+	}
+
+	existing, err := s.store.GetPreferenceByKey(userID, key)
+	if err != nil {
+		logger.Error(ctx, "Failed to check existing preference before conditional set", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+
+	if existing != nil {
+		currentNumeric, err := strconv.ParseFloat(existing.Value, 64)
+		if err != nil {
+			return nil, &ErrorNonNumericPreferenceValue
+		}
+		holds := newNumeric > currentNumeric
+		if condition == ConditionSetIfLess {
+			holds = newNumeric < currentNumeric
+		}
+		if !holds {
+			return &ConditionalSetResponse{Applied: false, CurrentValue: existing.Value}, nil
+		}
+	}
+
+	if err := s.store.UpsertPreference(userID, key, value, userID, false, 0, nil); err != nil {
+		logger.Error(ctx, "Failed to apply conditional set", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	recordPreferenceAudit(ctx, auditActionConditionalSet, userID, log.String("key", key), log.String("condition", string(condition)))
+	return &ConditionalSetResponse{Applied: true, CurrentValue: value}, nil
+}
+
+// DeletePreference deletes a single preference for the given user. The preference's current
+// value is read first both to confirm it exists, returning ErrorPreferenceNotFound otherwise, and
+// so the audit record (see PreferenceAuditRecord) captures what was deleted.
+func (s *preferenceService) DeletePreference(ctx context.Context, userID, key, lockToken string) *tidcommon.ServiceError {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+	key = normalizePreferenceKeyCase(key)
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return svcErr
+	}
+	if svcErr := validatePreferenceKey(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+		return svcErr
+	}
+
+	existing, err := s.store.GetPreferenceByKey(userID, key)
+	if err != nil {
+		logger.Error(ctx, "Failed to check existing preference before delete", log.Error(err))
+		return &tidcommon.InternalServerError
+	}
+	if existing == nil {
+		return &ErrorPreferenceNotFound
+	}
+
+	if err := s.store.DeletePreference(userID, key); err != nil {
+		logger.Error(ctx, "Failed to delete preference", log.Error(err))
+		return translateStoreError(err)
+	}
+
+	var oldValue *string
+	if existing != nil {
+		oldValue = &existing.Value
+	}
+	s.auditSink.RecordMutation(ctx, PreferenceAuditRecord{
+		Action:    auditActionDelete,
+		UserID:    userID,
+		Key:       key,
+		OldValue:  oldValue,
+		Timestamp: time.Now(),
+	})
+	s.webhook.Dispatch(PreferenceWebhookPayload{
+		UserID: userID, ChangedKeys: []string{key}, Operation: webhookOperationDelete, Timestamp: time.Now(),
+	})
+	return nil
+}
+
+// DeleteAllPreferences deletes every preference for the given user in a single statement and
+// returns the number of preferences deleted.
+func (s *preferenceService) DeleteAllPreferences(ctx context.Context, userID, lockToken string) (int64, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return 0, svcErr
+	}
+
+	deletedCount, err := s.store.DeleteAllPreferences(userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to delete all preferences", log.Error(err))
+		return 0, translateStoreError(err)
+	}
+	recordPreferenceAudit(ctx, auditActionDeleteAll, userID, log.Int("deletedCount", int(deletedCount)))
+	return deletedCount, nil
+}
+
+// DeleteNamespace deletes every override for userID under namespace, and returns the deleted
+// keys alongside the namespace's resulting effective state. Since no overrides remain after the
+// delete, Effective reports pure schema defaults for the namespace's registered keys rather than
+// an empty map, so a client can tell "deleted" apart from "reset to default".
+func (s *preferenceService) DeleteNamespace(
+	ctx context.Context, userID, namespace, lockToken string,
+) (*DeleteNamespaceResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	if strings.TrimSpace(namespace) == "" {
+		return nil, &ErrorMissingNamespace
+	}
+
+	deletedKeys, err := s.store.DeletePreferencesByNamespace(userID, namespace)
+	if err != nil {
+		logger.Error(ctx, "Failed to delete namespace", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	recordPreferenceAudit(ctx, auditActionDeleteNamespace, userID, log.String("namespace", namespace), log.Int("deletedCount", len(deletedKeys)))
+	return &DeleteNamespaceResponse{
+		DeletedKeys: deletedKeys,
+		Effective:   getNamespaceDefaults(namespace),
+	}, nil
+}
+
+// DeletePreferencesByPrefix deletes every one of userID's preferences whose key starts with
+// prefix, in a single transaction, and returns the keys that were deleted. prefix is required to
+// be non-empty (ErrorMissingPreferencePrefix) so a caller can never accidentally delete every
+// preference the user has by omitting it, and is otherwise validated the same way as the prefix
+// list query param (see validatePreferencePrefix).
+func (s *preferenceService) DeletePreferencesByPrefix(
+	ctx context.Context, userID, prefix, lockToken string,
+) (*DeletePreferencesResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	if prefix == "" {
+		return nil, &ErrorMissingPreferencePrefix
+	}
+	if svcErr := validatePreferencePrefix(prefix); svcErr != nil {
+		return nil, svcErr
+	}
+
+	deletedKeys, err := s.store.DeletePreferencesByPrefix(userID, prefix)
+	if err != nil {
+		logger.Error(ctx, "Failed to delete preferences by prefix", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	recordPreferenceAudit(ctx, auditActionDeleteByPrefix, userID,
+		log.String("prefix", prefix), log.Int("deletedCount", len(deletedKeys)))
+	return &DeletePreferencesResponse{DeletedKeys: deletedKeys}, nil
+}
+
+// DeletePreferences deletes every key in keys that userID currently has a value for, in a single
+// transaction, and returns the keys that were actually removed. A key with no value is skipped
+// rather than failing the rest of the batch.
+func (s *preferenceService) DeletePreferences(
+	ctx context.Context, userID string, keys []string, lockToken string,
+) ([]string, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	if len(keys) == 0 {
+		return nil, &ErrorEmptyDeleteKeys
+	}
+	for _, key := range keys {
+		if svcErr := validatePreferenceKey(key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+			return nil, svcErr
+		}
+	}
+
+	deletedKeys, err := s.store.DeletePreferences(userID, keys)
+	if err != nil {
+		logger.Error(ctx, "Failed to delete preferences", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	recordPreferenceAudit(ctx, auditActionDeleteBulk, userID, log.Int("deletedCount", len(deletedKeys)))
+	return deletedKeys, nil
+}
+
+// DiffPreferences compares the preferences of two users and returns the keys present only in
+// userA, only in userB, and the keys present in both whose values differ. The caller must be
+// authorized to read both users. By default, ValueA/ValueB for a Sensitive-schema key (see
+// PreferenceSchemaEntry.Sensitive) are redacted; passing reveal=true additionally requires the
+// caller to be authorized to update both users, and reveals the raw values for every key on an
+// audited, explicit opt-in basis.
+func (s *preferenceService) DiffPreferences(
+	ctx context.Context, userAID, userBID string, reveal bool,
+) (*PreferenceDiffResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	userA, svcErr := s.userService.GetUser(ctx, userAID, false)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	userB, svcErr := s.userService.GetUser(ctx, userBID, false)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+
+	if svcErr := s.checkDiffAccess(ctx, userA.OUID, userAID); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := s.checkDiffAccess(ctx, userB.OUID, userBID); svcErr != nil {
+		return nil, svcErr
+	}
+	if reveal {
+		if svcErr := s.checkRevealAccess(ctx, userA.OUID, userAID); svcErr != nil {
+			return nil, svcErr
+		}
+		if svcErr := s.checkRevealAccess(ctx, userB.OUID, userBID); svcErr != nil {
+			return nil, svcErr
+		}
+	}
+
+	prefsA, failedA, err := s.store.GetPreferencesByUserID(userAID)
+	if err != nil {
+		logger.Error(ctx, "Failed to load preferences for userA", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedA {
+		logger.Error(ctx, "Failed to decode preference value for userA; omitting from diff", log.String("key", key))
+	}
+	prefsB, failedB, err := s.store.GetPreferencesByUserID(userBID)
+	if err != nil {
+		logger.Error(ctx, "Failed to load preferences for userB", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedB {
+		logger.Error(ctx, "Failed to decode preference value for userB; omitting from diff", log.String("key", key))
+	}
+
+	diff := buildPreferenceDiff(prefsA, prefsB)
+	redactSensitiveDiffEntries(diff, reveal)
+	return diff, nil
+}
+
+// ExecuteBatch runs an ordered list of get/set/delete operations for the given user in a single
+// transaction and returns the per-operation results in request order. See BatchRequest.FailFast
+// for how a failing operation affects the rest of the batch.
+func (s *preferenceService) ExecuteBatch(
+	ctx context.Context, userID string, req *BatchRequest,
+) (*BatchResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if len(req.Operations) == 0 {
+		return nil, &ErrorEmptyBatchOperations
+	}
+	if svcErr := checkBatchValidationLimit(req.Operations); svcErr != nil {
+		return nil, svcErr
+	}
+
+	results, err := s.store.ExecuteBatch(userID, req.Operations, req.FailFast)
+	if err != nil {
+		logger.Error(ctx, "Failed to execute preference batch", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+
+	return &BatchResponse{Results: results}, nil
+}
+
+// buildSetOperations converts a key/value map into "set" batch operations ordered by key, so the
+// resulting BatchResponse.Results is deterministic regardless of map iteration order.
+func buildSetOperations(preferences map[string]string) []BatchOperation {
+	keys := make([]string, 0, len(preferences))
+	for key := range preferences {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ops := make([]BatchOperation, 0, len(keys))
+	for _, key := range keys {
+		ops = append(ops, BatchOperation{Op: BatchOperationSet, Key: key, Value: preferences[key]})
+	}
+	return ops
+}
+
+// MergePreferences upserts every key in preferences for userID within a single transaction,
+// leaving any of the user's preferences not present in preferences untouched. It is built on top
+// of ExecuteBatch, so the same per-operation validation and batch size limit apply. lockToken is
+// checked the same way as SetPreference's, before ExecuteBatch runs.
+func (s *preferenceService) MergePreferences(
+	ctx context.Context, userID string, preferences map[string]string, lockToken string,
+) (*BatchResponse, *tidcommon.ServiceError) {
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	return s.ExecuteBatch(ctx, userID, &BatchRequest{
+		Operations: buildSetOperations(preferences),
+		FailFast:   true,
+	})
+}
+
+// ReplaceAllPreferences replaces userID's entire preference set with preferences within a single
+// transaction: it diffs preferences against the user's current keys, upserting every key in
+// preferences and deleting every existing key absent from it. Like MergePreferences, it is built
+// on top of ExecuteBatch, so the upsert and delete run together in the one transaction ExecuteBatch
+// already wraps its operations in. lockToken is checked the same way as SetPreference's.
+func (s *preferenceService) ReplaceAllPreferences(
+	ctx context.Context, userID string, preferences map[string]string, lockToken string,
+) (*BatchResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+
+	current, _, err := s.store.GetPreferencesByUserID(userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to load current preferences for replace", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+
+	ops := buildSetOperations(preferences)
+	for _, pref := range current {
+		if _, ok := preferences[pref.Key]; !ok {
+			ops = append(ops, BatchOperation{Op: BatchOperationDelete, Key: pref.Key})
+		}
+	}
+
+	return s.ExecuteBatch(ctx, userID, &BatchRequest{Operations: ops, FailFast: true})
+}
+
+// ImportPreferences bulk-upserts req.Entries for the given user, resolving a key that already has
+// a value according to req.Conflict (default ImportConflictSkip), and returns counts of entries
+// inserted, overwritten, skipped, and failed. Running the same request twice with
+// ImportConflictSkip is safe: the second run skips every key the first run already wrote.
+//
+// By default (PreferenceImportConfig.ChunkSize 0, or Atomic true) the whole import runs in a
+// single transaction, as a database failure partway through leaves nothing applied. When
+// ChunkSize is set and Atomic is false, entries are instead applied in independently-committed
+// batches of ChunkSize, each its own transaction; ImportPreferencesResponse.ChunkResults reports
+// every batch's outcome, and a database failure in one batch stops the import but leaves every
+// already-committed batch in place, trading atomicity for bounded lock and memory use on very
+// large imports.
+func (s *preferenceService) ImportPreferences(
+	ctx context.Context, userID string, req *ImportPreferencesRequest, lockToken string,
+) (*ImportPreferencesResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	if len(req.Entries) == 0 {
+		return nil, &ErrorEmptyImportEntries
+	}
+
+	conflict := req.Conflict
+	if conflict == "" {
+		conflict = ImportConflictSkip
+	}
+	switch conflict {
+	case ImportConflictSkip, ImportConflictOverwrite, ImportConflictNewerWins:
+	default:
+		return nil, &ErrorInvalidImportConflictStrategy
+	}
+
+	importConfig := config.GetServerRuntime().Config.Preference.Import
+	var resp *ImportPreferencesResponse
+	if importConfig.ChunkSize > 0 && !importConfig.Atomic {
+		resp = s.importPreferencesChunked(ctx, userID, req.Entries, conflict, importConfig.ChunkSize)
+	} else {
+		var err error
+		resp, err = s.store.ImportPreferences(userID, req.Entries, conflict)
+		if err != nil {
+			logger.Error(ctx, "Failed to import preferences", log.Error(err))
+			return nil, translateStoreError(err)
+		}
+	}
+
+	recordPreferenceAudit(ctx, auditActionImport, userID,
+		log.String("conflict", string(conflict)), log.Int("entryCount", len(req.Entries)))
+	return resp, nil
+}
+
+// importPreferencesChunked applies entries in independently-committed batches of chunkSize,
+// accumulating each batch's counts into the overall totals and recording its own
+// ImportChunkResult. A database failure in one batch stops the import; batches already committed
+// are left as they are, and the failing batch's error is recorded on its ImportChunkResult rather
+// than returned, since everything applied so far is still a meaningful partial result.
+func (s *preferenceService) importPreferencesChunked(
+	ctx context.Context, userID string, entries []ImportEntry, conflict ImportConflictStrategy, chunkSize int,
+) *ImportPreferencesResponse {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	resp := &ImportPreferencesResponse{}
+	for start := 0; start < len(entries); start += chunkSize {
+		end := start + chunkSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		chunk := entries[start:end]
+
+		chunkResp, err := s.store.ImportPreferences(userID, chunk, conflict)
+		if err != nil {
+			logger.Error(ctx, "Failed to import preference chunk", log.Error(err), log.Int("chunkIndex", len(resp.ChunkResults)))
+			resp.ChunkResults = append(resp.ChunkResults, ImportChunkResult{
+				Index: len(resp.ChunkResults), Error: err.Error(),
+			})
+			break
+		}
+
+		resp.Inserted += chunkResp.Inserted
+		resp.Overwritten += chunkResp.Overwritten
+		resp.Skipped += chunkResp.Skipped
+		resp.Failed += chunkResp.Failed
+		resp.ChunkResults = append(resp.ChunkResults, ImportChunkResult{
+			Index: len(resp.ChunkResults), Inserted: chunkResp.Inserted, Overwritten: chunkResp.Overwritten,
+			Skipped: chunkResp.Skipped, Failed: chunkResp.Failed,
+		})
+	}
+	return resp
+}
+
+// CompareAndSwapPreferences applies req.Entries for the given user atomically: it checks every
+// entry's Expected value against the key's current value, and only if all of them match does it
+// write every entry's Value in a single transaction. When one or more entries mismatch, nothing
+// is written and the response reports MismatchedKeys so the caller can re-read those keys and
+// retry with fresh expected values.
+func (s *preferenceService) CompareAndSwapPreferences(
+	ctx context.Context, userID string, req *CompareAndSwapRequest, lockToken string,
+) (*CompareAndSwapResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := checkPreferenceLockForWrite(userID, lockToken); svcErr != nil {
+		return nil, svcErr
+	}
+	if len(req.Entries) == 0 {
+		return nil, &ErrorEmptyCASEntries
+	}
+	for _, entry := range req.Entries {
+		if svcErr := validatePreferenceKey(entry.Key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+			return nil, svcErr
+		}
+		if svcErr := checkUnknownKeyPolicy(entry.Key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleUnknownKey)
+			return nil, svcErr
+		}
+		if svcErr := checkPreferenceKeySchema(entry.Key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+			return nil, svcErr
+		}
+		if svcErr := validatePreferenceValue(entry.Value); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidValue)
+			return nil, svcErr
+		}
+		if svcErr := checkPreferenceValueSchema(entry.Key, entry.Value); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidValue)
+			return nil, svcErr
+		}
+		if svcErr := validateContentType(entry.Value); svcErr != nil {
+			return nil, svcErr
+		}
+	}
+
+	applied, mismatchedKeys, err := s.store.CompareAndSwapPreferences(userID, req.Entries)
+	if err != nil {
+		logger.Error(ctx, "Failed to execute preference compare-and-swap", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	if applied {
+		recordPreferenceAudit(ctx, auditActionCompareAndSwap, userID, log.Int("entryCount", len(req.Entries)))
+	}
+	return &CompareAndSwapResponse{Applied: applied, MismatchedKeys: mismatchedKeys}, nil
+}
+
+// SeedDefaultForAllUsers backfills value for key across every user in the deployment who does
+// not already have a value for that key, walking users in bounded batches so the operation
+// stays memory-bounded for large deployments. A user who already set the key (including one
+// whose value is enforced) is left untouched; only users with no current value are seeded.
+func (s *preferenceService) SeedDefaultForAllUsers(
+	ctx context.Context, callerID, key, value string,
+) (*SeedDefaultResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := validatePreferenceKey(key); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := validatePreferenceValue(value); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := validateContentType(value); svcErr != nil {
+		return nil, svcErr
+	}
+
+	resp := &SeedDefaultResponse{}
+	offset := 0
+	for {
+		userIDs, err := s.store.GetDistinctUserIDs(seedDefaultUserBatchSize, offset)
+		if err != nil {
+			logger.Error(ctx, "Failed to list users while seeding default preference", log.Error(err))
+			return nil, &tidcommon.InternalServerError
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			inserted, err := s.store.InsertPreferenceIfAbsent(userID, key, value, callerID)
+			if err != nil {
+				logger.Error(ctx, "Failed to seed default preference for user", log.Error(err))
+				return nil, translateStoreError(err)
+			}
+			resp.UsersProcessed++
+			if inserted {
+				resp.Seeded++
+			} else {
+				resp.Skipped++
+			}
+		}
+
+		logger.Debug(ctx, "Seed default preference progress",
+			log.String("key", key), log.Int("usersProcessed", resp.UsersProcessed), log.Int("seeded", resp.Seeded))
+
+		if len(userIDs) < seedDefaultUserBatchSize {
+			break
+		}
+		offset += seedDefaultUserBatchSize
+	}
+
+	recordPreferenceAudit(ctx, auditActionSeedDefault, callerID,
+		log.String("key", key), log.Int("usersProcessed", resp.UsersProcessed), log.Int("seeded", resp.Seeded))
+	return resp, nil
+}
+
+// validateRenamePrefix validates a BulkRenameRequest prefix using the same rules as a full
+// preference key (length and invisible-rune checks), since a prefix is itself a valid key
+// fragment to match against.
+func validateRenamePrefix(prefix string) *tidcommon.ServiceError {
+	if svcErr := validatePreferenceKey(prefix); svcErr != nil {
+		return &ErrorInvalidRenamePrefix
+	}
+	return nil
+}
+
+// BulkRenamePreferences renames, across every user in the deployment, each key that starts with
+// req.FromPrefix to req.ToPrefix plus the key's remainder, walking users in bounded batches (see
+// bulkRenameUserBatchSize) so the operation stays memory-bounded for large deployments. The
+// renamed key is recorded as written by callerID rather than the original owner.
+func (s *preferenceService) BulkRenamePreferences(
+	ctx context.Context, callerID string, req *BulkRenameRequest,
+) (*BulkRenameResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := validateRenamePrefix(req.FromPrefix); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := validateRenamePrefix(req.ToPrefix); svcErr != nil {
+		return nil, svcErr
+	}
+	if req.FromPrefix == req.ToPrefix {
+		return nil, &ErrorInvalidRenamePrefix
+	}
+	conflict := req.Conflict
+	if conflict == "" {
+		conflict = ImportConflictSkip
+	}
+	switch conflict {
+	case ImportConflictSkip, ImportConflictOverwrite, ImportConflictNewerWins:
+	default:
+		return nil, &ErrorInvalidImportConflictStrategy
+	}
+
+	resp := &BulkRenameResponse{}
+	offset := 0
+	for {
+		userIDs, err := s.store.GetDistinctUserIDs(bulkRenameUserBatchSize, offset)
+		if err != nil {
+			logger.Error(ctx, "Failed to list users while bulk-renaming preferences", log.Error(err))
+			return nil, &tidcommon.InternalServerError
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			renamed, skipped, err := s.store.RenameKeysByPrefix(userID, req.FromPrefix, req.ToPrefix, callerID, conflict)
+			if err != nil {
+				logger.Error(ctx, "Failed to bulk-rename preferences for user", log.Error(err))
+				return nil, translateStoreError(err)
+			}
+			resp.UsersProcessed++
+			resp.Renamed += renamed
+			resp.Skipped += skipped
+		}
+
+		logger.Debug(ctx, "Bulk-rename preference progress",
+			log.String("fromPrefix", req.FromPrefix), log.Int("usersProcessed", resp.UsersProcessed),
+			log.Int("renamed", resp.Renamed))
+
+		if len(userIDs) < bulkRenameUserBatchSize {
+			break
+		}
+		offset += bulkRenameUserBatchSize
+	}
+
+	recordPreferenceAudit(ctx, auditActionBulkRename, callerID,
+		log.String("fromPrefix", req.FromPrefix), log.String("toPrefix", req.ToPrefix), log.Int("renamed", resp.Renamed))
+	return resp, nil
+}
+
+// DeletePreferencesByValue deletes, across every user in the deployment, each preference whose
+// current value for req.Key exactly equals req.Value, walking users in bounded batches (see
+// deleteByValueUserBatchSize) so the operation stays memory-bounded for large deployments. Values
+// are compared after decoding (see preferenceStore.GetPreferenceByKey), so this matches
+// correctly regardless of whether a given row happens to be stored compressed.
+func (s *preferenceService) DeletePreferencesByValue(
+	ctx context.Context, callerID string, req *DeletePreferencesByValueRequest,
+) (*DeletePreferencesByValueResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if req.Key == "" {
+		return nil, &ErrorMissingDeleteByValueKey
+	}
+	if svcErr := validatePreferenceKey(req.Key); svcErr != nil {
+		return nil, svcErr
+	}
+	if !req.Confirm {
+		return nil, &ErrorDeleteByValueNotConfirmed
+	}
+
+	resp := &DeletePreferencesByValueResponse{}
+	offset := 0
+	for {
+		userIDs, err := s.store.GetDistinctUserIDs(deleteByValueUserBatchSize, offset)
+		if err != nil {
+			logger.Error(ctx, "Failed to list users while deleting preferences by value", log.Error(err))
+			return nil, &tidcommon.InternalServerError
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			resp.UsersProcessed++
+
+			pref, err := s.store.GetPreferenceByKey(userID, req.Key)
+			if err != nil {
+				logger.Error(ctx, "Failed to get preference while deleting preferences by value", log.Error(err))
+				return nil, translateStoreError(err)
+			}
+			if pref == nil || pref.Value != req.Value {
+				continue
+			}
+			if err := s.store.DeletePreference(userID, req.Key); err != nil {
+				logger.Error(ctx, "Failed to delete preference while deleting preferences by value", log.Error(err))
+				return nil, translateStoreError(err)
+			}
+			resp.Deleted++
+		}
+
+		if len(userIDs) < deleteByValueUserBatchSize {
+			break
+		}
+		offset += deleteByValueUserBatchSize
+	}
+
+	recordPreferenceAudit(ctx, auditActionDeleteByValue, callerID,
+		log.String("key", req.Key), log.Int("usersProcessed", resp.UsersProcessed), log.Int("deleted", resp.Deleted))
+	return resp, nil
+}
+
+// CopyPreferences clones req.SourceUserID's preferences into req.TargetUserID; see
+// PreferenceServiceInterface.CopyPreferences.
+func (s *preferenceService) CopyPreferences(
+	ctx context.Context, callerID string, req *CopyPreferencesRequest,
+) (*CopyPreferencesResponse, *tidcommon.ServiceError) {
+	if req.SourceUserID == "" || req.TargetUserID == "" || req.SourceUserID == req.TargetUserID {
+		return nil, &ErrorInvalidCopyRequest
+	}
+	if _, svcErr := s.userService.GetUser(ctx, req.SourceUserID, false); svcErr != nil {
+		return nil, svcErr
+	}
+	if _, svcErr := s.userService.GetUser(ctx, req.TargetUserID, false); svcErr != nil {
+		return nil, svcErr
+	}
+
+	copied, skipped, err := s.store.CopyPreferences(req.SourceUserID, req.TargetUserID, callerID, req.Keys, req.Prefix)
+	if err != nil {
+		log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName)).
+			Error(ctx, "Failed to copy preferences", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	recordPreferenceAudit(ctx, auditActionCopy, callerID,
+		log.MaskedString(log.LoggerKeyUserID, req.SourceUserID), log.Int("copied", len(copied)))
+	return &CopyPreferencesResponse{Copied: copied, Skipped: skipped}, nil
+}
+
+// ReconcileUserPreferences compares userID's stored preferences against the registered schema
+// catalog; see PreferenceServiceInterface.ReconcileUserPreferences.
+func (s *preferenceService) ReconcileUserPreferences(
+	ctx context.Context, callerID, userID string, req *ReconcilePreferencesRequest,
+) (*PreferenceReconciliationReport, *tidcommon.ServiceError) {
+	if _, svcErr := s.userService.GetUser(ctx, userID, false); svcErr != nil {
+		return nil, svcErr
+	}
+
+	report, svcErr := s.reconcilePreferencesForUser(ctx, callerID, userID, req.DryRun)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+
+	recordPreferenceAudit(ctx, auditActionReconcile, callerID,
+		log.MaskedString(log.LoggerKeyUserID, userID), log.Bool("dryRun", req.DryRun), log.Int("issues", len(report.Issues)))
+	return report, nil
+}
+
+// ReconcileAllUserPreferences walks every user in bounded batches, reconciling each against the
+// registered schema catalog and aggregating the results; see
+// PreferenceServiceInterface.ReconcileAllUserPreferences.
+func (s *preferenceService) ReconcileAllUserPreferences(
+	ctx context.Context, callerID string, req *ReconcilePreferencesRequest,
+) (*BulkReconciliationResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	resp := &BulkReconciliationResponse{DryRun: req.DryRun}
+	offset := 0
+	for {
+		userIDs, err := s.store.GetDistinctUserIDs(schemaReconcileUserBatchSize, offset)
+		if err != nil {
+			logger.Error(ctx, "Failed to list users while reconciling preferences against schema", log.Error(err))
+			return nil, &tidcommon.InternalServerError
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			report, svcErr := s.reconcilePreferencesForUser(ctx, callerID, userID, req.DryRun)
+			if svcErr != nil {
+				return nil, svcErr
+			}
+			resp.UsersProcessed++
+			if len(report.Issues) > 0 {
+				resp.UsersWithIssues++
+			}
+			for _, issue := range report.Issues {
+				switch issue.Type {
+				case ReconciliationIssueExtraKey:
+					resp.ExtraKeysFound++
+					if issue.Action == ReconciliationActionRemoved {
+						resp.ExtraKeysRemoved++
+					}
+				case ReconciliationIssueMissingRequiredKey:
+					resp.MissingRequiredKeysFound++
+					if issue.Action == ReconciliationActionSeeded {
+						resp.MissingRequiredKeysSeeded++
+					}
+				case ReconciliationIssueInvalidValue:
+					resp.InvalidValuesFound++
+				}
+			}
+		}
+
+		logger.Debug(ctx, "Reconcile preferences against schema progress",
+			log.Int("usersProcessed", resp.UsersProcessed), log.Int("usersWithIssues", resp.UsersWithIssues))
+
+		if len(userIDs) < schemaReconcileUserBatchSize {
+			break
+		}
+		offset += schemaReconcileUserBatchSize
+	}
+
+	recordPreferenceAudit(ctx, auditActionReconcile, callerID,
+		log.Bool("dryRun", req.DryRun), log.Int("usersProcessed", resp.UsersProcessed),
+		log.Int("usersWithIssues", resp.UsersWithIssues))
+	return resp, nil
+}
+
+// reconcilePreferencesForUser compares userID's stored preferences against the registered schema
+// catalog, fixing what it can unless dryRun is true. A deployment with no registered schema at
+// all has no catalog to enforce (mirroring checkUnknownKeyPolicy's anyRegistered carve-out), so
+// it reports no issues.
+func (s *preferenceService) reconcilePreferencesForUser(
+	ctx context.Context, callerID, userID string, dryRun bool,
+) (*PreferenceReconciliationReport, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	schemas := getRegisteredPreferenceSchemas()
+	report := &PreferenceReconciliationReport{UserID: userID, DryRun: dryRun, Issues: []PreferenceReconciliationIssue{}}
+	if len(schemas) == 0 {
+		return report, nil
+	}
+
+	prefs, _, err := s.store.GetPreferencesByUserID(userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to load preferences while reconciling against schema", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+
+	existing := make(map[string]Preference, len(prefs))
+	for _, pref := range prefs {
+		existing[pref.Key] = pref
+	}
+
+	for _, pref := range prefs {
+		if known, _ := isKnownPreferenceKey(pref.Key); known {
+			continue
+		}
+		issue := PreferenceReconciliationIssue{Key: pref.Key, Type: ReconciliationIssueExtraKey, Value: pref.Value}
+		if !dryRun && !pref.Enforced {
+			if err := s.store.DeletePreference(userID, pref.Key); err != nil {
+				logger.Error(ctx, "Failed to remove extra preference key while reconciling against schema", log.Error(err))
+				return nil, translateStoreError(err)
+			}
+			issue.Action = ReconciliationActionRemoved
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	for _, entry := range schemas {
+		pref, ok := existing[entry.Key]
+		if !ok {
+			if !entry.Required {
+				continue
+			}
+			issue := PreferenceReconciliationIssue{Key: entry.Key, Type: ReconciliationIssueMissingRequiredKey}
+			if !dryRun && entry.Default != "" {
+				if _, err := s.store.InsertPreferenceIfAbsent(userID, entry.Key, entry.Default, callerID); err != nil {
+					logger.Error(ctx, "Failed to seed missing required preference while reconciling against schema",
+						log.Error(err))
+					return nil, translateStoreError(err)
+				}
+				issue.Action = ReconciliationActionSeeded
+				issue.Value = entry.Default
+			}
+			report.Issues = append(report.Issues, issue)
+			continue
+		}
+		if len(entry.AllowedValues) > 0 && pref.Value != "" && !slices.Contains(entry.AllowedValues, pref.Value) {
+			report.Issues = append(report.Issues, PreferenceReconciliationIssue{
+				Key: entry.Key, Type: ReconciliationIssueInvalidValue, Value: pref.Value,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// AcquirePreferenceLock grants callerID an exclusive advisory lock on userID's preferences; see
+// PreferenceServiceInterface.AcquirePreferenceLock.
+func (s *preferenceService) AcquirePreferenceLock(
+	ctx context.Context, callerID, userID string, ttlSeconds int,
+) (*PreferenceLockResponse, *tidcommon.ServiceError) {
+	if _, svcErr := s.userService.GetUser(ctx, userID, false); svcErr != nil {
+		return nil, svcErr
+	}
+
+	lock, svcErr := acquirePreferenceLock(userID, callerID, ttlSeconds)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+
+	recordPreferenceAudit(ctx, auditActionLockAcquire, callerID, log.MaskedString(log.LoggerKeyUserID, userID))
+	return &PreferenceLockResponse{UserID: userID, Token: lock.Token, ExpiresAt: lock.ExpiresAt}, nil
+}
+
+// RefreshPreferenceLock extends userID's active lock; see
+// PreferenceServiceInterface.RefreshPreferenceLock.
+func (s *preferenceService) RefreshPreferenceLock(
+	ctx context.Context, callerID, userID, token string, ttlSeconds int,
+) (*PreferenceLockResponse, *tidcommon.ServiceError) {
+	lock, svcErr := refreshPreferenceLock(userID, token, ttlSeconds)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+
+	recordPreferenceAudit(ctx, auditActionLockRefresh, callerID, log.MaskedString(log.LoggerKeyUserID, userID))
+	return &PreferenceLockResponse{UserID: userID, Token: lock.Token, ExpiresAt: lock.ExpiresAt}, nil
+}
+
+// ReleasePreferenceLock releases userID's active lock; see
+// PreferenceServiceInterface.ReleasePreferenceLock.
+func (s *preferenceService) ReleasePreferenceLock(ctx context.Context, callerID, userID, token string) *tidcommon.ServiceError {
+	if svcErr := releasePreferenceLock(userID, token); svcErr != nil {
+		return svcErr
+	}
+
+	recordPreferenceAudit(ctx, auditActionLockRelease, callerID, log.MaskedString(log.LoggerKeyUserID, userID))
+	return nil
+}
+
+// GetPreferenceUsage reports userID's current preference count and total value byte length, for
+// the PreferenceQuotaConfig usage headers (see writeQuotaHeaders).
+func (s *preferenceService) GetPreferenceUsage(ctx context.Context, userID string) (*PreferenceUsage, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	usage, err := s.store.GetPreferenceUsage(userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to get preference usage", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	return usage, nil
+}
+
+// CheckPreferenceKeysExist reports, for each of req.Keys, whether userID has a current value for
+// it, via a single existence query rather than fetching every value.
+func (s *preferenceService) CheckPreferenceKeysExist(
+	ctx context.Context, userID string, req *ExistsRequest,
+) (*ExistsResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if len(req.Keys) == 0 {
+		return nil, &ErrorEmptyExistsKeys
+	}
+	if len(req.Keys) > maxExistsCheckKeys {
+		return nil, &ErrorTooManyExistsKeys
+	}
+	for _, key := range req.Keys {
+		if svcErr := validatePreferenceKey(key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+			return nil, svcErr
+		}
+	}
+
+	exists, err := s.store.CheckPreferenceKeysExist(userID, req.Keys)
+	if err != nil {
+		logger.Error(ctx, "Failed to check preference key existence", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	return &ExistsResponse{Exists: exists}, nil
+}
+
+// GetPreferencesByKeys returns userID's preferences among req.Keys in a single query; a
+// requested key with no current value is simply omitted from the result.
+func (s *preferenceService) GetPreferencesByKeys(
+	ctx context.Context, userID string, req *PreferenceQueryRequest,
+) (*PreferenceListResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if len(req.Keys) == 0 {
+		return nil, &ErrorEmptyQueryKeys
+	}
+	if len(req.Keys) > maxExistsCheckKeys {
+		return nil, &ErrorTooManyQueryKeys
+	}
+	for _, key := range req.Keys {
+		if svcErr := validatePreferenceKey(key); svcErr != nil {
+			recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+			return nil, svcErr
+		}
+	}
+
+	prefs, failedKeys, err := s.store.GetPreferencesByKeys(userID, req.Keys)
+	if err != nil {
+		logger.Error(ctx, "Failed to get preferences by keys", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedKeys {
+		logger.Error(ctx, "Failed to decode preference value; omitting from list",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+	}
+
+	resp := &PreferenceListResponse{
+		Preferences: make([]PreferenceResponse, 0, len(prefs)),
+		FailedKeys:  failedKeys,
+		TotalCount:  len(prefs),
+	}
+	for _, pref := range prefs {
+		resp.Preferences = append(resp.Preferences, buildPreferenceResponse(pref, false, false))
+		s.readCounts.record(userID, pref.Key)
+	}
+	return resp, nil
+}
+
+// StreamAllPreferences walks every user in the deployment in bounded batches (see
+// exportUserBatchSize), invoking emit once per preference belonging to that user. It stops and
+// returns InternalServerError on the first store or emit failure, so a caller streaming emit's
+// output directly to an HTTP response (the admin export) can tell a truncated stream from a
+// complete one.
+func (s *preferenceService) StreamAllPreferences(
+	ctx context.Context, emit func(PreferenceExportEntry) error,
+) *tidcommon.ServiceError {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	offset := 0
+	for {
+		userIDs, err := s.store.GetDistinctUserIDs(exportUserBatchSize, offset)
+		if err != nil {
+			logger.Error(ctx, "Failed to list users while streaming preference export", log.Error(err))
+			return &tidcommon.InternalServerError
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			prefs, failedKeys, err := s.store.GetPreferencesByUserID(userID)
+			if err != nil {
+				logger.Error(ctx, "Failed to load preferences while streaming preference export", log.Error(err))
+				return &tidcommon.InternalServerError
+			}
+			for _, key := range failedKeys {
+				logger.Error(ctx, "Failed to decode preference value during export; omitting from stream",
+					log.String("userID", userID), log.String("key", key))
+			}
+			for _, pref := range prefs {
+				value, _ := resolveEffectiveValue(pref.Key, pref.Value)
+				entry := PreferenceExportEntry{
+					UserID:    userID,
+					Key:       pref.Key,
+					Value:     value,
+					Enforced:  pref.Enforced,
+					UpdatedBy: pref.UpdatedBy,
+					UpdatedAt: pref.UpdatedAt,
+				}
+				if err := emit(entry); err != nil {
+					logger.Error(ctx, "Failed to emit preference export entry", log.Error(err))
+					return &tidcommon.InternalServerError
+				}
+			}
+		}
+
+		if len(userIDs) < exportUserBatchSize {
+			break
+		}
+		offset += exportUserBatchSize
+	}
+
+	return nil
+}
+
+// ExportUserPreferences returns userID's full preference set as a UserPreferenceExportDocument,
+// reusing the same GetPreferencesByUserID call ListPreferences's full-set path makes. DeploymentID
+// is read from the same server identifier preferenceStore uses to scope rows, so the export can be
+// traced back to the deployment it came from.
+func (s *preferenceService) ExportUserPreferences(
+	ctx context.Context, userID string,
+) (*UserPreferenceExportDocument, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	prefs, failedKeys, err := s.store.GetPreferencesByUserID(userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to load preferences for export", log.Error(err))
+		return nil, &tidcommon.InternalServerError
+	}
+	for _, key := range failedKeys {
+		logger.Error(ctx, "Failed to decode preference value during export; omitting from document",
+			log.MaskedString(log.LoggerKeyUserID, userID), log.String("key", key))
+	}
+
+	entries := make([]UserPreferenceExportEntry, 0, len(prefs))
+	for _, pref := range prefs {
+		value, _ := resolveEffectiveValue(pref.Key, pref.Value)
+		entries = append(entries, UserPreferenceExportEntry{
+			Key:           pref.Key,
+			Value:         value,
+			Enforced:      pref.Enforced,
+			SchemaVersion: pref.SchemaVersion,
+			UpdatedBy:     pref.UpdatedBy,
+			UpdatedAt:     pref.UpdatedAt,
+		})
+	}
+
+	return &UserPreferenceExportDocument{
+		ExportedAt:   time.Now().UTC(),
+		DeploymentID: config.GetServerRuntime().Config.Server.Identifier,
+		Preferences:  entries,
+	}, nil
+}
+
+// GetPreferenceHistory returns the recorded history of a single preference for userID, oldest
+// first (see preferenceStoreInterface.GetPreferenceHistory). A key with no recorded history
+// (never written, or written before USER_PREFERENCE_HISTORY started being populated) returns an
+// empty, non-nil Entries slice rather than an error.
+func (s *preferenceService) GetPreferenceHistory(
+	ctx context.Context, userID, key string,
+) (*PreferenceHistoryResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	if svcErr := validatePreferenceKey(key); svcErr != nil {
+		recordValidationRejection(ctx, userID, validationRuleInvalidKey)
+		return nil, svcErr
+	}
+
+	entries, err := s.store.GetPreferenceHistory(userID, key)
+	if err != nil {
+		logger.Error(ctx, "Failed to get preference history", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	return &PreferenceHistoryResponse{Key: key, Entries: entries}, nil
+}
+
+// GetPreferencesAsOf reconstructs userID's preference set as it stood at timestamp (see
+// preferenceStoreInterface.GetPreferencesAsOf). Access control is the caller's responsibility
+// (see GetPreferencesAsOf's doc comment on PreferenceServiceInterface).
+func (s *preferenceService) GetPreferencesAsOf(
+	ctx context.Context, userID string, timestamp time.Time,
+) (*PreferencesAsOfResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	entries, err := s.store.GetPreferencesAsOf(userID, timestamp)
+	if err != nil {
+		logger.Error(ctx, "Failed to get preferences as of timestamp", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+
+	return &PreferencesAsOfResponse{Timestamp: timestamp, Preferences: entries}, nil
+}
+
+// ListPreferenceSchemas returns the catalog of registered preference key schemas, so a generic
+// settings UI can render itself without hardcoding knowledge of individual keys. Keys with no
+// registered schema are not included.
+func (s *preferenceService) ListPreferenceSchemas(
+	_ context.Context,
+) (*PreferenceSchemaResponse, *tidcommon.ServiceError) {
+	return &PreferenceSchemaResponse{Schemas: getRegisteredPreferenceSchemas()}, nil
+}
+
+// GetPreferenceReadCountAggregate returns the total read count per preference key across all
+// users, for informing deprecation decisions about settings nobody reads. Entries is empty when
+// read-count tracking is disabled for this deployment, rather than reporting stale counts from
+// a time when it was enabled.
+func (s *preferenceService) GetPreferenceReadCountAggregate(
+	ctx context.Context,
+) (*ReadCountAggregateResponse, *tidcommon.ServiceError) {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	enabled := config.GetServerRuntime().Config.Preference.ReadCount.Enabled
+	resp := &ReadCountAggregateResponse{Enabled: enabled, Entries: make([]ReadCountAggregateEntry, 0)}
+	if !enabled {
+		return resp, nil
+	}
+
+	counts, err := s.store.GetReadCountAggregate(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to get preference read count aggregate", log.Error(err))
+		return nil, translateStoreError(err)
+	}
+	for _, count := range counts {
+		resp.Entries = append(resp.Entries, ReadCountAggregateEntry{Key: count.Key, TotalReads: count.TotalReads})
+	}
+	return resp, nil
+}
+
+// GetQueryDebugInfo returns the resolved SQL text, for the deployment's current database type,
+// of every fixed-arity store query, so operators can confirm which query variant is selected
+// without attaching a debugger. Returns ErrorDebugEndpointDisabled unless
+// PreferenceDebugConfig.Enabled is set, since the resolved query text is internal detail that
+// should not be exposed by default.
+func (s *preferenceService) GetQueryDebugInfo(_ context.Context) (*QueryDebugResponse, *tidcommon.ServiceError) {
+	if !config.GetServerRuntime().Config.Preference.Debug.Enabled {
+		return nil, &ErrorDebugEndpointDisabled
+	}
+
+	dbType := config.GetServerRuntime().Config.Database.User.Type
+	entries := make([]QueryDebugEntry, 0, len(debugQueries))
+	for id, query := range debugQueries {
+		entries = append(entries, QueryDebugEntry{ID: id, Query: query.GetQuery(dbType)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	return &QueryDebugResponse{DBType: dbType, Queries: entries}, nil
+}
+
+// checkDiffAccess validates that the caller is authorized to read the given user's preferences.
+func (s *preferenceService) checkDiffAccess(ctx context.Context, ouID, userID string) *tidcommon.ServiceError {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	allowed, svcErr := s.authzService.IsActionAllowed(ctx, security.ActionReadUser,
+		&sysauthz.ActionContext{ResourceType: security.ResourceTypeUser, OUID: ouID, ResourceID: userID})
+	if svcErr != nil {
+		logger.Error(ctx, "Failed to check authorization for preference diff", log.Error(svcErr))
+		return &tidcommon.InternalServerError
+	}
+	if !allowed {
+		return &tidcommon.ErrorUnauthorized
+	}
+	return nil
+}
+
+// checkRevealAccess validates that the caller holds the higher-privilege permission required to
+// reveal Sensitive-schema values in a diff read. There is no dedicated "reveal" permission in
+// this product's authorization model (see internal/system/security), so this reuses the update
+// action on the target user: it already implies more trust than the plain read checkDiffAccess
+// performs, which is the property a reveal gate needs.
+func (s *preferenceService) checkRevealAccess(ctx context.Context, ouID, userID string) *tidcommon.ServiceError {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	allowed, svcErr := s.authzService.IsActionAllowed(ctx, security.ActionUpdateUser,
+		&sysauthz.ActionContext{ResourceType: security.ResourceTypeUser, OUID: ouID, ResourceID: userID})
+	if svcErr != nil {
+		logger.Error(ctx, "Failed to check authorization for preference diff reveal", log.Error(svcErr))
+		return &tidcommon.InternalServerError
+	}
+	if !allowed {
+		return &tidcommon.ErrorUnauthorized
+	}
+	return nil
+}
+
+// CheckAdminAccess validates that the caller may act on userID's preferences through the admin
+// API; see PreferenceServiceInterface.CheckAdminAccess.
+func (s *preferenceService) CheckAdminAccess(ctx context.Context, userID string, write bool) *tidcommon.ServiceError {
+	target, svcErr := s.userService.GetUser(ctx, userID, false)
+	if svcErr != nil {
+		return svcErr
+	}
+	if write {
+		return s.checkRevealAccess(ctx, target.OUID, userID)
+	}
+	return s.checkDiffAccess(ctx, target.OUID, userID)
+}
+
+// checkNamespaceLimit enforces PreferenceNamespaceConfig.MaxPerUser for a write that creates a
+// new key. It is a no-op when the limit is unset (0), so enabling it costs nothing by default.
+func (s *preferenceService) checkNamespaceLimit(ctx context.Context, userID, key string) *tidcommon.ServiceError {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	maxPerUser := config.GetServerRuntime().Config.Preference.Namespace.MaxPerUser
+	if maxPerUser <= 0 {
+		return nil
+	}
+
+	namespaces, err := s.store.GetDistinctNamespaces(userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to get distinct preference namespaces", log.Error(err))
+		return &tidcommon.InternalServerError
+	}
+
+	namespace := extractNamespace(key)
+	for _, existing := range namespaces {
+		if existing == namespace {
+			return nil
+		}
+	}
+	if len(namespaces) >= maxPerUser {
+		return &ErrorNamespaceLimitExceeded
+	}
+	return nil
+}
+
+// checkPreferenceCountQuota enforces PreferenceQuotaConfig.MaxCount as a hard cap for a write
+// that creates a new key, when EnforceMaxCount is set. It is a no-op when EnforceMaxCount is
+// false or MaxCount is unset (0), preserving the original soft-quota-only behavior by default. A
+// write that only updates an existing key's value never reaches this check (see SetPreference),
+// so it never counts against the cap.
+func (s *preferenceService) checkPreferenceCountQuota(ctx context.Context, userID string) *tidcommon.ServiceError {
+	logger := log.GetLogger().With(log.String(log.LoggerKeyComponentName, serviceLoggerComponentName))
+
+	quota := config.GetServerRuntime().Config.Preference.Quota
+	if !quota.EnforceMaxCount || quota.MaxCount <= 0 {
+		return nil
+	}
+
+	count, err := s.store.CountPreferences(userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to count preferences for quota check", log.Error(err))
+		return &tidcommon.InternalServerError
+	}
+	if count >= quota.MaxCount {
+		return &ErrorPreferenceQuotaExceeded
+	}
+	return nil
+}
+
+// checkUnknownKeyPolicy enforces the deployment's unknown-key policy (see PreferenceSchemaConfig)
+// against a key with no registered schema. It is a no-op unless the policy is "strict" and at
+// least one schema is registered; a deployment that has never registered a schema has no catalog
+// to enforce, so every key is treated as known regardless of policy.
+func checkUnknownKeyPolicy(key string) *tidcommon.ServiceError {
+	if config.GetServerRuntime().Config.Preference.Schema.UnknownKeyPolicy != unknownKeyPolicyStrict {
+		return nil
+	}
+	known, anyRegistered := isKnownPreferenceKey(key)
+	if !anyRegistered || known {
+		return nil
+	}
+	return &ErrorUnknownPreferenceKey
+}
+
+// checkBatchValidationLimit enforces PreferenceBatchConfig.MaxTotalValueBytes against the
+// combined value length of every set operation in a batch request, bounding the per-value
+// validation work (content-type sniffing, and in the future schema/regex checks) a single request
+// can force the server to do. A no-op unless the limit is configured.
+func checkBatchValidationLimit(operations []BatchOperation) *tidcommon.ServiceError {
+	maxTotalValueBytes := config.GetServerRuntime().Config.Preference.Batch.MaxTotalValueBytes
+	if maxTotalValueBytes <= 0 {
+		return nil
+	}
+
+	total := 0
+	for _, op := range operations {
+		if op.Op == BatchOperationSet {
+			total += len(op.Value)
+		}
+	}
+	if total > maxTotalValueBytes {
+		return &ErrorBatchValidationLimitExceeded
+	}
+	return nil
+}
+
+// translateStoreError maps a store failure to the service-level error it should surface: a
+// database-read-only error (e.g. a brief standby window during a failover) becomes
+// ErrorServiceUnavailable so the client backs off instead of retrying a write that looks like a
+// generic server fault; a per-query timeout (see PreferenceQueryTimeoutConfig) becomes
+// ErrorQueryTimeout; any other error becomes tidcommon.InternalServerError.
+func translateStoreError(err error) *tidcommon.ServiceError {
+	if errors.Is(err, errDBReadOnly) {
+		return &ErrorServiceUnavailable
+	}
+	if errors.Is(err, errQueryTimeout) {
+		return &ErrorQueryTimeout
+	}
+	return &tidcommon.InternalServerError
+}
+
+// extractNamespace returns a preference key's namespace: its first namespaceSeparator-delimited
+// segment, or the whole key when it has no separator.
+func extractNamespace(key string) string {
+	if idx := strings.Index(key, namespaceSeparator); idx >= 0 {
+		return key[:idx]
+	}
+	return key
+}
+
+// validatePreferenceKey validates a preference key.
+func validatePreferenceKey(key string) *tidcommon.ServiceError {
+	if key == "" || len(key) > preferenceMaxKeyLength() {
+		return &ErrorInvalidPreferenceKey
+	}
+	if containsInvisibleKeyRune(key) {
+		return &ErrorInvalidPreferenceKey
+	}
+	return nil
+}
+
+// invisibleKeyRunes are characters that render invisibly, or identically to their neighbors, yet
+// are not byte-equal to them: the BOM / zero-width no-break space (U+FEFF), zero-width
+// space/joiners (U+200B-U+200D), and the bidi control characters clipboard tools sometimes
+// preserve on copy-paste (U+200E, U+200F, U+202A-U+202E, U+2066-U+2069). A key carrying one of
+// these looks identical to the clean key in a UI but fails a lookup against it, so they are
+// rejected outright rather than silently stripped.
+var invisibleKeyRunes = map[rune]bool{
+	'\uFEFF': true, // BOM / zero-width no-break space
+	'\u200B': true, // zero-width space
+	'\u200C': true, // zero-width non-joiner
+	'\u200D': true, // zero-width joiner
+	'\u200E': true, // left-to-right mark
+	'\u200F': true, // right-to-left mark
+	'\u202A': true, // left-to-right embedding
+	'\u202B': true, // right-to-left embedding
+	'\u202C': true, // pop directional formatting
+	'\u202D': true, // left-to-right override
+	'\u202E': true, // right-to-left override
+	'\u2066': true, // left-to-right isolate
+	'\u2067': true, // right-to-left isolate
+	'\u2068': true, // first strong isolate
+	'\u2069': true, // pop directional isolate
+}
+
+// containsInvisibleKeyRune reports whether key contains any rune in invisibleKeyRunes.
+func containsInvisibleKeyRune(key string) bool {
+	for _, r := range key {
+		if invisibleKeyRunes[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePreferenceValue validates a preference value.
+func validatePreferenceValue(value string) *tidcommon.ServiceError {
+	if len(value) > preferenceMaxValueLength() {
+		return &ErrorInvalidPreferenceValue
+	}
+	return nil
+}
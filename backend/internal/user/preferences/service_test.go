@@ -0,0 +1,3627 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/security"
+	"github.com/thunder-id/thunderid/internal/user"
+	"github.com/thunder-id/thunderid/tests/mocks/sysauthzmock"
+	"github.com/thunder-id/thunderid/tests/mocks/usermock"
+)
+
+type PreferenceServiceTestSuite struct {
+	suite.Suite
+	mockStore        *preferenceStoreInterfaceMock
+	mockUserService  *usermock.UserServiceInterfaceMock
+	mockAuthzService *sysauthzmock.SystemAuthorizationServiceInterfaceMock
+	service          *preferenceService
+}
+
+func TestPreferenceServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceServiceTestSuite))
+}
+
+func (suite *PreferenceServiceTestSuite) SetupTest() {
+	suite.mockStore = newPreferenceStoreInterfaceMock(suite.T())
+	suite.mockUserService = usermock.NewUserServiceInterfaceMock(suite.T())
+	suite.mockAuthzService = sysauthzmock.NewSystemAuthorizationServiceInterfaceMock(suite.T())
+	suite.service = &preferenceService{
+		store:        suite.mockStore,
+		userService:  suite.mockUserService,
+		authzService: suite.mockAuthzService,
+		readCounts:   newReadCountFlusher(suite.mockStore),
+		auditSink:    defaultPreferenceAuditSink{},
+		webhook:      noopWebhookDispatcher{},
+	}
+}
+
+// ListPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestListPreferences_Success() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", defaultPreferenceListLimit, 0).Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now},
+	}, nil, int64(1), nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Len(resp.Preferences, 1)
+	suite.Equal("theme", resp.Preferences[0].Key)
+	suite.Empty(resp.FailedKeys)
+	suite.Equal(1, resp.TotalCount)
+	suite.Nil(resp.NextOffset)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_PartialDecodeFailure() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", defaultPreferenceListLimit, 0).Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now},
+	}, []string{"locale"}, int64(2), nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Len(resp.Preferences, 1)
+	suite.Equal([]string{"locale"}, resp.FailedKeys)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_IncludeSizes() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", defaultPreferenceListLimit, 0).Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now},
+		{UserID: "user-1", Key: "locale", Value: "en-US", UpdatedAt: now},
+	}, nil, int64(2), nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", true, false, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 2)
+	suite.Require().NotNil(resp.Preferences[0].SizeBytes)
+	suite.Equal(len("dark"), *resp.Preferences[0].SizeBytes)
+	suite.Require().NotNil(resp.Preferences[1].SizeBytes)
+	suite.Equal(len("en-US"), *resp.Preferences[1].SizeBytes)
+	suite.Require().NotNil(resp.TotalSizeBytes)
+	suite.Equal(len("dark")+len("en-US"), *resp.TotalSizeBytes)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_Explain() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "theme", Default: "light", EmptyFallsThroughToDefault: true})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "theme")
+		schemaRegistryMu.Unlock()
+	}()
+
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", defaultPreferenceListLimit, 0).Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "", UpdatedAt: now},
+	}, nil, int64(1), nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, true, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	explain := resp.Preferences[0].Explain
+	suite.Require().NotNil(explain)
+	suite.Equal(layerSchemaDefault, explain.WonLayer)
+	suite.Equal([]PreferenceExplanationLayer{
+		{Layer: layerUser, Value: ""},
+		{Layer: layerSchemaDefault, Value: "light"},
+	}, explain.Layers)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_ExplainNotRequested() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", defaultPreferenceListLimit, 0).Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now},
+	}, nil, int64(1), nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Nil(resp.Preferences[0].Explain)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_StoreError() {
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", defaultPreferenceListLimit, 0).
+		Return(nil, nil, int64(0), errors.New("db error"))
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, 0)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_Paginated() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", 1, 1).Return([]Preference{
+		{UserID: "user-1", Key: "locale", Value: "en-US", UpdatedAt: now},
+	}, nil, int64(3), nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 1, 1)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("locale", resp.Preferences[0].Key)
+	suite.Equal(3, resp.TotalCount)
+	suite.Require().NotNil(resp.NextOffset)
+	suite.Equal(2, *resp.NextOffset)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_InvalidLimit() {
+	resp, svcErr := suite.service.ListPreferences(
+		context.Background(), "user-1", false, false, false, "", "", "", maxPreferenceListLimit+1, 0,
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceListLimit.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_InvalidOffset() {
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, -1)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceListOffset.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_PatternFiltersKeys() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "ui.dark.color", Value: "red", UpdatedAt: now},
+		{UserID: "user-1", Key: "ui.color", Value: "blue", UpdatedAt: now},
+		{UserID: "user-1", Key: "locale", Value: "en-US", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "ui.*.color", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("ui.dark.color", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_InvalidPattern() {
+	resp, svcErr := suite.service.ListPreferences(
+		context.Background(), "user-1", false, false, false, strings.Repeat("a", maxPreferencePatternLength+1), "", "", 0, 0,
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferencePattern.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_InvalidSort() {
+	resp, svcErr := suite.service.ListPreferences(
+		context.Background(), "user-1", false, false, false, "", "bogus", "", 0, 0,
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceSort.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_InvalidSortOrder() {
+	resp, svcErr := suite.service.ListPreferences(
+		context.Background(), "user-1", false, false, false, "", preferenceSortKey, "bogus", 0, 0,
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceSort.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_CustomSortUsesSortedStoreMethod() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDSorted", "user-1", preferenceSortUpdatedAt, preferenceSortOrderDesc).
+		Return([]Preference{
+			{UserID: "user-1", Key: "ui.theme", Value: "dark", UpdatedAt: now},
+		}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferences(
+		context.Background(), "user-1", false, false, false, "", preferenceSortUpdatedAt, preferenceSortOrderDesc, 0, 0,
+	)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("ui.theme", resp.Preferences[0].Key)
+	suite.mockStore.AssertNotCalled(suite.T(), "GetPreferencesByUserID", mock.Anything)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferencesByPrefix_Success() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByPrefix", "user-1", "editor.").Return([]Preference{
+		{UserID: "user-1", Key: "editor.tabSize", Value: "2", UpdatedAt: now},
+		{UserID: "user-1", Key: "editor.theme", Value: "dark", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferencesByPrefix(context.Background(), "user-1", "editor.")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal([]string{"editor.tabSize", "editor.theme"}, preferenceKeys(resp.Preferences))
+	suite.Equal(2, resp.TotalCount)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferencesByPrefix_NoMatches() {
+	suite.mockStore.On("GetPreferencesByPrefix", "user-1", "missing.").Return([]Preference{}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferencesByPrefix(context.Background(), "user-1", "missing.")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Empty(resp.Preferences)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferencesByPrefix_InvalidPrefix() {
+	resp, svcErr := suite.service.ListPreferencesByPrefix(
+		context.Background(), "user-1", strings.Repeat("a", maxPreferencePatternLength+1),
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferencePrefix.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferencesByPrefix_StoreError() {
+	suite.mockStore.On("GetPreferencesByPrefix", "user-1", "editor.").Return(nil, nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ListPreferencesByPrefix(context.Background(), "user-1", "editor.")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSearchPreferencesByValue_Success() {
+	now := time.Now()
+	suite.mockStore.On("SearchPreferencesByValue", "user-1", "", "dark").Return([]Preference{
+		{UserID: "user-1", Key: "editor.theme", Value: "dark", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.SearchPreferencesByValue(context.Background(), "user-1", "", "dark")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal([]string{"editor.theme"}, preferenceKeys(resp.Preferences))
+	suite.Equal(1, resp.TotalCount)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSearchPreferencesByValue_WithPrefix() {
+	now := time.Now()
+	suite.mockStore.On("SearchPreferencesByValue", "user-1", "editor.", "dark").Return([]Preference{
+		{UserID: "user-1", Key: "editor.theme", Value: "dark", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.SearchPreferencesByValue(context.Background(), "user-1", "editor.", "dark")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal([]string{"editor.theme"}, preferenceKeys(resp.Preferences))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSearchPreferencesByValue_NoMatches() {
+	suite.mockStore.On("SearchPreferencesByValue", "user-1", "", "solarized").Return([]Preference{}, nil, nil)
+
+	resp, svcErr := suite.service.SearchPreferencesByValue(context.Background(), "user-1", "", "solarized")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Empty(resp.Preferences)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSearchPreferencesByValue_InvalidValueContains() {
+	resp, svcErr := suite.service.SearchPreferencesByValue(
+		context.Background(), "user-1", "", strings.Repeat("a", maxPreferencePatternLength+1),
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValueContains.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSearchPreferencesByValue_InvalidPrefix() {
+	resp, svcErr := suite.service.SearchPreferencesByValue(
+		context.Background(), "user-1", strings.Repeat("a", maxPreferencePatternLength+1), "dark",
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferencePrefix.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSearchPreferencesByValue_StoreError() {
+	suite.mockStore.On("SearchPreferencesByValue", "user-1", "", "dark").Return(nil, nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.SearchPreferencesByValue(context.Background(), "user-1", "", "dark")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_SortByCatalogMixesCatalogAndNonCatalogKeys() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", DisplayOrder: 2})
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "notifications.email", DisplayOrder: 1})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "ui.theme")
+		delete(schemaRegistry, "notifications.email")
+		schemaRegistryMu.Unlock()
+	}()
+
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "unregistered.z", Value: "v", UpdatedAt: now},
+		{UserID: "user-1", Key: "ui.theme", Value: "dark", UpdatedAt: now},
+		{UserID: "user-1", Key: "notifications.email", Value: "true", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, true, "", "", "", 0, 0)
+
+	suite.Require().Nil(svcErr)
+	suite.Equal([]string{"notifications.email", "ui.theme", "unregistered.z"}, preferenceKeys(resp.Preferences))
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_UnsetDefaultIsMergedIntoList() {
+	suite.service.defaults = map[string]string{"theme": "dark"}
+
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "locale", Value: "en-US", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 2)
+	suite.Equal([]string{"locale", "theme"}, preferenceKeys(resp.Preferences))
+	defaultPref := resp.Preferences[1]
+	suite.Equal("dark", defaultPref.Value)
+	suite.True(defaultPref.IsDefault)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_UserSetKeyOverridesDefault() {
+	suite.service.defaults = map[string]string{"theme": "dark"}
+
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "light", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("theme", resp.Preferences[0].Key)
+	suite.Equal("light", resp.Preferences[0].Value)
+	suite.False(resp.Preferences[0].IsDefault)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_DefaultExcludedWhenPatternDoesNotMatch() {
+	suite.service.defaults = map[string]string{"theme": "dark"}
+
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "locale", Value: "en-US", UpdatedAt: now},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "locale", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("locale", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferences_NoConfiguredDefaultsUsesPaginatedPath() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByUserIDPaginated", "user-1", defaultPreferenceListLimit, 0).Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now},
+	}, nil, int64(1), nil)
+
+	resp, svcErr := suite.service.ListPreferences(context.Background(), "user-1", false, false, false, "", "", "", 0, 0)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.False(resp.Preferences[0].IsDefault)
+}
+
+// GetPreference Tests
+func (suite *PreferenceServiceTestSuite) TestGetPreference_Success() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_EmptyValueFallsThroughToDefault() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{
+		"theme": {Key: "theme", Default: "light", EmptyFallsThroughToDefault: true},
+	}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("light", resp.Value)
+	suite.True(resp.DefaultApplied)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_WhitespaceValueIsExplicitOverride() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{
+		"theme": {Key: "theme", Default: "light", EmptyFallsThroughToDefault: true},
+	}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: " ", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(" ", resp.Value)
+	suite.False(resp.DefaultApplied)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_NotFound() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_InheritsFromParentWhenUnset() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "child-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "child-1", false).
+		Return(&user.User{ID: "child-1", Attributes: json.RawMessage(`{"parentUserId":"parent-1"}`)}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "parent-1", "theme").Return(&Preference{
+		UserID: "parent-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "child-1", "theme", false, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+	suite.True(resp.InheritedFromParent)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_NoParentLinkReturnsNotFound() {
+	suite.mockStore.On("GetPreferenceByKey", "child-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "child-1", false).Return(&user.User{ID: "child-1"}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "child-1", "theme", false, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_EffectiveReturnsSchemaDefaultWhenUnset() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{"theme": {Key: "theme", Default: "light"}}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	suite.mockStore.On("GetPreferenceByKey", "child-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "child-1", false).Return(&user.User{ID: "child-1"}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "child-1", "theme", true, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("light", resp.Value)
+	suite.True(resp.DefaultApplied)
+	suite.False(resp.InheritedFromParent)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_EffectiveWithNoSchemaDefaultReturnsNotFound() {
+	suite.mockStore.On("GetPreferenceByKey", "child-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "child-1", false).Return(&user.User{ID: "child-1"}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "child-1", "theme", true, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_EffectivePrefersParentOverSchemaDefault() {
+	now := time.Now()
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{"theme": {Key: "theme", Default: "light"}}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	suite.mockStore.On("GetPreferenceByKey", "child-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "child-1", false).
+		Return(&user.User{ID: "child-1", Attributes: json.RawMessage(`{"parentUserId":"parent-1"}`)}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "parent-1", "theme").Return(&Preference{
+		UserID: "parent-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "child-1", "theme", true, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+	suite.True(resp.InheritedFromParent)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_ParentAlsoUnsetFallsThroughToGrandparent() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "child-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "child-1", false).
+		Return(&user.User{ID: "child-1", Attributes: json.RawMessage(`{"parentUserId":"parent-1"}`)}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "parent-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "parent-1", false).
+		Return(&user.User{ID: "parent-1", Attributes: json.RawMessage(`{"parentUserId":"grandparent-1"}`)}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "grandparent-1", "theme").Return(&Preference{
+		UserID: "grandparent-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "child-1", "theme", false, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+	suite.True(resp.InheritedFromParent)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_ParentUserLookupErrorReturnsNotFound() {
+	suite.mockStore.On("GetPreferenceByKey", "child-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "child-1", false).
+		Return(nil, &ErrorPreferenceNotFound)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "child-1", "theme", false, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_ParentLinkCycleStopsWithoutLooping() {
+	suite.mockStore.On("GetPreferenceByKey", "user-a", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-a", false).
+		Return(&user.User{ID: "user-a", Attributes: json.RawMessage(`{"parentUserId":"user-b"}`)}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-b", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-b", false).
+		Return(&user.User{ID: "user-b", Attributes: json.RawMessage(`{"parentUserId":"user-a"}`)}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-a", "theme", false, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_InvalidKey() {
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "", false, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_UnknownKeyRejectedUnderStrictPolicy() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{"ui.theme": {Key: "ui.theme"}}
+	schemaRegistryMu.Unlock()
+	config.GetServerRuntime().Config.Preference.Schema.UnknownKeyPolicy = unknownKeyPolicyStrict
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+		config.GetServerRuntime().Config.Preference.Schema.UnknownKeyPolicy = ""
+	}()
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "ui.unregistered", false, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorUnknownPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_UnknownKeyAllowedUnderLenientPolicy() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{"ui.theme": {Key: "ui.theme"}}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.unregistered").Return(&Preference{
+		UserID: "user-1", Key: "ui.unregistered", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "ui.unregistered", false, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_StrictPolicyAllowsKeyWhenNoSchemasRegistered() {
+	config.GetServerRuntime().Config.Preference.Schema.UnknownKeyPolicy = unknownKeyPolicyStrict
+	defer func() { config.GetServerRuntime().Config.Preference.Schema.UnknownKeyPolicy = "" }()
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.theme").Return(&Preference{
+		UserID: "user-1", Key: "ui.theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "ui.theme", false, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_DeviceOverridePresent() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1::device::device-1", "theme").Return(&Preference{
+		UserID: "user-1::device::device-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "device-1")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+	suite.True(resp.DeviceScoped)
+	suite.mockStore.AssertNotCalled(suite.T(), "GetPreferenceByKey", "user-1", "theme")
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_DeviceOverrideAbsentFallsBackToUserGlobal() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1::device::device-1", "theme").Return(nil, nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "light", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "device-1")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("light", resp.Value)
+	suite.False(resp.DeviceScoped)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreference_InvalidDeviceID() {
+	resp, svcErr := suite.service.GetPreference(
+		context.Background(), "user-1", "theme", false, strings.Repeat("a", maxDeviceIDLength+1))
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidDeviceID.Code, svcErr.Code)
+}
+
+// GetPreferenceWithFallback Tests
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceWithFallback_FirstKeyMatches() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.theme").Return(&Preference{
+		UserID: "user-1", Key: "ui.theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreferenceWithFallback(context.Background(), "user-1", []string{"ui.theme", "ui.darkmode"})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("ui.theme", resp.Key)
+	suite.Equal("dark", resp.Value)
+	suite.mockStore.AssertNotCalled(suite.T(), "GetPreferenceByKey", "user-1", "ui.darkmode")
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceWithFallback_FallsBackToLaterKey() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.theme").Return(nil, nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.darkmode").Return(&Preference{
+		UserID: "user-1", Key: "ui.darkmode", Value: "true", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreferenceWithFallback(context.Background(), "user-1", []string{"ui.theme", "ui.darkmode"})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("ui.darkmode", resp.Key)
+	suite.Equal("true", resp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceWithFallback_AllMissing() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.theme").Return(nil, nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.darkmode").Return(nil, nil)
+
+	resp, svcErr := suite.service.GetPreferenceWithFallback(context.Background(), "user-1", []string{"ui.theme", "ui.darkmode"})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceWithFallback_EmptyKeys() {
+	resp, svcErr := suite.service.GetPreferenceWithFallback(context.Background(), "user-1", []string{})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceWithFallback_InvalidKeyInChain() {
+	resp, svcErr := suite.service.GetPreferenceWithFallback(context.Background(), "user-1", []string{"ui.theme", ""})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+// SetPreference Tests
+func (suite *PreferenceServiceTestSuite) TestSetPreference_Success() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+}
+
+// TestSetPreference_WithTTL_SetsExpiresAt asserts that a positive ttlSeconds is turned into a
+// concrete EXPIRES_AT in the near future (see preferenceExpiresAt) before reaching the store,
+// rather than being passed through as a raw duration.
+func (suite *PreferenceServiceTestSuite) TestSetPreference_WithTTL_SetsExpiresAt() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0,
+		mock.MatchedBy(func(expiresAt *time.Time) bool {
+			if expiresAt == nil {
+				return false
+			}
+			until := time.Until(*expiresAt)
+			return until > 0 && until <= time.Hour
+		})).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 3600, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+// TestSetPreference_ZeroTTL_NoExpiry asserts that an omitted (zero) ttlSeconds passes a nil
+// expiresAt to the store, clearing any expiry a previous write on the same key set (see
+// preferenceExpiresAt).
+func (suite *PreferenceServiceTestSuite) TestSetPreference_ZeroTTL_NoExpiry() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+// Case-insensitive Key Normalization Tests
+func (suite *PreferenceServiceTestSuite) TestSetPreference_KeyCaseNormalization_WriteThenReadDifferentCase() {
+	config.GetServerRuntime().Config.Preference.KeyCase.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.KeyCase.Enabled = false }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil)
+
+	setResp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "Theme", "dark", false, 0, 0, nil, "", "", "")
+	suite.Nil(svcErr)
+	suite.Require().NotNil(setResp)
+
+	getResp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "")
+	suite.Nil(svcErr)
+	suite.Require().NotNil(getResp)
+	suite.Equal("dark", getResp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_KeyCaseNormalization_DisabledStaysCaseSensitive() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "Theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "Theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+
+	_, svcErr := suite.service.SetPreference(context.Background(), "user-1", "Theme", "dark", false, 0, 0, nil, "", "", "")
+	suite.Nil(svcErr)
+
+	getResp, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "")
+	suite.Nil(getResp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+// recordingAuditSink is a fake preferenceAuditSink that captures every emitted record, for tests
+// asserting on audit content rather than on recordPreferenceAudit's log output.
+type recordingAuditSink struct {
+	records []PreferenceAuditRecord
+}
+
+func (s *recordingAuditSink) RecordMutation(_ context.Context, record PreferenceAuditRecord) {
+	s.records = append(s.records, record)
+}
+
+// recordingWebhookDispatcher is a fake webhookDispatcher that captures every dispatched payload,
+// for tests asserting on webhook content rather than standing up a real listener.
+type recordingWebhookDispatcher struct {
+	payloads []PreferenceWebhookPayload
+}
+
+func (d *recordingWebhookDispatcher) Dispatch(payload PreferenceWebhookPayload) {
+	d.payloads = append(d.payloads, payload)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_DispatchesWebhook() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+	dispatcher := &recordingWebhookDispatcher{}
+	suite.service.webhook = dispatcher
+
+	_, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().Len(dispatcher.payloads, 1)
+	suite.Equal("user-1", dispatcher.payloads[0].UserID)
+	suite.Equal([]string{"theme"}, dispatcher.payloads[0].ChangedKeys)
+	suite.Equal(webhookOperationUpsert, dispatcher.payloads[0].Operation)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreference_DispatchesWebhook() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark",
+	}, nil)
+	suite.mockStore.On("DeletePreference", "user-1", "theme").Return(nil)
+	dispatcher := &recordingWebhookDispatcher{}
+	suite.service.webhook = dispatcher
+
+	svcErr := suite.service.DeletePreference(context.Background(), "user-1", "theme", "")
+
+	suite.Nil(svcErr)
+	suite.Require().Len(dispatcher.payloads, 1)
+	suite.Equal("user-1", dispatcher.payloads[0].UserID)
+	suite.Equal([]string{"theme"}, dispatcher.payloads[0].ChangedKeys)
+	suite.Equal(webhookOperationDelete, dispatcher.payloads[0].Operation)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_EmitsAuditRecordWithOldAndNewValue() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "light", UpdatedAt: now.Add(-time.Hour),
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+	sink := &recordingAuditSink{}
+	suite.service.auditSink = sink
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(sink.records, 1)
+	suite.Equal(auditActionSet, sink.records[0].Action)
+	suite.Equal("user-1", sink.records[0].UserID)
+	suite.Equal("theme", sink.records[0].Key)
+	suite.Require().NotNil(sink.records[0].OldValue)
+	suite.Equal("light", *sink.records[0].OldValue)
+	suite.Require().NotNil(sink.records[0].NewValue)
+	suite.Equal("dark", *sink.records[0].NewValue)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_DeviceScoped() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1::device::device-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1::device::device-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1::device::device-1", "theme").Return(&Preference{
+		UserID: "user-1::device::device-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "device-1", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+	suite.True(resp.DeviceScoped)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_InvalidDeviceID() {
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", strings.Repeat("a", maxDeviceIDLength+1), "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidDeviceID.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_InvalidValue() {
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", strings.Repeat("a", maxPreferenceValueLength+1), false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_ValueAboveConfiguredLimitIsRejected() {
+	config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{MaxValueLength: 10}
+	resetPreferenceLimitsForTest()
+	defer func() {
+		config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{}
+		resetPreferenceLimitsForTest()
+	}()
+
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", strings.Repeat("a", 11), false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_KeyAboveConfiguredLimitIsRejected() {
+	config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{MaxKeyLength: 5}
+	resetPreferenceLimitsForTest()
+	defer func() {
+		config.GetServerRuntime().Config.Preference.Limits = config.PreferenceLimitsConfig{}
+		resetPreferenceLimitsForTest()
+	}()
+
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "long-key-name", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_DisallowedContentType() {
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", "<html><body>hi</body></html>", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_NormalizesBooleanType() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "feature.enabled", Type: preferenceTypeBoolean})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "feature.enabled")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "feature.enabled").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "feature.enabled", "true", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "feature.enabled").Return(&Preference{
+		UserID: "user-1", Key: "feature.enabled", Value: "true",
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "feature.enabled", "On", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("true", resp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_RejectsUninterpretableBooleanValue() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "feature.enabled", Type: preferenceTypeBoolean})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "feature.enabled")
+		schemaRegistryMu.Unlock()
+	}()
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "feature.enabled", "maybe", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceTypeMismatch.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_NormalizesNumberType() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.fontSize", Type: preferenceTypeNumber})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "ui.fontSize")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.fontSize").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "ui.fontSize", "14", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.fontSize").Return(&Preference{
+		UserID: "user-1", Key: "ui.fontSize", Value: "14",
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "ui.fontSize", "  14  ", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("14", resp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_RejectsUninterpretableNumberValue() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.fontSize", Type: preferenceTypeNumber})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "ui.fontSize")
+		schemaRegistryMu.Unlock()
+	}()
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "ui.fontSize", "large", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceTypeMismatch.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_UpsertError() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(errors.New("db error"))
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_UpsertReadOnlyDBError() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).
+		Return(wrapWriteError("failed to upsert preference", &pq.Error{Code: "25006"}))
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorServiceUnavailable.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_UnknownKeyRejectedUnderStrictPolicy() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{"theme": {Key: "theme"}}
+	schemaRegistryMu.Unlock()
+	config.GetServerRuntime().Config.Preference.Schema.UnknownKeyPolicy = unknownKeyPolicyStrict
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+		config.GetServerRuntime().Config.Preference.Schema.UnknownKeyPolicy = ""
+	}()
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "unregistered", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorUnknownPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_KeyNotInAllowedSchemaIsRejected() {
+	allowedKeySchemaMu.Lock()
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{"theme": {}})
+	allowedKeySchemaMu.Unlock()
+	defer func() {
+		allowedKeySchemaMu.Lock()
+		allowedKeySchema = nil
+		allowedKeySchemaMu.Unlock()
+	}()
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "them", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_ValueViolatingSchemaPatternIsRejected() {
+	allowedKeySchemaMu.Lock()
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {ValuePattern: "^(light|dark)$"},
+	})
+	allowedKeySchemaMu.Unlock()
+	defer func() {
+		allowedKeySchemaMu.Lock()
+		allowedKeySchema = nil
+		allowedKeySchemaMu.Unlock()
+	}()
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "purple", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_AllowedBySchemaSucceeds() {
+	allowedKeySchemaMu.Lock()
+	allowedKeySchema = newPreferenceSchema(map[string]config.PreferenceSchemaKeyConfig{
+		"theme": {ValuePattern: "^(light|dark)$"},
+	})
+	allowedKeySchemaMu.Unlock()
+	defer func() {
+		allowedKeySchemaMu.Lock()
+		allowedKeySchema = nil
+		allowedKeySchemaMu.Unlock()
+	}()
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_EnforcedWriteWins() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", Enforced: true, UpdatedAt: now,
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "light", "user-1", true, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "light", Enforced: true, UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "light", true, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("light", resp.Value)
+	suite.True(resp.Enforced)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_PlainWriteBlockedByEnforcedValue() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", Enforced: true,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "light", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceEnforced.Code, svcErr.Code)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_NamespaceLimit_ExistingNamespaceAllowed() {
+	config.GetServerRuntime().Config.Preference.Namespace.MaxPerUser = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Namespace.MaxPerUser = 0 }()
+
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.font").Return(nil, nil).Once()
+	suite.mockStore.On("GetDistinctNamespaces", "user-1").Return([]string{"ui"}, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "ui.font", "sans", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "ui.font").Return(&Preference{
+		UserID: "user-1", Key: "ui.font", Value: "sans", UpdatedAt: now,
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "ui.font", "sans", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_NamespaceLimit_NewNamespaceRejected() {
+	config.GetServerRuntime().Config.Preference.Namespace.MaxPerUser = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Namespace.MaxPerUser = 0 }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(nil, nil)
+	suite.mockStore.On("GetDistinctNamespaces", "user-1").Return([]string{"ui"}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "notifications.email", "true", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorNamespaceLimitExceeded.Code, svcErr.Code)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference",
+		"user-1", "notifications.email", "true", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_NamespaceLimit_DisabledByDefault() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(nil, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "notifications.email", "true", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(&Preference{
+		UserID: "user-1", Key: "notifications.email", Value: "true",
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "notifications.email", "true", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.mockStore.AssertNotCalled(suite.T(), "GetDistinctNamespaces", "user-1")
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_CountQuota_NewKeyAtLimitRejected() {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 2, EnforceMaxCount: true}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(nil, nil)
+	suite.mockStore.On("CountPreferences", "user-1").Return(int64(2), nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "notifications.email", "true", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceQuotaExceeded.Code, svcErr.Code)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference",
+		"user-1", "notifications.email", "true", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_CountQuota_NewKeyBelowLimitAllowed() {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 2, EnforceMaxCount: true}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(nil, nil).Once()
+	suite.mockStore.On("CountPreferences", "user-1").Return(int64(1), nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "notifications.email", "true", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(&Preference{
+		UserID: "user-1", Key: "notifications.email", Value: "true",
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "notifications.email", "true", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_CountQuota_UpdateOnlyDoesNotCountAgainstLimit() {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 1, EnforceMaxCount: true}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark",
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "light",
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "light", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.mockStore.AssertNotCalled(suite.T(), "CountPreferences", "user-1")
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_CountQuota_DisabledByDefault() {
+	config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{MaxCount: 1}
+	defer func() { config.GetServerRuntime().Config.Preference.Quota = config.PreferenceQuotaConfig{} }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(nil, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "notifications.email", "true", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "notifications.email").Return(&Preference{
+		UserID: "user-1", Key: "notifications.email", Value: "true",
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "notifications.email", "true", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.mockStore.AssertNotCalled(suite.T(), "CountPreferences", "user-1")
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_IfUnmodifiedSince_Unchanged() {
+	updatedAt := time.Now().Add(-time.Hour)
+	ifUnmodifiedSince := updatedAt.Add(time.Minute)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: updatedAt,
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "light", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "light", false, 0, 0, &ifUnmodifiedSince, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("light", resp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_IfUnmodifiedSince_Changed() {
+	ifUnmodifiedSince := time.Now().Add(-time.Hour)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "light", false, 0, 0, &ifUnmodifiedSince, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceModified.Code, svcErr.Code)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_IfMatchETag_Matching() {
+	updatedAt := time.Now().Add(-time.Hour)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: updatedAt,
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "light", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", "light", false, 0, 0, nil, "", "", computePreferenceETag("dark", updatedAt))
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("light", resp.Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_IfMatchETag_Stale() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", "light", false, 0, 0, nil, "", "", `"stale-etag"`)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceModified.Code, svcErr.Code)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_IfMatchETag_AgainstNonexistentPreferenceFails() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil)
+
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", `"some-etag"`)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceModified.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_DedupSuppressesIdenticalRepeatWrite() {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	config.GetServerRuntime().Config.Preference.Dedup.WindowMillis = 60000
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("dark", resp.Value)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_DedupDisabledByDefaultStillWrites() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.mockStore.AssertCalled(suite.T(), "UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_DedupOutsideWindowStillWrites() {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	config.GetServerRuntime().Config.Preference.Dedup.WindowMillis = 1
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now().Add(-time.Hour),
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.mockStore.AssertCalled(suite.T(), "UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_DedupDifferentValueStillWrites() {
+	config.GetServerRuntime().Config.Preference.Dedup.Enabled = true
+	config.GetServerRuntime().Config.Preference.Dedup.WindowMillis = 60000
+	defer func() { config.GetServerRuntime().Config.Preference.Dedup = config.PreferenceDedupConfig{} }()
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now(),
+	}, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "light", UpdatedAt: time.Now(),
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "light", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.mockStore.AssertCalled(suite.T(), "UpsertPreference", "user-1", "theme", "light", "user-1", false, 0, (*time.Time)(nil))
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.SetPreference(context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, "", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSetPreference_AllowedWithMatchingLockToken() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil).Once()
+	suite.mockStore.On("UpsertPreference", "user-1", "theme", "dark", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark",
+	}, nil)
+
+	resp, svcErr := suite.service.SetPreference(
+		context.Background(), "user-1", "theme", "dark", false, 0, 0, nil, lock.Token, "", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+// DeletePreference Tests
+func (suite *PreferenceServiceTestSuite) TestDeletePreference_Success() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark",
+	}, nil)
+	suite.mockStore.On("DeletePreference", "user-1", "theme").Return(nil)
+
+	svcErr := suite.service.DeletePreference(context.Background(), "user-1", "theme", "")
+
+	suite.Nil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreference_InvalidKey() {
+	svcErr := suite.service.DeletePreference(context.Background(), "user-1", "", "")
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreference_NotFound() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil)
+
+	svcErr := suite.service.DeletePreference(context.Background(), "user-1", "theme", "")
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreference_GetExistingError() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, errors.New("db error"))
+
+	svcErr := suite.service.DeletePreference(context.Background(), "user-1", "theme", "")
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreference_EmitsAuditRecordWithOldValue() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark",
+	}, nil)
+	suite.mockStore.On("DeletePreference", "user-1", "theme").Return(nil)
+	sink := &recordingAuditSink{}
+	suite.service.auditSink = sink
+
+	svcErr := suite.service.DeletePreference(context.Background(), "user-1", "theme", "")
+
+	suite.Nil(svcErr)
+	suite.Require().Len(sink.records, 1)
+	suite.Equal(auditActionDelete, sink.records[0].Action)
+	suite.Equal("theme", sink.records[0].Key)
+	suite.Require().NotNil(sink.records[0].OldValue)
+	suite.Equal("dark", *sink.records[0].OldValue)
+	suite.Nil(sink.records[0].NewValue)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreference_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	svcErr = suite.service.DeletePreference(context.Background(), "user-1", "theme", "")
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// DeleteAllPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestDeleteAllPreferences_Success() {
+	suite.mockStore.On("DeleteAllPreferences", "user-1").Return(int64(3), nil)
+
+	deletedCount, svcErr := suite.service.DeleteAllPreferences(context.Background(), "user-1", "")
+
+	suite.Nil(svcErr)
+	suite.Equal(int64(3), deletedCount)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeleteAllPreferences_NoRows() {
+	suite.mockStore.On("DeleteAllPreferences", "user-1").Return(int64(0), nil)
+
+	deletedCount, svcErr := suite.service.DeleteAllPreferences(context.Background(), "user-1", "")
+
+	suite.Nil(svcErr)
+	suite.Equal(int64(0), deletedCount)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeleteAllPreferences_StoreError() {
+	suite.mockStore.On("DeleteAllPreferences", "user-1").Return(int64(0), errors.New("db error"))
+
+	deletedCount, svcErr := suite.service.DeleteAllPreferences(context.Background(), "user-1", "")
+
+	suite.Equal(int64(0), deletedCount)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeleteAllPreferences_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	deletedCount, svcErr := suite.service.DeleteAllPreferences(context.Background(), "user-1", "")
+
+	suite.Equal(int64(0), deletedCount)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// DeleteNamespace Tests
+func (suite *PreferenceServiceTestSuite) TestDeleteNamespace_Success() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{"ui.theme": {Key: "ui.theme", Default: "light"}}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	suite.mockStore.On("DeletePreferencesByNamespace", "user-1", "ui").Return([]string{"ui.theme"}, nil)
+
+	resp, svcErr := suite.service.DeleteNamespace(context.Background(), "user-1", "ui", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal([]string{"ui.theme"}, resp.DeletedKeys)
+	suite.Equal(map[string]string{"ui.theme": "light"}, resp.Effective)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeleteNamespace_MissingNamespace() {
+	resp, svcErr := suite.service.DeleteNamespace(context.Background(), "user-1", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorMissingNamespace.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeleteNamespace_StoreError() {
+	suite.mockStore.On("DeletePreferencesByNamespace", "user-1", "ui").Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.DeleteNamespace(context.Background(), "user-1", "ui", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeleteNamespace_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.DeleteNamespace(context.Background(), "user-1", "ui", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// DeletePreferencesByPrefix Tests
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByPrefix_Success() {
+	suite.mockStore.On("DeletePreferencesByPrefix", "user-1", "editor.").
+		Return([]string{"editor.tabSize", "editor.theme"}, nil)
+
+	resp, svcErr := suite.service.DeletePreferencesByPrefix(context.Background(), "user-1", "editor.", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal([]string{"editor.tabSize", "editor.theme"}, resp.DeletedKeys)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByPrefix_NoMatches() {
+	suite.mockStore.On("DeletePreferencesByPrefix", "user-1", "missing.").Return([]string{}, nil)
+
+	resp, svcErr := suite.service.DeletePreferencesByPrefix(context.Background(), "user-1", "missing.", "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Empty(resp.DeletedKeys)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByPrefix_EmptyPrefix() {
+	resp, svcErr := suite.service.DeletePreferencesByPrefix(context.Background(), "user-1", "", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorMissingPreferencePrefix.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByPrefix_InvalidPrefix() {
+	resp, svcErr := suite.service.DeletePreferencesByPrefix(
+		context.Background(), "user-1", strings.Repeat("a", maxPreferencePatternLength+1), "",
+	)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferencePrefix.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByPrefix_StoreError() {
+	suite.mockStore.On("DeletePreferencesByPrefix", "user-1", "editor.").Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.DeletePreferencesByPrefix(context.Background(), "user-1", "editor.", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByPrefix_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.DeletePreferencesByPrefix(context.Background(), "user-1", "editor.", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// DeletePreferences Tests
+func (suite *PreferenceServiceTestSuite) TestDeletePreferences_Success() {
+	suite.mockStore.On("DeletePreferences", "user-1", []string{"ui.theme", "ui.locale"}).
+		Return([]string{"ui.theme", "ui.locale"}, nil)
+
+	deletedKeys, svcErr := suite.service.DeletePreferences(context.Background(), "user-1", []string{"ui.theme", "ui.locale"}, "")
+
+	suite.Nil(svcErr)
+	suite.Equal([]string{"ui.theme", "ui.locale"}, deletedKeys)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferences_EmptyKeys() {
+	deletedKeys, svcErr := suite.service.DeletePreferences(context.Background(), "user-1", []string{}, "")
+
+	suite.Nil(deletedKeys)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorEmptyDeleteKeys.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferences_InvalidKey() {
+	deletedKeys, svcErr := suite.service.DeletePreferences(context.Background(), "user-1", []string{""}, "")
+
+	suite.Nil(deletedKeys)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferences_PartialNotFoundSkippedSilently() {
+	suite.mockStore.On("DeletePreferences", "user-1", []string{"ui.theme", "missing"}).
+		Return([]string{"ui.theme"}, nil)
+
+	deletedKeys, svcErr := suite.service.DeletePreferences(context.Background(), "user-1", []string{"ui.theme", "missing"}, "")
+
+	suite.Nil(svcErr)
+	suite.Equal([]string{"ui.theme"}, deletedKeys)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferences_StoreError() {
+	suite.mockStore.On("DeletePreferences", "user-1", []string{"ui.theme"}).
+		Return(nil, errors.New("db error"))
+
+	deletedKeys, svcErr := suite.service.DeletePreferences(context.Background(), "user-1", []string{"ui.theme"}, "")
+
+	suite.Nil(deletedKeys)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferences_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	deletedKeys, svcErr := suite.service.DeletePreferences(context.Background(), "user-1", []string{"ui.theme"}, "")
+
+	suite.Nil(deletedKeys)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// DiffPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestDiffPreferences_Success() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-a", false).
+		Return(&user.User{ID: "user-a", OUID: "ou-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-b", false).
+		Return(&user.User{ID: "user-b", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-a").Return([]Preference{
+		{Key: "theme", Value: "dark", UpdatedBy: "admin-1"},
+		{Key: "locale", Value: "en-US"},
+	}, nil, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-b").Return([]Preference{
+		{Key: "theme", Value: "light", UpdatedBy: "admin-2"},
+		{Key: "timezone", Value: "UTC"},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.DiffPreferences(context.Background(), "user-a", "user-b", false)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal([]string{"locale"}, resp.OnlyInA)
+	suite.Equal([]string{"timezone"}, resp.OnlyInB)
+	suite.Require().Len(resp.Differing, 1)
+	suite.Equal("theme", resp.Differing[0].Key)
+	suite.Equal("dark", resp.Differing[0].ValueA)
+	suite.Equal("light", resp.Differing[0].ValueB)
+	suite.Equal("admin-1", resp.Differing[0].UpdatedByA)
+	suite.Equal("admin-2", resp.Differing[0].UpdatedByB)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDiffPreferences_Unauthorized() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-a", false).
+		Return(&user.User{ID: "user-a", OUID: "ou-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-b", false).
+		Return(&user.User{ID: "user-b", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	resp, svcErr := suite.service.DiffPreferences(context.Background(), "user-a", "user-b", false)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.ErrorUnauthorized.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDiffPreferences_UserNotFound() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-a", false).
+		Return(nil, &user.ErrorUserNotFound)
+
+	resp, svcErr := suite.service.DiffPreferences(context.Background(), "user-a", "user-b", false)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(user.ErrorUserNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDiffPreferences_RedactsSensitiveKeyByDefault() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", Sensitive: true})
+	defer delete(schemaRegistry, "secret.token")
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-a", false).
+		Return(&user.User{ID: "user-a", OUID: "ou-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-b", false).
+		Return(&user.User{ID: "user-b", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-a").Return([]Preference{
+		{Key: "secret.token", Value: "aaa"},
+	}, nil, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-b").Return([]Preference{
+		{Key: "secret.token", Value: "bbb"},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.DiffPreferences(context.Background(), "user-a", "user-b", false)
+
+	suite.Nil(svcErr)
+	suite.Require().Len(resp.Differing, 1)
+	suite.Equal(redactedPreferenceValue, resp.Differing[0].ValueA)
+	suite.Equal(redactedPreferenceValue, resp.Differing[0].ValueB)
+	suite.True(resp.Differing[0].Redacted)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDiffPreferences_RevealAuthorizedShowsSensitiveValues() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", Sensitive: true})
+	defer delete(schemaRegistry, "secret.token")
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-a", false).
+		Return(&user.User{ID: "user-a", OUID: "ou-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-b", false).
+		Return(&user.User{ID: "user-b", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-a").Return([]Preference{
+		{Key: "secret.token", Value: "aaa"},
+	}, nil, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-b").Return([]Preference{
+		{Key: "secret.token", Value: "bbb"},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.DiffPreferences(context.Background(), "user-a", "user-b", true)
+
+	suite.Nil(svcErr)
+	suite.Require().Len(resp.Differing, 1)
+	suite.Equal("aaa", resp.Differing[0].ValueA)
+	suite.Equal("bbb", resp.Differing[0].ValueB)
+	suite.False(resp.Differing[0].Redacted)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDiffPreferences_RevealUnauthorized() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-a", false).
+		Return(&user.User{ID: "user-a", OUID: "ou-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-b", false).
+		Return(&user.User{ID: "user-b", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, security.ActionReadUser, mock.Anything).Return(true, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, security.ActionUpdateUser, mock.Anything).Return(false, nil)
+
+	resp, svcErr := suite.service.DiffPreferences(context.Background(), "user-a", "user-b", true)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.ErrorUnauthorized.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckAdminAccess_ReadAllowed() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).
+		Return(&user.User{ID: "user-1", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, security.ActionReadUser, mock.Anything).Return(true, nil)
+
+	svcErr := suite.service.CheckAdminAccess(context.Background(), "user-1", false)
+
+	suite.Nil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckAdminAccess_ReadUnauthorized() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).
+		Return(&user.User{ID: "user-1", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, security.ActionReadUser, mock.Anything).Return(false, nil)
+
+	svcErr := suite.service.CheckAdminAccess(context.Background(), "user-1", false)
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.ErrorUnauthorized.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckAdminAccess_WriteRequiresUpdateUser() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).
+		Return(&user.User{ID: "user-1", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, security.ActionReadUser, mock.Anything).Return(true, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, security.ActionUpdateUser, mock.Anything).Return(false, nil)
+
+	svcErr := suite.service.CheckAdminAccess(context.Background(), "user-1", true)
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.ErrorUnauthorized.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckAdminAccess_WriteAllowed() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).
+		Return(&user.User{ID: "user-1", OUID: "ou-1"}, nil)
+	suite.mockAuthzService.On("IsActionAllowed", mock.Anything, security.ActionUpdateUser, mock.Anything).Return(true, nil)
+
+	svcErr := suite.service.CheckAdminAccess(context.Background(), "user-1", true)
+
+	suite.Nil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckAdminAccess_UserNotFound() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).
+		Return(nil, &user.ErrorUserNotFound)
+
+	svcErr := suite.service.CheckAdminAccess(context.Background(), "user-1", false)
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(user.ErrorUserNotFound.Code, svcErr.Code)
+}
+
+// ExecuteBatch Tests
+func (suite *PreferenceServiceTestSuite) TestExecuteBatch_Success() {
+	req := &BatchRequest{Operations: []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}}
+	suite.mockStore.On("ExecuteBatch", "user-1", req.Operations, false).
+		Return([]BatchOperationResult{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}, nil)
+
+	resp, svcErr := suite.service.ExecuteBatch(context.Background(), "user-1", req)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Results, 1)
+	suite.Equal("theme", resp.Results[0].Key)
+}
+
+func (suite *PreferenceServiceTestSuite) TestExecuteBatch_EmptyOperations() {
+	resp, svcErr := suite.service.ExecuteBatch(context.Background(), "user-1", &BatchRequest{})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorEmptyBatchOperations.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestExecuteBatch_StoreError() {
+	req := &BatchRequest{Operations: []BatchOperation{{Op: BatchOperationGet, Key: "theme"}}}
+	suite.mockStore.On("ExecuteBatch", "user-1", req.Operations, false).Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ExecuteBatch(context.Background(), "user-1", req)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestExecuteBatch_ValidationLimitExceeded() {
+	config.GetServerRuntime().Config.Preference.Batch.MaxTotalValueBytes = 5
+	defer func() { config.GetServerRuntime().Config.Preference.Batch.MaxTotalValueBytes = 0 }()
+	req := &BatchRequest{Operations: []BatchOperation{
+		{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+		{Op: BatchOperationSet, Key: "locale", Value: "en-US"},
+	}}
+
+	resp, svcErr := suite.service.ExecuteBatch(context.Background(), "user-1", req)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorBatchValidationLimitExceeded.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestExecuteBatch_ValidationLimitOnlyCountsSetValues() {
+	config.GetServerRuntime().Config.Preference.Batch.MaxTotalValueBytes = 4
+	defer func() { config.GetServerRuntime().Config.Preference.Batch.MaxTotalValueBytes = 0 }()
+	req := &BatchRequest{Operations: []BatchOperation{
+		{Op: BatchOperationGet, Key: "theme"},
+		{Op: BatchOperationDelete, Key: "locale"},
+		{Op: BatchOperationSet, Key: "ui.mode", Value: "dark"},
+	}}
+	suite.mockStore.On("ExecuteBatch", "user-1", req.Operations, false).
+		Return([]BatchOperationResult{{Op: BatchOperationSet, Key: "ui.mode", Value: "dark"}}, nil)
+
+	resp, svcErr := suite.service.ExecuteBatch(context.Background(), "user-1", req)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+}
+
+// MergePreferences Tests
+func (suite *PreferenceServiceTestSuite) TestMergePreferences_Success() {
+	expectedOps := []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}
+	suite.mockStore.On("ExecuteBatch", "user-1", expectedOps, true).
+		Return([]BatchOperationResult{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}, nil)
+
+	resp, svcErr := suite.service.MergePreferences(context.Background(), "user-1", map[string]string{"theme": "dark"}, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Results, 1)
+	suite.Equal("theme", resp.Results[0].Key)
+}
+
+func (suite *PreferenceServiceTestSuite) TestMergePreferences_DoesNotDeleteUntouchedKeys() {
+	expectedOps := []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}
+	suite.mockStore.On("ExecuteBatch", "user-1", expectedOps, true).
+		Return([]BatchOperationResult{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}, nil)
+
+	_, svcErr := suite.service.MergePreferences(context.Background(), "user-1", map[string]string{"theme": "dark"}, "")
+
+	suite.Nil(svcErr)
+	suite.mockStore.AssertNotCalled(suite.T(), "GetPreferencesByUserID", mock.Anything)
+}
+
+func (suite *PreferenceServiceTestSuite) TestMergePreferences_EmptyMap() {
+	resp, svcErr := suite.service.MergePreferences(context.Background(), "user-1", map[string]string{}, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorEmptyBatchOperations.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestMergePreferences_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.MergePreferences(context.Background(), "user-1", map[string]string{"theme": "dark"}, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// ReplaceAllPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestReplaceAllPreferences_RemovesStaleKey() {
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{Key: "theme", Value: "light"},
+		{Key: "locale", Value: "en-US"},
+	}, nil, nil)
+	expectedOps := []BatchOperation{
+		{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+		{Op: BatchOperationDelete, Key: "locale"},
+	}
+	suite.mockStore.On("ExecuteBatch", "user-1", expectedOps, true).
+		Return([]BatchOperationResult{
+			{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+			{Op: BatchOperationDelete, Key: "locale"},
+		}, nil)
+
+	resp, svcErr := suite.service.ReplaceAllPreferences(context.Background(), "user-1", map[string]string{"theme": "dark"}, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Results, 2)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReplaceAllPreferences_NoStaleKeys() {
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{Key: "theme", Value: "light"},
+	}, nil, nil)
+	expectedOps := []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}
+	suite.mockStore.On("ExecuteBatch", "user-1", expectedOps, true).
+		Return([]BatchOperationResult{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}, nil)
+
+	resp, svcErr := suite.service.ReplaceAllPreferences(context.Background(), "user-1", map[string]string{"theme": "dark"}, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Results, 1)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReplaceAllPreferences_LoadCurrentError() {
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return(nil, nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ReplaceAllPreferences(context.Background(), "user-1", map[string]string{"theme": "dark"}, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReplaceAllPreferences_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.ReplaceAllPreferences(context.Background(), "user-1", map[string]string{"theme": "dark"}, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// ImportPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_Success() {
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{{Key: "theme", Value: "dark"}}, Conflict: ImportConflictOverwrite}
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries, ImportConflictOverwrite).
+		Return(&ImportPreferencesResponse{Overwritten: 1}, nil)
+
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(1, resp.Overwritten)
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_DefaultsConflictToSkip() {
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{{Key: "theme", Value: "dark"}}}
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries, ImportConflictSkip).
+		Return(&ImportPreferencesResponse{Inserted: 1}, nil)
+
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(1, resp.Inserted)
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_EmptyEntries() {
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", &ImportPreferencesRequest{}, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorEmptyImportEntries.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_InvalidConflictStrategy() {
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{{Key: "theme", Value: "dark"}}, Conflict: "bogus"}
+
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidImportConflictStrategy.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_StoreError() {
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{{Key: "theme", Value: "dark"}}}
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries, ImportConflictSkip).
+		Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_ChunksWhenConfigured() {
+	config.GetServerRuntime().Config.Preference.Import.ChunkSize = 2
+	defer func() { config.GetServerRuntime().Config.Preference.Import = config.PreferenceImportConfig{} }()
+
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{
+		{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "c", Value: "3"},
+	}}
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries[0:2], ImportConflictSkip).
+		Return(&ImportPreferencesResponse{Inserted: 2}, nil)
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries[2:3], ImportConflictSkip).
+		Return(&ImportPreferencesResponse{Inserted: 1}, nil)
+
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(3, resp.Inserted)
+	suite.Require().Len(resp.ChunkResults, 2)
+	suite.Equal(ImportChunkResult{Index: 0, Inserted: 2}, resp.ChunkResults[0])
+	suite.Equal(ImportChunkResult{Index: 1, Inserted: 1}, resp.ChunkResults[1])
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_AtomicDisablesChunking() {
+	config.GetServerRuntime().Config.Preference.Import.ChunkSize = 2
+	config.GetServerRuntime().Config.Preference.Import.Atomic = true
+	defer func() { config.GetServerRuntime().Config.Preference.Import = config.PreferenceImportConfig{} }()
+
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{
+		{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "c", Value: "3"},
+	}}
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries, ImportConflictSkip).
+		Return(&ImportPreferencesResponse{Inserted: 3}, nil)
+
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(3, resp.Inserted)
+	suite.Empty(resp.ChunkResults)
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_ChunkFailureStopsWithPartialResults() {
+	config.GetServerRuntime().Config.Preference.Import.ChunkSize = 2
+	defer func() { config.GetServerRuntime().Config.Preference.Import = config.PreferenceImportConfig{} }()
+
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{
+		{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "c", Value: "3"},
+	}}
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries[0:2], ImportConflictSkip).
+		Return(&ImportPreferencesResponse{Inserted: 2}, nil)
+	suite.mockStore.On("ImportPreferences", "user-1", req.Entries[2:3], ImportConflictSkip).
+		Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(2, resp.Inserted)
+	suite.Require().Len(resp.ChunkResults, 2)
+	suite.Equal(ImportChunkResult{Index: 0, Inserted: 2}, resp.ChunkResults[0])
+	suite.Equal("db error", resp.ChunkResults[1].Error)
+}
+
+func (suite *PreferenceServiceTestSuite) TestImportPreferences_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	req := &ImportPreferencesRequest{Entries: []ImportEntry{{Key: "theme", Value: "dark"}}}
+	resp, svcErr := suite.service.ImportPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCompareAndSwapPreferences_Applied() {
+	req := &CompareAndSwapRequest{Entries: []CompareAndSwapEntry{
+		{Key: "theme", Expected: "light", Value: "dark"},
+		{Key: "locale", Expected: "en", Value: "fr"},
+	}}
+	suite.mockStore.On("CompareAndSwapPreferences", "user-1", req.Entries).
+		Return(true, nil, nil)
+
+	resp, svcErr := suite.service.CompareAndSwapPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.True(resp.Applied)
+	suite.Empty(resp.MismatchedKeys)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCompareAndSwapPreferences_Mismatch() {
+	req := &CompareAndSwapRequest{Entries: []CompareAndSwapEntry{
+		{Key: "theme", Expected: "light", Value: "dark"},
+		{Key: "locale", Expected: "en", Value: "fr"},
+	}}
+	suite.mockStore.On("CompareAndSwapPreferences", "user-1", req.Entries).
+		Return(false, []string{"theme"}, nil)
+
+	resp, svcErr := suite.service.CompareAndSwapPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.False(resp.Applied)
+	suite.Equal([]string{"theme"}, resp.MismatchedKeys)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCompareAndSwapPreferences_EmptyEntries() {
+	resp, svcErr := suite.service.CompareAndSwapPreferences(context.Background(), "user-1", &CompareAndSwapRequest{}, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorEmptyCASEntries.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCompareAndSwapPreferences_InvalidKey() {
+	req := &CompareAndSwapRequest{Entries: []CompareAndSwapEntry{{Key: "", Expected: "", Value: "dark"}}}
+
+	resp, svcErr := suite.service.CompareAndSwapPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCompareAndSwapPreferences_InvalidValue() {
+	req := &CompareAndSwapRequest{
+		Entries: []CompareAndSwapEntry{{Key: "theme", Expected: "light", Value: strings.Repeat("a", maxPreferenceValueLength+1)}},
+	}
+
+	resp, svcErr := suite.service.CompareAndSwapPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCompareAndSwapPreferences_StoreError() {
+	req := &CompareAndSwapRequest{Entries: []CompareAndSwapEntry{{Key: "theme", Expected: "light", Value: "dark"}}}
+	suite.mockStore.On("CompareAndSwapPreferences", "user-1", req.Entries).
+		Return(false, nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.CompareAndSwapPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCompareAndSwapPreferences_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	req := &CompareAndSwapRequest{Entries: []CompareAndSwapEntry{{Key: "theme", Expected: "light", Value: "dark"}}}
+	resp, svcErr := suite.service.CompareAndSwapPreferences(context.Background(), "user-1", req, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// ConditionalSetPreference Tests
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_AppliedNoExistingValue() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "max.seen.notification.id").Return(nil, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "max.seen.notification.id", "5", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "max.seen.notification.id", "5", ConditionSetIfGreater, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.True(resp.Applied)
+	suite.Equal("5", resp.CurrentValue)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_GreaterApplied() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "max.seen.notification.id").Return(&Preference{
+		UserID: "user-1", Key: "max.seen.notification.id", Value: "5",
+	}, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "max.seen.notification.id", "9", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "max.seen.notification.id", "9", ConditionSetIfGreater, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.True(resp.Applied)
+	suite.Equal("9", resp.CurrentValue)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_GreaterNotApplied() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "max.seen.notification.id").Return(&Preference{
+		UserID: "user-1", Key: "max.seen.notification.id", Value: "9",
+	}, nil)
+
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "max.seen.notification.id", "5", ConditionSetIfGreater, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.False(resp.Applied)
+	suite.Equal("9", resp.CurrentValue)
+	suite.mockStore.AssertNotCalled(suite.T(), "UpsertPreference", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_LessApplied() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "min.balance").Return(&Preference{
+		UserID: "user-1", Key: "min.balance", Value: "9",
+	}, nil)
+	suite.mockStore.On("UpsertPreference", "user-1", "min.balance", "5", "user-1", false, 0, (*time.Time)(nil)).Return(nil)
+
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "min.balance", "5", ConditionSetIfLess, "")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.True(resp.Applied)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_InvalidCondition() {
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "theme", "5", ConditionalSetCondition("bogus"), "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidConditionalSetCondition.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_NonNumericNewValue() {
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "theme", "dark", ConditionSetIfGreater, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorNonNumericPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_NonNumericStoredValue() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "dark",
+	}, nil)
+
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "theme", "5", ConditionSetIfGreater, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorNonNumericPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_InvalidKey() {
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "", "5", ConditionSetIfGreater, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_StoreError() {
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "max.seen.notification.id").Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ConditionalSetPreference(context.Background(), "user-1", "max.seen.notification.id", "5", ConditionSetIfGreater, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestConditionalSetPreference_BlockedByLockHeldByAnotherCaller() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 0)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.ConditionalSetPreference(
+		context.Background(), "user-1", "max.seen.notification.id", "5", ConditionSetIfGreater, "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// SeedDefaultForAllUsers Tests
+func (suite *PreferenceServiceTestSuite) TestSeedDefaultForAllUsers_Success() {
+	suite.mockStore.On("GetDistinctUserIDs", seedDefaultUserBatchSize, 0).
+		Return([]string{"user-1", "user-2"}, nil)
+	suite.mockStore.On("InsertPreferenceIfAbsent", "user-1", "theme", "dark", "admin-1").Return(true, nil)
+	suite.mockStore.On("InsertPreferenceIfAbsent", "user-2", "theme", "dark", "admin-1").Return(false, nil)
+
+	resp, svcErr := suite.service.SeedDefaultForAllUsers(context.Background(), "admin-1", "theme", "dark")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(2, resp.UsersProcessed)
+	suite.Equal(1, resp.Seeded)
+	suite.Equal(1, resp.Skipped)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSeedDefaultForAllUsers_Pagination() {
+	firstBatch := make([]string, seedDefaultUserBatchSize)
+	for i := range firstBatch {
+		firstBatch[i] = fmt.Sprintf("user-%d", i)
+	}
+	suite.mockStore.On("GetDistinctUserIDs", seedDefaultUserBatchSize, 0).Return(firstBatch, nil)
+	suite.mockStore.On("GetDistinctUserIDs", seedDefaultUserBatchSize, seedDefaultUserBatchSize).
+		Return([]string{"user-last"}, nil)
+	suite.mockStore.On("InsertPreferenceIfAbsent", mock.Anything, "theme", "dark", "admin-1").Return(true, nil)
+
+	resp, svcErr := suite.service.SeedDefaultForAllUsers(context.Background(), "admin-1", "theme", "dark")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(seedDefaultUserBatchSize+1, resp.UsersProcessed)
+	suite.Equal(seedDefaultUserBatchSize+1, resp.Seeded)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSeedDefaultForAllUsers_InvalidKey() {
+	resp, svcErr := suite.service.SeedDefaultForAllUsers(context.Background(), "admin-1", "", "dark")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSeedDefaultForAllUsers_InvalidValue() {
+	resp, svcErr := suite.service.SeedDefaultForAllUsers(
+		context.Background(), "admin-1", "theme", strings.Repeat("a", maxPreferenceValueLength+1))
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSeedDefaultForAllUsers_ListUsersError() {
+	suite.mockStore.On("GetDistinctUserIDs", seedDefaultUserBatchSize, 0).Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.SeedDefaultForAllUsers(context.Background(), "admin-1", "theme", "dark")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// ListPreferenceSchemas Tests
+func (suite *PreferenceServiceTestSuite) TestListPreferenceSchemas_Empty() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = make(map[string]PreferenceSchemaEntry)
+	schemaRegistryMu.Unlock()
+
+	resp, svcErr := suite.service.ListPreferenceSchemas(context.Background())
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Empty(resp.Schemas)
+}
+
+func (suite *PreferenceServiceTestSuite) TestListPreferenceSchemas_Registered() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = make(map[string]PreferenceSchemaEntry)
+	schemaRegistryMu.Unlock()
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "ui.theme", Type: "enum", AllowedValues: []string{"light", "dark"}})
+
+	resp, svcErr := suite.service.ListPreferenceSchemas(context.Background())
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Schemas, 1)
+	suite.Equal("ui.theme", resp.Schemas[0].Key)
+}
+
+func (suite *PreferenceServiceTestSuite) TestSeedDefaultForAllUsers_InsertError() {
+	suite.mockStore.On("GetDistinctUserIDs", seedDefaultUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockStore.On("InsertPreferenceIfAbsent", "user-1", "theme", "dark", "admin-1").
+		Return(false, errors.New("db error"))
+
+	resp, svcErr := suite.service.SeedDefaultForAllUsers(context.Background(), "admin-1", "theme", "dark")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// BulkRenamePreferences Tests
+func (suite *PreferenceServiceTestSuite) TestBulkRenamePreferences_Success() {
+	suite.mockStore.On("GetDistinctUserIDs", bulkRenameUserBatchSize, 0).
+		Return([]string{"user-1", "user-2"}, nil)
+	suite.mockStore.On("RenameKeysByPrefix", "user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip).
+		Return(2, 0, nil)
+	suite.mockStore.On("RenameKeysByPrefix", "user-2", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip).
+		Return(0, 1, nil)
+
+	resp, svcErr := suite.service.BulkRenamePreferences(
+		context.Background(), "admin-1", &BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.new."})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(2, resp.UsersProcessed)
+	suite.Equal(2, resp.Renamed)
+	suite.Equal(1, resp.Skipped)
+}
+
+func (suite *PreferenceServiceTestSuite) TestBulkRenamePreferences_DefaultsConflictToSkip() {
+	suite.mockStore.On("GetDistinctUserIDs", bulkRenameUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockStore.On("RenameKeysByPrefix", "user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip).
+		Return(1, 0, nil)
+
+	resp, svcErr := suite.service.BulkRenamePreferences(
+		context.Background(), "admin-1", &BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.new."})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(1, resp.Renamed)
+}
+
+func (suite *PreferenceServiceTestSuite) TestBulkRenamePreferences_EmptyFromPrefix() {
+	resp, svcErr := suite.service.BulkRenamePreferences(
+		context.Background(), "admin-1", &BulkRenameRequest{FromPrefix: "", ToPrefix: "ui.new."})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidRenamePrefix.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestBulkRenamePreferences_SamePrefix() {
+	resp, svcErr := suite.service.BulkRenamePreferences(
+		context.Background(), "admin-1", &BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.old."})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidRenamePrefix.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestBulkRenamePreferences_InvalidConflictStrategy() {
+	resp, svcErr := suite.service.BulkRenamePreferences(
+		context.Background(), "admin-1",
+		&BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.new.", Conflict: ImportConflictStrategy("bogus")})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidImportConflictStrategy.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestBulkRenamePreferences_ListUsersError() {
+	suite.mockStore.On("GetDistinctUserIDs", bulkRenameUserBatchSize, 0).Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.BulkRenamePreferences(
+		context.Background(), "admin-1", &BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.new."})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestBulkRenamePreferences_StoreError() {
+	suite.mockStore.On("GetDistinctUserIDs", bulkRenameUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockStore.On("RenameKeysByPrefix", "user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip).
+		Return(0, 0, errors.New("db error"))
+
+	resp, svcErr := suite.service.BulkRenamePreferences(
+		context.Background(), "admin-1", &BulkRenameRequest{FromPrefix: "ui.old.", ToPrefix: "ui.new."})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// ReconcileUserPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_NoSchemaRegistered() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Empty(resp.Issues)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_ExtraKeyRemoved() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "theme"})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "theme")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark"},
+		{UserID: "user-1", Key: "stale.key", Value: "x"},
+	}, nil, nil)
+	suite.mockStore.On("DeletePreference", "user-1", "stale.key").Return(nil)
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Issues, 1)
+	suite.Equal("stale.key", resp.Issues[0].Key)
+	suite.Equal(ReconciliationIssueExtraKey, resp.Issues[0].Type)
+	suite.Equal(ReconciliationActionRemoved, resp.Issues[0].Action)
+	suite.mockStore.AssertCalled(suite.T(), "DeletePreference", "user-1", "stale.key")
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_ExtraKeyEnforcedNotRemoved() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "theme"})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "theme")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "stale.key", Value: "x", Enforced: true},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Issues, 1)
+	suite.Equal(ReconciliationActionNone, resp.Issues[0].Action)
+	suite.mockStore.AssertNotCalled(suite.T(), "DeletePreference", mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_MissingRequiredKeySeeded() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "locale", Required: true, Default: "en-US"})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "locale")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{}, nil, nil)
+	suite.mockStore.On("InsertPreferenceIfAbsent", "user-1", "locale", "en-US", "admin-1").Return(true, nil)
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Issues, 1)
+	suite.Equal("locale", resp.Issues[0].Key)
+	suite.Equal(ReconciliationIssueMissingRequiredKey, resp.Issues[0].Type)
+	suite.Equal(ReconciliationActionSeeded, resp.Issues[0].Action)
+	suite.Equal("en-US", resp.Issues[0].Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_DryRunMakesNoChanges() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "locale", Required: true, Default: "en-US"})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "locale")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "stale.key", Value: "x"},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{DryRun: true})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.True(resp.DryRun)
+	suite.Require().Len(resp.Issues, 2)
+	for _, issue := range resp.Issues {
+		suite.Equal(ReconciliationActionNone, issue.Action)
+	}
+	suite.mockStore.AssertNotCalled(suite.T(), "DeletePreference", mock.Anything, mock.Anything)
+	suite.mockStore.AssertNotCalled(suite.T(), "InsertPreferenceIfAbsent", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_InvalidValue() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "theme", AllowedValues: []string{"light", "dark"}})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "theme")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "blue"},
+	}, nil, nil)
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Issues, 1)
+	suite.Equal(ReconciliationIssueInvalidValue, resp.Issues[0].Type)
+	suite.Equal("blue", resp.Issues[0].Value)
+	suite.Equal(ReconciliationActionNone, resp.Issues[0].Action)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_UserNotFound() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(nil, &user.ErrorUserNotFound)
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(user.ErrorUserNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileUserPreferences_StoreError() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "theme"})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "theme")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return(nil, nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ReconcileUserPreferences(
+		context.Background(), "admin-1", "user-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// ReconcileAllUserPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestReconcileAllUserPreferences_Aggregates() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "locale", Required: true, Default: "en-US"})
+	defer func() {
+		schemaRegistryMu.Lock()
+		delete(schemaRegistry, "locale")
+		schemaRegistryMu.Unlock()
+	}()
+
+	suite.mockStore.On("GetDistinctUserIDs", schemaReconcileUserBatchSize, 0).
+		Return([]string{"user-1", "user-2"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{}, nil, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-2").Return([]Preference{
+		{UserID: "user-2", Key: "locale", Value: "fr-FR"},
+	}, nil, nil)
+	suite.mockStore.On("InsertPreferenceIfAbsent", "user-1", "locale", "en-US", "admin-1").Return(true, nil)
+
+	resp, svcErr := suite.service.ReconcileAllUserPreferences(
+		context.Background(), "admin-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(2, resp.UsersProcessed)
+	suite.Equal(1, resp.UsersWithIssues)
+	suite.Equal(1, resp.MissingRequiredKeysFound)
+	suite.Equal(1, resp.MissingRequiredKeysSeeded)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReconcileAllUserPreferences_ListUsersError() {
+	suite.mockStore.On("GetDistinctUserIDs", schemaReconcileUserBatchSize, 0).Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.ReconcileAllUserPreferences(
+		context.Background(), "admin-1", &ReconcilePreferencesRequest{})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// GetPreferenceUsage Tests
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceUsage_Success() {
+	suite.mockStore.On("GetPreferenceUsage", "user-1").
+		Return(&PreferenceUsage{Count: 12, TotalBytes: 4096}, nil)
+
+	usage, svcErr := suite.service.GetPreferenceUsage(context.Background(), "user-1")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(usage)
+	suite.Equal(int64(12), usage.Count)
+	suite.Equal(int64(4096), usage.TotalBytes)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceUsage_StoreError() {
+	suite.mockStore.On("GetPreferenceUsage", "user-1").Return(nil, errors.New("db error"))
+
+	usage, svcErr := suite.service.GetPreferenceUsage(context.Background(), "user-1")
+
+	suite.Nil(usage)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// CheckPreferenceKeysExist Tests
+
+func (suite *PreferenceServiceTestSuite) TestCheckPreferenceKeysExist_Success() {
+	suite.mockStore.On("CheckPreferenceKeysExist", "user-1", []string{"ui.theme", "ui.locale"}).
+		Return(map[string]bool{"ui.theme": true, "ui.locale": false}, nil)
+
+	resp, svcErr := suite.service.CheckPreferenceKeysExist(
+		context.Background(), "user-1", &ExistsRequest{Keys: []string{"ui.theme", "ui.locale"}})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(map[string]bool{"ui.theme": true, "ui.locale": false}, resp.Exists)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckPreferenceKeysExist_EmptyKeys() {
+	resp, svcErr := suite.service.CheckPreferenceKeysExist(context.Background(), "user-1", &ExistsRequest{Keys: nil})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorEmptyExistsKeys.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckPreferenceKeysExist_TooManyKeys() {
+	keys := make([]string, maxExistsCheckKeys+1)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	resp, svcErr := suite.service.CheckPreferenceKeysExist(context.Background(), "user-1", &ExistsRequest{Keys: keys})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorTooManyExistsKeys.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckPreferenceKeysExist_InvalidKey() {
+	resp, svcErr := suite.service.CheckPreferenceKeysExist(
+		context.Background(), "user-1", &ExistsRequest{Keys: []string{""}})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCheckPreferenceKeysExist_StoreError() {
+	suite.mockStore.On("CheckPreferenceKeysExist", "user-1", []string{"ui.theme"}).
+		Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.CheckPreferenceKeysExist(
+		context.Background(), "user-1", &ExistsRequest{Keys: []string{"ui.theme"}})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// GetPreferencesByKeys Tests
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferencesByKeys_MixOfExistingAndMissing() {
+	now := time.Now()
+	suite.mockStore.On("GetPreferencesByKeys", "user-1", []string{"ui.theme", "ui.locale", "missing"}).
+		Return([]Preference{
+			{UserID: "user-1", Key: "ui.theme", Value: "dark", UpdatedAt: now},
+		}, nil, nil)
+
+	resp, svcErr := suite.service.GetPreferencesByKeys(
+		context.Background(), "user-1", &PreferenceQueryRequest{Keys: []string{"ui.theme", "ui.locale", "missing"}})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Require().Len(resp.Preferences, 1)
+	suite.Equal("ui.theme", resp.Preferences[0].Key)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferencesByKeys_EmptyKeys() {
+	resp, svcErr := suite.service.GetPreferencesByKeys(context.Background(), "user-1", &PreferenceQueryRequest{Keys: nil})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorEmptyQueryKeys.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferencesByKeys_TooManyKeys() {
+	keys := make([]string, maxExistsCheckKeys+1)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	resp, svcErr := suite.service.GetPreferencesByKeys(context.Background(), "user-1", &PreferenceQueryRequest{Keys: keys})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorTooManyQueryKeys.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferencesByKeys_InvalidKey() {
+	resp, svcErr := suite.service.GetPreferencesByKeys(
+		context.Background(), "user-1", &PreferenceQueryRequest{Keys: []string{""}})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferencesByKeys_StoreError() {
+	suite.mockStore.On("GetPreferencesByKeys", "user-1", []string{"ui.theme"}).
+		Return(nil, nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.GetPreferencesByKeys(
+		context.Background(), "user-1", &PreferenceQueryRequest{Keys: []string{"ui.theme"}})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// StreamAllPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestStreamAllPreferences_Success() {
+	now := time.Now()
+	suite.mockStore.On("GetDistinctUserIDs", exportUserBatchSize, 0).Return([]string{"user-1", "user-2"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now},
+	}, nil, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-2").Return([]Preference{
+		{UserID: "user-2", Key: "theme", Value: "light", UpdatedAt: now},
+	}, nil, nil)
+
+	var emitted []PreferenceExportEntry
+	svcErr := suite.service.StreamAllPreferences(context.Background(), func(entry PreferenceExportEntry) error {
+		emitted = append(emitted, entry)
+		return nil
+	})
+
+	suite.Nil(svcErr)
+	suite.Require().Len(emitted, 2)
+	suite.Equal("user-1", emitted[0].UserID)
+	suite.Equal("dark", emitted[0].Value)
+	suite.Equal("user-2", emitted[1].UserID)
+	suite.Equal("light", emitted[1].Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestStreamAllPreferences_Pagination() {
+	firstBatch := make([]string, exportUserBatchSize)
+	for i := range firstBatch {
+		firstBatch[i] = fmt.Sprintf("user-%d", i)
+	}
+	suite.mockStore.On("GetDistinctUserIDs", exportUserBatchSize, 0).Return(firstBatch, nil)
+	suite.mockStore.On("GetDistinctUserIDs", exportUserBatchSize, exportUserBatchSize).
+		Return([]string{"user-last"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", mock.Anything).Return([]Preference{
+		{UserID: "user-x", Key: "theme", Value: "dark", UpdatedAt: time.Now()},
+	}, nil, nil)
+
+	count := 0
+	svcErr := suite.service.StreamAllPreferences(context.Background(), func(entry PreferenceExportEntry) error {
+		count++
+		return nil
+	})
+
+	suite.Nil(svcErr)
+	suite.Equal(exportUserBatchSize+1, count)
+}
+
+func (suite *PreferenceServiceTestSuite) TestStreamAllPreferences_ListUsersError() {
+	suite.mockStore.On("GetDistinctUserIDs", exportUserBatchSize, 0).Return(nil, errors.New("db error"))
+
+	svcErr := suite.service.StreamAllPreferences(context.Background(), func(entry PreferenceExportEntry) error {
+		return nil
+	})
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestStreamAllPreferences_GetPreferencesError() {
+	suite.mockStore.On("GetDistinctUserIDs", exportUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return(nil, nil, errors.New("db error"))
+
+	svcErr := suite.service.StreamAllPreferences(context.Background(), func(entry PreferenceExportEntry) error {
+		return nil
+	})
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestStreamAllPreferences_EmitError() {
+	suite.mockStore.On("GetDistinctUserIDs", exportUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: time.Now()},
+	}, nil, nil)
+
+	svcErr := suite.service.StreamAllPreferences(context.Background(), func(entry PreferenceExportEntry) error {
+		return errors.New("write failed")
+	})
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// ExportUserPreferences Tests
+func (suite *PreferenceServiceTestSuite) TestExportUserPreferences_Success() {
+	now := time.Now()
+	config.GetServerRuntime().Config.Server.Identifier = "deployment-1"
+	defer func() { config.GetServerRuntime().Config.Server.Identifier = "" }()
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return([]Preference{
+		{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now},
+		{UserID: "user-1", Key: "locale", Value: "en-US", UpdatedAt: now},
+	}, nil, nil)
+
+	doc, svcErr := suite.service.ExportUserPreferences(context.Background(), "user-1")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(doc)
+	suite.Equal("deployment-1", doc.DeploymentID)
+	suite.Require().Len(doc.Preferences, 2)
+	suite.Equal("theme", doc.Preferences[0].Key)
+	suite.Equal("dark", doc.Preferences[0].Value)
+	suite.Equal("locale", doc.Preferences[1].Key)
+	suite.Equal("en-US", doc.Preferences[1].Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestExportUserPreferences_StoreError() {
+	suite.mockStore.On("GetPreferencesByUserID", "user-1").Return(nil, nil, errors.New("db error"))
+
+	doc, svcErr := suite.service.ExportUserPreferences(context.Background(), "user-1")
+
+	suite.Nil(doc)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+// GetPreferenceReadCountAggregate Tests
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceReadCountAggregate_DisabledReturnsEmpty() {
+	resp, svcErr := suite.service.GetPreferenceReadCountAggregate(context.Background())
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.False(resp.Enabled)
+	suite.Empty(resp.Entries)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceReadCountAggregate_EnabledReturnsEntries() {
+	config.GetServerRuntime().Config.Preference.ReadCount.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.ReadCount.Enabled = false }()
+	suite.mockStore.On("GetReadCountAggregate", mock.Anything).Return([]PreferenceReadCount{{Key: "theme", TotalReads: 5}}, nil)
+
+	resp, svcErr := suite.service.GetPreferenceReadCountAggregate(context.Background())
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.True(resp.Enabled)
+	suite.Equal([]ReadCountAggregateEntry{{Key: "theme", TotalReads: 5}}, resp.Entries)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceReadCountAggregate_StoreError() {
+	config.GetServerRuntime().Config.Preference.ReadCount.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.ReadCount.Enabled = false }()
+	suite.mockStore.On("GetReadCountAggregate", mock.Anything).Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.GetPreferenceReadCountAggregate(context.Background())
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceReadCountAggregate_QueryTimeout() {
+	config.GetServerRuntime().Config.Preference.ReadCount.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.ReadCount.Enabled = false }()
+	suite.mockStore.On("GetReadCountAggregate", mock.Anything).
+		Return(nil, fmt.Errorf("query timed out: %w", errQueryTimeout))
+
+	resp, svcErr := suite.service.GetPreferenceReadCountAggregate(context.Background())
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorQueryTimeout.Code, svcErr.Code)
+}
+
+// GetQueryDebugInfo Tests
+func (suite *PreferenceServiceTestSuite) TestGetQueryDebugInfo_DisabledReturnsError() {
+	resp, svcErr := suite.service.GetQueryDebugInfo(context.Background())
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorDebugEndpointDisabled.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetQueryDebugInfo_EnabledReturnsResolvedQueries() {
+	config.GetServerRuntime().Config.Preference.Debug.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.Debug.Enabled = false }()
+	config.GetServerRuntime().Config.Database.User.Type = "sqlite"
+	defer func() { config.GetServerRuntime().Config.Database.User.Type = "" }()
+
+	resp, svcErr := suite.service.GetQueryDebugInfo(context.Background())
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("sqlite", resp.DBType)
+	suite.Equal(len(debugQueries), len(resp.Queries))
+	for i := 1; i < len(resp.Queries); i++ {
+		suite.True(resp.Queries[i-1].ID < resp.Queries[i].ID)
+	}
+}
+
+// Read-count recording Tests
+func (suite *PreferenceServiceTestSuite) TestGetPreference_RecordsReadWhenEnabled() {
+	config.GetServerRuntime().Config.Preference.ReadCount.Enabled = true
+	defer func() { config.GetServerRuntime().Config.Preference.ReadCount.Enabled = false }()
+	now := time.Now()
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").
+		Return(&Preference{UserID: "user-1", Key: "theme", Value: "dark", UpdatedAt: now}, nil)
+
+	_, svcErr := suite.service.GetPreference(context.Background(), "user-1", "theme", false, "")
+
+	suite.Nil(svcErr)
+	suite.Equal(int64(1), suite.service.readCounts.buffer[readCountKey{userID: "user-1", key: "theme"}])
+}
+
+// validatePreferenceKey Tests
+func (suite *PreferenceServiceTestSuite) TestValidatePreferenceKey_RejectsInvisibleCharacters() {
+	keys := map[string]string{
+		"leading BOM":               "\uFEFFtheme",
+		"embedded zero-width space": "ui\u200B.theme",
+		"zero-width non-joiner":     "ui.the\u200Cme",
+		"zero-width joiner":         "ui.the\u200Dme",
+		"left-to-right mark":        "ui.\u200Etheme",
+		"right-to-left mark":        "ui.\u200Ftheme",
+		"left-to-right embedding":   "ui.\u202Atheme",
+		"right-to-left override":    "ui.\u202Etheme",
+		"left-to-right isolate":     "ui.\u2066theme",
+		"pop directional isolate":   "ui.\u2069theme",
+	}
+
+	for name, key := range keys {
+		suite.Run(name, func() {
+			svcErr := validatePreferenceKey(key)
+
+			suite.Require().NotNil(svcErr)
+			suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+		})
+	}
+}
+
+func (suite *PreferenceServiceTestSuite) TestValidatePreferenceKey_AllowsCleanKey() {
+	svcErr := validatePreferenceKey("ui.theme")
+
+	suite.Nil(svcErr)
+}
+
+// AcquirePreferenceLock Tests
+func (suite *PreferenceServiceTestSuite) TestAcquirePreferenceLock_Success() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+
+	resp, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+	defer releasePreferenceLock("user-1", resp.Token)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("user-1", resp.UserID)
+	suite.NotEmpty(resp.Token)
+}
+
+func (suite *PreferenceServiceTestSuite) TestAcquirePreferenceLock_UserNotFound() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(nil, &user.ErrorUserNotFound)
+
+	resp, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(user.ErrorUserNotFound.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestAcquirePreferenceLock_ConflictWithDifferentHolder() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+
+	first, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", first.Token)
+
+	resp, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-2", "user-1", 60)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestAcquirePreferenceLock_SameHolderRenews() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+
+	first, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", first.Token)
+
+	second, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 120)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(second)
+	suite.True(second.ExpiresAt.After(first.ExpiresAt) || second.ExpiresAt.Equal(first.ExpiresAt))
+}
+
+// RefreshPreferenceLock Tests
+func (suite *PreferenceServiceTestSuite) TestRefreshPreferenceLock_Success() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.RefreshPreferenceLock(context.Background(), "admin-1", "user-1", lock.Token, 120)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(lock.Token, resp.Token)
+}
+
+func (suite *PreferenceServiceTestSuite) TestRefreshPreferenceLock_WrongToken() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	resp, svcErr := suite.service.RefreshPreferenceLock(context.Background(), "admin-1", "user-1", "wrong-token", 120)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestRefreshPreferenceLock_NoActiveLock() {
+	resp, svcErr := suite.service.RefreshPreferenceLock(context.Background(), "admin-1", "user-no-lock", "some-token", 120)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+// ReleasePreferenceLock Tests
+func (suite *PreferenceServiceTestSuite) TestReleasePreferenceLock_Success() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+	suite.Require().Nil(svcErr)
+
+	svcErr = suite.service.ReleasePreferenceLock(context.Background(), "admin-1", "user-1", lock.Token)
+
+	suite.Nil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReleasePreferenceLock_WrongToken() {
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	lock, svcErr := suite.service.AcquirePreferenceLock(context.Background(), "admin-1", "user-1", 60)
+	suite.Require().Nil(svcErr)
+	defer releasePreferenceLock("user-1", lock.Token)
+
+	svcErr = suite.service.ReleasePreferenceLock(context.Background(), "admin-1", "user-1", "wrong-token")
+
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorPreferenceLockHeld.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestReleasePreferenceLock_NoOpWhenAlreadyGone() {
+	svcErr := suite.service.ReleasePreferenceLock(context.Background(), "admin-1", "user-no-lock", "some-token")
+
+	suite.Nil(svcErr)
+}
+
+// DeletePreferencesByValue Tests
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByValue_Success() {
+	suite.mockStore.On("GetDistinctUserIDs", deleteByValueUserBatchSize, 0).
+		Return([]string{"user-1", "user-2"}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(&Preference{
+		UserID: "user-1", Key: "theme", Value: "broken",
+	}, nil)
+	suite.mockStore.On("DeletePreference", "user-1", "theme").Return(nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-2", "theme").Return(&Preference{
+		UserID: "user-2", Key: "theme", Value: "dark",
+	}, nil)
+
+	resp, svcErr := suite.service.DeletePreferencesByValue(
+		context.Background(), "admin-1", &DeletePreferencesByValueRequest{Key: "theme", Value: "broken", Confirm: true})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(2, resp.UsersProcessed)
+	suite.Equal(1, resp.Deleted)
+	suite.mockStore.AssertNotCalled(suite.T(), "DeletePreference", "user-2", "theme")
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByValue_NoMatches() {
+	suite.mockStore.On("GetDistinctUserIDs", deleteByValueUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, nil)
+
+	resp, svcErr := suite.service.DeletePreferencesByValue(
+		context.Background(), "admin-1", &DeletePreferencesByValueRequest{Key: "theme", Value: "broken", Confirm: true})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(0, resp.Deleted)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByValue_MissingKey() {
+	resp, svcErr := suite.service.DeletePreferencesByValue(
+		context.Background(), "admin-1", &DeletePreferencesByValueRequest{Value: "broken", Confirm: true})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorMissingDeleteByValueKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByValue_NotConfirmed() {
+	resp, svcErr := suite.service.DeletePreferencesByValue(
+		context.Background(), "admin-1", &DeletePreferencesByValueRequest{Key: "theme", Value: "broken"})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorDeleteByValueNotConfirmed.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByValue_ListUsersError() {
+	suite.mockStore.On("GetDistinctUserIDs", deleteByValueUserBatchSize, 0).Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.DeletePreferencesByValue(
+		context.Background(), "admin-1", &DeletePreferencesByValueRequest{Key: "theme", Value: "broken", Confirm: true})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestDeletePreferencesByValue_StoreError() {
+	suite.mockStore.On("GetDistinctUserIDs", deleteByValueUserBatchSize, 0).Return([]string{"user-1"}, nil)
+	suite.mockStore.On("GetPreferenceByKey", "user-1", "theme").Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.DeletePreferencesByValue(
+		context.Background(), "admin-1", &DeletePreferencesByValueRequest{Key: "theme", Value: "broken", Confirm: true})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCopyPreferences_Success() {
+	suite.mockUserService.On("GetUser", mock.Anything, "template-1", false).Return(&user.User{ID: "template-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("CopyPreferences", "template-1", "user-1", "admin-1", []string{"ui.theme"}, "").
+		Return([]string{"ui.theme"}, nil, nil)
+
+	resp, svcErr := suite.service.CopyPreferences(context.Background(), "admin-1",
+		&CopyPreferencesRequest{SourceUserID: "template-1", TargetUserID: "user-1", Keys: []string{"ui.theme"}})
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal([]string{"ui.theme"}, resp.Copied)
+	suite.Empty(resp.Skipped)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCopyPreferences_MissingSourceUserID() {
+	resp, svcErr := suite.service.CopyPreferences(context.Background(), "admin-1",
+		&CopyPreferencesRequest{TargetUserID: "user-1"})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidCopyRequest.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCopyPreferences_SameSourceAndTargetUser() {
+	resp, svcErr := suite.service.CopyPreferences(context.Background(), "admin-1",
+		&CopyPreferencesRequest{SourceUserID: "user-1", TargetUserID: "user-1"})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidCopyRequest.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCopyPreferences_SourceUserNotFound() {
+	suite.mockUserService.On("GetUser", mock.Anything, "template-1", false).
+		Return(nil, &user.ErrorUserNotFound)
+
+	resp, svcErr := suite.service.CopyPreferences(context.Background(), "admin-1",
+		&CopyPreferencesRequest{SourceUserID: "template-1", TargetUserID: "user-1"})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestCopyPreferences_StoreError() {
+	suite.mockUserService.On("GetUser", mock.Anything, "template-1", false).Return(&user.User{ID: "template-1"}, nil)
+	suite.mockUserService.On("GetUser", mock.Anything, "user-1", false).Return(&user.User{ID: "user-1"}, nil)
+	suite.mockStore.On("CopyPreferences", "template-1", "user-1", "admin-1", []string(nil), "").
+		Return(nil, nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.CopyPreferences(context.Background(), "admin-1",
+		&CopyPreferencesRequest{SourceUserID: "template-1", TargetUserID: "user-1"})
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceHistory_Success() {
+	now := time.Now()
+	light, dark := "light", "dark"
+	suite.mockStore.On("GetPreferenceHistory", "user-1", "theme").Return([]PreferenceHistoryEntry{
+		{Value: &light, ChangedAt: now.Add(-time.Hour)},
+		{Value: &dark, ChangedAt: now},
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreferenceHistory(context.Background(), "user-1", "theme")
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal("theme", resp.Key)
+	suite.Require().Len(resp.Entries, 2)
+	suite.Equal("light", *resp.Entries[0].Value)
+	suite.Equal("dark", *resp.Entries[1].Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceHistory_InvalidKey() {
+	resp, svcErr := suite.service.GetPreferenceHistory(context.Background(), "user-1", "")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferenceHistory_StoreError() {
+	suite.mockStore.On("GetPreferenceHistory", "user-1", "theme").Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.GetPreferenceHistory(context.Background(), "user-1", "theme")
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferencesAsOf_Success() {
+	asOf := time.Now()
+	suite.mockStore.On("GetPreferencesAsOf", "user-1", asOf).Return([]PreferenceAsOfEntry{
+		{Key: "theme", Value: "dark"},
+		{Key: "locale", Value: "en-US"},
+	}, nil)
+
+	resp, svcErr := suite.service.GetPreferencesAsOf(context.Background(), "user-1", asOf)
+
+	suite.Nil(svcErr)
+	suite.Require().NotNil(resp)
+	suite.Equal(asOf, resp.Timestamp)
+	suite.Require().Len(resp.Preferences, 2)
+	suite.Equal("theme", resp.Preferences[0].Key)
+	suite.Equal("dark", resp.Preferences[0].Value)
+}
+
+func (suite *PreferenceServiceTestSuite) TestGetPreferencesAsOf_StoreError() {
+	asOf := time.Now()
+	suite.mockStore.On("GetPreferencesAsOf", "user-1", asOf).Return(nil, errors.New("db error"))
+
+	resp, svcErr := suite.service.GetPreferencesAsOf(context.Background(), "user-1", asOf)
+
+	suite.Nil(resp)
+	suite.Require().NotNil(svcErr)
+	suite.Equal(tidcommon.InternalServerError.Code, svcErr.Code)
+}
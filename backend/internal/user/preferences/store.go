@@ -0,0 +1,1626 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	dbmodel "github.com/thunder-id/thunderid/internal/system/database/model"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/internal/system/database/provider"
+	"github.com/thunder-id/thunderid/internal/system/error/apierror"
+	"github.com/thunder-id/thunderid/internal/system/kmprovider"
+	tidcommon "github.com/thunder-id/thunderid/pkg/thunderidengine/common"
+)
+
+// errDBReadOnly is wrapped into a write's returned error when the database rejected it because
+// it is currently read-only, e.g. a standby briefly serving writes during a failover. Service
+// methods check for it with errors.Is to surface ErrorServiceUnavailable instead of a generic
+// internal server error.
+var errDBReadOnly = errors.New("database is currently read-only")
+
+// errQueryTimeout is wrapped into a query's returned error when it was cancelled by a
+// per-query-ID timeout configured via PreferenceQueryTimeoutConfig (see withQueryTimeout).
+// Service methods check for it with errors.Is to surface ErrorQueryTimeout instead of a generic
+// internal server error.
+var errQueryTimeout = errors.New("query exceeded its configured timeout")
+
+// isReadOnlyDBError reports whether err indicates the database rejected a write because it is
+// currently read-only. Detected via the Postgres "read_only_sql_transaction" SQLSTATE (25006),
+// with a message-based fallback for drivers (e.g. SQLite's "attempt to write a readonly
+// database") that don't surface a typed error.
+func isReadOnlyDBError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "25006" {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "read-only transaction") || strings.Contains(msg, "readonly database")
+}
+
+// wrapWriteError wraps a write failure with op for logging context, additionally wrapping
+// errDBReadOnly when err indicates the database is currently read-only.
+func wrapWriteError(op string, err error) error {
+	if isReadOnlyDBError(err) {
+		return fmt.Errorf("%s: %w: %w", op, errDBReadOnly, err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// preferenceStoreInterface defines the interface for preference store operations.
+type preferenceStoreInterface interface {
+	// GetPreferencesByUserID returns the preferences that could be decoded, plus the keys of any
+	// rows that failed to decode, so a single corrupt value does not fail the whole call.
+	GetPreferencesByUserID(userID string) ([]Preference, []string, error)
+	// GetPreferencesByUserIDPaginated is GetPreferencesByUserID limited to a single page (ordered
+	// PREFERENCE_KEY ASC, same as GetPreferencesByUserID), plus the user's total preference count
+	// across all pages, for users with too many keys to load at once. Unlike GetPreferencesByUserID,
+	// neither the page nor the count excludes rows whose EXPIRES_AT has passed.
+	GetPreferencesByUserIDPaginated(userID string, limit, offset int) ([]Preference, []string, int64, error)
+	// GetPreferencesByUserIDSorted is GetPreferencesByUserID with a caller-chosen ORDER BY: sortField
+	// ("key" or "updated_at", see preferenceSortColumns) and sortOrder ("asc" or "desc", see
+	// preferenceSortDirections) are each mapped through an allowlist before being interpolated into
+	// the query (see buildGetPreferencesByUserIDSortedQuery), so an unrecognized value can never
+	// reach it as a raw SQL fragment; either falls back to its default (key, asc) instead.
+	GetPreferencesByUserIDSorted(userID, sortField, sortOrder string) ([]Preference, []string, error)
+	GetPreferenceByKey(userID, key string) (*Preference, error)
+	// UpsertPreference creates or updates a preference, as described on preferenceStore's
+	// implementation. expiresAt, if non-nil, sets EXPIRES_AT to that time (see
+	// SetPreferenceRequest.TTLSeconds); nil clears any expiry a previous write on the same key set.
+	UpsertPreference(userID, key, value, updatedBy string, enforced bool, schemaVersion int, expiresAt *time.Time) error
+	// InsertPreferenceIfAbsent inserts value for userID/key only if the user has no current
+	// value for the key, and reports whether a row was inserted (true) versus left untouched
+	// because an active value already existed (false).
+	InsertPreferenceIfAbsent(userID, key, value, updatedBy string) (bool, error)
+	DeletePreference(userID, key string) error
+	// DeleteAllPreferences deletes every preference row for userID in a single statement and
+	// returns the number of rows deleted.
+	DeleteAllPreferences(userID string) (int64, error)
+	// DeletePreferencesByNamespace deletes every override whose key's namespace (see
+	// extractNamespace) is namespace, and returns the deleted keys.
+	DeletePreferencesByNamespace(userID, namespace string) ([]string, error)
+	// DeletePreferences deletes every key in keys that userID currently has a value for, in a
+	// single transaction, and returns the keys that were actually deleted. A key with no value is
+	// skipped rather than failing the rest of the batch.
+	DeletePreferences(userID string, keys []string) ([]string, error)
+	// DeletePreferencesByPrefix deletes every one of userID's preferences whose key starts with
+	// prefix (matched the same way as GetPreferencesByPrefix), in a single transaction, and
+	// returns the keys that were deleted. A prefix matching nothing returns an empty, non-nil
+	// slice rather than an error.
+	DeletePreferencesByPrefix(userID, prefix string) ([]string, error)
+	GetDistinctUserIDs(limit, offset int) ([]string, error)
+	GetDistinctNamespaces(userID string) ([]string, error)
+	// GetPreferenceUsage reports userID's current preference count and total value byte length,
+	// for the PreferenceQuotaConfig usage headers.
+	GetPreferenceUsage(userID string) (*PreferenceUsage, error)
+	// CheckPreferenceKeysExist reports, for each of keys, whether userID has a current
+	// (non-deleted) value for it. Every key in keys is present in the result, defaulting to
+	// false.
+	CheckPreferenceKeysExist(userID string, keys []string) (map[string]bool, error)
+	DeleteExpiredPreferences() (int64, error)
+	DeleteSoftDeletedPreferencesPastWindow(cutoff time.Time) (int64, error)
+	DeletePreferencesByUserID(userID string) (int64, error)
+	ExecuteBatch(userID string, ops []BatchOperation, failFast bool) ([]BatchOperationResult, error)
+	// IncrementReadCount bumps a single preference's read counter by delta. Called from the
+	// read-count flusher's periodic flush rather than on every read (see readcount.go).
+	IncrementReadCount(userID, key string, delta int64) error
+	// GetReadCountAggregate sums read counts per key across all users, for the read-count
+	// admin aggregate endpoint. Bounded by both ctx and this query's configured timeout (see
+	// PreferenceQueryTimeoutConfig); a query cancelled by the latter returns errQueryTimeout.
+	GetReadCountAggregate(ctx context.Context) ([]PreferenceReadCount, error)
+	// ImportPreferences bulk-upserts entries for userID inside a single transaction, resolving
+	// keys that already have a value according to conflict. See ImportPreferencesResponse.
+	ImportPreferences(
+		userID string, entries []ImportEntry, conflict ImportConflictStrategy,
+	) (*ImportPreferencesResponse, error)
+	// CompareAndSwapPreferences checks every entry's expected value against userID's current
+	// value for that key and, only if every entry matches, writes all of them in a single
+	// transaction. Returns the keys that did not match when the swap was not applied.
+	CompareAndSwapPreferences(userID string, entries []CompareAndSwapEntry) (applied bool, mismatchedKeys []string, err error)
+	// RenameKeysByPrefix renames every one of userID's keys that starts with fromPrefix to
+	// toPrefix plus the key's remainder after fromPrefix, inside a single transaction, resolving
+	// a target key that already has a value according to conflict. The renamed key is recorded
+	// as written by updatedBy (the caller performing the bulk rename), not the original owner.
+	// Returns the number of keys renamed and skipped.
+	RenameKeysByPrefix(
+		userID, fromPrefix, toPrefix, updatedBy string, conflict ImportConflictStrategy,
+	) (renamed int, skipped int, err error)
+	// CopyPreferences copies, inside a single transaction, sourceUserID's preferences matching
+	// keys or prefix (or every preference, if both are empty) into targetUserID, writing each one
+	// as updatedBy. A target key that already has a value is left untouched. Returns the keys
+	// actually copied and the keys skipped because the target already had a value for them.
+	CopyPreferences(
+		sourceUserID, targetUserID, updatedBy string, keys []string, prefix string,
+	) (copied []string, skipped []string, err error)
+	// GetPreferenceHistory returns userID's recorded history for key, oldest first: one entry per
+	// UpsertPreference write plus a tombstone entry (Value nil) per DeletePreference call. See
+	// queryInsertPreferenceHistory.
+	GetPreferenceHistory(userID, key string) ([]PreferenceHistoryEntry, error)
+	// GetPreferencesAsOf reconstructs userID's preference set as of timestamp from
+	// USER_PREFERENCE_HISTORY, one entry per key that had a non-deleted value at or before
+	// timestamp. See queryGetPreferencesAsOf.
+	GetPreferencesAsOf(userID string, timestamp time.Time) ([]PreferenceAsOfEntry, error)
+	// CountPreferences reports userID's current (non-deleted) preference count, for enforcing
+	// PreferenceQuotaConfig.MaxCount as a hard limit (see checkPreferenceCountQuota).
+	CountPreferences(userID string) (int64, error)
+	// GetPreferencesByPrefix retrieves a user's (non-deleted) preferences whose key starts with
+	// prefix, matched via a SQL LIKE pushed down to the database (see
+	// queryGetPreferencesByPrefix). As with GetPreferencesByUserID, a row whose value fails to
+	// decode is omitted from the returned preferences and its key reported in failedKeys.
+	GetPreferencesByPrefix(userID, prefix string) ([]Preference, []string, error)
+	// SearchPreferencesByValue retrieves a user's (non-deleted) preferences whose decoded value
+	// contains valueContains, matched in application code against each row's decoded value (see
+	// buildSearchPreferencesByValueQuery), so encrypted and compressed values are matched on
+	// their plaintext rather than missed because the stored column holds ciphertext or gzip
+	// bytes. When prefix is non-empty, it is combined with valueContains using AND semantics:
+	// only keys that both start with prefix and have a matching value are returned. As with
+	// GetPreferencesByPrefix, a row whose value fails to decode is omitted from the returned
+	// preferences and its key reported in failedKeys.
+	SearchPreferencesByValue(userID, prefix, valueContains string) ([]Preference, []string, error)
+	// GetPreferencesByKeys retrieves a user's (non-deleted) preferences among keys, via a single
+	// WHERE PREFERENCE_KEY IN (...) query (see buildGetPreferencesByKeysQuery); a key with no
+	// current value is simply absent from the result. As with GetPreferencesByPrefix, a row whose
+	// value fails to decode is omitted from the returned preferences and its key reported in
+	// failedKeys.
+	GetPreferencesByKeys(userID string, keys []string) ([]Preference, []string, error)
+}
+
+// preferenceStore is the default implementation of preferenceStoreInterface.
+type preferenceStore struct {
+	dbProvider   provider.DBProviderInterface
+	deploymentID string
+	crypto       kmprovider.ConfigCryptoProvider
+}
+
+// newPreferenceStore creates a new instance of preferenceStore. crypto is used only for keys
+// registered with storagePolicyEncrypted (see codec.go); it may be nil in deployments that
+// register no such keys.
+func newPreferenceStore(crypto kmprovider.ConfigCryptoProvider) preferenceStoreInterface {
+	return &preferenceStore{
+		dbProvider:   provider.GetDBProvider(),
+		deploymentID: config.GetServerRuntime().Config.Server.Identifier,
+		crypto:       crypto,
+	}
+}
+
+// getDBClient is a helper method to get the database client.
+func (s *preferenceStore) getDBClient() (provider.DBClientInterface, error) {
+	dbClient, err := s.dbProvider.GetUserDBClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database client: %w", err)
+	}
+	return dbClient, nil
+}
+
+// withQueryTimeout derives a context bounded by queryID's configured timeout (see
+// PreferenceQueryTimeoutConfig.PerQueryMillis), applied on top of ctx's own deadline, if any. A
+// queryID with no configured timeout, or a non-positive one, returns ctx unchanged. The returned
+// cancel func must always be called by the caller.
+func (s *preferenceStore) withQueryTimeout(ctx context.Context, queryID string) (context.Context, context.CancelFunc) {
+	timeoutMillis := config.GetServerRuntime().Config.Preference.QueryTimeout.PerQueryMillis[queryID]
+	if timeoutMillis <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(timeoutMillis)*time.Millisecond)
+}
+
+// wrapQueryTimeout wraps err with errQueryTimeout when queryCtx was cancelled by its own
+// deadline (set via withQueryTimeout) rather than by the caller's parent context, so callers can
+// distinguish a per-query timeout from the request simply being cancelled upstream.
+func wrapQueryTimeout(queryCtx context.Context, err error) error {
+	if err != nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", errQueryTimeout, err)
+	}
+	return err
+}
+
+// GetPreferencesByUserID retrieves all preferences for a user. A row whose value fails to
+// decode (e.g. a key-rotation mistake leaving it undecryptable) is omitted from the returned
+// preferences and its key is reported in failedKeys instead of failing the whole call.
+func (s *preferenceStore) GetPreferencesByUserID(userID string) ([]Preference, []string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := dbClient.Query(queryGetPreferencesByUserID, userID, s.deploymentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	prefs := make([]Preference, 0, len(results))
+	var failedKeys []string
+	for _, row := range results {
+		pref, err := s.buildPreferenceFromRow(userID, row)
+		if err != nil {
+			var decErr *decodeError
+			if errors.As(err, &decErr) {
+				failedKeys = append(failedKeys, decErr.key)
+				continue
+			}
+			return nil, nil, err
+		}
+		prefs = append(prefs, *pref)
+	}
+	return prefs, failedKeys, nil
+}
+
+// GetPreferencesByUserIDPaginated retrieves a single page of a user's preferences, plus their
+// total count across all pages. As with GetPreferencesByUserID, a row whose value fails to
+// decode is omitted from the page rather than failing the call.
+func (s *preferenceStore) GetPreferencesByUserIDPaginated(
+	userID string, limit, offset int,
+) ([]Preference, []string, int64, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	countResults, err := dbClient.Query(queryCountPreferencesByUserID, userID, s.deploymentID)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to count preferences: %w", err)
+	}
+	var totalCount int64
+	if len(countResults) > 0 {
+		totalCount = parseInt64Column(countResults[0], "preference_count")
+	}
+
+	results, err := dbClient.Query(queryGetPreferencesByUserIDPaginated, userID, s.deploymentID, limit, offset)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	prefs := make([]Preference, 0, len(results))
+	var failedKeys []string
+	for _, row := range results {
+		pref, err := s.buildPreferenceFromRow(userID, row)
+		if err != nil {
+			var decErr *decodeError
+			if errors.As(err, &decErr) {
+				failedKeys = append(failedKeys, decErr.key)
+				continue
+			}
+			return nil, nil, 0, err
+		}
+		prefs = append(prefs, *pref)
+	}
+	return prefs, failedKeys, totalCount, nil
+}
+
+// GetPreferencesByUserIDSorted is GetPreferencesByUserID with a caller-chosen ORDER BY; see
+// buildGetPreferencesByUserIDSortedQuery.
+func (s *preferenceStore) GetPreferencesByUserIDSorted(userID, sortField, sortOrder string) ([]Preference, []string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := buildGetPreferencesByUserIDSortedQuery(sortField, sortOrder)
+	results, err := dbClient.Query(query, userID, s.deploymentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get sorted preferences: %w", err)
+	}
+
+	prefs := make([]Preference, 0, len(results))
+	var failedKeys []string
+	for _, row := range results {
+		pref, err := s.buildPreferenceFromRow(userID, row)
+		if err != nil {
+			var decErr *decodeError
+			if errors.As(err, &decErr) {
+				failedKeys = append(failedKeys, decErr.key)
+				continue
+			}
+			return nil, nil, err
+		}
+		prefs = append(prefs, *pref)
+	}
+	return prefs, failedKeys, nil
+}
+
+// GetPreferenceByKey retrieves a single preference by user and key.
+func (s *preferenceStore) GetPreferenceByKey(userID, key string) (*Preference, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := dbClient.Query(queryGetPreferenceByKey, userID, key, s.deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preference: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	return s.buildPreferenceFromRow(userID, results[0])
+}
+
+// UpsertPreference creates or updates a preference, recording who wrote it, whether the write is
+// enforced, and the schema version the value was written in, then records the written value in
+// USER_PREFERENCE_HISTORY in the same transaction (see queryInsertPreferenceHistory). Both
+// statements are unconditional writes with no app-level "check if it exists, then insert or
+// update" step, so if this races with a concurrent DeletePreference call for the same key, the
+// guaranteed end state is still deterministic and driver-independent: whichever transaction's
+// commit the database orders last decides the outcome. In particular, an upsert that commits
+// after a delete always recreates the row, since ON CONFLICT then finds nothing to match and
+// falls through to a plain INSERT.
+func (s *preferenceStore) UpsertPreference(
+	userID, key, value, updatedBy string, enforced bool, schemaVersion int, expiresAt *time.Time,
+) error {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return err
+	}
+
+	encodedValue, err := s.encodePreferenceValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	var expiresAtArg interface{}
+	if expiresAt != nil {
+		expiresAtArg = *expiresAt
+	}
+
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin upsert transaction: %w", err)
+	}
+	if _, err := tx.Exec(queryUpsertPreferenceWithExpiry, userID, key, encodedValue, s.deploymentID, updatedBy, enforced,
+		normalizeSchemaVersion(schemaVersion), expiresAtArg); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to rollback upsert transaction: %w", rollbackErr)
+		}
+		return wrapWriteError("failed to upsert preference", err)
+	}
+	if _, err := tx.Exec(queryInsertPreferenceHistory, userID, key, encodedValue, s.deploymentID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to rollback upsert transaction: %w", rollbackErr)
+		}
+		return wrapWriteError("failed to record preference history", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit upsert transaction: %w", err)
+	}
+	return nil
+}
+
+// InsertPreferenceIfAbsent inserts value for userID/key only if the user has no current value
+// for the key (reviving a soft-deleted row in place if one exists), and reports whether it
+// actually inserted a row.
+func (s *preferenceStore) InsertPreferenceIfAbsent(userID, key, value, updatedBy string) (bool, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return false, err
+	}
+
+	encodedValue, err := s.encodePreferenceValue(key, value)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := dbClient.Execute(queryInsertPreferenceIfAbsent, userID, key, encodedValue, s.deploymentID, updatedBy)
+	if err != nil {
+		return false, wrapWriteError("failed to insert preference if absent", err)
+	}
+	return affected > 0, nil
+}
+
+// DeletePreference deletes a single preference for a user and records a tombstone entry (Value
+// nil) for it in USER_PREFERENCE_HISTORY in the same transaction (see
+// queryInsertPreferenceHistory). The delete itself is an unconditional hard DELETE keyed on
+// (user, key), with no notion of "don't delete if it was just recreated" — see UpsertPreference
+// for the guaranteed end state when this races with a concurrent upsert of the same key.
+func (s *preferenceStore) DeletePreference(userID, key string) error {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin delete transaction: %w", err)
+	}
+	if _, err := tx.Exec(queryDeletePreference, userID, key, s.deploymentID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to rollback delete transaction: %w", rollbackErr)
+		}
+		return wrapWriteError("failed to delete preference", err)
+	}
+	if _, err := tx.Exec(queryInsertPreferenceHistory, userID, key, nil, s.deploymentID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to rollback delete transaction: %w", rollbackErr)
+		}
+		return wrapWriteError("failed to record preference history", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit delete transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteAllPreferences deletes every preference row for userID in a single statement and returns
+// the number of rows deleted.
+func (s *preferenceStore) DeleteAllPreferences(userID string) (int64, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return 0, err
+	}
+
+	deletedCount, err := dbClient.Execute(queryDeleteAllPreferences, userID, s.deploymentID)
+	if err != nil {
+		return 0, wrapWriteError("failed to delete preferences", err)
+	}
+	return deletedCount, nil
+}
+
+// DeletePreferencesByNamespace deletes every override for userID whose key's namespace is
+// namespace, inside a single transaction, and returns the keys that were deleted. A namespace
+// with no overrides returns an empty, non-nil slice.
+func (s *preferenceStore) DeletePreferencesByNamespace(userID, namespace string) ([]string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, _, err := s.GetPreferencesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedKeys := make([]string, 0)
+	for _, pref := range preferences {
+		if extractNamespace(pref.Key) == namespace {
+			deletedKeys = append(deletedKeys, pref.Key)
+		}
+	}
+	if len(deletedKeys) == 0 {
+		return deletedKeys, nil
+	}
+
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin namespace delete transaction: %w", err)
+	}
+	for _, key := range deletedKeys {
+		if _, err := tx.Exec(queryDeletePreference, userID, key, s.deploymentID); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, fmt.Errorf("failed to rollback namespace delete transaction: %w", rollbackErr)
+			}
+			return nil, wrapWriteError("failed to delete preference during namespace delete", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit namespace delete transaction: %w", err)
+	}
+	return deletedKeys, nil
+}
+
+// DeletePreferencesByPrefix deletes every one of userID's preferences whose key starts with
+// prefix, inside a single transaction, and returns the deleted keys. It enumerates the matching
+// keys via GetPreferencesByPrefix's SQL LIKE match rather than loading the user's full preference
+// set, unlike DeletePreferencesByNamespace.
+func (s *preferenceStore) DeletePreferencesByPrefix(userID, prefix string) ([]string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, _, err := s.GetPreferencesByPrefix(userID, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedKeys := make([]string, 0, len(preferences))
+	for _, pref := range preferences {
+		deletedKeys = append(deletedKeys, pref.Key)
+	}
+	if len(deletedKeys) == 0 {
+		return deletedKeys, nil
+	}
+
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin prefix delete transaction: %w", err)
+	}
+	for _, key := range deletedKeys {
+		if _, err := tx.Exec(queryDeletePreference, userID, key, s.deploymentID); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, fmt.Errorf("failed to rollback prefix delete transaction: %w", rollbackErr)
+			}
+			return nil, wrapWriteError("failed to delete preference during prefix delete", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prefix delete transaction: %w", err)
+	}
+	return deletedKeys, nil
+}
+
+// DeletePreferences deletes every key in keys that userID currently has a value for, inside a
+// single transaction, and returns the keys that were actually deleted. A key with no value is
+// skipped rather than failing the rest of the batch.
+func (s *preferenceStore) DeletePreferences(userID string, keys []string) ([]string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	existingKeys, err := s.CheckPreferenceKeysExist(userID, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check preference key existence before bulk delete: %w", err)
+	}
+
+	deletedKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if existingKeys[key] {
+			deletedKeys = append(deletedKeys, key)
+		}
+	}
+	if len(deletedKeys) == 0 {
+		return deletedKeys, nil
+	}
+
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin bulk delete transaction: %w", err)
+	}
+	for _, key := range deletedKeys {
+		if _, err := tx.Exec(queryDeletePreference, userID, key, s.deploymentID); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, fmt.Errorf("failed to rollback bulk delete transaction: %w", rollbackErr)
+			}
+			return nil, wrapWriteError("failed to delete preference during bulk delete", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk delete transaction: %w", err)
+	}
+	return deletedKeys, nil
+}
+
+// GetDistinctUserIDs retrieves a page of distinct user IDs that have preferences.
+// Used by the reconciliation job to walk the preference table in bounded batches.
+func (s *preferenceStore) GetDistinctUserIDs(limit, offset int) ([]string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := dbClient.Query(queryGetDistinctPreferenceUserIDs, s.deploymentID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get distinct preference user IDs: %w", err)
+	}
+
+	userIDs := make([]string, 0, len(results))
+	for _, row := range results {
+		userID := parseStringColumn(row, "user_id")
+		if userID != "" {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+// GetDistinctNamespaces retrieves the distinct preference key namespaces a user currently has,
+// via a grouped query, for enforcing PreferenceNamespaceConfig.MaxPerUser.
+func (s *preferenceStore) GetDistinctNamespaces(userID string) ([]string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := dbClient.Query(queryGetDistinctNamespaces, userID, s.deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get distinct preference namespaces: %w", err)
+	}
+
+	namespaces := make([]string, 0, len(results))
+	for _, row := range results {
+		namespace := parseStringColumn(row, "namespace")
+		if namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces, nil
+}
+
+// GetPreferenceUsage reports userID's current preference count and total value byte length via
+// a grouped query, for the PreferenceQuotaConfig usage headers.
+func (s *preferenceStore) GetPreferenceUsage(userID string) (*PreferenceUsage, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := dbClient.Query(queryGetPreferenceUsage, userID, s.deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preference usage: %w", err)
+	}
+	if len(results) == 0 {
+		return &PreferenceUsage{}, nil
+	}
+
+	return &PreferenceUsage{
+		Count:      parseInt64Column(results[0], "preference_count"),
+		TotalBytes: parseInt64Column(results[0], "total_bytes"),
+	}, nil
+}
+
+// CheckPreferenceKeysExist reports, for each of keys, whether userID has a current
+// (non-deleted) value for it, via a single WHERE PREFERENCE_KEY IN (...) query selecting only
+// keys, rather than fetching and decoding every value.
+func (s *preferenceStore) CheckPreferenceKeysExist(userID string, keys []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		exists[key] = false
+	}
+
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := buildCheckPreferenceKeysExistQuery(userID, keys, s.deploymentID)
+	results, err := dbClient.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check preference key existence: %w", err)
+	}
+
+	for _, row := range results {
+		key, ok := row["preference_key"].(string)
+		if !ok {
+			continue
+		}
+		exists[key] = true
+	}
+	return exists, nil
+}
+
+// DeleteExpiredPreferences purges preferences whose expiry time has passed.
+func (s *preferenceStore) DeleteExpiredPreferences() (int64, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := dbClient.Execute(queryDeleteExpiredPreferences, s.deploymentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired preferences: %w", err)
+	}
+	return affected, nil
+}
+
+// DeleteSoftDeletedPreferencesPastWindow purges soft-deleted preferences older than cutoff.
+func (s *preferenceStore) DeleteSoftDeletedPreferencesPastWindow(cutoff time.Time) (int64, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := dbClient.Execute(queryDeleteSoftDeletedPreferencesPastWindow, s.deploymentID, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete soft-deleted preferences: %w", err)
+	}
+	return affected, nil
+}
+
+// DeletePreferencesByUserID purges all preferences belonging to a single user.
+// Used by the reconciliation job to clear preferences orphaned by user deletion.
+func (s *preferenceStore) DeletePreferencesByUserID(userID string) (int64, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := dbClient.Execute(queryDeletePreferencesByUserID, s.deploymentID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete preferences for user: %w", err)
+	}
+	return affected, nil
+}
+
+// ExecuteBatch runs an ordered list of get/set/delete operations for a user inside a single
+// database transaction, returning one result per operation in request order. A "get" observes
+// writes made earlier in the same batch even though they are not yet committed.
+//
+// When failFast is true, the first operation error (invalid key/value, unsupported op, not
+// found, or a database failure) aborts the whole batch: the transaction is rolled back and none
+// of its writes take effect, including those from operations that already succeeded. When
+// false, a failing operation is recorded on its own result and the remaining operations still
+// run and commit together with the ones that succeeded.
+func (s *preferenceStore) ExecuteBatch(
+	userID string, ops []BatchOperation, failFast bool,
+) ([]BatchOperationResult, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin batch transaction: %w", err)
+	}
+
+	results := make([]BatchOperationResult, 0, len(ops))
+	pending := make(map[string]*pendingPreference, len(ops))
+
+	for _, op := range ops {
+		result := s.executeBatchOp(tx, userID, op, pending)
+		results = append(results, result)
+		if result.Error != nil && failFast {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, fmt.Errorf("failed to rollback batch transaction: %w", rollbackErr)
+			}
+			return results, nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit batch transaction: %w", err)
+	}
+	return results, nil
+}
+
+// pendingPreference tracks an in-batch write so that a later "get" in the same batch observes
+// uncommitted writes, and a later "set" can respect an in-batch enforced flag without
+// re-reading the database. A nil value means the key was deleted earlier in the batch.
+type pendingPreference struct {
+	value    *string
+	enforced bool
+}
+
+// executeBatchOp executes a single batch operation against the open transaction and returns its
+// result. Failures are reported on the result rather than returned as an error, so the caller
+// can decide, based on failFast, whether to keep going.
+func (s *preferenceStore) executeBatchOp(
+	tx dbmodel.TxInterface, userID string, op BatchOperation, pending map[string]*pendingPreference,
+) BatchOperationResult {
+	result := BatchOperationResult{Op: op.Op, Key: op.Key}
+
+	switch op.Op {
+	case BatchOperationSet:
+		if svcErr := validatePreferenceKey(op.Key); svcErr != nil {
+			result.Error = buildBatchOperationError(svcErr)
+			return result
+		}
+		if svcErr := validatePreferenceValue(op.Value); svcErr != nil {
+			result.Error = buildBatchOperationError(svcErr)
+			return result
+		}
+		if svcErr := validateContentType(op.Value); svcErr != nil {
+			result.Error = buildBatchOperationError(svcErr)
+			return result
+		}
+		enforced, err := s.isEnforced(userID, op.Key, pending)
+		if err != nil {
+			result.Error = buildBatchOperationError(&tidcommon.InternalServerError)
+			return result
+		}
+		if enforced && !op.Enforced {
+			result.Error = buildBatchOperationError(&ErrorPreferenceEnforced)
+			return result
+		}
+		encodedValue, err := s.encodePreferenceValue(op.Key, op.Value)
+		if err != nil {
+			result.Error = buildBatchOperationError(&tidcommon.InternalServerError)
+			return result
+		}
+		if _, err := tx.Exec(
+			queryUpsertPreference, userID, op.Key, encodedValue, s.deploymentID, userID, op.Enforced,
+			normalizeSchemaVersion(op.SchemaVersion),
+		); err != nil {
+			result.Error = buildBatchOperationError(buildBatchWriteError(err))
+			return result
+		}
+		value := op.Value
+		pending[op.Key] = &pendingPreference{value: &value, enforced: op.Enforced}
+		result.Value = op.Value
+		result.Enforced = op.Enforced
+
+	case BatchOperationDelete:
+		if svcErr := validatePreferenceKey(op.Key); svcErr != nil {
+			result.Error = buildBatchOperationError(svcErr)
+			return result
+		}
+		if _, err := tx.Exec(queryDeletePreference, userID, op.Key, s.deploymentID); err != nil {
+			result.Error = buildBatchOperationError(buildBatchWriteError(err))
+			return result
+		}
+		pending[op.Key] = &pendingPreference{}
+
+	case BatchOperationGet:
+		if svcErr := validatePreferenceKey(op.Key); svcErr != nil {
+			result.Error = buildBatchOperationError(svcErr)
+			return result
+		}
+		if pv, touched := pending[op.Key]; touched {
+			if pv.value == nil {
+				result.Error = buildBatchOperationError(&ErrorPreferenceNotFound)
+				return result
+			}
+			result.Value, _ = resolveEffectiveValue(op.Key, *pv.value)
+			result.Enforced = pv.enforced
+			return result
+		}
+		pref, err := s.GetPreferenceByKey(userID, op.Key)
+		if err != nil {
+			result.Error = buildBatchOperationError(&tidcommon.InternalServerError)
+			return result
+		}
+		if pref == nil {
+			result.Error = buildBatchOperationError(&ErrorPreferenceNotFound)
+			return result
+		}
+		result.Value, _ = resolveEffectiveValue(op.Key, pref.Value)
+		result.Enforced = pref.Enforced
+
+	default:
+		result.Error = buildBatchOperationError(&ErrorInvalidBatchOperationType)
+	}
+
+	return result
+}
+
+// isEnforced reports whether a key currently carries the enforced flag, preferring the in-batch
+// pending state (so a later set in the same batch observes an earlier set's flag) and falling
+// back to the persisted value when the key has not been touched yet in this batch.
+func (s *preferenceStore) isEnforced(userID, key string, pending map[string]*pendingPreference) (bool, error) {
+	if pv, touched := pending[key]; touched {
+		return pv.enforced, nil
+	}
+	existing, err := s.GetPreferenceByKey(userID, key)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		return false, nil
+	}
+	return existing.Enforced, nil
+}
+
+// IncrementReadCount bumps a single preference's read counter by delta.
+func (s *preferenceStore) IncrementReadCount(userID, key string, delta int64) error {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = dbClient.Execute(queryIncrementReadCount, userID, key, delta, s.deploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to increment preference read count: %w", err)
+	}
+	return nil
+}
+
+// GetReadCountAggregate sums read counts per key across all users in the deployment.
+func (s *preferenceStore) GetReadCountAggregate(ctx context.Context) ([]PreferenceReadCount, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	queryCtx, cancel := s.withQueryTimeout(ctx, queryGetReadCountAggregate.GetID())
+	defer cancel()
+
+	results, err := dbClient.QueryContext(queryCtx, queryGetReadCountAggregate, s.deploymentID)
+	if err != nil {
+		return nil, wrapQueryTimeout(queryCtx, fmt.Errorf("failed to get preference read count aggregate: %w", err))
+	}
+
+	counts := make([]PreferenceReadCount, 0, len(results))
+	for _, row := range results {
+		counts = append(counts, PreferenceReadCount{
+			Key:        parseStringColumn(row, "preference_key"),
+			TotalReads: parseInt64Column(row, "total_reads"),
+		})
+	}
+	return counts, nil
+}
+
+// ImportPreferences bulk-upserts entries for userID inside a single transaction. A key with no
+// existing value is always inserted. A key that already has a value is resolved per conflict:
+// ImportConflictSkip (default) leaves it untouched, ImportConflictOverwrite always replaces it,
+// and ImportConflictNewerWins replaces it only if the imported entry's UpdatedAt is strictly
+// after the stored value's UpdatedAt. An entry with an invalid key/value is counted as failed
+// and does not abort the rest of the import.
+func (s *preferenceStore) ImportPreferences(
+	userID string, entries []ImportEntry, conflict ImportConflictStrategy,
+) (*ImportPreferencesResponse, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+
+	resp := &ImportPreferencesResponse{}
+	for _, entry := range entries {
+		if err := s.importEntry(tx, userID, entry, conflict, resp); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, fmt.Errorf("failed to rollback import transaction: %w", rollbackErr)
+			}
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import transaction: %w", err)
+	}
+	return resp, nil
+}
+
+// importEntry resolves and applies a single import entry, tallying its outcome on resp. It
+// returns an error only for a database failure, since invalid entries are recorded as Failed
+// rather than aborting the import.
+func (s *preferenceStore) importEntry(
+	tx dbmodel.TxInterface, userID string, entry ImportEntry, conflict ImportConflictStrategy, resp *ImportPreferencesResponse,
+) error {
+	if svcErr := validatePreferenceKey(entry.Key); svcErr != nil {
+		resp.Failed++
+		return nil
+	}
+	if svcErr := validatePreferenceValue(entry.Value); svcErr != nil {
+		resp.Failed++
+		return nil
+	}
+	if svcErr := validateContentType(entry.Value); svcErr != nil {
+		resp.Failed++
+		return nil
+	}
+
+	existing, err := s.GetPreferenceByKey(userID, entry.Key)
+	if err != nil {
+		return fmt.Errorf("failed to get preference during import: %w", err)
+	}
+
+	if existing != nil {
+		switch conflict {
+		case ImportConflictOverwrite:
+			// fall through to write below
+		case ImportConflictNewerWins:
+			if !entry.UpdatedAt.After(existing.UpdatedAt) {
+				resp.Skipped++
+				return nil
+			}
+		default:
+			resp.Skipped++
+			return nil
+		}
+	}
+
+	encodedValue, err := s.encodePreferenceValue(entry.Key, entry.Value)
+	if err != nil {
+		return fmt.Errorf("failed to encode preference value during import: %w", err)
+	}
+	if _, err := tx.Exec(
+		queryUpsertPreference, userID, entry.Key, encodedValue, s.deploymentID, userID, entry.Enforced,
+		normalizeSchemaVersion(entry.SchemaVersion),
+	); err != nil {
+		return wrapWriteError("failed to upsert preference during import", err)
+	}
+
+	if existing == nil {
+		resp.Inserted++
+	} else {
+		resp.Overwritten++
+	}
+	return nil
+}
+
+// CompareAndSwapPreferences checks every entry's expected value against userID's current value
+// for that key before writing anything. If any entry's expected value does not match, the swap
+// is not applied and every mismatched key is returned so the caller can re-read and retry; no
+// transaction is opened in that case. If every entry matches, all of them are written together
+// in a single transaction, mirroring ImportPreferences's read-then-write-in-tx shape.
+func (s *preferenceStore) CompareAndSwapPreferences(
+	userID string, entries []CompareAndSwapEntry,
+) (bool, []string, error) {
+	var mismatchedKeys []string
+	for _, entry := range entries {
+		existing, err := s.GetPreferenceByKey(userID, entry.Key)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to get preference during compare-and-swap: %w", err)
+		}
+		current := ""
+		if existing != nil {
+			current = existing.Value
+		}
+		if current != entry.Expected {
+			mismatchedKeys = append(mismatchedKeys, entry.Key)
+		}
+	}
+	if len(mismatchedKeys) > 0 {
+		return false, mismatchedKeys, nil
+	}
+
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return false, nil, err
+	}
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to begin compare-and-swap transaction: %w", err)
+	}
+
+	for _, entry := range entries {
+		encodedValue, err := s.encodePreferenceValue(entry.Key, entry.Value)
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return false, nil, fmt.Errorf("failed to rollback compare-and-swap transaction: %w", rollbackErr)
+			}
+			return false, nil, fmt.Errorf("failed to encode preference value during compare-and-swap: %w", err)
+		}
+		if _, err := tx.Exec(
+			queryUpsertPreference, userID, entry.Key, encodedValue, s.deploymentID, userID, false, defaultSchemaVersion,
+		); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return false, nil, fmt.Errorf("failed to rollback compare-and-swap transaction: %w", rollbackErr)
+			}
+			return false, nil, wrapWriteError("failed to upsert preference during compare-and-swap", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, nil, fmt.Errorf("failed to commit compare-and-swap transaction: %w", err)
+	}
+	return true, nil, nil
+}
+
+// RenameKeysByPrefix renames every one of userID's keys that starts with fromPrefix to toPrefix
+// plus the key's remainder after fromPrefix, inside a single transaction. A rename whose target
+// key already has a value is resolved per conflict (see ImportConflictStrategy), mirroring how
+// importEntry resolves a conflicting key; when resolution leaves the target untouched, the
+// source key and its value are left in place too, so nothing is lost.
+func (s *preferenceStore) RenameKeysByPrefix(
+	userID, fromPrefix, toPrefix, updatedBy string, conflict ImportConflictStrategy,
+) (int, int, error) {
+	preferences, _, err := s.GetPreferencesByUserID(userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	existingByKey := make(map[string]Preference, len(preferences))
+	for _, pref := range preferences {
+		existingByKey[pref.Key] = pref
+	}
+
+	type rename struct {
+		from, to      string
+		value         string
+		enforced      bool
+		schemaVersion int
+	}
+	var renames []rename
+	skipped := 0
+	for _, pref := range preferences {
+		if !strings.HasPrefix(pref.Key, fromPrefix) {
+			continue
+		}
+		toKey := toPrefix + strings.TrimPrefix(pref.Key, fromPrefix)
+		if toKey == pref.Key {
+			skipped++
+			continue
+		}
+		if target, exists := existingByKey[toKey]; exists {
+			switch conflict {
+			case ImportConflictOverwrite:
+				// fall through to rename below
+			case ImportConflictNewerWins:
+				if !pref.UpdatedAt.After(target.UpdatedAt) {
+					skipped++
+					continue
+				}
+			default:
+				skipped++
+				continue
+			}
+		}
+		renames = append(renames, rename{
+			from: pref.Key, to: toKey, value: pref.Value, enforced: pref.Enforced, schemaVersion: pref.SchemaVersion,
+		})
+	}
+	if len(renames) == 0 {
+		return 0, skipped, nil
+	}
+
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return 0, 0, err
+	}
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin bulk-rename transaction: %w", err)
+	}
+
+	for _, r := range renames {
+		encodedValue, err := s.encodePreferenceValue(r.to, r.value)
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return 0, 0, fmt.Errorf("failed to rollback bulk-rename transaction: %w", rollbackErr)
+			}
+			return 0, 0, fmt.Errorf("failed to encode preference value during bulk-rename: %w", err)
+		}
+		if _, err := tx.Exec(
+			queryUpsertPreference, userID, r.to, encodedValue, s.deploymentID, updatedBy, r.enforced,
+			normalizeSchemaVersion(r.schemaVersion),
+		); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return 0, 0, fmt.Errorf("failed to rollback bulk-rename transaction: %w", rollbackErr)
+			}
+			return 0, 0, wrapWriteError("failed to upsert renamed preference during bulk-rename", err)
+		}
+		if _, err := tx.Exec(queryDeletePreference, userID, r.from, s.deploymentID); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return 0, 0, fmt.Errorf("failed to rollback bulk-rename transaction: %w", rollbackErr)
+			}
+			return 0, 0, wrapWriteError("failed to delete renamed preference during bulk-rename", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit bulk-rename transaction: %w", err)
+	}
+	return len(renames), skipped, nil
+}
+
+// CopyPreferences copies sourceUserID's preferences matching keys or prefix into targetUserID;
+// see preferenceStoreInterface.CopyPreferences.
+func (s *preferenceStore) CopyPreferences(
+	sourceUserID, targetUserID, updatedBy string, keys []string, prefix string,
+) ([]string, []string, error) {
+	sourcePrefs, _, err := s.GetPreferencesByUserID(sourceUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keySet := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		keySet[key] = true
+	}
+	copyAll := len(keySet) == 0 && prefix == ""
+
+	var selected []Preference
+	for _, pref := range sourcePrefs {
+		if copyAll || keySet[pref.Key] || (prefix != "" && strings.HasPrefix(pref.Key, prefix)) {
+			selected = append(selected, pref)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil, nil
+	}
+
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := dbClient.BeginTx()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin copy-preferences transaction: %w", err)
+	}
+
+	var copied, skippedKeys []string
+	for _, pref := range selected {
+		encodedValue, err := s.encodePreferenceValue(pref.Key, pref.Value)
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, nil, fmt.Errorf("failed to rollback copy-preferences transaction: %w", rollbackErr)
+			}
+			return nil, nil, fmt.Errorf("failed to encode preference value during copy: %w", err)
+		}
+		result, err := tx.Exec(queryInsertPreferenceIfAbsent, targetUserID, pref.Key, encodedValue, s.deploymentID, updatedBy)
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, nil, fmt.Errorf("failed to rollback copy-preferences transaction: %w", rollbackErr)
+			}
+			return nil, nil, wrapWriteError("failed to copy preference", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, nil, fmt.Errorf("failed to rollback copy-preferences transaction: %w", rollbackErr)
+			}
+			return nil, nil, fmt.Errorf("failed to read copy-preferences result: %w", err)
+		}
+		if affected > 0 {
+			copied = append(copied, pref.Key)
+		} else {
+			skippedKeys = append(skippedKeys, pref.Key)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit copy-preferences transaction: %w", err)
+	}
+	return copied, skippedKeys, nil
+}
+
+// GetPreferenceHistory retrieves userID's recorded history for key, oldest first.
+func (s *preferenceStore) GetPreferenceHistory(userID, key string) ([]PreferenceHistoryEntry, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := dbClient.Query(queryGetPreferenceHistory, userID, key, s.deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preference history: %w", err)
+	}
+
+	entries := make([]PreferenceHistoryEntry, 0, len(results))
+	for _, row := range results {
+		entry, err := s.buildPreferenceHistoryEntry(row)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// GetPreferencesAsOf reconstructs userID's preference set as of timestamp from
+// USER_PREFERENCE_HISTORY.
+func (s *preferenceStore) GetPreferencesAsOf(userID string, timestamp time.Time) ([]PreferenceAsOfEntry, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := dbClient.Query(queryGetPreferencesAsOf, userID, s.deploymentID, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences as of timestamp: %w", err)
+	}
+
+	entries := make([]PreferenceAsOfEntry, 0, len(results))
+	for _, row := range results {
+		entry, err := s.buildPreferenceAsOfEntry(row)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// CountPreferences reports userID's current (non-deleted) preference count, reusing the same
+// query GetPreferencesByUserIDPaginated uses for its total_count.
+func (s *preferenceStore) CountPreferences(userID string) (int64, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return 0, err
+	}
+
+	results, err := dbClient.Query(queryCountPreferencesByUserID, userID, s.deploymentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count preferences: %w", err)
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return parseInt64Column(results[0], "preference_count"), nil
+}
+
+// GetPreferencesByPrefix retrieves a user's preferences whose key starts with prefix. A row
+// whose value fails to decode is omitted from the returned preferences and its key is reported
+// in failedKeys instead of failing the whole call, mirroring GetPreferencesByUserID.
+func (s *preferenceStore) GetPreferencesByPrefix(userID, prefix string) ([]Preference, []string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	likePattern := escapeLikePrefix(prefix) + "%"
+	results, err := dbClient.Query(queryGetPreferencesByPrefix, userID, s.deploymentID, likePattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get preferences by prefix: %w", err)
+	}
+
+	prefs := make([]Preference, 0, len(results))
+	var failedKeys []string
+	for _, row := range results {
+		pref, err := s.buildPreferenceFromRow(userID, row)
+		if err != nil {
+			var decErr *decodeError
+			if errors.As(err, &decErr) {
+				failedKeys = append(failedKeys, decErr.key)
+				continue
+			}
+			return nil, nil, err
+		}
+		prefs = append(prefs, *pref)
+	}
+	return prefs, failedKeys, nil
+}
+
+// SearchPreferencesByValue retrieves a user's (non-deleted) preferences whose decoded value
+// contains valueContains, optionally AND-ed with a key prefix; see
+// buildSearchPreferencesByValueQuery. The match is applied to each row's decoded value in
+// application code, not pushed down as a SQL LIKE on the stored column, since that column holds
+// ciphertext or gzip bytes for encrypted/compressed keys (see codec.go) rather than the plaintext
+// valueContains is expressed in. A row whose value fails to decode is omitted from the returned
+// preferences and its key reported in failedKeys instead of failing the whole call, mirroring
+// GetPreferencesByPrefix.
+func (s *preferenceStore) SearchPreferencesByValue(userID, prefix, valueContains string) ([]Preference, []string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query, args := buildSearchPreferencesByValueQuery(userID, prefix, s.deploymentID)
+	results, err := dbClient.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search preferences by value: %w", err)
+	}
+
+	prefs := make([]Preference, 0, len(results))
+	var failedKeys []string
+	for _, row := range results {
+		pref, err := s.buildPreferenceFromRow(userID, row)
+		if err != nil {
+			var decErr *decodeError
+			if errors.As(err, &decErr) {
+				failedKeys = append(failedKeys, decErr.key)
+				continue
+			}
+			return nil, nil, err
+		}
+		if !strings.Contains(pref.Value, valueContains) {
+			continue
+		}
+		prefs = append(prefs, *pref)
+	}
+	return prefs, failedKeys, nil
+}
+
+// GetPreferencesByKeys retrieves a user's preferences among keys in a single query; a key with
+// no current value is simply absent from the result rather than an error. A row whose value
+// fails to decode is omitted from the returned preferences and its key is reported in
+// failedKeys instead of failing the whole call, mirroring GetPreferencesByPrefix.
+func (s *preferenceStore) GetPreferencesByKeys(userID string, keys []string) ([]Preference, []string, error) {
+	dbClient, err := s.getDBClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query, args := buildGetPreferencesByKeysQuery(userID, keys, s.deploymentID)
+	results, err := dbClient.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get preferences by keys: %w", err)
+	}
+
+	prefs := make([]Preference, 0, len(results))
+	var failedKeys []string
+	for _, row := range results {
+		pref, err := s.buildPreferenceFromRow(userID, row)
+		if err != nil {
+			var decErr *decodeError
+			if errors.As(err, &decErr) {
+				failedKeys = append(failedKeys, decErr.key)
+				continue
+			}
+			return nil, nil, err
+		}
+		prefs = append(prefs, *pref)
+	}
+	return prefs, failedKeys, nil
+}
+
+// buildBatchWriteError maps a write failure within a batch operation to the service-level error
+// it should report: a database-read-only error becomes ErrorServiceUnavailable, any other error
+// becomes tidcommon.InternalServerError.
+func buildBatchWriteError(err error) *tidcommon.ServiceError {
+	if isReadOnlyDBError(err) {
+		return &ErrorServiceUnavailable
+	}
+	return &tidcommon.InternalServerError
+}
+
+// buildBatchOperationError converts a service error into its API representation for embedding
+// in a per-operation batch result.
+func buildBatchOperationError(svcErr *tidcommon.ServiceError) *apierror.ErrorResponse {
+	return &apierror.ErrorResponse{
+		Code:        svcErr.Code,
+		Message:     svcErr.Error,
+		Description: svcErr.ErrorDescription,
+	}
+}
+
+// buildPreferenceFromRow constructs a Preference from a database result row.
+func (s *preferenceStore) buildPreferenceFromRow(userID string, row map[string]interface{}) (*Preference, error) {
+	key, ok := row["preference_key"].(string)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse preference_key")
+	}
+
+	rawValue, ok := row["preference_value"].(string)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse preference_value")
+	}
+	value, err := s.decodePreferenceValue(rawValue)
+	if err != nil {
+		return nil, &decodeError{key: key, err: err}
+	}
+
+	updatedBy := parseStringColumn(row, "updated_by")
+	enforced := parseBoolColumn(row, "enforced")
+	schemaVersion := normalizeSchemaVersion(int(parseInt64Column(row, "schema_version")))
+
+	createdAt, err := parseTimeColumn(row, "created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt, err := parseTimeColumn(row, "updated_at")
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := parseOptionalTimeColumn(row, "expires_at")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Preference{
+		UserID:        userID,
+		Key:           key,
+		Value:         value,
+		UpdatedBy:     updatedBy,
+		Enforced:      enforced,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+		ExpiresAt:     expiresAt,
+	}, nil
+}
+
+// buildPreferenceHistoryEntry constructs a PreferenceHistoryEntry from a USER_PREFERENCE_HISTORY
+// result row. Unlike buildPreferenceFromRow's preference_value, a NULL preference_value here is
+// meaningful (a tombstone recording a delete), so it is preserved as a nil Value rather than
+// defaulting to "".
+func (s *preferenceStore) buildPreferenceHistoryEntry(row map[string]interface{}) (PreferenceHistoryEntry, error) {
+	var value *string
+	if rawValue, ok := row["preference_value"].(string); ok {
+		decoded, err := s.decodePreferenceValue(rawValue)
+		if err != nil {
+			return PreferenceHistoryEntry{}, fmt.Errorf("failed to decode preference history value: %w", err)
+		}
+		value = &decoded
+	}
+
+	changedAt, err := parseTimeColumn(row, "changed_at")
+	if err != nil {
+		return PreferenceHistoryEntry{}, err
+	}
+
+	return PreferenceHistoryEntry{Value: value, ChangedAt: changedAt}, nil
+}
+
+// buildPreferenceAsOfEntry constructs a PreferenceAsOfEntry from a queryGetPreferencesAsOf result
+// row. Unlike buildPreferenceHistoryEntry, queryGetPreferencesAsOf already excludes tombstones, so
+// preference_value is always present here.
+func (s *preferenceStore) buildPreferenceAsOfEntry(row map[string]interface{}) (PreferenceAsOfEntry, error) {
+	key := parseStringColumn(row, "preference_key")
+
+	value, err := s.decodePreferenceValue(parseStringColumn(row, "preference_value"))
+	if err != nil {
+		return PreferenceAsOfEntry{}, fmt.Errorf("failed to decode preference as-of value: %w", err)
+	}
+
+	return PreferenceAsOfEntry{Key: key, Value: value}, nil
+}
+
+// parseStringColumn safely extracts a string from a result row, returning "" for nil.
+func parseStringColumn(row map[string]interface{}, key string) string {
+	if row[key] == nil {
+		return ""
+	}
+	if s, ok := row[key].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// parseInt64Column safely extracts an int64 from a result row, returning 0 for nil.
+func parseInt64Column(row map[string]interface{}, key string) int64 {
+	if row[key] == nil {
+		return 0
+	}
+	if i, ok := row[key].(int64); ok {
+		return i
+	}
+	return 0
+}
+
+// decodeError wraps a per-row value-decode failure with the preference key it applies to, so
+// callers that tolerate partial failures (see GetPreferencesByUserID) can identify and skip just
+// that row instead of failing the whole call.
+type decodeError struct {
+	key string
+	err error
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("failed to decode preference %q: %v", e.key, e.err)
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+// parseBoolColumn safely extracts a bool from a result row, returning false for nil.
+func parseBoolColumn(row map[string]interface{}, key string) bool {
+	if row[key] == nil {
+		return false
+	}
+	if b, ok := row[key].(bool); ok {
+		return b
+	}
+	return false
+}
+
+// parseTimeColumn parses a timestamp column into a time.Time, handling the time.Time, []byte, and
+// RFC3339 string representations different database drivers return for the same column type
+// (Postgres' driver returns time.Time directly; SQLite's returns a string or []byte).
+func parseTimeColumn(row map[string]interface{}, key string) (time.Time, error) {
+	switch v := row[key].(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		return parsed, nil
+	case []byte:
+		parsed, err := time.Parse(time.RFC3339, string(v))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		return parsed, nil
+	default:
+		return time.Time{}, fmt.Errorf("failed to parse %s", key)
+	}
+}
+
+// parseOptionalTimeColumn is parseTimeColumn for a nullable timestamp column, returning nil for a
+// NULL or absent value instead of an error.
+func parseOptionalTimeColumn(row map[string]interface{}, key string) (*time.Time, error) {
+	if row[key] == nil {
+		return nil, nil
+	}
+	parsed, err := parseTimeColumn(row, key)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,406 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"fmt"
+	"strings"
+
+	dbmodel "github.com/thunder-id/thunderid/internal/system/database/model"
+)
+
+var (
+	// queryGetPreferencesByUserID retrieves all (non-deleted, non-expired) preferences for a
+	// user; a row whose EXPIRES_AT has passed is excluded here rather than relying solely on the
+	// background reconciler's periodic DeleteExpiredPreferences sweep, so a just-expired key
+	// reads back as absent immediately instead of up to reconcileInterval late.
+	queryGetPreferencesByUserID = dbmodel.DBQuery{
+		ID: "PREF-01",
+		Query: `SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT, EXPIRES_AT ` +
+			`FROM "USER_PREFERENCE" ` +
+			`WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL ` +
+			`AND (EXPIRES_AT IS NULL OR EXPIRES_AT > NOW()) ORDER BY PREFERENCE_KEY ASC`,
+		SQLiteQuery: `SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT, EXPIRES_AT ` +
+			`FROM "USER_PREFERENCE" ` +
+			`WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL ` +
+			`AND (EXPIRES_AT IS NULL OR EXPIRES_AT > datetime('now')) ORDER BY PREFERENCE_KEY ASC`,
+	}
+
+	// queryGetPreferenceByKey retrieves a single (non-expired) preference by user and key. See
+	// queryGetPreferencesByUserID for why expiry is filtered here rather than left to the
+	// reconciler alone.
+	queryGetPreferenceByKey = dbmodel.DBQuery{
+		ID: "PREF-02",
+		Query: `SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT, EXPIRES_AT ` +
+			`FROM "USER_PREFERENCE" ` +
+			`WHERE USER_ID = $1 AND PREFERENCE_KEY = $2 AND DEPLOYMENT_ID = $3 AND DELETED_AT IS NULL ` +
+			`AND (EXPIRES_AT IS NULL OR EXPIRES_AT > NOW())`,
+		SQLiteQuery: `SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT, EXPIRES_AT ` +
+			`FROM "USER_PREFERENCE" ` +
+			`WHERE USER_ID = $1 AND PREFERENCE_KEY = $2 AND DEPLOYMENT_ID = $3 AND DELETED_AT IS NULL ` +
+			`AND (EXPIRES_AT IS NULL OR EXPIRES_AT > datetime('now'))`,
+	}
+
+	// queryUpsertPreference inserts or updates a single preference, recording who wrote it,
+	// whether the write is enforced (see PreferenceDiffEntry / BatchOperation.Enforced), and the
+	// schema version the value was written in (see BatchOperation.SchemaVersion). See
+	// preferenceStore.UpsertPreference for the guaranteed outcome when this races with a
+	// concurrent queryDeletePreference on the same key.
+	queryUpsertPreference = dbmodel.DBQuery{
+		ID: "PREF-03",
+		Query: `INSERT INTO "USER_PREFERENCE" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT) ` +
+			`VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) ` +
+			`ON CONFLICT (DEPLOYMENT_ID, USER_ID, PREFERENCE_KEY) ` +
+			`DO UPDATE SET PREFERENCE_VALUE = EXCLUDED.PREFERENCE_VALUE, UPDATED_BY = EXCLUDED.UPDATED_BY, ` +
+			`ENFORCED = EXCLUDED.ENFORCED, SCHEMA_VERSION = EXCLUDED.SCHEMA_VERSION, UPDATED_AT = NOW(), DELETED_AT = NULL`,
+		SQLiteQuery: `INSERT INTO "USER_PREFERENCE" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT) ` +
+			`VALUES ($1, $2, $3, $4, $5, $6, $7, datetime('now'), datetime('now')) ` +
+			`ON CONFLICT (DEPLOYMENT_ID, USER_ID, PREFERENCE_KEY) ` +
+			`DO UPDATE SET PREFERENCE_VALUE = excluded.PREFERENCE_VALUE, UPDATED_BY = excluded.UPDATED_BY, ` +
+			`ENFORCED = excluded.ENFORCED, SCHEMA_VERSION = excluded.SCHEMA_VERSION, UPDATED_AT = datetime('now'), DELETED_AT = NULL`,
+	}
+
+	// queryUpsertPreferenceWithExpiry is queryUpsertPreference plus an EXPIRES_AT column, used only
+	// by preferenceStore.UpsertPreference (the single-preference write path backing SetPreference
+	// and ConditionalSetPreference), not by the batch-oriented writes in ExecuteBatch,
+	// ImportPreferences, CompareAndSwapPreferences, or RenameKeysByPrefix/CopyPreferences, none of
+	// which have an expiry concept to set. A nil expiresAt argument clears any expiry a previous
+	// write on the same key set, since EXPIRES_AT is always overwritten with EXCLUDED.EXPIRES_AT
+	// rather than preserved when omitted.
+	queryUpsertPreferenceWithExpiry = dbmodel.DBQuery{
+		ID: "PREF-24",
+		Query: `INSERT INTO "USER_PREFERENCE" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, UPDATED_BY, ENFORCED, SCHEMA_VERSION, EXPIRES_AT, CREATED_AT, UPDATED_AT) ` +
+			`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) ` +
+			`ON CONFLICT (DEPLOYMENT_ID, USER_ID, PREFERENCE_KEY) ` +
+			`DO UPDATE SET PREFERENCE_VALUE = EXCLUDED.PREFERENCE_VALUE, UPDATED_BY = EXCLUDED.UPDATED_BY, ` +
+			`ENFORCED = EXCLUDED.ENFORCED, SCHEMA_VERSION = EXCLUDED.SCHEMA_VERSION, EXPIRES_AT = EXCLUDED.EXPIRES_AT, ` +
+			`UPDATED_AT = NOW(), DELETED_AT = NULL`,
+		SQLiteQuery: `INSERT INTO "USER_PREFERENCE" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, UPDATED_BY, ENFORCED, SCHEMA_VERSION, EXPIRES_AT, CREATED_AT, UPDATED_AT) ` +
+			`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, datetime('now'), datetime('now')) ` +
+			`ON CONFLICT (DEPLOYMENT_ID, USER_ID, PREFERENCE_KEY) ` +
+			`DO UPDATE SET PREFERENCE_VALUE = excluded.PREFERENCE_VALUE, UPDATED_BY = excluded.UPDATED_BY, ` +
+			`ENFORCED = excluded.ENFORCED, SCHEMA_VERSION = excluded.SCHEMA_VERSION, EXPIRES_AT = excluded.EXPIRES_AT, ` +
+			`UPDATED_AT = datetime('now'), DELETED_AT = NULL`,
+	}
+
+	// queryDeletePreference deletes a single preference for a user.
+	queryDeletePreference = dbmodel.DBQuery{
+		ID:    "PREF-04",
+		Query: `DELETE FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND PREFERENCE_KEY = $2 AND DEPLOYMENT_ID = $3`,
+	}
+
+	// queryDeleteAllPreferences deletes all preferences for a user.
+	queryDeleteAllPreferences = dbmodel.DBQuery{
+		ID:    "PREF-05",
+		Query: `DELETE FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2`,
+	}
+
+	// queryGetDistinctPreferenceUserIDs retrieves the distinct user IDs that have preferences.
+	queryGetDistinctPreferenceUserIDs = dbmodel.DBQuery{
+		ID: "PREF-06",
+		Query: `SELECT DISTINCT USER_ID FROM "USER_PREFERENCE" WHERE DEPLOYMENT_ID = $1 ` +
+			`ORDER BY USER_ID LIMIT $2 OFFSET $3`,
+	}
+
+	// queryDeleteExpiredPreferences purges every preference whose expiry time has passed in one
+	// DELETE statement; the purge is not split into batches.
+	queryDeleteExpiredPreferences = dbmodel.DBQuery{
+		ID: "PREF-07",
+		Query: `DELETE FROM "USER_PREFERENCE" WHERE DEPLOYMENT_ID = $1 AND EXPIRES_AT IS NOT NULL ` +
+			`AND EXPIRES_AT < NOW()`,
+		SQLiteQuery: `DELETE FROM "USER_PREFERENCE" WHERE DEPLOYMENT_ID = $1 AND EXPIRES_AT IS NOT NULL ` +
+			`AND EXPIRES_AT < datetime('now')`,
+	}
+
+	// queryDeleteSoftDeletedPreferencesPastWindow purges soft-deleted preferences past the retention window.
+	queryDeleteSoftDeletedPreferencesPastWindow = dbmodel.DBQuery{
+		ID: "PREF-08",
+		Query: `DELETE FROM "USER_PREFERENCE" WHERE DEPLOYMENT_ID = $1 AND DELETED_AT IS NOT NULL ` +
+			`AND DELETED_AT < $2`,
+	}
+
+	// queryDeletePreferencesByUserID purges all preferences for a single (now-deleted) user.
+	queryDeletePreferencesByUserID = dbmodel.DBQuery{
+		ID:    "PREF-09",
+		Query: `DELETE FROM "USER_PREFERENCE" WHERE DEPLOYMENT_ID = $1 AND USER_ID = $2`,
+	}
+
+	// queryInsertPreferenceIfAbsent inserts a preference only if the user has no current
+	// (non-deleted) value for the key, reviving a soft-deleted row in place if one exists;
+	// it never overwrites an active value. Used to backfill a default for users who have
+	// never set the key, via PreferenceServiceInterface.SeedDefaultForAllUsers.
+	queryInsertPreferenceIfAbsent = dbmodel.DBQuery{
+		ID: "PREF-11",
+		Query: `INSERT INTO "USER_PREFERENCE" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, UPDATED_BY, ENFORCED, CREATED_AT, UPDATED_AT) ` +
+			`VALUES ($1, $2, $3, $4, $5, false, NOW(), NOW()) ` +
+			`ON CONFLICT (DEPLOYMENT_ID, USER_ID, PREFERENCE_KEY) ` +
+			`DO UPDATE SET PREFERENCE_VALUE = EXCLUDED.PREFERENCE_VALUE, UPDATED_BY = EXCLUDED.UPDATED_BY, ` +
+			`UPDATED_AT = NOW(), DELETED_AT = NULL ` +
+			`WHERE "USER_PREFERENCE".DELETED_AT IS NOT NULL`,
+		SQLiteQuery: `INSERT INTO "USER_PREFERENCE" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, UPDATED_BY, ENFORCED, CREATED_AT, UPDATED_AT) ` +
+			`VALUES ($1, $2, $3, $4, $5, false, datetime('now'), datetime('now')) ` +
+			`ON CONFLICT (DEPLOYMENT_ID, USER_ID, PREFERENCE_KEY) ` +
+			`DO UPDATE SET PREFERENCE_VALUE = excluded.PREFERENCE_VALUE, UPDATED_BY = excluded.UPDATED_BY, ` +
+			`UPDATED_AT = datetime('now'), DELETED_AT = NULL ` +
+			`WHERE "USER_PREFERENCE".DELETED_AT IS NOT NULL`,
+	}
+
+	// queryGetDistinctNamespaces retrieves a user's distinct preference key namespaces (the
+	// first "."-separated segment of each key) via a grouped query, for enforcing
+	// PreferenceNamespaceConfig.MaxPerUser.
+	queryGetDistinctNamespaces = dbmodel.DBQuery{
+		ID: "PREF-10",
+		Query: `SELECT SPLIT_PART(PREFERENCE_KEY, '.', 1) AS NAMESPACE FROM "USER_PREFERENCE" ` +
+			`WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL GROUP BY NAMESPACE`,
+		SQLiteQuery: `SELECT CASE WHEN INSTR(PREFERENCE_KEY, '.') > 0 ` +
+			`THEN SUBSTR(PREFERENCE_KEY, 1, INSTR(PREFERENCE_KEY, '.') - 1) ELSE PREFERENCE_KEY END AS NAMESPACE ` +
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL GROUP BY NAMESPACE`,
+	}
+
+	// queryIncrementReadCount bumps a single preference's read counter by delta. Called from the
+	// read-count flusher's periodic flush, once per buffered key, rather than once per read, to
+	// avoid write amplification (see PreferenceReadCountConfig).
+	queryIncrementReadCount = dbmodel.DBQuery{
+		ID: "PREF-12",
+		Query: `UPDATE "USER_PREFERENCE" SET READ_COUNT = READ_COUNT + $3 ` +
+			`WHERE USER_ID = $1 AND PREFERENCE_KEY = $2 AND DEPLOYMENT_ID = $4`,
+	}
+
+	// queryGetReadCountAggregate sums read counts per key across all users in the deployment, for
+	// the read-count admin aggregate endpoint.
+	queryGetReadCountAggregate = dbmodel.DBQuery{
+		ID: "PREF-13",
+		Query: `SELECT PREFERENCE_KEY, SUM(READ_COUNT) AS TOTAL_READS FROM "USER_PREFERENCE" ` +
+			`WHERE DEPLOYMENT_ID = $1 AND DELETED_AT IS NULL GROUP BY PREFERENCE_KEY ORDER BY TOTAL_READS DESC`,
+	}
+
+	// queryGetPreferenceUsage aggregates a user's current preference count and total value byte
+	// length via a grouped query, for the PreferenceQuotaConfig usage headers.
+	queryGetPreferenceUsage = dbmodel.DBQuery{
+		ID: "PREF-14",
+		Query: `SELECT COUNT(*) AS PREFERENCE_COUNT, COALESCE(SUM(OCTET_LENGTH(PREFERENCE_VALUE)), 0) AS TOTAL_BYTES ` +
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL`,
+		SQLiteQuery: `SELECT COUNT(*) AS PREFERENCE_COUNT, COALESCE(SUM(LENGTH(PREFERENCE_VALUE)), 0) AS TOTAL_BYTES ` +
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL`,
+	}
+
+	// queryGetPreferencesByUserIDPaginated retrieves a single page of a user's (non-deleted)
+	// preferences, ordered the same way as queryGetPreferencesByUserID so a page boundary never
+	// reshuffles keys between pages.
+	queryGetPreferencesByUserIDPaginated = dbmodel.DBQuery{
+		ID: "PREF-16",
+		Query: `SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT ` +
+			`FROM "USER_PREFERENCE" ` +
+			`WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL ORDER BY PREFERENCE_KEY ASC LIMIT $3 OFFSET $4`,
+	}
+
+	// queryCountPreferencesByUserID counts a user's (non-deleted) preferences, for
+	// GetPreferencesByUserIDPaginated's total_count.
+	queryCountPreferencesByUserID = dbmodel.DBQuery{
+		ID: "PREF-17",
+		Query: `SELECT COUNT(*) AS PREFERENCE_COUNT ` +
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL`,
+	}
+
+	// queryInsertPreferenceHistory records a preference's written value at CHANGED_AT, inside the
+	// same transaction as the USER_PREFERENCE write that superseded it (see
+	// preferenceStore.UpsertPreference and preferenceStore.DeletePreference). A NULL value
+	// records a tombstone: the key was deleted at CHANGED_AT.
+	queryInsertPreferenceHistory = dbmodel.DBQuery{
+		ID: "PREF-18",
+		Query: `INSERT INTO "USER_PREFERENCE_HISTORY" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, CHANGED_AT) ` +
+			`VALUES ($1, $2, $3, $4, NOW())`,
+		SQLiteQuery: `INSERT INTO "USER_PREFERENCE_HISTORY" ` +
+			`(USER_ID, PREFERENCE_KEY, PREFERENCE_VALUE, DEPLOYMENT_ID, CHANGED_AT) ` +
+			`VALUES ($1, $2, $3, $4, datetime('now'))`,
+	}
+
+	// queryGetPreferenceHistory retrieves a user's recorded history for a single key, oldest
+	// first.
+	queryGetPreferenceHistory = dbmodel.DBQuery{
+		ID: "PREF-19",
+		Query: `SELECT PREFERENCE_VALUE, CHANGED_AT FROM "USER_PREFERENCE_HISTORY" ` +
+			`WHERE USER_ID = $1 AND PREFERENCE_KEY = $2 AND DEPLOYMENT_ID = $3 ORDER BY CHANGED_AT ASC`,
+	}
+
+	// queryGetPreferencesByPrefix retrieves a user's (non-deleted) preferences whose key starts
+	// with a prefix, pushing the filter into SQL via LIKE rather than loading the full set into
+	// memory (contrast matchesPreferencePattern). The LIKE pattern arg is built by
+	// escapeLikePrefix, which escapes '%'/'_'/the escape character itself so they match literally
+	// rather than as SQL wildcards.
+	queryGetPreferencesByPrefix = dbmodel.DBQuery{
+		ID: "PREF-20",
+		Query: `SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT ` +
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 ` +
+			`AND PREFERENCE_KEY LIKE $3 ESCAPE '\' AND DELETED_AT IS NULL ORDER BY PREFERENCE_KEY ASC`,
+	}
+	// queryGetPreferencesAsOf reconstructs a user's preference set as of a past point in time
+	// from USER_PREFERENCE_HISTORY: for each key, the correlated subquery finds that key's latest
+	// history row at or before the given timestamp, considering tombstones (NULL PREFERENCE_VALUE)
+	// when determining which row is latest; the outer WHERE then excludes tombstones from the
+	// result, so a key that was deleted at or before the timestamp is correctly reported as absent
+	// rather than reverting to an older, since-deleted value.
+	queryGetPreferencesAsOf = dbmodel.DBQuery{
+		ID: "PREF-25",
+		Query: `SELECT h.PREFERENCE_KEY, h.PREFERENCE_VALUE FROM "USER_PREFERENCE_HISTORY" h ` +
+			`WHERE h.USER_ID = $1 AND h.DEPLOYMENT_ID = $2 AND h.CHANGED_AT <= $3 ` +
+			`AND h.CHANGED_AT = (SELECT MAX(h2.CHANGED_AT) FROM "USER_PREFERENCE_HISTORY" h2 ` +
+			`WHERE h2.USER_ID = h.USER_ID AND h2.PREFERENCE_KEY = h.PREFERENCE_KEY ` +
+			`AND h2.DEPLOYMENT_ID = h.DEPLOYMENT_ID AND h2.CHANGED_AT <= $3) ` +
+			`AND h.PREFERENCE_VALUE IS NOT NULL ORDER BY h.PREFERENCE_KEY ASC`,
+	}
+)
+
+// likeEscapeChar is the escape character used by escapeLikePrefix and queryGetPreferencesByPrefix's
+// ESCAPE clause.
+const likeEscapeChar = `\`
+
+// escapeLikePrefix escapes prefix's '%', '_', and literal backslash characters so it can be used
+// as a SQL LIKE pattern's prefix (with a trailing, unescaped '%' appended by the caller) without
+// its own characters being interpreted as LIKE wildcards.
+func escapeLikePrefix(prefix string) string {
+	replacer := strings.NewReplacer(
+		likeEscapeChar, likeEscapeChar+likeEscapeChar,
+		"%", likeEscapeChar+"%",
+		"_", likeEscapeChar+"_",
+	)
+	return replacer.Replace(prefix)
+}
+
+// buildCheckPreferenceKeysExistQuery returns the query and args selecting which of keys
+// currently have a non-deleted value for userID, for CheckPreferenceKeysExist.
+// PREFERENCE_VALUE is intentionally not selected: the caller only needs existence.
+func buildCheckPreferenceKeysExistQuery(userID string, keys []string, deploymentID string) (dbmodel.DBQuery, []interface{}) {
+	placeholders := make([]string, len(keys))
+	args := make([]interface{}, 0, len(keys)+2)
+	args = append(args, userID)
+	for i, key := range keys {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, key)
+	}
+	deploymentIDIdx := len(keys) + 2
+	args = append(args, deploymentID)
+
+	query := fmt.Sprintf(
+		`SELECT PREFERENCE_KEY FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND PREFERENCE_KEY IN (%s) `+
+			`AND DEPLOYMENT_ID = $%d AND DELETED_AT IS NULL`,
+		strings.Join(placeholders, ","), deploymentIDIdx)
+
+	return dbmodel.DBQuery{ID: "PREF-15", Query: query}, args
+}
+
+// buildSearchPreferencesByValueQuery returns the query and args selecting a user's (non-deleted)
+// preferences, optionally scoped to a key prefix, for SearchPreferencesByValue. Unlike
+// queryGetPreferencesByPrefix, it deliberately does NOT push the value match down to SQL as
+// a PREFERENCE_VALUE LIKE: that column holds ciphertext for storagePolicyEncrypted keys and gzip
+// bytes for storagePolicyCompressed/over-threshold values (see codec.go), so a LIKE against the
+// raw column can never match a substring that is only present in the decoded plaintext.
+// SearchPreferencesByValue instead decodes every candidate row and matches valueContains against
+// the decoded value in application code. The prefix LIKE pattern is still escaped by
+// escapeLikePrefix, the same escaping queryGetPreferencesByPrefix's prefix pattern uses, so a
+// literal '%'/'_' in prefix is matched literally rather than as a wildcard.
+func buildSearchPreferencesByValueQuery(userID, prefix, deploymentID string) (dbmodel.DBQuery, []interface{}) {
+	args := []interface{}{userID, deploymentID}
+
+	query := `SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT ` +
+		`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL`
+	if prefix != "" {
+		prefixPattern := escapeLikePrefix(prefix) + "%"
+		args = append(args, prefixPattern)
+		query += fmt.Sprintf(` AND PREFERENCE_KEY LIKE $%d ESCAPE '\'`, len(args))
+	}
+	query += ` ORDER BY PREFERENCE_KEY ASC`
+
+	return dbmodel.DBQuery{ID: "PREF-21", Query: query}, args
+}
+
+// preferenceSortColumns maps the allowed "sort" query parameter values (preferenceSortKey,
+// preferenceSortUpdatedAt) to the column buildGetPreferencesByUserIDSortedQuery orders by. A
+// column name is only ever selected from this allowlist, never interpolated from the caller's
+// raw value, so an unrecognized sortField can never reach the query as a raw SQL fragment.
+var preferenceSortColumns = map[string]string{
+	preferenceSortKey:       "PREFERENCE_KEY",
+	preferenceSortUpdatedAt: "UPDATED_AT",
+}
+
+// preferenceSortDirections maps the allowed "order" query parameter values (preferenceSortOrderAsc,
+// preferenceSortOrderDesc) to their SQL keyword, the same way preferenceSortColumns guards the
+// column name.
+var preferenceSortDirections = map[string]string{
+	preferenceSortOrderAsc:  "ASC",
+	preferenceSortOrderDesc: "DESC",
+}
+
+// buildGetPreferencesByUserIDSortedQuery returns the query for GetPreferencesByUserIDSorted,
+// substituting sortField and sortOrder for the column and direction of its ORDER BY clause via
+// preferenceSortColumns/preferenceSortDirections. Either falls back to its default (key, asc)
+// when not a recognized value, rather than being interpolated as-is; callers are expected to
+// have already rejected an unrecognized value with validatePreferenceSort, so this is a second,
+// defensive line of guarding against a value reaching raw SQL.
+func buildGetPreferencesByUserIDSortedQuery(sortField, sortOrder string) dbmodel.DBQuery {
+	column, ok := preferenceSortColumns[sortField]
+	if !ok {
+		column = preferenceSortColumns[preferenceSortKey]
+	}
+	direction, ok := preferenceSortDirections[sortOrder]
+	if !ok {
+		direction = preferenceSortDirections[preferenceSortOrderAsc]
+	}
+
+	return dbmodel.DBQuery{
+		ID: "PREF-22",
+		Query: fmt.Sprintf(
+			`SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT `+
+				`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL `+
+				`ORDER BY %s %s`,
+			column, direction),
+	}
+}
+
+// buildGetPreferencesByKeysQuery returns the query and args selecting a user's (non-deleted)
+// preferences whose key is one of keys, for GetPreferencesByKeys. Like
+// buildCheckPreferenceKeysExistQuery, keys are expanded into an IN (...) list of placeholders
+// rather than passed as a single array bound to PREFERENCE_KEY = ANY($N), since this package's
+// DB client issues positional placeholder queries rather than array-typed parameters.
+func buildGetPreferencesByKeysQuery(userID string, keys []string, deploymentID string) (dbmodel.DBQuery, []interface{}) {
+	placeholders := make([]string, len(keys))
+	args := make([]interface{}, 0, len(keys)+2)
+	args = append(args, userID)
+	for i, key := range keys {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, key)
+	}
+	deploymentIDIdx := len(keys) + 2
+	args = append(args, deploymentID)
+
+	query := fmt.Sprintf(
+		`SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT `+
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND PREFERENCE_KEY IN (%s) `+
+			`AND DEPLOYMENT_ID = $%d AND DELETED_AT IS NULL`,
+		strings.Join(placeholders, ","), deploymentIDIdx)
+
+	return dbmodel.DBQuery{ID: "PREF-23", Query: query}, args
+}
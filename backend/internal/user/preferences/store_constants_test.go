@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbmodel "github.com/thunder-id/thunderid/internal/system/database/model"
+)
+
+// TestGetPreferenceQueries_ExcludeExpiredRows asserts that both single-key and bulk preference
+// reads filter out rows whose EXPIRES_AT has passed, in every supported dialect, so an expired
+// preference reads back as absent immediately rather than only after the hourly reconciler sweep
+// (DeleteExpiredPreferences) next runs.
+func TestGetPreferenceQueries_ExcludeExpiredRows(t *testing.T) {
+	queries := []struct {
+		name  string
+		query dbmodel.DBQueryInterface
+	}{
+		{"queryGetPreferencesByUserID", &queryGetPreferencesByUserID},
+		{"queryGetPreferenceByKey", &queryGetPreferenceByKey},
+	}
+
+	for _, q := range queries {
+		assert.Contains(t, q.query.GetQuery("postgres"), "EXPIRES_AT IS NULL OR EXPIRES_AT > NOW()", "%s: postgres", q.name)
+		assert.Contains(t, q.query.GetQuery("sqlite"), "EXPIRES_AT IS NULL OR EXPIRES_AT > datetime('now')", "%s: sqlite", q.name)
+	}
+}
+
+// TestQueryUpsertPreferenceWithExpiry_IncludesExpiresAtColumn asserts that the expiry-aware upsert
+// query, unlike the plain queryUpsertPreference it is a variant of, both inserts and conflict-updates
+// EXPIRES_AT, in every supported dialect.
+func TestQueryUpsertPreferenceWithExpiry_IncludesExpiresAtColumn(t *testing.T) {
+	for _, dbType := range []string{"postgres", "sqlite"} {
+		query := queryUpsertPreferenceWithExpiry.GetQuery(dbType)
+		assert.Contains(t, query, "EXPIRES_AT", "dbType=%s", dbType)
+		assert.Contains(t, query, "SCHEMA_VERSION, EXPIRES_AT, CREATED_AT", "dbType=%s", dbType)
+		assert.Contains(t, strings.ToUpper(query), "EXPIRES_AT = EXCLUDED.EXPIRES_AT", "dbType=%s", dbType)
+		assert.NotContains(t, queryUpsertPreference.GetQuery(dbType), "EXPIRES_AT", "dbType=%s", dbType)
+	}
+}
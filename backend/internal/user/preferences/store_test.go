@@ -0,0 +1,2530 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+	"github.com/thunder-id/thunderid/tests/mocks/database/modelmock"
+	"github.com/thunder-id/thunderid/tests/mocks/database/providermock"
+)
+
+const testPreferenceDeploymentID = "test-deployment-id"
+
+// mockResult is a simple mock implementation of sql.Result.
+type mockResult struct{}
+
+func (m *mockResult) LastInsertId() (int64, error) { return 0, nil }
+func (m *mockResult) RowsAffected() (int64, error) { return 1, nil }
+
+var _ sql.Result = (*mockResult)(nil)
+
+type mockResultZero struct{}
+
+func (m *mockResultZero) LastInsertId() (int64, error) { return 0, nil }
+func (m *mockResultZero) RowsAffected() (int64, error) { return 0, nil }
+
+var _ sql.Result = (*mockResultZero)(nil)
+
+type PreferenceStoreTestSuite struct {
+	suite.Suite
+	mockDBProvider *providermock.DBProviderInterfaceMock
+	mockDBClient   *providermock.DBClientInterfaceMock
+	mockTx         *modelmock.TxInterfaceMock
+	store          *preferenceStore
+}
+
+func TestPreferenceStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceStoreTestSuite))
+}
+
+func (suite *PreferenceStoreTestSuite) SetupTest() {
+	suite.mockDBProvider = providermock.NewDBProviderInterfaceMock(suite.T())
+	suite.mockDBClient = providermock.NewDBClientInterfaceMock(suite.T())
+	suite.mockTx = modelmock.NewTxInterfaceMock(suite.T())
+	suite.store = &preferenceStore{
+		dbProvider:   suite.mockDBProvider,
+		deploymentID: testPreferenceDeploymentID,
+	}
+}
+
+// GetPreferencesByUserID Tests
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserID_Success() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserID("user-1")
+
+	suite.NoError(err)
+	suite.Len(prefs, 1)
+	suite.Equal("theme", prefs[0].Key)
+	suite.Equal("dark", prefs[0].Value)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserID_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserID("user-1")
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserID_PartialDecodeFailure() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+			{"preference_key": "locale", "preference_value": "gz1:not-valid-base64!!", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserID("user-1")
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("theme", prefs[0].Key)
+	suite.Equal([]string{"locale"}, failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserID_MalformedRowStillFailsCall() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{{"preference_value": "dark"}}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserID("user-1")
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+}
+
+// GetPreferencesByUserIDPaginated Tests
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDPaginated_Success() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{{"preference_count": int64(2)}}, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserIDPaginated, "user-1", testPreferenceDeploymentID, 1, 0).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, totalCount, err := suite.store.GetPreferencesByUserIDPaginated("user-1", 1, 0)
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("theme", prefs[0].Key)
+	suite.Empty(failedKeys)
+	suite.Equal(int64(2), totalCount)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDPaginated_OutOfRangeOffsetReturnsEmptyList() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{{"preference_count": int64(2)}}, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserIDPaginated, "user-1", testPreferenceDeploymentID, 50, 1000).
+		Return([]map[string]interface{}{}, nil)
+
+	prefs, failedKeys, totalCount, err := suite.store.GetPreferencesByUserIDPaginated("user-1", 50, 1000)
+
+	suite.NoError(err)
+	suite.Empty(prefs)
+	suite.Empty(failedKeys)
+	suite.Equal(int64(2), totalCount)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDPaginated_CountQueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	prefs, failedKeys, totalCount, err := suite.store.GetPreferencesByUserIDPaginated("user-1", 50, 0)
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+	suite.Zero(totalCount)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDPaginated_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{{"preference_count": int64(2)}}, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserIDPaginated, "user-1", testPreferenceDeploymentID, 50, 0).
+		Return(nil, errors.New("db error"))
+
+	prefs, failedKeys, totalCount, err := suite.store.GetPreferencesByUserIDPaginated("user-1", 50, 0)
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+	suite.Zero(totalCount)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDPaginated_PartialDecodeFailure() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{{"preference_count": int64(2)}}, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserIDPaginated, "user-1", testPreferenceDeploymentID, 50, 0).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+			{"preference_key": "locale", "preference_value": "gz1:not-valid-base64!!", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, totalCount, err := suite.store.GetPreferencesByUserIDPaginated("user-1", 50, 0)
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("theme", prefs[0].Key)
+	suite.Equal([]string{"locale"}, failedKeys)
+	suite.Equal(int64(2), totalCount)
+}
+
+// GetPreferenceByKey Tests
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceByKey_Success() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	pref, err := suite.store.GetPreferenceByKey("user-1", "theme")
+
+	suite.NoError(err)
+	suite.NotNil(pref)
+	suite.Equal("dark", pref.Value)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceByKey_NotFound() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+
+	pref, err := suite.store.GetPreferenceByKey("user-1", "theme")
+
+	suite.NoError(err)
+	suite.Nil(pref)
+}
+
+// TestGetPreferenceByKey_PopulatesExpiresAt asserts that a row carrying a non-NULL EXPIRES_AT is
+// surfaced on Preference.ExpiresAt; the SQL WHERE clause is what actually keeps an expired row out
+// of the result set (see TestGetPreferenceQueries_ExcludeExpiredRows in store_constants_test.go),
+// so this only needs a non-expired row to confirm the value round-trips.
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceByKey_PopulatesExpiresAt() {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now, "expires_at": expiresAt},
+		}, nil)
+
+	pref, err := suite.store.GetPreferenceByKey("user-1", "theme")
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.Require().NotNil(pref.ExpiresAt)
+	suite.True(expiresAt.Equal(*pref.ExpiresAt))
+}
+
+// TestGetPreferenceByKey_NoExpiryLeavesExpiresAtNil asserts that a row with no EXPIRES_AT value
+// (the common case for a preference with no TTL) leaves Preference.ExpiresAt nil rather than
+// erroring, exercising parseOptionalTimeColumn's NULL/absent-key handling.
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceByKey_NoExpiryLeavesExpiresAtNil() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	pref, err := suite.store.GetPreferenceByKey("user-1", "theme")
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.Nil(pref.ExpiresAt)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceHistory_Success() {
+	changedAt1 := time.Now().Add(-time.Hour)
+	changedAt2 := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceHistory, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_value": "light", "changed_at": changedAt1},
+			{"preference_value": "dark", "changed_at": changedAt2},
+		}, nil)
+
+	entries, err := suite.store.GetPreferenceHistory("user-1", "theme")
+
+	suite.NoError(err)
+	suite.Require().Len(entries, 2)
+	suite.Require().NotNil(entries[0].Value)
+	suite.Equal("light", *entries[0].Value)
+	suite.Require().NotNil(entries[1].Value)
+	suite.Equal("dark", *entries[1].Value)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceHistory_Tombstone() {
+	changedAt := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceHistory, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_value": nil, "changed_at": changedAt},
+		}, nil)
+
+	entries, err := suite.store.GetPreferenceHistory("user-1", "theme")
+
+	suite.NoError(err)
+	suite.Require().Len(entries, 1)
+	suite.Nil(entries[0].Value)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceHistory_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceHistory, "user-1", "theme", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	entries, err := suite.store.GetPreferenceHistory("user-1", "theme")
+
+	suite.Error(err)
+	suite.Nil(entries)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesAsOf_Success() {
+	asOf := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesAsOf, "user-1", testPreferenceDeploymentID, asOf).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark"},
+			{"preference_key": "locale", "preference_value": "en-US"},
+		}, nil)
+
+	entries, err := suite.store.GetPreferencesAsOf("user-1", asOf)
+
+	suite.NoError(err)
+	suite.Require().Len(entries, 2)
+	suite.Equal("theme", entries[0].Key)
+	suite.Equal("dark", entries[0].Value)
+	suite.Equal("locale", entries[1].Key)
+	suite.Equal("en-US", entries[1].Value)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesAsOf_QueryError() {
+	asOf := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesAsOf, "user-1", testPreferenceDeploymentID, asOf).
+		Return(nil, errors.New("db error"))
+
+	entries, err := suite.store.GetPreferencesAsOf("user-1", asOf)
+
+	suite.Error(err)
+	suite.Nil(entries)
+}
+
+// CountPreferences Tests
+func (suite *PreferenceStoreTestSuite) TestCountPreferences_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{{"preference_count": int64(3)}}, nil)
+
+	count, err := suite.store.CountPreferences("user-1")
+
+	suite.NoError(err)
+	suite.Equal(int64(3), count)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCountPreferences_NoRowsReturnsZero() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+
+	count, err := suite.store.CountPreferences("user-1")
+
+	suite.NoError(err)
+	suite.Equal(int64(0), count)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCountPreferences_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryCountPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	count, err := suite.store.CountPreferences("user-1")
+
+	suite.Error(err)
+	suite.Equal(int64(0), count)
+}
+
+// GetPreferencesByPrefix Tests
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByPrefix_Success() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, "editor.%").
+		Return([]map[string]interface{}{
+			{"preference_key": "editor.tabSize", "preference_value": "2", "created_at": now, "updated_at": now},
+			{"preference_key": "editor.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByPrefix("user-1", "editor.")
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 2)
+	suite.Equal("editor.tabSize", prefs[0].Key)
+	suite.Equal("editor.theme", prefs[1].Key)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByPrefix_NoMatches() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, "missing.%").
+		Return([]map[string]interface{}{}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByPrefix("user-1", "missing.")
+
+	suite.NoError(err)
+	suite.Empty(prefs)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByPrefix_EscapesWildcardCharacters() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, `literal\_\%%`).
+		Return([]map[string]interface{}{
+			{"preference_key": "literal_%key", "preference_value": "v", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByPrefix("user-1", "literal_%")
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("literal_%key", prefs[0].Key)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByPrefix_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, "editor.%").
+		Return(nil, errors.New("db error"))
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByPrefix("user-1", "editor.")
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+}
+
+// SearchPreferencesByValue Tests
+func (suite *PreferenceStoreTestSuite) TestSearchPreferencesByValue_Match() {
+	now := time.Now()
+	query, args := buildSearchPreferencesByValueQuery("user-1", "", testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{
+			{"preference_key": "editor.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.SearchPreferencesByValue("user-1", "", "dark")
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("editor.theme", prefs[0].Key)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestSearchPreferencesByValue_NoMatch() {
+	now := time.Now()
+	query, args := buildSearchPreferencesByValueQuery("user-1", "", testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{
+			{"preference_key": "editor.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.SearchPreferencesByValue("user-1", "", "solarized")
+
+	suite.NoError(err)
+	suite.Empty(prefs)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestSearchPreferencesByValue_WithPrefix() {
+	now := time.Now()
+	query, args := buildSearchPreferencesByValueQuery("user-1", "editor.", testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{
+			{"preference_key": "editor.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.SearchPreferencesByValue("user-1", "editor.", "dark")
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("editor.theme", prefs[0].Key)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestSearchPreferencesByValue_QueryError() {
+	query, args := buildSearchPreferencesByValueQuery("user-1", "", testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return(nil, errors.New("db error"))
+
+	prefs, failedKeys, err := suite.store.SearchPreferencesByValue("user-1", "", "dark")
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+}
+
+// TestSearchPreferencesByValue_MatchesDecodedEncryptedValue proves the synth-1528 fix: a
+// substring that only appears in an encrypted preference's decoded plaintext, never in its
+// stored ciphertext, is still found, because the match is applied in Go to the decoded value
+// rather than pushed down as a SQL LIKE against the raw column (see
+// buildSearchPreferencesByValueQuery).
+func (suite *PreferenceStoreTestSuite) TestSearchPreferencesByValue_MatchesDecodedEncryptedValue() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", StoragePolicy: storagePolicyEncrypted})
+	defer delete(schemaRegistry, "secret.token")
+	suite.store.crypto = identityCrypto{}
+
+	now := time.Now()
+	query, args := buildSearchPreferencesByValueQuery("user-1", "", testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "secret.token", "preference_value": encryptedValuePrefix + "c2VjcmV0LXZhbHVl",
+				"created_at": now, "updated_at": now,
+			},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.SearchPreferencesByValue("user-1", "", "secret-value")
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("secret.token", prefs[0].Key)
+	suite.Equal("secret-value", prefs[0].Value)
+	suite.Empty(failedKeys)
+}
+
+// buildSearchPreferencesByValueQuery Tests
+func (suite *PreferenceStoreTestSuite) TestBuildSearchPreferencesByValueQuery_ValueOnly() {
+	query, args := buildSearchPreferencesByValueQuery("user-1", "", testPreferenceDeploymentID)
+
+	suite.Equal("PREF-21", query.ID)
+	suite.Equal(
+		`SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT `+
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL `+
+			`ORDER BY PREFERENCE_KEY ASC`,
+		query.Query,
+	)
+	suite.Equal([]interface{}{"user-1", testPreferenceDeploymentID}, args)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildSearchPreferencesByValueQuery_WithPrefix() {
+	query, args := buildSearchPreferencesByValueQuery("user-1", "editor.", testPreferenceDeploymentID)
+
+	suite.Equal("PREF-21", query.ID)
+	suite.Equal(
+		`SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT `+
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND DEPLOYMENT_ID = $2 AND DELETED_AT IS NULL `+
+			`AND PREFERENCE_KEY LIKE $3 ESCAPE '\' ORDER BY PREFERENCE_KEY ASC`,
+		query.Query,
+	)
+	suite.Equal([]interface{}{"user-1", testPreferenceDeploymentID, "editor.%"}, args)
+}
+
+// GetPreferencesByUserIDSorted Tests
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDSorted_KeyAsc() {
+	now := time.Now()
+	query := buildGetPreferencesByUserIDSortedQuery(preferenceSortKey, preferenceSortOrderAsc)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", query, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserIDSorted("user-1", preferenceSortKey, preferenceSortOrderAsc)
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("theme", prefs[0].Key)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDSorted_KeyDesc() {
+	now := time.Now()
+	query := buildGetPreferencesByUserIDSortedQuery(preferenceSortKey, preferenceSortOrderDesc)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", query, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserIDSorted("user-1", preferenceSortKey, preferenceSortOrderDesc)
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDSorted_UpdatedAtAsc() {
+	now := time.Now()
+	query := buildGetPreferencesByUserIDSortedQuery(preferenceSortUpdatedAt, preferenceSortOrderAsc)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", query, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserIDSorted(
+		"user-1", preferenceSortUpdatedAt, preferenceSortOrderAsc)
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDSorted_UpdatedAtDesc() {
+	now := time.Now()
+	query := buildGetPreferencesByUserIDSortedQuery(preferenceSortUpdatedAt, preferenceSortOrderDesc)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", query, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserIDSorted(
+		"user-1", preferenceSortUpdatedAt, preferenceSortOrderDesc)
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByUserIDSorted_QueryError() {
+	query := buildGetPreferencesByUserIDSortedQuery(preferenceSortKey, preferenceSortOrderAsc)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", query, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByUserIDSorted("user-1", preferenceSortKey, preferenceSortOrderAsc)
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+}
+
+// buildGetPreferencesByUserIDSortedQuery Tests
+func (suite *PreferenceStoreTestSuite) TestBuildGetPreferencesByUserIDSortedQuery_DefaultsOnUnknownValues() {
+	query := buildGetPreferencesByUserIDSortedQuery("bogus", "bogus")
+
+	suite.Equal("PREF-22", query.ID)
+	suite.Contains(query.Query, "ORDER BY PREFERENCE_KEY ASC")
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildGetPreferencesByUserIDSortedQuery_UpdatedAtDesc() {
+	query := buildGetPreferencesByUserIDSortedQuery(preferenceSortUpdatedAt, preferenceSortOrderDesc)
+
+	suite.Equal("PREF-22", query.ID)
+	suite.Contains(query.Query, "ORDER BY UPDATED_AT DESC")
+}
+
+// UpsertPreference Tests
+func (suite *PreferenceStoreTestSuite) TestUpsertPreference_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "theme", "dark", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	err := suite.store.UpsertPreference("user-1", "theme", "dark", "user-1", false, 1, nil)
+
+	suite.NoError(err)
+}
+
+func (suite *PreferenceStoreTestSuite) TestUpsertPreference_Enforced() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", true, 1, nil,
+	).Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "theme", "dark", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	err := suite.store.UpsertPreference("user-1", "theme", "dark", "user-1", true, 1, nil)
+
+	suite.NoError(err)
+}
+
+func (suite *PreferenceStoreTestSuite) TestUpsertPreference_ExecuteError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(nil, errors.New("db error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	err := suite.store.UpsertPreference("user-1", "theme", "dark", "user-1", false, 1, nil)
+
+	suite.Error(err)
+}
+
+func (suite *PreferenceStoreTestSuite) TestUpsertPreference_HistoryExecuteError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "theme", "dark", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	err := suite.store.UpsertPreference("user-1", "theme", "dark", "user-1", false, 1, nil)
+
+	suite.Error(err)
+}
+
+func (suite *PreferenceStoreTestSuite) TestUpsertPreference_ReadOnlyDBError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(nil, &pq.Error{Code: "25006", Message: "cannot execute UPDATE in a read-only transaction"})
+	suite.mockTx.On("Rollback").Return(nil)
+
+	err := suite.store.UpsertPreference("user-1", "theme", "dark", "user-1", false, 1, nil)
+
+	suite.Error(err)
+	suite.True(errors.Is(err, errDBReadOnly))
+}
+
+func (suite *PreferenceStoreTestSuite) TestUpsertPreference_ReadOnlyDBError_MessageFallback() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(nil, errors.New("attempt to write a readonly database"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	err := suite.store.UpsertPreference("user-1", "theme", "dark", "user-1", false, 1, nil)
+
+	suite.Error(err)
+	suite.True(errors.Is(err, errDBReadOnly))
+}
+
+func (suite *PreferenceStoreTestSuite) TestIsReadOnlyDBError() {
+	suite.False(isReadOnlyDBError(nil))
+	suite.True(isReadOnlyDBError(&pq.Error{Code: "25006", Message: "read-only transaction"}))
+	suite.True(isReadOnlyDBError(errors.New("pq: cannot execute INSERT in a read-only transaction")))
+	suite.True(isReadOnlyDBError(errors.New("attempt to write a readonly database")))
+	suite.False(isReadOnlyDBError(errors.New("connection refused")))
+}
+
+// InsertPreferenceIfAbsent Tests
+func (suite *PreferenceStoreTestSuite) TestInsertPreferenceIfAbsent_Inserted() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On(
+		"Execute", queryInsertPreferenceIfAbsent, "user-1", "theme", "dark", testPreferenceDeploymentID, "admin-1",
+	).Return(int64(1), nil)
+
+	inserted, err := suite.store.InsertPreferenceIfAbsent("user-1", "theme", "dark", "admin-1")
+
+	suite.NoError(err)
+	suite.True(inserted)
+}
+
+func (suite *PreferenceStoreTestSuite) TestInsertPreferenceIfAbsent_AlreadyPresent() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On(
+		"Execute", queryInsertPreferenceIfAbsent, "user-1", "theme", "dark", testPreferenceDeploymentID, "admin-1",
+	).Return(int64(0), nil)
+
+	inserted, err := suite.store.InsertPreferenceIfAbsent("user-1", "theme", "dark", "admin-1")
+
+	suite.NoError(err)
+	suite.False(inserted)
+}
+
+func (suite *PreferenceStoreTestSuite) TestInsertPreferenceIfAbsent_ExecuteError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On(
+		"Execute", queryInsertPreferenceIfAbsent, "user-1", "theme", "dark", testPreferenceDeploymentID, "admin-1",
+	).Return(int64(0), errors.New("db error"))
+
+	inserted, err := suite.store.InsertPreferenceIfAbsent("user-1", "theme", "dark", "admin-1")
+
+	suite.Error(err)
+	suite.False(inserted)
+}
+
+// DeletePreference Tests
+func (suite *PreferenceStoreTestSuite) TestDeletePreference_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "theme", nil, testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	err := suite.store.DeletePreference("user-1", "theme")
+
+	suite.NoError(err)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreference_HistoryExecuteError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "theme", nil, testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	err := suite.store.DeletePreference("user-1", "theme")
+
+	suite.Error(err)
+}
+
+// TestUpsertPreference_ConcurrentWithDeletePreference_NoRace exercises interleaved, concurrent
+// UpsertPreference/DeletePreference calls for the same key. Both write unconditionally inside a
+// transaction, with no app-level "check if it exists, then insert or update" step (see the doc
+// comments on UpsertPreference and DeletePreference), so there is no in-process race for this
+// test to surface; it guards against a future change reintroducing one, e.g. by adding a
+// read-then-write step to either method.
+func (suite *PreferenceStoreTestSuite) TestUpsertPreference_ConcurrentWithDeletePreference_NoRace() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "theme", mock.Anything, testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "theme", mock.Anything, testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "theme", nil, testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	const iterations = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, iterations*2)
+
+	for i := 0; i < iterations; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- suite.store.DeletePreference("user-1", "theme")
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- suite.store.UpsertPreference("user-1", "theme", "dark", "user-1", false, 1, nil)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		suite.NoError(err)
+	}
+}
+
+func (suite *PreferenceStoreTestSuite) TestUpsertAndGetPreferenceByKey_MixedPlaintextAndEncryptedKeysForSameUser() {
+	RegisterPreferenceSchema(PreferenceSchemaEntry{Key: "secret.token", StoragePolicy: storagePolicyEncrypted})
+	defer delete(schemaRegistry, "secret.token")
+	suite.store.crypto = identityCrypto{}
+
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "ui.theme", "dark", testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceHistory, "user-1", "ui.theme", "dark", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On(
+		"Exec", queryUpsertPreferenceWithExpiry, "user-1", "secret.token", encryptedValuePrefix+"c2VjcmV0LXZhbHVl",
+		testPreferenceDeploymentID, "user-1", false, 1, nil,
+	).Return(&mockResult{}, nil)
+	suite.mockTx.On(
+		"Exec", queryInsertPreferenceHistory, "user-1", "secret.token", encryptedValuePrefix+"c2VjcmV0LXZhbHVl",
+		testPreferenceDeploymentID,
+	).Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	suite.NoError(suite.store.UpsertPreference("user-1", "ui.theme", "dark", "user-1", false, 1, nil))
+	suite.NoError(suite.store.UpsertPreference("user-1", "secret.token", "secret-value", "user-1", false, 1, nil))
+
+	now := time.Now()
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "ui.theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "secret.token", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "secret.token", "preference_value": encryptedValuePrefix + "c2VjcmV0LXZhbHVl",
+				"created_at": now, "updated_at": now,
+			},
+		}, nil)
+
+	plainPref, err := suite.store.GetPreferenceByKey("user-1", "ui.theme")
+	suite.NoError(err)
+	suite.Require().NotNil(plainPref)
+	suite.Equal("dark", plainPref.Value)
+
+	secretPref, err := suite.store.GetPreferenceByKey("user-1", "secret.token")
+	suite.NoError(err)
+	suite.Require().NotNil(secretPref)
+	suite.Equal("secret-value", secretPref.Value)
+}
+
+// DeleteAllPreferences Tests
+func (suite *PreferenceStoreTestSuite) TestDeleteAllPreferences_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryDeleteAllPreferences, "user-1", testPreferenceDeploymentID).
+		Return(int64(3), nil)
+
+	deletedCount, err := suite.store.DeleteAllPreferences("user-1")
+
+	suite.NoError(err)
+	suite.Equal(int64(3), deletedCount)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeleteAllPreferences_NoRows() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryDeleteAllPreferences, "user-1", testPreferenceDeploymentID).
+		Return(int64(0), nil)
+
+	deletedCount, err := suite.store.DeleteAllPreferences("user-1")
+
+	suite.NoError(err)
+	suite.Equal(int64(0), deletedCount)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeleteAllPreferences_ExecuteError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryDeleteAllPreferences, "user-1", testPreferenceDeploymentID).
+		Return(int64(0), errors.New("db error"))
+
+	deletedCount, err := suite.store.DeleteAllPreferences("user-1")
+
+	suite.Error(err)
+	suite.Equal(int64(0), deletedCount)
+}
+
+// GetDistinctUserIDs Tests
+func (suite *PreferenceStoreTestSuite) TestGetDistinctUserIDs_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetDistinctPreferenceUserIDs, testPreferenceDeploymentID, 200, 0).
+		Return([]map[string]interface{}{
+			{"user_id": "user-1"},
+			{"user_id": "user-2"},
+		}, nil)
+
+	userIDs, err := suite.store.GetDistinctUserIDs(200, 0)
+
+	suite.NoError(err)
+	suite.Equal([]string{"user-1", "user-2"}, userIDs)
+}
+
+// GetDistinctNamespaces Tests
+func (suite *PreferenceStoreTestSuite) TestGetDistinctNamespaces_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetDistinctNamespaces, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"namespace": "ui"},
+			{"namespace": "notifications"},
+		}, nil)
+
+	namespaces, err := suite.store.GetDistinctNamespaces("user-1")
+
+	suite.NoError(err)
+	suite.Equal([]string{"ui", "notifications"}, namespaces)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetDistinctNamespaces_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetDistinctNamespaces, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	namespaces, err := suite.store.GetDistinctNamespaces("user-1")
+
+	suite.Error(err)
+	suite.Nil(namespaces)
+}
+
+// IncrementReadCount Tests
+func (suite *PreferenceStoreTestSuite) TestIncrementReadCount_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryIncrementReadCount, "user-1", "theme", int64(3), testPreferenceDeploymentID).
+		Return(int64(1), nil)
+
+	err := suite.store.IncrementReadCount("user-1", "theme", 3)
+
+	suite.NoError(err)
+}
+
+func (suite *PreferenceStoreTestSuite) TestIncrementReadCount_ExecuteError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryIncrementReadCount, "user-1", "theme", int64(1), testPreferenceDeploymentID).
+		Return(int64(0), errors.New("db error"))
+
+	err := suite.store.IncrementReadCount("user-1", "theme", 1)
+
+	suite.Error(err)
+}
+
+// GetReadCountAggregate Tests
+func (suite *PreferenceStoreTestSuite) TestGetReadCountAggregate_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("QueryContext", mock.Anything, queryGetReadCountAggregate, testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "total_reads": int64(42)},
+			{"preference_key": "locale", "total_reads": int64(7)},
+		}, nil)
+
+	counts, err := suite.store.GetReadCountAggregate(context.Background())
+
+	suite.NoError(err)
+	suite.Equal([]PreferenceReadCount{
+		{Key: "theme", TotalReads: 42},
+		{Key: "locale", TotalReads: 7},
+	}, counts)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetReadCountAggregate_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("QueryContext", mock.Anything, queryGetReadCountAggregate, testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	counts, err := suite.store.GetReadCountAggregate(context.Background())
+
+	suite.Error(err)
+	suite.Nil(counts)
+}
+
+// TestGetReadCountAggregate_ExceedsConfiguredTimeout verifies that a query ID with a configured
+// PreferenceQueryTimeoutConfig.PerQueryMillis entry is cancelled once it runs longer than that
+// timeout, independent of the caller's own context, and that the resulting error unwraps to
+// errQueryTimeout.
+func (suite *PreferenceStoreTestSuite) TestGetReadCountAggregate_ExceedsConfiguredTimeout() {
+	config.GetServerRuntime().Config.Preference.QueryTimeout.PerQueryMillis = map[string]int64{
+		queryGetReadCountAggregate.GetID(): 1,
+	}
+	defer func() { config.GetServerRuntime().Config.Preference.QueryTimeout.PerQueryMillis = nil }()
+
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("QueryContext", mock.Anything, queryGetReadCountAggregate, testPreferenceDeploymentID).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			<-ctx.Done()
+		}).
+		Return(nil, context.DeadlineExceeded)
+
+	counts, err := suite.store.GetReadCountAggregate(context.Background())
+
+	suite.Error(err)
+	suite.ErrorIs(err, errQueryTimeout)
+	suite.Nil(counts)
+}
+
+// DeleteExpiredPreferences Tests
+func (suite *PreferenceStoreTestSuite) TestDeleteExpiredPreferences_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryDeleteExpiredPreferences, testPreferenceDeploymentID).
+		Return(int64(2), nil)
+
+	reclaimed, err := suite.store.DeleteExpiredPreferences()
+
+	suite.NoError(err)
+	suite.Equal(int64(2), reclaimed)
+}
+
+// DeleteSoftDeletedPreferencesPastWindow Tests
+func (suite *PreferenceStoreTestSuite) TestDeleteSoftDeletedPreferencesPastWindow_Success() {
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryDeleteSoftDeletedPreferencesPastWindow, testPreferenceDeploymentID, cutoff).
+		Return(int64(1), nil)
+
+	reclaimed, err := suite.store.DeleteSoftDeletedPreferencesPastWindow(cutoff)
+
+	suite.NoError(err)
+	suite.Equal(int64(1), reclaimed)
+}
+
+// DeletePreferencesByUserID Tests
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByUserID_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Execute", queryDeletePreferencesByUserID, testPreferenceDeploymentID, "user-1").
+		Return(int64(4), nil)
+
+	reclaimed, err := suite.store.DeletePreferencesByUserID("user-1")
+
+	suite.NoError(err)
+	suite.Equal(int64(4), reclaimed)
+}
+
+// buildPreferenceFromRow Tests
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_MissingKey() {
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{})
+
+	suite.Error(err)
+	suite.Nil(pref)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_UpdatedBy() {
+	now := time.Now()
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark", "updated_by": "user-1",
+		"created_at": now, "updated_at": now,
+	})
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.Equal("user-1", pref.UpdatedBy)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_UpdatedByMissing() {
+	now := time.Now()
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark",
+		"created_at": now, "updated_at": now,
+	})
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.Equal("", pref.UpdatedBy)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_Enforced() {
+	now := time.Now()
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark", "enforced": true,
+		"created_at": now, "updated_at": now,
+	})
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.True(pref.Enforced)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_EnforcedMissing() {
+	now := time.Now()
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark",
+		"created_at": now, "updated_at": now,
+	})
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.False(pref.Enforced)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_TimestampsAsTimeTime() {
+	now := time.Now()
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark",
+		"created_at": now, "updated_at": now,
+	})
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.True(pref.CreatedAt.Equal(now))
+	suite.True(pref.UpdatedAt.Equal(now))
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_TimestampsAsRFC3339Strings() {
+	now := time.Now().UTC().Truncate(time.Second)
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark",
+		"created_at": now.Format(time.RFC3339), "updated_at": now.Format(time.RFC3339),
+	})
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.True(pref.CreatedAt.Equal(now))
+	suite.True(pref.UpdatedAt.Equal(now))
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_TimestampsAsRFC3339Bytes() {
+	now := time.Now().UTC().Truncate(time.Second)
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark",
+		"created_at": []byte(now.Format(time.RFC3339)), "updated_at": []byte(now.Format(time.RFC3339)),
+	})
+
+	suite.NoError(err)
+	suite.Require().NotNil(pref)
+	suite.True(pref.CreatedAt.Equal(now))
+	suite.True(pref.UpdatedAt.Equal(now))
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_MalformedCreatedAt() {
+	now := time.Now()
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark",
+		"created_at": "not-a-timestamp", "updated_at": now,
+	})
+
+	suite.Error(err)
+	suite.Nil(pref)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildPreferenceFromRow_MissingUpdatedAt() {
+	now := time.Now()
+	pref, err := suite.store.buildPreferenceFromRow("user-1", map[string]interface{}{
+		"preference_key": "theme", "preference_value": "dark", "created_at": now,
+	})
+
+	suite.Error(err)
+	suite.Nil(pref)
+}
+
+// ExecuteBatch Tests
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_SetAndGetFromPending() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	ops := []BatchOperation{
+		{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+		{Op: BatchOperationGet, Key: "theme"},
+	}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Equal("dark", results[0].Value)
+	suite.Equal("dark", results[1].Value)
+	suite.Nil(results[1].Error)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_GetEmptyValueFallsThroughToDefault() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{
+		"theme": {Key: "theme", Default: "light", EmptyFallsThroughToDefault: true},
+	}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "", "created_at": time.Now(), "updated_at": time.Now()},
+		}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	results, err := suite.store.ExecuteBatch("user-1", []BatchOperation{{Op: BatchOperationGet, Key: "theme"}}, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Nil(results[0].Error)
+	suite.Equal("light", results[0].Value)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_GetFromPendingEmptyValueFallsThroughToDefault() {
+	schemaRegistryMu.Lock()
+	schemaRegistry = map[string]PreferenceSchemaEntry{
+		"theme": {Key: "theme", Default: "light", EmptyFallsThroughToDefault: true},
+	}
+	schemaRegistryMu.Unlock()
+	defer func() {
+		schemaRegistryMu.Lock()
+		schemaRegistry = make(map[string]PreferenceSchemaEntry)
+		schemaRegistryMu.Unlock()
+	}()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	ops := []BatchOperation{
+		{Op: BatchOperationSet, Key: "theme", Value: ""},
+		{Op: BatchOperationGet, Key: "theme"},
+	}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Equal("light", results[1].Value)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_EnforcedSetBlocksLaterPlainSetInSameBatch() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", true, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	ops := []BatchOperation{
+		{Op: BatchOperationSet, Key: "theme", Value: "dark", Enforced: true},
+		{Op: BatchOperationSet, Key: "theme", Value: "light"},
+	}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Nil(results[0].Error)
+	suite.True(results[0].Enforced)
+	suite.Require().NotNil(results[1].Error)
+	suite.Equal(ErrorPreferenceEnforced.Code, results[1].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_SetBlockedByExistingEnforcedValue() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "theme", "preference_value": "dark", "enforced": true,
+				"created_at": time.Now(), "updated_at": time.Now(),
+			},
+		}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	ops := []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "light"}}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Require().NotNil(results[0].Error)
+	suite.Equal(ErrorPreferenceEnforced.Code, results[0].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_DeleteThenGetFromPending() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	ops := []BatchOperation{
+		{Op: BatchOperationDelete, Key: "theme"},
+		{Op: BatchOperationGet, Key: "theme"},
+	}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Require().NotNil(results[1].Error)
+	suite.Equal(ErrorPreferenceNotFound.Code, results[1].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_GetNotFoundViaDB() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	results, err := suite.store.ExecuteBatch("user-1", []BatchOperation{{Op: BatchOperationGet, Key: "theme"}}, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Require().NotNil(results[0].Error)
+	suite.Equal(ErrorPreferenceNotFound.Code, results[0].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_FailFastRollsBackAndStops() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Rollback").Return(nil)
+
+	ops := []BatchOperation{
+		{Op: BatchOperationGet, Key: ""},
+		{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+	}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, true)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Require().NotNil(results[0].Error)
+	suite.Equal(ErrorInvalidPreferenceKey.Code, results[0].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_SetDisallowedContentType() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	ops := []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "<html><body>hi</body></html>"}}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Require().NotNil(results[0].Error)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, results[0].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_MixedValidAndOversizedValues_NotFailFast() {
+	oversized := strings.Repeat("x", maxPreferenceValueLength+1)
+
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	ops := []BatchOperation{
+		{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+		{Op: BatchOperationSet, Key: "bio", Value: oversized},
+	}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Nil(results[0].Error)
+	suite.Equal("dark", results[0].Value)
+	suite.Require().NotNil(results[1].Error)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, results[1].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_MixedValidAndOversizedValues_FailFast() {
+	oversized := strings.Repeat("x", maxPreferenceValueLength+1)
+
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Rollback").Return(nil)
+
+	ops := []BatchOperation{
+		{Op: BatchOperationSet, Key: "theme", Value: "dark"},
+		{Op: BatchOperationSet, Key: "bio", Value: oversized},
+		{Op: BatchOperationSet, Key: "locale", Value: "en-US"},
+	}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, true)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Nil(results[0].Error)
+	suite.Require().NotNil(results[1].Error)
+	suite.Equal(ErrorInvalidPreferenceValue.Code, results[1].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_InvalidOperationType() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	results, err := suite.store.ExecuteBatch("user-1", []BatchOperation{{Op: "unknown", Key: "theme"}}, false)
+
+	suite.NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Require().NotNil(results[0].Error)
+	suite.Equal(ErrorInvalidBatchOperationType.Code, results[0].Error.Code)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_BeginTxError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(nil, errors.New("begin error"))
+
+	results, err := suite.store.ExecuteBatch("user-1", []BatchOperation{{Op: BatchOperationGet, Key: "theme"}}, false)
+
+	suite.Error(err)
+	suite.Nil(results)
+}
+
+func (suite *PreferenceStoreTestSuite) TestExecuteBatch_CommitError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(errors.New("commit error"))
+
+	ops := []BatchOperation{{Op: BatchOperationSet, Key: "theme", Value: "dark"}}
+
+	results, err := suite.store.ExecuteBatch("user-1", ops, false)
+
+	suite.Error(err)
+	suite.Nil(results)
+}
+
+// ImportPreferences Tests
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_InsertsNewKey() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	resp, err := suite.store.ImportPreferences("user-1", []ImportEntry{{Key: "theme", Value: "dark"}}, ImportConflictSkip)
+
+	suite.NoError(err)
+	suite.Require().NotNil(resp)
+	suite.Equal(1, resp.Inserted)
+	suite.Equal(0, resp.Overwritten)
+	suite.Equal(0, resp.Skipped)
+	suite.Equal(0, resp.Failed)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_SkipLeavesExistingValueUntouched() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "theme", "preference_value": "dark", "enforced": false,
+				"created_at": time.Now(), "updated_at": time.Now(),
+			},
+		}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	resp, err := suite.store.ImportPreferences("user-1", []ImportEntry{{Key: "theme", Value: "light"}}, ImportConflictSkip)
+
+	suite.NoError(err)
+	suite.Require().NotNil(resp)
+	suite.Equal(0, resp.Inserted)
+	suite.Equal(0, resp.Overwritten)
+	suite.Equal(1, resp.Skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_OverwriteReplacesExistingValue() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "theme", "preference_value": "dark", "enforced": false,
+				"created_at": time.Now(), "updated_at": time.Now(),
+			},
+		}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "light", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	resp, err := suite.store.ImportPreferences("user-1", []ImportEntry{{Key: "theme", Value: "light"}}, ImportConflictOverwrite)
+
+	suite.NoError(err)
+	suite.Require().NotNil(resp)
+	suite.Equal(0, resp.Inserted)
+	suite.Equal(1, resp.Overwritten)
+	suite.Equal(0, resp.Skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_NewerWinsReplacesWhenImportedIsNewer() {
+	stored := time.Now().Add(-time.Hour)
+	imported := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "theme", "preference_value": "dark", "enforced": false,
+				"created_at": stored, "updated_at": stored,
+			},
+		}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "light", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	resp, err := suite.store.ImportPreferences(
+		"user-1", []ImportEntry{{Key: "theme", Value: "light", UpdatedAt: imported}}, ImportConflictNewerWins,
+	)
+
+	suite.NoError(err)
+	suite.Require().NotNil(resp)
+	suite.Equal(1, resp.Overwritten)
+	suite.Equal(0, resp.Skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_NewerWinsSkipsWhenImportedIsOlder() {
+	stored := time.Now()
+	imported := stored.Add(-time.Hour)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "theme", "preference_value": "dark", "enforced": false,
+				"created_at": stored, "updated_at": stored,
+			},
+		}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	resp, err := suite.store.ImportPreferences(
+		"user-1", []ImportEntry{{Key: "theme", Value: "light", UpdatedAt: imported}}, ImportConflictNewerWins,
+	)
+
+	suite.NoError(err)
+	suite.Require().NotNil(resp)
+	suite.Equal(0, resp.Overwritten)
+	suite.Equal(1, resp.Skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_InvalidEntryCountsAsFailedAndContinues() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	resp, err := suite.store.ImportPreferences("user-1", []ImportEntry{
+		{Key: "", Value: "x"},
+		{Key: "theme", Value: "dark"},
+	}, ImportConflictSkip)
+
+	suite.NoError(err)
+	suite.Require().NotNil(resp)
+	suite.Equal(1, resp.Failed)
+	suite.Equal(1, resp.Inserted)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_BeginTxError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(nil, errors.New("begin error"))
+
+	resp, err := suite.store.ImportPreferences("user-1", []ImportEntry{{Key: "theme", Value: "dark"}}, ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Nil(resp)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_GetPreferenceErrorRollsBack() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return(nil, errors.New("query error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	resp, err := suite.store.ImportPreferences("user-1", []ImportEntry{{Key: "theme", Value: "dark"}}, ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Nil(resp)
+}
+
+func (suite *PreferenceStoreTestSuite) TestImportPreferences_CommitError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(errors.New("commit error"))
+
+	resp, err := suite.store.ImportPreferences("user-1", []ImportEntry{{Key: "theme", Value: "dark"}}, ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Nil(resp)
+}
+
+// CompareAndSwapPreferences Tests
+func (suite *PreferenceStoreTestSuite) TestCompareAndSwapPreferences_AppliedWhenAllMatch() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "theme", "preference_value": "light", "enforced": false,
+				"created_at": time.Now(), "updated_at": time.Now(),
+			},
+		}, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "locale", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "locale", "fr", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	applied, mismatchedKeys, err := suite.store.CompareAndSwapPreferences("user-1", []CompareAndSwapEntry{
+		{Key: "theme", Expected: "light", Value: "dark"},
+		{Key: "locale", Expected: "", Value: "fr"},
+	})
+
+	suite.NoError(err)
+	suite.True(applied)
+	suite.Empty(mismatchedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCompareAndSwapPreferences_NotAppliedOnMismatchWithoutWriting() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{
+				"preference_key": "theme", "preference_value": "dark", "enforced": false,
+				"created_at": time.Now(), "updated_at": time.Now(),
+			},
+		}, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "locale", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+
+	applied, mismatchedKeys, err := suite.store.CompareAndSwapPreferences("user-1", []CompareAndSwapEntry{
+		{Key: "theme", Expected: "light", Value: "dark"},
+		{Key: "locale", Expected: "en", Value: "fr"},
+	})
+
+	suite.NoError(err)
+	suite.False(applied)
+	suite.Equal([]string{"theme", "locale"}, mismatchedKeys)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestCompareAndSwapPreferences_GetPreferenceError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return(nil, errors.New("query error"))
+
+	applied, mismatchedKeys, err := suite.store.CompareAndSwapPreferences("user-1", []CompareAndSwapEntry{
+		{Key: "theme", Expected: "light", Value: "dark"},
+	})
+
+	suite.Error(err)
+	suite.False(applied)
+	suite.Nil(mismatchedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCompareAndSwapPreferences_BeginTxError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockDBClient.On("BeginTx").Return(nil, errors.New("begin error"))
+
+	applied, mismatchedKeys, err := suite.store.CompareAndSwapPreferences("user-1", []CompareAndSwapEntry{
+		{Key: "theme", Expected: "", Value: "dark"},
+	})
+
+	suite.Error(err)
+	suite.False(applied)
+	suite.Nil(mismatchedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCompareAndSwapPreferences_CommitError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceByKey, "user-1", "theme", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "theme", "dark", testPreferenceDeploymentID, "user-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(errors.New("commit error"))
+
+	applied, mismatchedKeys, err := suite.store.CompareAndSwapPreferences("user-1", []CompareAndSwapEntry{
+		{Key: "theme", Expected: "", Value: "dark"},
+	})
+
+	suite.Error(err)
+	suite.False(applied)
+	suite.Nil(mismatchedKeys)
+}
+
+// DeletePreferencesByNamespace Tests
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByNamespace_Success() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+			{"preference_key": "ui.locale", "preference_value": "en", "created_at": now, "updated_at": now},
+			{"preference_key": "billing.plan", "preference_value": "pro", "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.locale", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	deletedKeys, err := suite.store.DeletePreferencesByNamespace("user-1", "ui")
+
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"ui.theme", "ui.locale"}, deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByNamespace_NoMatches() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+
+	deletedKeys, err := suite.store.DeletePreferencesByNamespace("user-1", "ui")
+
+	suite.NoError(err)
+	suite.Empty(deletedKeys)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByNamespace_GetPreferencesError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	deletedKeys, err := suite.store.DeletePreferencesByNamespace("user-1", "ui")
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByNamespace_BeginTxError() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(nil, errors.New("begin tx error"))
+
+	deletedKeys, err := suite.store.DeletePreferencesByNamespace("user-1", "ui")
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByNamespace_ExecErrorRollsBack() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.theme", testPreferenceDeploymentID).
+		Return(nil, errors.New("exec error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	deletedKeys, err := suite.store.DeletePreferencesByNamespace("user-1", "ui")
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByNamespace_CommitError() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(errors.New("commit error"))
+
+	deletedKeys, err := suite.store.DeletePreferencesByNamespace("user-1", "ui")
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+// DeletePreferencesByPrefix Tests
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByPrefix_Success() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, "editor.%").
+		Return([]map[string]interface{}{
+			{"preference_key": "editor.tabSize", "preference_value": "2", "created_at": now, "updated_at": now},
+			{"preference_key": "editor.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "editor.tabSize", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "editor.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	deletedKeys, err := suite.store.DeletePreferencesByPrefix("user-1", "editor.")
+
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"editor.tabSize", "editor.theme"}, deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByPrefix_NoMatches() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, "missing.%").
+		Return([]map[string]interface{}{}, nil)
+
+	deletedKeys, err := suite.store.DeletePreferencesByPrefix("user-1", "missing.")
+
+	suite.NoError(err)
+	suite.Empty(deletedKeys)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByPrefix_GetPreferencesError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, "editor.%").
+		Return(nil, errors.New("db error"))
+
+	deletedKeys, err := suite.store.DeletePreferencesByPrefix("user-1", "editor.")
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferencesByPrefix_ExecErrorRollsBack() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByPrefix, "user-1", testPreferenceDeploymentID, "editor.%").
+		Return([]map[string]interface{}{
+			{"preference_key": "editor.theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "editor.theme", testPreferenceDeploymentID).
+		Return(nil, errors.New("exec error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	deletedKeys, err := suite.store.DeletePreferencesByPrefix("user-1", "editor.")
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+// DeletePreferences Tests
+func (suite *PreferenceStoreTestSuite) TestDeletePreferences_Success() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"ui.theme", "ui.locale", "missing"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{{"preference_key": "ui.theme"}, {"preference_key": "ui.locale"}}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.locale", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	deletedKeys, err := suite.store.DeletePreferences("user-1", []string{"ui.theme", "ui.locale", "missing"})
+
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"ui.theme", "ui.locale"}, deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferences_NoMatches() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"missing"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{}, nil)
+
+	deletedKeys, err := suite.store.DeletePreferences("user-1", []string{"missing"})
+
+	suite.NoError(err)
+	suite.Empty(deletedKeys)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferences_CheckKeysExistError() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"ui.theme"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return(nil, errors.New("db error"))
+
+	deletedKeys, err := suite.store.DeletePreferences("user-1", []string{"ui.theme"})
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferences_BeginTxError() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"ui.theme"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{{"preference_key": "ui.theme"}}, nil)
+	suite.mockDBClient.On("BeginTx").Return(nil, errors.New("begin tx error"))
+
+	deletedKeys, err := suite.store.DeletePreferences("user-1", []string{"ui.theme"})
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferences_ExecErrorRollsBack() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"ui.theme"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{{"preference_key": "ui.theme"}}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.theme", testPreferenceDeploymentID).
+		Return(nil, errors.New("exec error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	deletedKeys, err := suite.store.DeletePreferences("user-1", []string{"ui.theme"})
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestDeletePreferences_CommitError() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"ui.theme"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{{"preference_key": "ui.theme"}}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(errors.New("commit error"))
+
+	deletedKeys, err := suite.store.DeletePreferences("user-1", []string{"ui.theme"})
+
+	suite.Error(err)
+	suite.Nil(deletedKeys)
+}
+
+// RenameKeysByPrefix Tests
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_Success() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+			{"preference_key": "billing.plan", "preference_value": "pro", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "ui.new.theme", "dark", testPreferenceDeploymentID, "admin-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.old.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.NoError(err)
+	suite.Equal(1, renamed)
+	suite.Equal(0, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_NoMatches() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "billing.plan", "preference_value": "pro", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.NoError(err)
+	suite.Equal(0, renamed)
+	suite.Equal(0, skipped)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_ConflictSkipLeavesBothKeysUntouched() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+			{"preference_key": "ui.new.theme", "preference_value": "light", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.NoError(err)
+	suite.Equal(0, renamed)
+	suite.Equal(1, skipped)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_ConflictOverwriteReplacesTarget() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+			{"preference_key": "ui.new.theme", "preference_value": "light", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "ui.new.theme", "dark", testPreferenceDeploymentID, "admin-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.old.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictOverwrite)
+
+	suite.NoError(err)
+	suite.Equal(1, renamed)
+	suite.Equal(0, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_ConflictNewerWinsReplacesWhenSourceIsNewer() {
+	older := time.Now().Add(-time.Hour)
+	newer := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": newer, "updated_at": newer},
+			{"preference_key": "ui.new.theme", "preference_value": "light", "enforced": false, "created_at": older, "updated_at": older},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "ui.new.theme", "dark", testPreferenceDeploymentID, "admin-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.old.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictNewerWins)
+
+	suite.NoError(err)
+	suite.Equal(1, renamed)
+	suite.Equal(0, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_ConflictNewerWinsSkipsWhenSourceIsOlder() {
+	older := time.Now().Add(-time.Hour)
+	newer := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": older, "updated_at": older},
+			{"preference_key": "ui.new.theme", "preference_value": "light", "enforced": false, "created_at": newer, "updated_at": newer},
+		}, nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictNewerWins)
+
+	suite.NoError(err)
+	suite.Equal(0, renamed)
+	suite.Equal(1, skipped)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_GetPreferencesError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Equal(0, renamed)
+	suite.Equal(0, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_BeginTxError() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(nil, errors.New("begin error"))
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Equal(0, renamed)
+	suite.Equal(0, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_UpsertExecErrorRollsBack() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "ui.new.theme", "dark", testPreferenceDeploymentID, "admin-1", false, 1).
+		Return(nil, errors.New("exec error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Equal(0, renamed)
+	suite.Equal(0, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_DeleteExecErrorRollsBack() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "ui.new.theme", "dark", testPreferenceDeploymentID, "admin-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.old.theme", testPreferenceDeploymentID).
+		Return(nil, errors.New("exec error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Equal(0, renamed)
+	suite.Equal(0, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestRenameKeysByPrefix_CommitError() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.old.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryUpsertPreference, "user-1", "ui.new.theme", "dark", testPreferenceDeploymentID, "admin-1", false, 1).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryDeletePreference, "user-1", "ui.old.theme", testPreferenceDeploymentID).
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(errors.New("commit error"))
+
+	renamed, skipped, err := suite.store.RenameKeysByPrefix("user-1", "ui.old.", "ui.new.", "admin-1", ImportConflictSkip)
+
+	suite.Error(err)
+	suite.Equal(0, renamed)
+	suite.Equal(0, skipped)
+}
+
+// CopyPreferences Tests
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_SelectiveByKeys() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+			{"preference_key": "billing.plan", "preference_value": "pro", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "ui.theme", "dark", testPreferenceDeploymentID, "admin-1").
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", []string{"ui.theme"}, "")
+
+	suite.NoError(err)
+	suite.Equal([]string{"ui.theme"}, copied)
+	suite.Empty(skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_SelectiveByPrefix() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+			{"preference_key": "ui.locale", "preference_value": "en", "enforced": false, "created_at": now, "updated_at": now},
+			{"preference_key": "billing.plan", "preference_value": "pro", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "ui.theme", "dark", testPreferenceDeploymentID, "admin-1").
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "ui.locale", "en", testPreferenceDeploymentID, "admin-1").
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", nil, "ui.")
+
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"ui.theme", "ui.locale"}, copied)
+	suite.Empty(skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_CopyAllWhenNoKeysOrPrefix() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+			{"preference_key": "billing.plan", "preference_value": "pro", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "ui.theme", "dark", testPreferenceDeploymentID, "admin-1").
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "billing.plan", "pro", testPreferenceDeploymentID, "admin-1").
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", nil, "")
+
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"ui.theme", "billing.plan"}, copied)
+	suite.Empty(skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_SkipsKeyAlreadySetOnTarget() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "ui.theme", "dark", testPreferenceDeploymentID, "admin-1").
+		Return(&mockResultZero{}, nil)
+	suite.mockTx.On("Commit").Return(nil)
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", []string{"ui.theme"}, "")
+
+	suite.NoError(err)
+	suite.Empty(copied)
+	suite.Equal([]string{"ui.theme"}, skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_NoMatches() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "billing.plan", "preference_value": "pro", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", []string{"ui.theme"}, "")
+
+	suite.NoError(err)
+	suite.Empty(copied)
+	suite.Empty(skipped)
+	suite.mockDBClient.AssertNotCalled(suite.T(), "BeginTx")
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_GetPreferencesError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", []string{"ui.theme"}, "")
+
+	suite.Error(err)
+	suite.Empty(copied)
+	suite.Empty(skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_BeginTxError() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(nil, errors.New("begin error"))
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", []string{"ui.theme"}, "")
+
+	suite.Error(err)
+	suite.Empty(copied)
+	suite.Empty(skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_ExecErrorRollsBack() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "ui.theme", "dark", testPreferenceDeploymentID, "admin-1").
+		Return(nil, errors.New("exec error"))
+	suite.mockTx.On("Rollback").Return(nil)
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", []string{"ui.theme"}, "")
+
+	suite.Error(err)
+	suite.Empty(copied)
+	suite.Empty(skipped)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCopyPreferences_CommitError() {
+	now := time.Now()
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferencesByUserID, "template-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_key": "ui.theme", "preference_value": "dark", "enforced": false, "created_at": now, "updated_at": now},
+		}, nil)
+	suite.mockDBClient.On("BeginTx").Return(suite.mockTx, nil)
+	suite.mockTx.On("Exec", queryInsertPreferenceIfAbsent, "user-1", "ui.theme", "dark", testPreferenceDeploymentID, "admin-1").
+		Return(&mockResult{}, nil)
+	suite.mockTx.On("Commit").Return(errors.New("commit error"))
+
+	copied, skipped, err := suite.store.CopyPreferences("template-1", "user-1", "admin-1", []string{"ui.theme"}, "")
+
+	suite.Error(err)
+	suite.Empty(copied)
+	suite.Empty(skipped)
+}
+
+// GetPreferenceUsage Tests
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceUsage_Success() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceUsage, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{
+			{"preference_count": int64(12), "total_bytes": int64(4096)},
+		}, nil)
+
+	usage, err := suite.store.GetPreferenceUsage("user-1")
+
+	suite.NoError(err)
+	suite.Require().NotNil(usage)
+	suite.Equal(int64(12), usage.Count)
+	suite.Equal(int64(4096), usage.TotalBytes)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceUsage_NoRows() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceUsage, "user-1", testPreferenceDeploymentID).
+		Return([]map[string]interface{}{}, nil)
+
+	usage, err := suite.store.GetPreferenceUsage("user-1")
+
+	suite.NoError(err)
+	suite.Require().NotNil(usage)
+	suite.Equal(int64(0), usage.Count)
+	suite.Equal(int64(0), usage.TotalBytes)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferenceUsage_QueryError() {
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", queryGetPreferenceUsage, "user-1", testPreferenceDeploymentID).
+		Return(nil, errors.New("db error"))
+
+	usage, err := suite.store.GetPreferenceUsage("user-1")
+
+	suite.Error(err)
+	suite.Nil(usage)
+}
+
+// buildCheckPreferenceKeysExistQuery Tests
+func (suite *PreferenceStoreTestSuite) TestBuildCheckPreferenceKeysExistQuery() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"theme", "locale"}, testPreferenceDeploymentID)
+
+	suite.Equal("PREF-15", query.ID)
+	suite.Equal(
+		`SELECT PREFERENCE_KEY FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND PREFERENCE_KEY IN ($2,$3) `+
+			`AND DEPLOYMENT_ID = $4 AND DELETED_AT IS NULL`,
+		query.Query,
+	)
+	suite.Equal([]interface{}{"user-1", "theme", "locale", testPreferenceDeploymentID}, args)
+}
+
+// CheckPreferenceKeysExist Tests
+func (suite *PreferenceStoreTestSuite) TestCheckPreferenceKeysExist_Success() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"theme", "locale", "missing"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{{"preference_key": "theme"}}, nil)
+
+	exists, err := suite.store.CheckPreferenceKeysExist("user-1", []string{"theme", "locale", "missing"})
+
+	suite.NoError(err)
+	suite.Equal(map[string]bool{"theme": true, "locale": false, "missing": false}, exists)
+}
+
+func (suite *PreferenceStoreTestSuite) TestCheckPreferenceKeysExist_QueryError() {
+	query, args := buildCheckPreferenceKeysExistQuery("user-1", []string{"theme"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return(nil, errors.New("db error"))
+
+	exists, err := suite.store.CheckPreferenceKeysExist("user-1", []string{"theme"})
+
+	suite.Error(err)
+	suite.Nil(exists)
+}
+
+func (suite *PreferenceStoreTestSuite) TestBuildGetPreferencesByKeysQuery() {
+	query, args := buildGetPreferencesByKeysQuery("user-1", []string{"theme", "locale"}, testPreferenceDeploymentID)
+
+	suite.Equal("PREF-23", query.ID)
+	suite.Equal(
+		`SELECT PREFERENCE_KEY, PREFERENCE_VALUE, UPDATED_BY, ENFORCED, SCHEMA_VERSION, CREATED_AT, UPDATED_AT `+
+			`FROM "USER_PREFERENCE" WHERE USER_ID = $1 AND PREFERENCE_KEY IN ($2,$3) `+
+			`AND DEPLOYMENT_ID = $4 AND DELETED_AT IS NULL`,
+		query.Query,
+	)
+	suite.Equal([]interface{}{"user-1", "theme", "locale", testPreferenceDeploymentID}, args)
+}
+
+// GetPreferencesByKeys Tests
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByKeys_Success() {
+	now := time.Now()
+	query, args := buildGetPreferencesByKeysQuery("user-1", []string{"theme", "locale", "missing"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return([]map[string]interface{}{
+			{"preference_key": "theme", "preference_value": "dark", "created_at": now, "updated_at": now},
+		}, nil)
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByKeys("user-1", []string{"theme", "locale", "missing"})
+
+	suite.NoError(err)
+	suite.Require().Len(prefs, 1)
+	suite.Equal("theme", prefs[0].Key)
+	suite.Empty(failedKeys)
+}
+
+func (suite *PreferenceStoreTestSuite) TestGetPreferencesByKeys_QueryError() {
+	query, args := buildGetPreferencesByKeysQuery("user-1", []string{"theme"}, testPreferenceDeploymentID)
+	suite.mockDBProvider.On("GetUserDBClient").Return(suite.mockDBClient, nil)
+	suite.mockDBClient.On("Query", append([]interface{}{query}, args...)...).
+		Return(nil, errors.New("db error"))
+
+	prefs, failedKeys, err := suite.store.GetPreferencesByKeys("user-1", []string{"theme"})
+
+	suite.Error(err)
+	suite.Nil(prefs)
+	suite.Nil(failedKeys)
+}
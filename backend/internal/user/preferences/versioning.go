@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PreferenceMigrationFunc upgrades a single preference key's value from fromVersion to
+// fromVersion+1. It is registered via RegisterPreferenceMigration.
+type PreferenceMigrationFunc func(value string) (string, error)
+
+var (
+	migrationRegistry   map[string]map[int]PreferenceMigrationFunc
+	migrationRegistryMu sync.RWMutex
+)
+
+func init() {
+	migrationRegistry = make(map[string]map[int]PreferenceMigrationFunc)
+}
+
+// RegisterPreferenceMigration registers a migration that upgrades key's value from fromVersion
+// to fromVersion+1. Callers should register during their own initialization. Chains of
+// migrations (e.g. v1->v2 and v2->v3 both registered for key) are applied in sequence by
+// migratePreferenceValue; registering a second migration for the same (key, fromVersion) pair
+// overwrites the first.
+func RegisterPreferenceMigration(key string, fromVersion int, migrate PreferenceMigrationFunc) {
+	migrationRegistryMu.Lock()
+	defer migrationRegistryMu.Unlock()
+	if migrationRegistry[key] == nil {
+		migrationRegistry[key] = make(map[int]PreferenceMigrationFunc)
+	}
+	migrationRegistry[key][fromVersion] = migrate
+}
+
+// migratePreferenceValue repeatedly applies registered migrations to upgrade value from
+// storedVersion, stopping as soon as no migration is registered for the current version. A key
+// with no registered migrations, or one already at the latest registered version, is returned
+// unchanged. If a migration in the chain fails, value and storedVersion are returned unchanged
+// along with the error, so the caller can fall back to the pre-migration value.
+func migratePreferenceValue(key, value string, storedVersion int) (migratedValue string, migratedVersion int, err error) {
+	migrationRegistryMu.RLock()
+	defer migrationRegistryMu.RUnlock()
+
+	migratedValue, migratedVersion = value, storedVersion
+	for {
+		migrate, ok := migrationRegistry[key][migratedVersion]
+		if !ok {
+			return migratedValue, migratedVersion, nil
+		}
+		upgraded, migrateErr := migrate(migratedValue)
+		if migrateErr != nil {
+			return value, storedVersion, fmt.Errorf(
+				"failed to migrate preference %q from schema version %d: %w", key, migratedVersion, migrateErr)
+		}
+		migratedValue = upgraded
+		migratedVersion++
+	}
+}
+
+// normalizeSchemaVersion returns version, or defaultSchemaVersion if version is not positive.
+// Used wherever a caller may omit SchemaVersion (0) to mean "use the current default".
+func normalizeSchemaVersion(version int) int {
+	if version <= 0 {
+		return defaultSchemaVersion
+	}
+	return version
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PreferenceVersioningTestSuite struct {
+	suite.Suite
+}
+
+func TestPreferenceVersioningTestSuite(t *testing.T) {
+	suite.Run(t, new(PreferenceVersioningTestSuite))
+}
+
+func (suite *PreferenceVersioningTestSuite) SetupTest() {
+	migrationRegistryMu.Lock()
+	migrationRegistry = make(map[string]map[int]PreferenceMigrationFunc)
+	migrationRegistryMu.Unlock()
+}
+
+func (suite *PreferenceVersioningTestSuite) TestNormalizeSchemaVersion() {
+	suite.Equal(defaultSchemaVersion, normalizeSchemaVersion(0))
+	suite.Equal(defaultSchemaVersion, normalizeSchemaVersion(-1))
+	suite.Equal(2, normalizeSchemaVersion(2))
+}
+
+func (suite *PreferenceVersioningTestSuite) TestMigratePreferenceValue_NoRegisteredMigration() {
+	value, version, err := migratePreferenceValue("ui.theme", "dark", 1)
+
+	suite.NoError(err)
+	suite.Equal("dark", value)
+	suite.Equal(1, version)
+}
+
+func (suite *PreferenceVersioningTestSuite) TestMigratePreferenceValue_UpgradesV1ToV2OnRead() {
+	RegisterPreferenceMigration("ui.theme", 1, func(value string) (string, error) {
+		return "dark-mode:" + value, nil
+	})
+
+	value, version, err := migratePreferenceValue("ui.theme", "dark", 1)
+
+	suite.NoError(err)
+	suite.Equal("dark-mode:dark", value)
+	suite.Equal(2, version)
+}
+
+func (suite *PreferenceVersioningTestSuite) TestMigratePreferenceValue_ChainsConsecutiveMigrations() {
+	RegisterPreferenceMigration("ui.theme", 1, func(value string) (string, error) {
+		return value + "-v2", nil
+	})
+	RegisterPreferenceMigration("ui.theme", 2, func(value string) (string, error) {
+		return value + "-v3", nil
+	})
+
+	value, version, err := migratePreferenceValue("ui.theme", "dark", 1)
+
+	suite.NoError(err)
+	suite.Equal("dark-v2-v3", value)
+	suite.Equal(3, version)
+}
+
+func (suite *PreferenceVersioningTestSuite) TestMigratePreferenceValue_ErrorFallsBackToOriginal() {
+	migrateErr := errors.New("cannot parse legacy value")
+	RegisterPreferenceMigration("ui.theme", 1, func(value string) (string, error) {
+		return "", migrateErr
+	})
+
+	value, version, err := migratePreferenceValue("ui.theme", "dark", 1)
+
+	suite.Require().Error(err)
+	suite.ErrorIs(err, migrateErr)
+	suite.Equal("dark", value)
+	suite.Equal(1, version)
+}
+
+func (suite *PreferenceVersioningTestSuite) TestBuildPreferenceResponse_UpgradesV1ToV2OnRead() {
+	RegisterPreferenceMigration("ui.theme", 1, func(value string) (string, error) {
+		return "dark-mode:" + value, nil
+	})
+
+	resp := buildPreferenceResponse(Preference{Key: "ui.theme", Value: "dark", SchemaVersion: 1}, false, false)
+
+	suite.Equal("dark-mode:dark", resp.Value)
+	suite.Equal(2, resp.SchemaVersion)
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2026, WSO2 LLC. (https://www.wso2.com).
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package preferences
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thunder-id/thunderid/internal/system/config"
+)
+
+func TestNewWebhookDispatcher_NoURLReturnsNoop(t *testing.T) {
+	d := newWebhookDispatcher(config.PreferenceWebhookConfig{})
+
+	assert.IsType(t, noopWebhookDispatcher{}, d)
+}
+
+func TestNewWebhookDispatcher_URLConfiguredReturnsHTTPDispatcher(t *testing.T) {
+	d := newWebhookDispatcher(config.PreferenceWebhookConfig{URL: "https://example.com/hook"})
+
+	assert.IsType(t, &httpWebhookDispatcher{}, d)
+}
+
+func TestHTTPWebhookDispatcher_Dispatch_SendsSignedPayload(t *testing.T) {
+	const secret = "test-secret"
+	received := make(chan struct{})
+	var gotBody []byte
+	var gotSignature string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(webhookSignatureHeader)
+		w.WriteHeader(http.StatusOK)
+		close(received)
+	}))
+	defer server.Close()
+
+	d := newWebhookDispatcher(config.PreferenceWebhookConfig{URL: server.URL, Secret: secret})
+	payload := PreferenceWebhookPayload{
+		UserID: "user-1", ChangedKeys: []string{"theme"}, Operation: webhookOperationUpsert, Timestamp: time.Now(),
+	}
+	d.Dispatch(payload)
+
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered in time")
+	}
+
+	var gotPayload PreferenceWebhookPayload
+	require.NoError(t, json.Unmarshal(gotBody, &gotPayload))
+	assert.Equal(t, "user-1", gotPayload.UserID)
+	assert.Equal(t, []string{"theme"}, gotPayload.ChangedKeys)
+	assert.Equal(t, webhookOperationUpsert, gotPayload.Operation)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(gotBody)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), gotSignature)
+}
+
+func TestHTTPWebhookDispatcher_Dispatch_RetriesOnFailure(t *testing.T) {
+	originalBackoff := webhookRetryBackoff
+	webhookRetryBackoff = time.Millisecond
+	defer func() { webhookRetryBackoff = originalBackoff }()
+
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newWebhookDispatcher(config.PreferenceWebhookConfig{URL: server.URL, MaxRetries: 2})
+	d.Dispatch(PreferenceWebhookPayload{UserID: "user-1", ChangedKeys: []string{"theme"}, Operation: webhookOperationDelete})
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&attempts) == 3
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHTTPWebhookDispatcher_Dispatch_GivesUpAfterMaxRetries(t *testing.T) {
+	originalBackoff := webhookRetryBackoff
+	webhookRetryBackoff = time.Millisecond
+	defer func() { webhookRetryBackoff = originalBackoff }()
+
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	d := newWebhookDispatcher(config.PreferenceWebhookConfig{URL: server.URL, MaxRetries: 1})
+	d.Dispatch(PreferenceWebhookPayload{UserID: "user-1", ChangedKeys: []string{"theme"}, Operation: webhookOperationDelete})
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&attempts) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+}
+
+func TestNoopWebhookDispatcher_DispatchIsNoOp(t *testing.T) {
+	noopWebhookDispatcher{}.Dispatch(PreferenceWebhookPayload{})
+}